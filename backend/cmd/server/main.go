@@ -24,10 +24,61 @@ func main() {
 	srv := server.NewServer()
 	defer srv.Shutdown()
 
+	// Optionally mirror query history to an audit log file
+	if auditLog := os.Getenv("DATA_WARDEN_AUDIT_LOG"); auditLog != "" {
+		srv.SetAuditLog(auditLog)
+		log.Printf("Writing query audit log to %s", auditLog)
+	}
+
+	// Optionally persist table size snapshots across restarts
+	if snapshotFile := os.Getenv("DATA_WARDEN_SIZE_SNAPSHOTS"); snapshotFile != "" {
+		if err := srv.SetSizeSnapshotFile(snapshotFile); err != nil {
+			log.Printf("Failed to load table size snapshots: %v", err)
+		} else {
+			log.Printf("Persisting table size snapshots to %s", snapshotFile)
+		}
+	}
+
+	// Optionally persist saved queries across restarts
+	if savedQueryFile := os.Getenv("DATA_WARDEN_SAVED_QUERIES"); savedQueryFile != "" {
+		if err := srv.SetSavedQueryFile(savedQueryFile); err != nil {
+			log.Printf("Failed to load saved queries: %v", err)
+		} else {
+			log.Printf("Persisting saved queries to %s", savedQueryFile)
+		}
+	}
+
+	// Optionally remember connections in an encrypted file
+	if storeFile := os.Getenv("DATA_WARDEN_CONNECTION_STORE"); storeFile != "" {
+		if err := srv.SetConnectionStore(storeFile, os.Getenv("DATA_WARDEN_CONNECTION_SECRET")); err != nil {
+			log.Printf("Failed to load connection store: %v", err)
+		} else {
+			log.Printf("Persisting saved connections to %s", storeFile)
+		}
+	}
+
+	// Optionally compress every large response
+	compressAll := os.Getenv("DATA_WARDEN_COMPRESS") == "gzip"
+
 	// Setup stdin/stdout for JSON-RPC communication
 	scanner := bufio.NewScanner(os.Stdin)
 	writer := bufio.NewWriter(os.Stdout)
 
+	// Notifications share the write mutex with responses
+	srv.SetNotifier(func(notification *protocol.Notification) {
+		if err := sendNotification(writer, notification); err != nil {
+			log.Printf("Error sending notification: %v", err)
+		}
+	})
+
+	// Report lost and restored connections without client polling, and
+	// optionally re-open lost ones (e.g. after a server restart)
+	if os.Getenv("DATA_WARDEN_AUTO_RECONNECT") == "1" {
+		srv.SetAutoReconnect(true)
+		log.Println("Reconnecting lost connections automatically")
+	}
+	srv.StartHealthSweep(server.DefaultHealthSweepInterval)
+
 	log.Println("Backend ready, waiting for requests...")
 
 	// Main request loop - handle requests concurrently
@@ -49,6 +100,14 @@ func main() {
 			// Handle request
 			response := srv.HandleRequest(&req)
 
+			// Compress large results when requested per request, globally
+			// or negotiated by initialize
+			if req.Compress == "gzip" || compressAll || srv.Capabilities().Compression == "gzip" {
+				if err := protocol.CompressResult(response, protocol.CompressionThreshold); err != nil {
+					log.Printf("Error compressing response: %v", err)
+				}
+			}
+
 			// Send response (synchronize writes)
 			if err := sendResponse(writer, response); err != nil {
 				log.Printf("Error sending response: %v", err)
@@ -62,17 +121,30 @@ func main() {
 }
 
 func sendResponse(writer *bufio.Writer, response *protocol.Response) error {
-	// Lock to prevent concurrent writes
-	writeMutex.Lock()
-	defer writeMutex.Unlock()
-
 	data, err := json.Marshal(response)
 	if err != nil {
 		return fmt.Errorf("failed to marshal response: %w", err)
 	}
 
+	return writeMessage(writer, data)
+}
+
+func sendNotification(writer *bufio.Writer, notification *protocol.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	return writeMessage(writer, data)
+}
+
+func writeMessage(writer *bufio.Writer, data []byte) error {
+	// Lock to prevent concurrent writes
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
 	if _, err := writer.Write(data); err != nil {
-		return fmt.Errorf("failed to write response: %w", err)
+		return fmt.Errorf("failed to write message: %w", err)
 	}
 
 	if err := writer.WriteByte('\n'); err != nil {
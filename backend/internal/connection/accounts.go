@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// ListUsers returns the accounts defined on the server. If the current user
+// cannot read mysql.user, an empty unavailable list is returned instead of
+// an error.
+func (c *Connection) ListUsers(ctx context.Context) (*protocol.AccountList, error) {
+	accounts, err := c.queryAccounts(ctx, "SELECT User, Host, account_locked = 'Y' FROM mysql.user ORDER BY User, Host")
+	if mysqlErrorNumber(err) == errBadField {
+		// Older servers and MariaDB don't expose account_locked
+		accounts, err = c.queryAccounts(ctx, "SELECT User, Host, FALSE FROM mysql.user ORDER BY User, Host")
+	}
+	return accountList(accounts, err, "users")
+}
+
+// ListRoles returns the roles defined on the server (MySQL 8 and MariaDB).
+// MySQL stores roles as locked accounts with no password, and role_edges
+// records roles that have been granted.
+func (c *Connection) ListRoles(ctx context.Context) (*protocol.AccountList, error) {
+	accounts, err := c.queryAccounts(ctx, `
+		SELECT User, Host, TRUE FROM mysql.user
+		WHERE account_locked = 'Y' AND password_expired = 'Y' AND authentication_string = ''
+		UNION
+		SELECT FROM_USER, FROM_HOST, TRUE FROM mysql.role_edges
+		ORDER BY 1, 2`)
+	if n := mysqlErrorNumber(err); n == errNoSuchTable || n == errBadField {
+		// MariaDB flags roles in mysql.user instead
+		accounts, err = c.queryAccounts(ctx, "SELECT User, Host, FALSE FROM mysql.user WHERE is_role = 'Y' ORDER BY User")
+	}
+	return accountList(accounts, err, "roles")
+}
+
+func (c *Connection) queryAccounts(ctx context.Context, query string) ([]protocol.Account, error) {
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]protocol.Account, 0, 16)
+	for rows.Next() {
+		var account protocol.Account
+		var locked sql.NullBool
+		if err := rows.Scan(&account.User, &account.Host, &locked); err != nil {
+			return nil, err
+		}
+		account.AccountLocked = locked.Valid && locked.Bool
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// accountList wraps an account query result, turning permission and
+// missing-table errors into an informational unavailable result
+func accountList(accounts []protocol.Account, err error, kind string) (*protocol.AccountList, error) {
+	if err != nil {
+		if isPermissionError(err) {
+			return &protocol.AccountList{
+				Accounts: []protocol.Account{},
+				Message:  fmt.Sprintf("The current user is not allowed to list %s (requires SELECT on the mysql schema)", kind),
+			}, nil
+		}
+		if n := mysqlErrorNumber(err); n == errNoSuchTable || n == errBadField {
+			return &protocol.AccountList{
+				Accounts: []protocol.Account{},
+				Message:  fmt.Sprintf("This server does not support listing %s", kind),
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+	}
+
+	return &protocol.AccountList{
+		Accounts:  accounts,
+		Available: true,
+	}, nil
+}
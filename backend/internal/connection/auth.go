@@ -0,0 +1,63 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// pluginRecorder is the driver logger for a connection. The driver reports
+// an unsupported authentication plugin only through its logger, so the
+// recorder keeps the plugin name for the connect error and forwards every
+// message to the standard logger like the driver's default does.
+type pluginRecorder struct {
+	mu     sync.Mutex
+	plugin string
+}
+
+func (r *pluginRecorder) Print(v ...interface{}) {
+	if len(v) == 2 && v[0] == "unknown auth plugin:" {
+		r.mu.Lock()
+		r.plugin = fmt.Sprint(v[1])
+		r.mu.Unlock()
+	}
+	log.Print("[mysql] ", fmt.Sprintln(v...))
+}
+
+// unknownPlugin returns the last unsupported plugin the server asked for
+func (r *pluginRecorder) unknownPlugin() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.plugin
+}
+
+// authPluginError explains a failed login caused by the server asking for an
+// authentication plugin the connection config does not allow, naming the
+// plugin. It returns nil for any other error.
+func authPluginError(err error, recorder *pluginRecorder, ssl bool) error {
+	switch {
+	case errors.Is(err, mysql.ErrCleartextPassword):
+		hint := ""
+		if !ssl {
+			hint = " and SSL, since the password is sent unencrypted"
+		}
+		return fmt.Errorf("authentication failed: the user requires the mysql_clear_password plugin (used for LDAP and PAM). Enable allowCleartextPasswords%s", hint)
+	case errors.Is(err, mysql.ErrNativePassword):
+		return fmt.Errorf("authentication failed: the user requires the mysql_native_password plugin, which allowNativePasswords disables")
+	case errors.Is(err, mysql.ErrOldPassword):
+		return fmt.Errorf("authentication failed: the user requires the insecure mysql_old_password plugin. Set the allowOldPasswords parameter to use it")
+	case errors.Is(err, mysql.ErrUnknownPlugin):
+		plugin := "an unknown plugin"
+		if name := recorder.unknownPlugin(); name != "" {
+			plugin = "the " + name + " plugin"
+		}
+		return fmt.Errorf("authentication failed: the server requested %s, which the MySQL driver does not support", plugin)
+	case strings.Contains(err.Error(), "caching_sha2_password"):
+		return fmt.Errorf("authentication failed: the caching_sha2_password plugin could not complete full authentication (%v). Enable SSL, or allow the server to send its RSA public key", err)
+	}
+	return nil
+}
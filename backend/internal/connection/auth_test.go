@@ -0,0 +1,45 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestAuthPluginError(t *testing.T) {
+	recorder := &pluginRecorder{}
+	recorder.Print("unknown auth plugin:", "authentication_ldap_sasl_client")
+
+	tests := []struct {
+		err  error
+		ssl  bool
+		want string // empty when the error is not a plugin mismatch
+	}{
+		{mysql.ErrCleartextPassword, false, "mysql_clear_password plugin (used for LDAP and PAM). Enable allowCleartextPasswords and SSL"},
+		{mysql.ErrCleartextPassword, true, "Enable allowCleartextPasswords"},
+		{fmt.Errorf("ping: %w", mysql.ErrNativePassword), false, "mysql_native_password"},
+		{mysql.ErrOldPassword, false, "mysql_old_password"},
+		{mysql.ErrUnknownPlugin, false, "the authentication_ldap_sasl_client plugin"},
+		{errors.New("unexpected resp from server for caching_sha2_password, perform full authentication"), false, "caching_sha2_password"},
+		{&mysql.MySQLError{Number: errAccessDenied, Message: "Access denied"}, false, ""},
+	}
+	for _, tt := range tests {
+		got := authPluginError(tt.err, recorder, tt.ssl)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("authPluginError(%v) = %v, want nil", tt.err, got)
+			}
+			continue
+		}
+		if got == nil || !strings.Contains(got.Error(), tt.want) {
+			t.Errorf("authPluginError(%v) = %v, want it to contain %q", tt.err, got, tt.want)
+		}
+	}
+
+	if got := authPluginError(mysql.ErrUnknownPlugin, &pluginRecorder{}, false); !strings.Contains(got.Error(), "an unknown plugin") {
+		t.Errorf("Expected a generic message without a recorded plugin, got %v", got)
+	}
+}
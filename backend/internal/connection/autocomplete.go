@@ -0,0 +1,87 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// sqlKeywords are offered alongside schema names for completion
+var sqlKeywords = []string{
+	"ADD", "ALL", "ALTER", "AND", "AS", "ASC", "AUTO_INCREMENT", "BETWEEN",
+	"BIGINT", "BY", "CASE", "CHAR", "CHECK", "COLUMN", "CONSTRAINT", "COUNT",
+	"CREATE", "CROSS", "DATABASE", "DATE", "DATETIME", "DECIMAL", "DEFAULT",
+	"DELETE", "DESC", "DESCRIBE", "DISTINCT", "DROP", "ELSE", "END", "ENUM",
+	"EXISTS", "EXPLAIN", "FALSE", "FOREIGN", "FROM", "FULL", "GROUP", "HAVING",
+	"IF", "IGNORE", "IN", "INDEX", "INNER", "INSERT", "INT", "INTERVAL", "INTO",
+	"IS", "JOIN", "JSON", "KEY", "LEFT", "LIKE", "LIMIT", "NOT", "NULL",
+	"OFFSET", "ON", "OR", "ORDER", "OUTER", "PRIMARY", "REFERENCES", "REPLACE",
+	"RIGHT", "SELECT", "SET", "SHOW", "TABLE", "TEXT", "THEN", "TIMESTAMP",
+	"TRUE", "TRUNCATE", "UNION", "UNIQUE", "UNSIGNED", "UPDATE", "USE", "USING",
+	"VALUES", "VARCHAR", "VIEW", "WHEN", "WHERE", "WITH",
+}
+
+// systemSchemaFilter excludes the server's own schemas from an unscoped
+// autocomplete schema
+const systemSchemaFilter = "TABLE_SCHEMA NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')"
+
+// GetAutocompleteSchema returns databases, tables and columns for editor
+// completion in two information_schema queries. An empty database returns
+// every non-system database.
+func (c *Connection) GetAutocompleteSchema(ctx context.Context, database string) (*protocol.AutocompleteSchema, error) {
+	filter, args := systemSchemaFilter, []interface{}{}
+	if database != "" {
+		filter, args = "TABLE_SCHEMA = ?", []interface{}{database}
+	}
+
+	schema := &protocol.AutocompleteSchema{
+		Databases: make(map[string]map[string][]protocol.AutocompleteColumn),
+		Keywords:  sqlKeywords,
+	}
+
+	// Tables first so tables without visible columns still complete
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES WHERE "+filter, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tables: %w", err)
+	}
+	for rows.Next() {
+		var db, table string
+		if err := rows.Scan(&db, &table); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if schema.Databases[db] == nil {
+			schema.Databases[db] = make(map[string][]protocol.AutocompleteColumn)
+		}
+		schema.Databases[db][table] = []protocol.AutocompleteColumn{}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = c.db.QueryContext(ctx,
+		"SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, DATA_TYPE FROM information_schema.COLUMNS WHERE "+
+			filter+" ORDER BY TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load columns: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var db, table string
+		var col protocol.AutocompleteColumn
+		if err := rows.Scan(&db, &table, &col.Name, &col.Type); err != nil {
+			return nil, err
+		}
+		col.Type = strings.ToLower(col.Type)
+		if schema.Databases[db] == nil {
+			schema.Databases[db] = make(map[string][]protocol.AutocompleteColumn)
+		}
+		schema.Databases[db][table] = append(schema.Databases[db][table], col)
+	}
+
+	return schema, rows.Err()
+}
@@ -0,0 +1,114 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// integerMaxValues are the signed and unsigned maximums of MySQL integer types
+var integerMaxValues = map[string][2]uint64{
+	"tinyint":   {math.MaxInt8, math.MaxUint8},
+	"smallint":  {math.MaxInt16, math.MaxUint16},
+	"mediumint": {1<<23 - 1, 1<<24 - 1},
+	"int":       {math.MaxInt32, math.MaxUint32},
+	"integer":   {math.MaxInt32, math.MaxUint32},
+	"bigint":    {math.MaxInt64, math.MaxUint64},
+}
+
+// integerMaxValue returns the largest value an integer column type such as
+// "int(11) unsigned" can hold
+func integerMaxValue(columnType string) (uint64, bool) {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	base := columnType
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
+
+	limits, ok := integerMaxValues[base]
+	if !ok {
+		return 0, false
+	}
+	if strings.Contains(columnType, "unsigned") {
+		return limits[1], true
+	}
+	return limits[0], true
+}
+
+// escapeLike escapes LIKE wildcards so a name matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// autoIncrementValue reads the Auto_increment column of SHOW TABLE STATUS.
+// Counters of BIGINT UNSIGNED columns may be above math.MaxInt64, which the
+// driver returns as decimal text.
+func autoIncrementValue(value interface{}) (uint64, bool) {
+	n, ok := unsignedBigint(value).(uint64)
+	return n, ok
+}
+
+// GetAutoIncrement returns the table's next AUTO_INCREMENT value alongside
+// the capacity of its auto-increment column. On MySQL 8 the counter comes from
+// cached table statistics and may lag by up to information_schema_stats_expiry.
+func (c *Connection) GetAutoIncrement(ctx context.Context, database, table string) (*protocol.AutoIncrementStatus, error) {
+	status := &protocol.AutoIncrementStatus{Database: database, Table: table}
+
+	query := fmt.Sprintf("SHOW TABLE STATUS FROM %s LIKE '%s'",
+		quoteIdentifier(database), strings.ReplaceAll(escapeLike(table), "'", "''"))
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table status: %w", err)
+	}
+	defer rows.Close()
+
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table status: %w", err)
+	}
+	found := false
+	for rows.Next() {
+		row, err := scanner.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		if next, ok := autoIncrementValue(row["Auto_increment"]); ok {
+			status.NextValue = &next
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("table not found: %s.%s", database, table)
+	}
+
+	var columnKey string
+	err = c.db.QueryRowContext(ctx,
+		`SELECT COLUMN_NAME, COLUMN_TYPE, COLUMN_KEY FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND EXTRA LIKE '%auto_increment%'`,
+		database, table,
+	).Scan(&status.Column, &status.ColumnType, &columnKey)
+	if err == sql.ErrNoRows {
+		return status, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auto-increment column: %w", err)
+	}
+	status.PrimaryKey = columnKey == "PRI"
+
+	if max, ok := integerMaxValue(status.ColumnType); ok {
+		status.MaxValue = max
+		if status.NextValue != nil && *status.NextValue > 0 {
+			// The next value has not been used yet
+			status.UsedPercent = float64(*status.NextValue-1) / float64(max) * 100
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,58 @@
+package connection
+
+import "testing"
+
+func TestIntegerMaxValue(t *testing.T) {
+	testCases := []struct {
+		columnType string
+		expected   uint64
+		ok         bool
+	}{
+		{"tinyint", 127, true},
+		{"tinyint(3) unsigned", 255, true},
+		{"smallint unsigned", 65535, true},
+		{"mediumint", 8388607, true},
+		{"int(11)", 2147483647, true},
+		{"INT UNSIGNED", 4294967295, true},
+		{"bigint", 9223372036854775807, true},
+		{"bigint(20) unsigned zerofill", 18446744073709551615, true},
+		{"decimal(10,0)", 0, false},
+		{"varchar(36)", 0, false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := integerMaxValue(tc.columnType)
+		if ok != tc.ok || got != tc.expected {
+			t.Errorf("integerMaxValue(%q): expected %d/%v, got %d/%v", tc.columnType, tc.expected, tc.ok, got, ok)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`order_items%\`); got != `order\_items\%\\` {
+		t.Errorf("Unexpected escaped pattern: %s", got)
+	}
+}
+
+func TestAutoIncrementValue(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		expected uint64
+		ok       bool
+	}{
+		{int64(42), 42, true},
+		{[]byte("1001"), 1001, true},
+		// BIGINT UNSIGNED counters past MaxInt64 arrive as text
+		{[]byte("18446744073709551610"), 18446744073709551610, true},
+		{"9223372036854775808", 9223372036854775808, true},
+		{uint64(18446744073709551615), 18446744073709551615, true},
+		{nil, 0, false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := autoIncrementValue(tc.value)
+		if ok != tc.ok || got != tc.expected {
+			t.Errorf("autoIncrementValue(%v): expected %d/%v, got %d/%v", tc.value, tc.expected, tc.ok, got, ok)
+		}
+	}
+}
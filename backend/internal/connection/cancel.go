@@ -0,0 +1,160 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// queryWithKill runs a query on a dedicated pooled connection. If ctx is
+// cancelled while the query runs, the driver only drops the client socket and
+// the server keeps executing the statement (holding locks and CPU), so a
+// KILL QUERY is issued for the connection's thread as well.
+// The returned release function must be called once the rows are consumed.
+func (c *Connection) queryWithKill(ctx context.Context, sqlQuery string, args ...interface{}) (*sql.Rows, func(), error) {
+	return c.queryWithKillWatch(ctx, nil, sqlQuery, args...)
+}
+
+// queryWithKillWatch is queryWithKill with a hook that runs once the server
+// thread is known and before the query is sent. The stop function it returns
+// is called when the rows are released.
+func (c *Connection) queryWithKillWatch(ctx context.Context, watch func(threadID uint64) (stop func()), sqlQuery string, args ...interface{}) (*sql.Rows, func(), error) {
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stop := c.watchCancel(ctx, threadID)
+	stopWatch := func() {}
+	if watch != nil {
+		stopWatch = watch(threadID)
+	}
+
+	rows, err := conn.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		stopWatch()
+		stop()
+		releaseAfter(ctx, conn, sqlQuery)
+		return nil, nil, err
+	}
+
+	release := func() {
+		stopWatch()
+		stop()
+		rows.Close()
+		releaseAfter(ctx, conn, sqlQuery)
+	}
+	return rows, release, nil
+}
+
+// execWithKill runs a statement on a pinned connection. Like queryWithKill,
+// it issues a KILL QUERY if ctx is cancelled while the statement runs, so a
+// long ALTER or DROP stops on the server rather than finishing unseen.
+func (c *Connection) execWithKill(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseAfter(ctx, conn, query)
+
+	stop := c.watchCancel(ctx, threadID)
+	defer stop()
+	return conn.ExecContext(ctx, query, args...)
+}
+
+// pinConn reserves a pooled connection for session-scoped work and returns
+// its server thread id
+func (c *Connection) pinConn(ctx context.Context) (*sql.Conn, uint64, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var threadID uint64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&threadID); err != nil {
+		conn.Close()
+		return nil, 0, err
+	}
+
+	return conn, threadID, nil
+}
+
+// watchCancel kills the query running on threadID when ctx is cancelled.
+// The returned stop function ends the watch and waits for an in-progress kill
+// to finish, so a kill can never hit a later query on the same connection.
+func (c *Connection) watchCancel(ctx context.Context, threadID uint64) func() {
+	var mu sync.Mutex
+	finished := false
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			if !finished {
+				c.killQuery(threadID)
+			}
+			mu.Unlock()
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			mu.Lock()
+			finished = true
+			mu.Unlock()
+			close(done)
+		})
+	}
+}
+
+// killQuery asks the server to abort the statement running on threadID
+func (c *Connection) killQuery(threadID uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", threadID)); err != nil {
+		log.Printf("Failed to kill query on thread %d: %v", threadID, err)
+		return
+	}
+	log.Printf("Killed query on thread %d", threadID)
+}
+
+// releaseConn returns a pinned connection to the pool, discarding it instead
+// if ctx was cancelled so a pending kill cannot affect its next user
+func releaseConn(ctx context.Context, conn *sql.Conn) {
+	if ctx.Err() != nil {
+		discardConn(conn)
+		return
+	}
+	conn.Close()
+}
+
+// releaseAfter releases a pinned connection once statements have run on it,
+// discarding it if any of them may have changed session state (USE, SET,
+// open transactions, temporary tables) so that state cannot leak into
+// unrelated requests that later draw the same connection from the pool
+func releaseAfter(ctx context.Context, conn *sql.Conn, statements ...string) {
+	for _, stmt := range statements {
+		if changesSessionState(stmt) {
+			discardConn(conn)
+			return
+		}
+	}
+	releaseConn(ctx, conn)
+}
+
+// discardConn closes a pinned connection without returning it to the pool,
+// for connections left in a state other queries must not inherit
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
@@ -0,0 +1,30 @@
+package connection
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExecWithKillKillsCancelledStatement(t *testing.T) {
+	// The kill runs on a second connection
+	connector := &fakeSessionConnector{}
+	c := newFakeSessionConnectionFrom(t, connector, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.execWithKill(ctx, "ALTER TABLE `shop`.`big` FORCE"); err == nil {
+		t.Fatal("Expected the cancelled statement to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Statement was not stopped by the context, took %v", elapsed)
+	}
+	if !connector.killed(1) {
+		t.Errorf("Expected a KILL QUERY for the statement's thread, got %v", connector.kills)
+	}
+
+	if _, err := c.execWithKill(context.Background(), "CREATE DATABASE IF NOT EXISTS `shop`"); err != nil {
+		t.Errorf("Expected the statement to run, got %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// Selectivity thresholds for cardinality advice: below lowSelectivity an
+// index on the columns alone rarely beats a scan; statistics off by more
+// than staleStatisticsFactor from the actual count are worth refreshing
+const (
+	lowSelectivity        = 0.01
+	highSelectivity       = 0.3
+	staleStatisticsFactor = 2.0
+)
+
+// indexStatistic is one column of an index from information_schema.STATISTICS
+type indexStatistic struct {
+	index       string
+	seq         int64
+	column      string
+	cardinality *int64
+}
+
+// indexEstimate finds an index whose leading columns are exactly columns,
+// in any order, and returns it with the estimated number of distinct
+// values of that prefix. The primary key is preferred; nil means no index
+// starts with the columns or its statistics are missing.
+func indexEstimate(stats []indexStatistic, columns []string, normalize func(string) string) (string, *int64) {
+	wanted := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		wanted[normalize(column)] = true
+	}
+
+	var order []string
+	byIndex := make(map[string][]indexStatistic)
+	for _, stat := range stats {
+		if _, ok := byIndex[stat.index]; !ok {
+			if stat.index == "PRIMARY" {
+				order = append([]string{stat.index}, order...)
+			} else {
+				order = append(order, stat.index)
+			}
+		}
+		byIndex[stat.index] = append(byIndex[stat.index], stat)
+	}
+
+	for _, index := range order {
+		parts := byIndex[index]
+		if len(parts) < len(wanted) {
+			continue
+		}
+		matched := 0
+		var estimate *int64
+		for _, part := range parts {
+			if part.seq > int64(len(wanted)) {
+				continue
+			}
+			if !wanted[normalize(part.column)] {
+				break
+			}
+			matched++
+			if part.seq == int64(len(wanted)) {
+				estimate = part.cardinality
+			}
+		}
+		if matched == len(wanted) && estimate != nil {
+			return index, estimate
+		}
+	}
+	return "", nil
+}
+
+// selectivity is distinct values per row, or nil for an empty table
+func selectivity(distinct, rows int64) *float64 {
+	if rows <= 0 {
+		return nil
+	}
+	ratio := float64(distinct) / float64(rows)
+	return &ratio
+}
+
+// cardinalityAdvice explains what the selectivity means for indexing the
+// columns, preferring the actual counts when they were computed
+func cardinalityAdvice(result *protocol.ColumnCardinality) []string {
+	advice := []string{}
+	ratio := result.EstimatedSelectivity
+	if result.ActualSelectivity != nil {
+		ratio = result.ActualSelectivity
+	}
+
+	columns := strings.Join(result.Columns, ", ")
+	switch {
+	case ratio == nil && result.Index == "" && result.ActualDistinct == nil:
+		advice = append(advice, fmt.Sprintf("No index starts with (%s), so there is no estimate; compute the actual count to judge selectivity.", columns))
+	case ratio == nil:
+		// An empty table
+	case *ratio < lowSelectivity:
+		advice = append(advice, fmt.Sprintf("Low selectivity (%.3g): each value of (%s) matches about %.0f rows on average, so an index on these columns alone is unlikely to beat a table scan. Consider them as later columns of a composite index instead.", *ratio, columns, 1 / *ratio))
+	case *ratio >= highSelectivity:
+		advice = append(advice, fmt.Sprintf("High selectivity (%.3g): an index on (%s) narrows lookups to few rows.", *ratio, columns))
+	default:
+		advice = append(advice, fmt.Sprintf("Moderate selectivity (%.3g): an index on (%s) helps queries for rare values; check the value distribution.", *ratio, columns))
+	}
+
+	if result.EstimatedDistinct != nil && result.ActualDistinct != nil {
+		estimated, actual := float64(*result.EstimatedDistinct), float64(*result.ActualDistinct)
+		if estimated > actual*staleStatisticsFactor || actual > estimated*staleStatisticsFactor {
+			advice = append(advice, fmt.Sprintf("Index statistics for %s estimate %d distinct values but there are %d; run ANALYZE TABLE to refresh them.",
+				result.Index, *result.EstimatedDistinct, *result.ActualDistinct))
+		}
+	}
+	return advice
+}
+
+// GetColumnCardinality compares the optimizer's estimate of distinct values
+// of one or more columns, from the statistics of an index starting with
+// them, with the table's estimated row count. With actual set it also
+// counts distinct values and rows exactly, which reads the whole table.
+// COUNT(DISTINCT) skips rows where any of the columns is NULL.
+func (c *Connection) GetColumnCardinality(ctx context.Context, database, table string, columns []string, actual bool) (*protocol.ColumnCardinality, error) {
+	startTime := time.Now()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("at least one column is required")
+	}
+
+	existing, err := c.columnNames(ctx, database, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		if !existing[c.NormalizeIdentifier(column)] {
+			return nil, fmt.Errorf("column %s not found in %s.%s", column, database, table)
+		}
+	}
+
+	result := &protocol.ColumnCardinality{
+		Database: database,
+		Table:    table,
+		Columns:  columns,
+	}
+
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		database, table).Scan(&result.EstimatedRows); err != nil {
+		return nil, cardinalityError(ctx, err)
+	}
+
+	stats, err := c.indexStatistics(ctx, database, table)
+	if err != nil {
+		return nil, cardinalityError(ctx, err)
+	}
+	result.Index, result.EstimatedDistinct = indexEstimate(stats, columns, c.NormalizeIdentifier)
+	if result.EstimatedDistinct != nil {
+		result.EstimatedSelectivity = selectivity(*result.EstimatedDistinct, result.EstimatedRows)
+	}
+
+	if actual {
+		quoted := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = quoteIdentifier(column)
+		}
+		rows, release, err := c.queryWithKill(ctx, fmt.Sprintf("SELECT COUNT(DISTINCT %s), COUNT(*) FROM %s",
+			strings.Join(quoted, ", "), qualifiedTable(database, table)))
+		if err != nil {
+			return nil, cardinalityError(ctx, err)
+		}
+		var distinct, total int64
+		if rows.Next() {
+			err = rows.Scan(&distinct, &total)
+		} else {
+			err = rows.Err()
+		}
+		release()
+		if err != nil {
+			return nil, cardinalityError(ctx, err)
+		}
+		result.ActualDistinct = &distinct
+		result.ActualRows = &total
+		result.ActualSelectivity = selectivity(distinct, total)
+	}
+
+	result.Advice = cardinalityAdvice(result)
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// cardinalityError reports a cancellation instead of the error it caused
+func cardinalityError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("query cancelled: %w", ctx.Err())
+	}
+	return fmt.Errorf("failed to read cardinality: %w", err)
+}
+
+// columnNames returns the normalized names of a table's columns
+func (c *Connection) columnNames(ctx context.Context, database, table string) (map[string]bool, error) {
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		database, table)
+	if err != nil {
+		return nil, cardinalityError(ctx, err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[c.NormalizeIdentifier(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("table %s.%s not found", database, table)
+	}
+	return names, nil
+}
+
+// indexStatistics reads the per-column index statistics of a table, the
+// same data as SHOW INDEX
+func (c *Connection) indexStatistics(ctx context.Context, database, table string) ([]indexStatistic, error) {
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT INDEX_NAME, SEQ_IN_INDEX, COLUMN_NAME, CARDINALITY FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX`,
+		database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []indexStatistic
+	for rows.Next() {
+		var stat indexStatistic
+		var column sql.NullString
+		var cardinality sql.NullInt64
+		if err := rows.Scan(&stat.index, &stat.seq, &column, &cardinality); err != nil {
+			return nil, err
+		}
+		// Functional key parts have no column name
+		stat.column = column.String
+		stat.cardinality = nullInt64(cardinality)
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,95 @@
+package connection
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestIndexEstimate(t *testing.T) {
+	n := func(v int64) *int64 { return &v }
+	stats := []indexStatistic{
+		{index: "idx_status_created", seq: 1, column: "status", cardinality: n(4)},
+		{index: "idx_status_created", seq: 2, column: "created_at", cardinality: n(90000)},
+		{index: "PRIMARY", seq: 1, column: "id", cardinality: n(100000)},
+		{index: "idx_email", seq: 1, column: "email", cardinality: nil},
+	}
+
+	testCases := []struct {
+		name          string
+		columns       []string
+		expectedIndex string
+		expected      *int64
+	}{
+		{"Primary key", []string{"id"}, "PRIMARY", n(100000)},
+		{"Leading column", []string{"status"}, "idx_status_created", n(4)},
+		{"Prefix in any order", []string{"created_at", "Status"}, "idx_status_created", n(90000)},
+		{"Not a leading column", []string{"created_at"}, "", nil},
+		{"Statistics missing", []string{"email"}, "", nil},
+		{"No index", []string{"note"}, "", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, estimate := indexEstimate(stats, tc.columns, strings.ToLower)
+			if index != tc.expectedIndex {
+				t.Errorf("Expected index %q, got %q", tc.expectedIndex, index)
+			}
+			if (estimate == nil) != (tc.expected == nil) || (estimate != nil && *estimate != *tc.expected) {
+				t.Errorf("Expected estimate %v, got %v", tc.expected, estimate)
+			}
+		})
+	}
+}
+
+func TestCardinalityAdvice(t *testing.T) {
+	n := func(v int64) *int64 { return &v }
+
+	testCases := []struct {
+		name     string
+		result   protocol.ColumnCardinality
+		expected []string
+	}{
+		{
+			name:     "Low selectivity",
+			result:   protocol.ColumnCardinality{Columns: []string{"status"}, Index: "idx_status", EstimatedSelectivity: selectivity(4, 100000)},
+			expected: []string{"Low selectivity (4e-05): each value of (status) matches about 25000 rows"},
+		},
+		{
+			name:     "High selectivity",
+			result:   protocol.ColumnCardinality{Columns: []string{"email"}, Index: "idx_email", EstimatedSelectivity: selectivity(99000, 100000)},
+			expected: []string{"High selectivity (0.99)"},
+		},
+		{
+			name:     "Actual counts win over the estimate",
+			result:   protocol.ColumnCardinality{Columns: []string{"city"}, Index: "idx_city", EstimatedDistinct: n(100), EstimatedSelectivity: selectivity(100, 100000), ActualDistinct: n(5000), ActualSelectivity: selectivity(5000, 100000)},
+			expected: []string{"Moderate selectivity (0.05)", "estimate 100 distinct values but there are 5000; run ANALYZE TABLE"},
+		},
+		{
+			name:     "No estimate",
+			result:   protocol.ColumnCardinality{Columns: []string{"note"}},
+			expected: []string{"No index starts with (note)"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			advice := cardinalityAdvice(&tc.result)
+			if len(advice) != len(tc.expected) {
+				t.Fatalf("Expected %d lines of advice, got %v", len(tc.expected), advice)
+			}
+			for i, want := range tc.expected {
+				if !strings.Contains(advice[i], want) {
+					t.Errorf("Expected advice %q to contain %q", advice[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectivityOfEmptyTable(t *testing.T) {
+	if ratio := selectivity(0, 0); ratio != nil {
+		t.Errorf("Expected no selectivity for an empty table, got %v", *ratio)
+	}
+}
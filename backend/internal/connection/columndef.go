@@ -0,0 +1,109 @@
+package connection
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+var (
+	// currentTimestampPattern matches defaults that are keywords, not strings
+	currentTimestampPattern = regexp.MustCompile(`(?i)^(current_timestamp|now|localtime|localtimestamp)(\(\d*\))?$`)
+	// onUpdatePattern extracts the ON UPDATE clause from SHOW COLUMNS Extra
+	onUpdatePattern = regexp.MustCompile(`(?i)\bon update (current_timestamp(\(\d*\))?)`)
+)
+
+// generatedType returns "VIRTUAL" or "STORED" when SHOW COLUMNS Extra marks
+// a generated column, or "". MariaDB calls stored columns PERSISTENT.
+func generatedType(extra string) string {
+	extra = strings.ToUpper(extra)
+	switch {
+	case strings.Contains(extra, "VIRTUAL GENERATED"):
+		return "VIRTUAL"
+	case strings.Contains(extra, "STORED GENERATED"), strings.Contains(extra, "PERSISTENT GENERATED"):
+		return "STORED"
+	}
+	return ""
+}
+
+// setGenerated fills in a column's generated column fields from its Extra
+func setGenerated(col *protocol.Column) {
+	col.GeneratedType = generatedType(col.Extra)
+	col.IsGenerated = col.GeneratedType != ""
+	col.Values = enumValues(col.Type)
+}
+
+// columnDefinition rebuilds a column's definition as used by MODIFY and
+// CHANGE COLUMN from its SHOW COLUMNS details, so redefining a column keeps
+// its type, collation, nullability, default, extras and comment. Generated
+// columns are refused if their expression is not known.
+func columnDefinition(col protocol.Column) (string, error) {
+	generated := generatedType(col.Extra)
+	if generated != "" && col.GenerationExpression == "" {
+		return "", fmt.Errorf("cannot redefine generated column %s: its expression is not available", col.Name)
+	}
+
+	parts := []string{quoteIdentifier(col.Name), col.Type}
+	if col.Collation != "" {
+		if !charsetNamePattern.MatchString(col.Collation) {
+			return "", fmt.Errorf("invalid collation: %s", col.Collation)
+		}
+		parts = append(parts, "COLLATE "+col.Collation)
+	}
+	if generated != "" {
+		parts = append(parts, "GENERATED ALWAYS AS ("+col.GenerationExpression+") "+generated)
+	}
+	if col.Nullable {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
+	}
+	if col.Default != nil && generated == "" {
+		parts = append(parts, "DEFAULT "+defaultExpression(*col.Default, col.Extra))
+	}
+
+	extra := strings.ToUpper(col.Extra)
+	if strings.Contains(extra, "AUTO_INCREMENT") {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+	if m := onUpdatePattern.FindStringSubmatch(col.Extra); m != nil {
+		parts = append(parts, "ON UPDATE "+strings.ToUpper(m[1]))
+	}
+	if strings.Contains(extra, "INVISIBLE") {
+		parts = append(parts, "INVISIBLE")
+	}
+	if col.Comment != "" {
+		parts = append(parts, "COMMENT "+quoteString(col.Comment))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// defaultExpression renders a SHOW COLUMNS default for a column definition.
+// MySQL 8 marks expression defaults with DEFAULT_GENERATED in Extra; those
+// are parenthesized, while plain values are quoted as strings.
+func defaultExpression(value, extra string) string {
+	switch {
+	case currentTimestampPattern.MatchString(value):
+		return strings.ToUpper(value)
+	case strings.HasPrefix(value, "b'") && strings.HasSuffix(value, "'"):
+		// BIT defaults are shown as bit literals
+		return value
+	case strings.Contains(strings.ToUpper(extra), "DEFAULT_GENERATED"):
+		return "(" + value + ")"
+	}
+	return quoteString(value)
+}
+
+// findColumn returns the named column; column names compare
+// case-insensitively in MySQL
+func findColumn(columns []protocol.Column, name string) (protocol.Column, bool) {
+	for _, col := range columns {
+		if strings.EqualFold(col.Name, name) {
+			return col, true
+		}
+	}
+	return protocol.Column{}, false
+}
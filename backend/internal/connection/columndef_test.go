@@ -0,0 +1,97 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestColumnDefinition(t *testing.T) {
+	value := func(s string) *string { return &s }
+
+	testCases := []struct {
+		name     string
+		column   protocol.Column
+		expected string
+	}{
+		{
+			name:     "Auto increment key",
+			column:   protocol.Column{Name: "id", Type: "bigint unsigned", Extra: "auto_increment"},
+			expected: "`id` bigint unsigned NOT NULL AUTO_INCREMENT",
+		},
+		{
+			name: "String default with collation and comment",
+			column: protocol.Column{Name: "status", Type: "varchar(20)", Collation: "utf8mb4_bin",
+				Default: value("it's new"), Comment: "Order status"},
+			expected: "`status` varchar(20) COLLATE utf8mb4_bin NOT NULL DEFAULT 'it''s new' COMMENT 'Order status'",
+		},
+		{
+			name: "Timestamp with on update",
+			column: protocol.Column{Name: "updated_at", Type: "timestamp(3)", Nullable: true,
+				Default: value("CURRENT_TIMESTAMP(3)"), Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP(3)"},
+			expected: "`updated_at` timestamp(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)",
+		},
+		{
+			name: "Expression default",
+			column: protocol.Column{Name: "ref", Type: "char(36)", Collation: "ascii_general_ci",
+				Default: value("uuid()"), Extra: "DEFAULT_GENERATED"},
+			expected: "`ref` char(36) COLLATE ascii_general_ci NOT NULL DEFAULT (uuid())",
+		},
+		{
+			name:     "Bit default and invisible",
+			column:   protocol.Column{Name: "flags", Type: "bit(3)", Default: value("b'101'"), Extra: "INVISIBLE"},
+			expected: "`flags` bit(3) NOT NULL DEFAULT b'101' INVISIBLE",
+		},
+		{
+			name:     "Nullable without default",
+			column:   protocol.Column{Name: "note", Type: "text", Nullable: true},
+			expected: "`note` text NULL",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := columnDefinition(tc.column)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestColumnDefinitionGeneratedColumns(t *testing.T) {
+	col := protocol.Column{Name: "total", Type: "decimal(10,2)", Nullable: true, Extra: "STORED GENERATED"}
+	if _, err := columnDefinition(col); err == nil {
+		t.Error("Expected error for a generated column without its expression")
+	}
+
+	col.GenerationExpression = "(`price` * `quantity`)"
+	col.Comment = "line total"
+	got, err := columnDefinition(col)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "`total` decimal(10,2) GENERATED ALWAYS AS ((`price` * `quantity`)) STORED NULL COMMENT 'line total'"
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestGeneratedType(t *testing.T) {
+	tests := map[string]string{
+		"VIRTUAL GENERATED":           "VIRTUAL",
+		"STORED GENERATED":            "STORED",
+		"PERSISTENT GENERATED":        "STORED",
+		"VIRTUAL GENERATED INVISIBLE": "VIRTUAL",
+		"DEFAULT_GENERATED":           "",
+		"auto_increment":              "",
+	}
+	for extra, want := range tests {
+		if got := generatedType(extra); got != want {
+			t.Errorf("generatedType(%q) = %q, want %q", extra, got, want)
+		}
+	}
+}
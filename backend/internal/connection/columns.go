@@ -0,0 +1,94 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// maxBulkTables bounds the tables per information_schema query so the
+// statement and its placeholder count stay reasonable
+const maxBulkTables = 500
+
+// bulkColumnsQuery returns an information_schema.COLUMNS query for n
+// (schema, table) pairs. Servers without generated columns lack
+// GENERATION_EXPRESSION, so it is only read when generation is set.
+func bulkColumnsQuery(n int, generation bool) string {
+	pairs := strings.TrimSuffix(strings.Repeat("(?, ?), ", n), ", ")
+	expression := "''"
+	if generation {
+		expression = "GENERATION_EXPRESSION"
+	}
+	return `SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE,
+	COLUMN_KEY, COLUMN_DEFAULT, EXTRA, COLUMN_COMMENT, COLLATION_NAME, ` + expression + `
+	FROM information_schema.COLUMNS
+	WHERE (TABLE_SCHEMA, TABLE_NAME) IN (` + pairs + `)
+	ORDER BY TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION`
+}
+
+// ListColumnsBulk returns the columns of many tables, keyed by database then
+// table, using one information_schema query per batch of tables. Tables that
+// do not exist are omitted.
+func (c *Connection) ListColumnsBulk(ctx context.Context, tables []protocol.TableRef) (map[string]map[string][]protocol.Column, error) {
+	result := make(map[string]map[string][]protocol.Column)
+
+	for start := 0; start < len(tables); start += maxBulkTables {
+		end := start + maxBulkTables
+		if end > len(tables) {
+			end = len(tables)
+		}
+		batch := tables[start:end]
+
+		args := make([]interface{}, 0, len(batch)*2)
+		for _, t := range batch {
+			args = append(args, t.Database, t.Table)
+		}
+
+		if err := c.collectColumns(ctx, bulkColumnsQuery(len(batch), c.version.hasGenerationExpression()), args, result); err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("listing columns cancelled: %w", ctx.Err())
+			}
+			return nil, fmt.Errorf("failed to list columns: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Connection) collectColumns(ctx context.Context, query string, args []interface{}, result map[string]map[string][]protocol.Column) error {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var database, table, nullStr string
+		var col protocol.Column
+		var defaultVal, collation, expression sql.NullString
+		if err := rows.Scan(&database, &table, &col.Name, &col.Type, &nullStr,
+			&col.Key, &defaultVal, &col.Extra, &col.Comment, &collation, &expression); err != nil {
+			return err
+		}
+
+		col.Nullable = nullStr == "YES"
+		col.Collation = collation.String
+		setGenerated(&col)
+		if col.IsGenerated {
+			col.GenerationExpression = expression.String
+		}
+		if defaultVal.Valid {
+			col.Default = &defaultVal.String
+		}
+
+		if result[database] == nil {
+			result[database] = make(map[string][]protocol.Column)
+		}
+		result[database][table] = append(result[database][table], col)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,26 @@
+package connection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBulkColumnsQuery(t *testing.T) {
+	query := bulkColumnsQuery(3, true)
+
+	if !strings.Contains(query, "IN ((?, ?), (?, ?), (?, ?))") {
+		t.Errorf("Expected three placeholder pairs, got:\n%s", query)
+	}
+	if strings.Count(query, "?") != 6 {
+		t.Errorf("Expected 6 placeholders, got %d", strings.Count(query, "?"))
+	}
+	if !strings.Contains(query, "ORDER BY TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION") {
+		t.Error("Expected columns in ordinal order")
+	}
+	if !strings.Contains(query, "GENERATION_EXPRESSION") {
+		t.Error("Expected generation expressions to be read")
+	}
+	if strings.Contains(bulkColumnsQuery(1, false), "GENERATION_EXPRESSION") {
+		t.Error("Expected no GENERATION_EXPRESSION for servers without generated columns")
+	}
+}
@@ -0,0 +1,65 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// setTableCommentSQL builds the ALTER TABLE statement that sets a table
+// comment; an empty comment removes it
+func setTableCommentSQL(database, table, comment string) string {
+	return fmt.Sprintf("ALTER TABLE %s COMMENT = %s", qualifiedTable(database, table), quoteString(comment))
+}
+
+// SetTableComment sets or clears a table's comment
+func (c *Connection) SetTableComment(ctx context.Context, database, table, comment string) error {
+	query := setTableCommentSQL(database, table, comment)
+	if err := c.checkStatement(query); err != nil {
+		return err
+	}
+
+	if _, err := c.execWithKill(ctx, query); err != nil {
+		return fmt.Errorf("failed to set table comment: %w", err)
+	}
+	return nil
+}
+
+// setColumnCommentSQL builds a MODIFY COLUMN statement that changes only
+// the column's comment
+func setColumnCommentSQL(database, table string, col protocol.Column, comment string) (string, error) {
+	col.Comment = comment
+	definition, err := columnDefinition(col)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", qualifiedTable(database, table), definition), nil
+}
+
+// SetColumnComment sets or clears a column's comment. MySQL can only change
+// a column comment by redefining the column, so the current definition is
+// read first and repeated unchanged.
+func (c *Connection) SetColumnComment(ctx context.Context, database, table, column, comment string) error {
+	columns, err := c.ListColumnsContext(ctx, database, table)
+	if err != nil {
+		return err
+	}
+	col, ok := findColumn(columns, column)
+	if !ok {
+		return fmt.Errorf("column not found: %s.%s.%s", database, table, column)
+	}
+
+	query, err := setColumnCommentSQL(database, table, col, comment)
+	if err != nil {
+		return err
+	}
+	if err := c.checkStatement(query); err != nil {
+		return err
+	}
+
+	if _, err := c.execWithKill(ctx, query); err != nil {
+		return fmt.Errorf("failed to set column comment: %w", err)
+	}
+	return nil
+}
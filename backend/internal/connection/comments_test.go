@@ -0,0 +1,37 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestSetTableCommentSQL(t *testing.T) {
+	got := setTableCommentSQL("shop", "orders", `Customer's orders \ archive`)
+	expected := "ALTER TABLE `shop`.`orders` COMMENT = 'Customer''s orders \\\\ archive'"
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestSetColumnCommentSQL(t *testing.T) {
+	col := protocol.Column{Name: "total", Type: "decimal(10,2)", Comment: "old"}
+
+	got, err := setColumnCommentSQL("shop", "orders", col, "Order total in cents")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "ALTER TABLE `shop`.`orders` MODIFY COLUMN `total` decimal(10,2) NOT NULL COMMENT 'Order total in cents'"
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// An empty comment clears it
+	got, err = setColumnCommentSQL("shop", "orders", col, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "ALTER TABLE `shop`.`orders` MODIFY COLUMN `total` decimal(10,2) NOT NULL"; got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,150 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// copyTableSQL builds the statements that copy src to dst: CREATE TABLE LIKE
+// for the structure and, if withData is set, INSERT ... SELECT for the rows.
+// Generated columns cannot be inserted into, so when src has any the other
+// columns are listed explicitly and the copy computes its own values.
+func copyTableSQL(src, dst protocol.TableRef, withData bool, columns []protocol.Column) (create string, insert string, err error) {
+	if src.Database == "" || src.Table == "" || dst.Database == "" || dst.Table == "" {
+		return "", "", fmt.Errorf("source and destination database and table are required")
+	}
+	if src == dst {
+		return "", "", fmt.Errorf("source and destination are the same table")
+	}
+
+	srcName := qualifiedTable(src.Database, src.Table)
+	dstName := qualifiedTable(dst.Database, dst.Table)
+
+	create = fmt.Sprintf("CREATE TABLE %s LIKE %s", dstName, srcName)
+	if withData {
+		insert = fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", dstName, srcName)
+		if names := insertableColumns(columns); len(names) < len(columns) {
+			list := quoteIdentifierList(names)
+			insert = fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", dstName, list, list, srcName)
+		}
+	}
+	return create, insert, nil
+}
+
+// insertableColumns returns the names of the columns that accept values,
+// leaving out generated columns
+func insertableColumns(columns []protocol.Column) []string {
+	names := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !col.IsGenerated {
+			names = append(names, col.Name)
+		}
+	}
+	return names
+}
+
+// CopyTableOptions controls CopyTable
+type CopyTableOptions struct {
+	// WithData copies the rows as well as the structure
+	WithData bool
+	// DisableForeignKeyChecks turns foreign key checks off on the copy's
+	// connection only, as ScriptOptions does for scripts. The previous
+	// setting is restored afterwards, even on failure.
+	DisableForeignKeyChecks bool
+}
+
+// CopyTable creates dst with the structure of src and optionally copies its
+// rows. CREATE TABLE commits implicitly, so only the row copy runs in a
+// transaction; if it fails, the new table is dropped again.
+func (c *Connection) CopyTable(ctx context.Context, src, dst protocol.TableRef, options CopyTableOptions) error {
+	withData := options.WithData
+	var columns []protocol.Column
+	if withData {
+		var err error
+		if columns, err = c.ListColumns(src.Database, src.Table); err != nil {
+			return err
+		}
+	}
+
+	create, insert, err := copyTableSQL(src, dst, withData, columns)
+	if err != nil {
+		return err
+	}
+	if err := c.checkStatement(create); err != nil {
+		return err
+	}
+	if insert != "" {
+		if err := c.checkStatement(insert); err != nil {
+			return err
+		}
+	}
+
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	// A session whose foreign key checks could not be restored is never
+	// returned to the pool
+	discard := false
+	defer func() {
+		if discard {
+			discardConn(conn)
+			return
+		}
+		releaseConn(ctx, conn)
+	}()
+
+	stop := c.watchCancel(ctx, threadID)
+	defer stop()
+
+	if options.DisableForeignKeyChecks {
+		restore, err := disableForeignKeyChecks(ctx, conn)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := restore(); err != nil {
+				log.Printf("Failed to restore foreign key checks, discarding connection: %v", err)
+				discard = true
+			}
+		}()
+	}
+
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+	if insert == "" {
+		return nil
+	}
+
+	copyErr := func() error {
+		if _, err := conn.ExecContext(ctx, "START TRANSACTION"); err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, insert); err != nil {
+			rollback(conn)
+			return fmt.Errorf("failed to copy rows: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+			rollback(conn)
+			return fmt.Errorf("failed to commit copy: %w", err)
+		}
+		return nil
+	}()
+	if copyErr != nil {
+		// Don't leave a half-made copy behind. The pinned connection may be
+		// unusable after a cancel, so use the pool with a fresh context.
+		dropCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		dstName := qualifiedTable(dst.Database, dst.Table)
+		if _, err := c.db.ExecContext(dropCtx, "DROP TABLE IF EXISTS "+dstName); err != nil {
+			log.Printf("Failed to drop partial copy %s: %v", dstName, err)
+		}
+		return copyErr
+	}
+	return nil
+}
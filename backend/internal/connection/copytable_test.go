@@ -0,0 +1,80 @@
+package connection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestCopyTableSQL(t *testing.T) {
+	src := protocol.TableRef{Database: "shop", Table: "orders"}
+	dst := protocol.TableRef{Database: "scratch", Table: "orders`copy"}
+
+	create, insert, err := copyTableSQL(src, dst, false, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if create != "CREATE TABLE `scratch`.`orders``copy` LIKE `shop`.`orders`" {
+		t.Errorf("Unexpected create statement: %s", create)
+	}
+	if insert != "" {
+		t.Errorf("Expected no insert for structure-only copy, got: %s", insert)
+	}
+
+	_, insert, err = copyTableSQL(src, dst, true, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if insert != "INSERT INTO `scratch`.`orders``copy` SELECT * FROM `shop`.`orders`" {
+		t.Errorf("Unexpected insert statement: %s", insert)
+	}
+
+	if _, _, err := copyTableSQL(src, src, true, nil); err == nil {
+		t.Error("Expected error when copying a table onto itself")
+	}
+	if _, _, err := copyTableSQL(src, protocol.TableRef{Table: "orders"}, false, nil); err == nil {
+		t.Error("Expected error for missing destination database")
+	}
+}
+
+func TestCopyTableSQLSkipsGeneratedColumns(t *testing.T) {
+	src := protocol.TableRef{Database: "shop", Table: "orders"}
+	dst := protocol.TableRef{Database: "shop", Table: "orders_copy"}
+	columns := []protocol.Column{
+		{Name: "id", Extra: "auto_increment"},
+		{Name: "total", IsGenerated: true, GeneratedType: "STORED"},
+		{Name: "note"},
+	}
+
+	_, insert, err := copyTableSQL(src, dst, true, columns)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "INSERT INTO `shop`.`orders_copy` (`id`, `note`) SELECT `id`, `note` FROM `shop`.`orders`"
+	if insert != want {
+		t.Errorf("Unexpected insert statement:\n got: %s\nwant: %s", insert, want)
+	}
+}
+
+func TestCopyTableDisableForeignKeyChecks(t *testing.T) {
+	src := protocol.TableRef{Database: "fk", Table: "orders"}
+	dst := protocol.TableRef{Database: "fk", Table: "orders_copy"}
+	ctx := context.Background()
+
+	// Dropping the failed copy needs a second connection
+	failing := newFakeSessionConnection(t, 2)
+	if err := failing.CopyTable(ctx, src, dst, CopyTableOptions{WithData: true}); err == nil {
+		t.Fatal("Expected the foreign key to reject the rows")
+	}
+
+	// A single pooled connection makes every request reuse the copy's session
+	c := newFakeSessionConnection(t, 1)
+	options := CopyTableOptions{WithData: true, DisableForeignKeyChecks: true}
+	if err := c.CopyTable(ctx, src, dst, options); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if checks := foreignKeyChecks(t, c); checks != "1" {
+		t.Errorf("Expected foreign key checks restored, got %s", checks)
+	}
+}
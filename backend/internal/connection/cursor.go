@@ -0,0 +1,187 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// Cursor is an open result set read in batches. It holds a pinned pooled
+// connection until it is exhausted or closed, so callers must always Close it.
+type Cursor struct {
+	mu        sync.Mutex
+	rows      *sql.Rows
+	release   func()
+	cancel    context.CancelFunc
+	columns   []string
+	original  []string
+	typeNames []string
+	fetched   int64
+	done      bool
+	closed    bool
+	// How BIGINT UNSIGNED and SET values are rendered
+	values valueOptions
+	// ctx is the query's context, cancelled by cancel
+	ctx context.Context
+	// prefetch, when set by FetchAhead, delivers batches read ahead by a
+	// goroutine that owns rows; see prefetch.go
+	prefetch     chan prefetchedBatch
+	prefetchDone chan struct{}
+	pending      [][]interface{}
+	ended        bool
+	endErr       error
+}
+
+// OpenCursor runs a query and returns a cursor over its rows. Only
+// statements that return rows are accepted. The query is not tied to a
+// request context; it runs until the cursor is closed.
+func (c *Connection) OpenCursor(sqlQuery string, namedArgs map[string]interface{}) (*Cursor, error) {
+	return c.OpenCursorContext(context.Background(), sqlQuery, namedArgs)
+}
+
+// OpenCursorContext is OpenCursor for a cursor that lives within ctx:
+// cancelling ctx kills the query, even while it is still producing its
+// first row, and the cursor's later fetches fail. The connection's default
+// query timeout bounds the cursor's whole lifetime.
+func (c *Connection) OpenCursorContext(parent context.Context, sqlQuery string, namedArgs map[string]interface{}) (*Cursor, error) {
+	if err := c.checkStatement(sqlQuery); err != nil {
+		return nil, err
+	}
+	if isWriteStatement(sqlQuery) {
+		return nil, fmt.Errorf("cursors require a statement that returns rows")
+	}
+
+	var args []interface{}
+	if namedArgs != nil {
+		var err error
+		sqlQuery, args, err = bindNamedParams(sqlQuery, namedArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkPacketSize(sqlQuery); err != nil {
+		return nil, err
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := c.defaultQueryTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	rows, release, err := c.queryWithKill(ctx, sqlQuery, args...)
+	if err != nil {
+		err = c.defaultTimeoutError(parent, ctx, sqlQuery, err)
+		cancel()
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	cursor := &Cursor{rows: rows, release: release, cancel: cancel, ctx: ctx, values: c.valueOptions()}
+	if err := cursor.describe(); err != nil {
+		cursor.Close()
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// describe reads the result's column names and types
+func (cur *Cursor) describe() error {
+	columns, err := cur.rows.Columns()
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+	cur.typeNames, err = columnTypeNames(cur.rows)
+	if err != nil {
+		return err
+	}
+
+	cur.columns = columns
+	// Joins without aliases can repeat names such as "id"
+	if unique, renamed := uniqueColumnNames(columns); renamed {
+		cur.columns = unique
+		cur.original = columns
+	}
+	return nil
+}
+
+// Columns returns the result's column names, with duplicates renamed as in
+// ExecuteQuery, and the server's original names if any were renamed
+func (cur *Cursor) Columns() (columns, original []string) {
+	return cur.columns, cur.original
+}
+
+// Fetch returns up to n further rows. Once the result is exhausted the
+// batch reports Done and the cursor's connection is released.
+func (cur *Cursor) Fetch(ctx context.Context, n int) (*protocol.CursorBatch, error) {
+	cur.mu.Lock()
+	defer cur.mu.Unlock()
+
+	if cur.closed {
+		return nil, fmt.Errorf("cursor is closed")
+	}
+
+	batch := &protocol.CursorBatch{Rows: make([][]interface{}, 0, n)}
+	if cur.prefetch != nil {
+		return cur.fetchAhead(ctx, batch, n)
+	}
+	for len(batch.Rows) < n && !cur.done {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("fetch cancelled: %w", ctx.Err())
+		}
+		if !cur.rows.Next() {
+			cur.done = true
+			break
+		}
+		row, err := scanRow(cur.rows, cur.typeNames, cur.values)
+		if err != nil {
+			return nil, err
+		}
+		batch.Rows = append(batch.Rows, row)
+		cur.fetched++
+	}
+
+	if cur.done {
+		err := cur.rows.Err()
+		cur.closeLocked()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating rows: %w", err)
+		}
+	}
+
+	batch.Done = cur.done
+	batch.RowsFetched = cur.fetched
+	return batch, nil
+}
+
+// Close releases the cursor's connection. Closing a cursor that still has
+// unread rows kills the query rather than draining the rest of the result.
+func (cur *Cursor) Close() {
+	cur.mu.Lock()
+	defer cur.mu.Unlock()
+	cur.closeLocked()
+}
+
+func (cur *Cursor) closeLocked() {
+	if cur.closed {
+		return
+	}
+	cur.closed = true
+
+	if !cur.done {
+		// Cancelling first makes the driver drop the connection instead of
+		// reading every remaining row, and the connection is discarded.
+		// That also stops a prefetching goroutine, which must be gone
+		// before rows is closed.
+		cur.cancel()
+		cur.waitPrefetch()
+		cur.release()
+		return
+	}
+	cur.waitPrefetch()
+	cur.release()
+	cur.cancel()
+}
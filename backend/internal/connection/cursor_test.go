@@ -0,0 +1,175 @@
+package connection
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCursorFetchesInBatches(t *testing.T) {
+	// With one pooled connection, a leaked cursor would block the next query
+	c := newFakeSessionConnection(t, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := c.OpenCursor("SELECT n FROM seq_5", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if columns, _ := cursor.Columns(); len(columns) != 1 || columns[0] != "n" {
+		t.Errorf("Unexpected columns: %v", columns)
+	}
+
+	expected := []struct {
+		rows int
+		done bool
+	}{{2, false}, {2, false}, {1, true}}
+	for i, want := range expected {
+		batch, err := cursor.Fetch(ctx, 2)
+		if err != nil {
+			t.Fatalf("Fetch %d: unexpected error: %v", i+1, err)
+		}
+		if len(batch.Rows) != want.rows || batch.Done != want.done {
+			t.Errorf("Fetch %d: expected %d rows (done %v), got %d (done %v)",
+				i+1, want.rows, want.done, len(batch.Rows), batch.Done)
+		}
+	}
+
+	if _, err := cursor.Fetch(ctx, 2); err == nil {
+		t.Error("Expected error fetching from an exhausted cursor")
+	}
+	cursor.Close()
+
+	if _, err := c.ExecuteQueryWithContext(ctx, "SELECT 1", 0, 0); err != nil {
+		t.Errorf("Exhausted cursor did not release its connection: %v", err)
+	}
+}
+
+func TestCursorCloseReleasesConnection(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := c.OpenCursor("SELECT n FROM seq_100", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := cursor.Fetch(ctx, 10); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cursor.Close()
+	cursor.Close()
+
+	if _, err := c.ExecuteQueryWithContext(ctx, "SELECT 1", 0, 0); err != nil {
+		t.Errorf("Closed cursor did not release its connection: %v", err)
+	}
+}
+
+func TestOpenCursorContextCancelsQuery(t *testing.T) {
+	// The kill runs on a second connection
+	c := newFakeSessionConnection(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.OpenCursorContext(ctx, "SELECT SLEEP(60)", nil); err == nil {
+		t.Fatal("Expected the cancelled query to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Query was not stopped by the context, took %v", elapsed)
+	}
+}
+
+func TestOpenCursorRejectsWrites(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	if _, err := c.OpenCursor("DELETE FROM orders WHERE id = 1", nil); err == nil {
+		t.Error("Expected error opening a cursor over a write statement")
+	}
+}
+
+func TestCursorFetchAhead(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := c.OpenCursor("SELECT n FROM seq_10", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cursor.FetchAhead(2, 3)
+
+	// Fetch counts need not match the read-ahead batch size
+	var got []interface{}
+	for _, count := range []int{4, 1, 5} {
+		batch, err := cursor.Fetch(ctx, count)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, row := range batch.Rows {
+			got = append(got, row[0])
+		}
+		if batch.Done != (len(got) == 10) {
+			t.Errorf("Expected done only after all rows, got done %v after %d rows", batch.Done, len(got))
+		}
+	}
+	for i, value := range got {
+		if value != int64(i+1) {
+			t.Errorf("Row %d: expected %d, got %v", i, i+1, value)
+		}
+	}
+	if len(got) != 10 {
+		t.Errorf("Expected 10 rows, got %d", len(got))
+	}
+
+	if _, err := c.ExecuteQueryWithContext(ctx, "SELECT 1", 0, 0); err != nil {
+		t.Errorf("Exhausted cursor did not release its connection: %v", err)
+	}
+}
+
+func TestCursorFetchAheadCloseReleasesConnection(t *testing.T) {
+	// Closing a cursor with unread rows kills its query, which takes a
+	// second connection
+	c := newFakeSessionConnection(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := c.OpenCursor("SELECT n FROM seq_1000", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cursor.FetchAhead(2, 10)
+	if _, err := cursor.Fetch(ctx, 5); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The read-ahead goroutine is blocked on a full buffer; closing must
+	// stop it and give the connection back
+	cursor.Close()
+
+	for i := 0; i < 2; i++ {
+		conn, err := c.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Closed cursor did not release its connection: %v", err)
+		}
+		defer conn.Close()
+	}
+}
+
+func TestCursorFetchAheadCancelledFetch(t *testing.T) {
+	c := newFakeSessionConnection(t, 2)
+
+	cursor, err := c.OpenCursor("SELECT n FROM seq_5", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer cursor.Close()
+	cursor.FetchAhead(1, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cursor.Fetch(ctx, 2); err == nil {
+		// The first batch may already be buffered; the next wait must fail
+		if _, err := cursor.Fetch(ctx, 10); err == nil {
+			t.Error("Expected a cancelled fetch to fail")
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// charsetNamePattern matches character set and collation names, which are
+// not quotable identifiers and must be validated instead
+var charsetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// createDatabaseSQL builds a CREATE DATABASE IF NOT EXISTS statement with an
+// optional character set and collation
+func createDatabaseSQL(name, charset, collation string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("database name is required")
+	}
+
+	query := "CREATE DATABASE IF NOT EXISTS " + quoteIdentifier(name)
+	if charset != "" {
+		if !charsetNamePattern.MatchString(charset) {
+			return "", fmt.Errorf("invalid character set: %s", charset)
+		}
+		query += " CHARACTER SET " + charset
+	}
+	if collation != "" {
+		if !charsetNamePattern.MatchString(collation) {
+			return "", fmt.Errorf("invalid collation: %s", collation)
+		}
+		query += " COLLATE " + collation
+	}
+	return query, nil
+}
+
+// CreateDatabase creates a database if it does not already exist
+func (c *Connection) CreateDatabase(ctx context.Context, name, charset, collation string) error {
+	query, err := createDatabaseSQL(name, charset, collation)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.execWithKill(ctx, query); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	return nil
+}
+
+// GetCurrentDatabase returns the default database that statements run
+// against. A USE inside one request never outlives it (see releaseAfter),
+// so this is the database every pooled session starts in: the configured
+// one, or none.
+func (c *Connection) GetCurrentDatabase(ctx context.Context) (*protocol.CurrentDatabase, error) {
+	var name sql.NullString
+	if err := c.db.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&name); err != nil {
+		return nil, fmt.Errorf("failed to get current database: %w", err)
+	}
+	return &protocol.CurrentDatabase{Database: name.String, Selected: name.Valid}, nil
+}
+
+// GetDatabaseDDL returns the SHOW CREATE DATABASE statement for a database
+func (c *Connection) GetDatabaseDDL(ctx context.Context, name string) (string, error) {
+	var database, ddl string
+	err := c.db.QueryRowContext(ctx, "SHOW CREATE DATABASE "+quoteIdentifier(name)).Scan(&database, &ddl)
+	if err != nil {
+		return "", fmt.Errorf("failed to get database DDL: %w", err)
+	}
+	return ddl, nil
+}
+
+// DropDatabase drops a database and everything in it
+func (c *Connection) DropDatabase(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("database name is required")
+	}
+
+	if _, err := c.execWithKill(ctx, "DROP DATABASE "+quoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+	return nil
+}
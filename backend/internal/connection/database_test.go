@@ -0,0 +1,40 @@
+package connection
+
+import "testing"
+
+func TestCreateDatabaseSQL(t *testing.T) {
+	testCases := []struct {
+		name        string
+		database    string
+		charset     string
+		collation   string
+		expected    string
+		expectError bool
+	}{
+		{"Name only", "app", "", "", "CREATE DATABASE IF NOT EXISTS `app`", false},
+		{"Charset and collation", "app", "utf8mb4", "utf8mb4_0900_ai_ci", "CREATE DATABASE IF NOT EXISTS `app` CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci", false},
+		{"Collation only", "app", "", "utf8mb4_bin", "CREATE DATABASE IF NOT EXISTS `app` COLLATE utf8mb4_bin", false},
+		{"Backtick in name", "we`ird", "", "", "CREATE DATABASE IF NOT EXISTS `we``ird`", false},
+		{"Empty name", "", "", "", "", true},
+		{"Injected charset", "app", "utf8mb4; DROP DATABASE app", "", "", true},
+		{"Injected collation", "app", "", "utf8mb4_bin'", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := createDatabaseSQL(tc.database, tc.charset, tc.collation)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// maxCascadeDepth bounds how far cascades are followed, which also stops
+// self-referencing and circular foreign keys
+const maxCascadeDepth = 8
+
+// rowCondition builds a WHERE condition matching every column in match.
+// Columns are sorted so the condition and its arguments are stable.
+func rowCondition(match map[string]interface{}) (string, []interface{}, error) {
+	if len(match) == 0 {
+		return "", nil, fmt.Errorf("no columns given to identify the rows")
+	}
+
+	columns := make([]string, 0, len(match))
+	for column := range match {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	conditions := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		value := match[column]
+		if value == nil {
+			conditions = append(conditions, quoteIdentifier(column)+" IS NULL")
+			continue
+		}
+		conditions = append(conditions, quoteIdentifier(column)+" = ?")
+		args = append(args, namedArgValue(value))
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// cascadeCondition builds a condition on fk's table matching the children
+// of the parent rows selected by parentCondition
+func cascadeCondition(fk protocol.ForeignKey, parentCondition string) string {
+	return fmt.Sprintf("(%s) IN (SELECT %s FROM %s WHERE %s)",
+		quoteIdentifierList(fk.Columns), quoteIdentifierList(fk.ReferencedColumns),
+		qualifiedTable(fk.ReferencedDatabase, fk.ReferencedTable), parentCondition)
+}
+
+func quoteIdentifierList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// PreviewDelete reports what deleting the rows of a table matching match
+// would do through foreign keys, without deleting anything: rows removed by
+// ON DELETE CASCADE (followed through further cascades), rows set to NULL,
+// and rows whose RESTRICT or NO ACTION keys would make the delete fail.
+// Counts are per foreign key, so a row reachable along two paths is counted
+// twice.
+func (c *Connection) PreviewDelete(ctx context.Context, database, table string, match map[string]interface{}) (*protocol.DeletePreview, error) {
+	condition, args, err := rowCondition(match)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer releaseConn(ctx, conn)
+
+	stop := c.watchCancel(ctx, threadID)
+	defer stop()
+
+	count := func(database, table, condition string) (int64, error) {
+		var n int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", qualifiedTable(database, table), condition)
+		if err := conn.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+			if ctx.Err() != nil {
+				return 0, fmt.Errorf("preview cancelled: %w", ctx.Err())
+			}
+			return 0, fmt.Errorf("failed to count rows in %s.%s: %w", database, table, err)
+		}
+		return n, nil
+	}
+
+	preview := &protocol.DeletePreview{
+		Database: database,
+		Table:    table,
+		Effects:  []protocol.DeleteEffect{},
+	}
+	if preview.Rows, err = count(database, table, condition); err != nil {
+		return nil, err
+	}
+	if preview.Rows == 0 {
+		return preview, nil
+	}
+
+	type pending struct {
+		database, table, condition string
+		depth                      int
+	}
+	queue := []pending{{database, table, condition, 0}}
+	cascadeTables := make(map[protocol.TableRef]bool)
+
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		keys, err := c.referencingForeignKeys(ctx, conn, parent.database, parent.table)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range keys {
+			childCondition := cascadeCondition(fk, parent.condition)
+			rows, err := count(fk.Database, fk.Table, childCondition)
+			if err != nil {
+				return nil, err
+			}
+			if rows == 0 {
+				continue
+			}
+
+			preview.Effects = append(preview.Effects, protocol.DeleteEffect{
+				Database:   fk.Database,
+				Table:      fk.Table,
+				ForeignKey: fk.Name,
+				Action:     fk.OnDelete,
+				Rows:       rows,
+				Depth:      parent.depth + 1,
+			})
+
+			switch fk.OnDelete {
+			case "CASCADE":
+				preview.CascadeRows += rows
+				cascadeTables[protocol.TableRef{Database: fk.Database, Table: fk.Table}] = true
+				if parent.depth+1 >= maxCascadeDepth {
+					preview.Truncated = true
+					continue
+				}
+				queue = append(queue, pending{fk.Database, fk.Table, childCondition, parent.depth + 1})
+			case "SET NULL", "SET DEFAULT":
+				// Updated children keep their own children
+			default:
+				// RESTRICT and NO ACTION reject the whole delete
+				preview.Blocked = true
+			}
+		}
+	}
+
+	preview.CascadeTables = len(cascadeTables)
+	return preview, nil
+}
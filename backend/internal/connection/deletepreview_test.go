@@ -0,0 +1,54 @@
+package connection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestRowCondition(t *testing.T) {
+	condition, args, err := rowCondition(map[string]interface{}{
+		"tenant_id":  float64(7),
+		"id":         "a1",
+		"deleted_at": nil,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "`deleted_at` IS NULL AND `id` = ? AND `tenant_id` = ?"
+	if condition != expected {
+		t.Errorf("Expected %s, got %s", expected, condition)
+	}
+	if want := []interface{}{"a1", int64(7)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+
+	if _, _, err := rowCondition(nil); err == nil {
+		t.Error("Expected error for an empty match")
+	}
+}
+
+func TestCascadeCondition(t *testing.T) {
+	orders := protocol.ForeignKey{
+		Database: "shop", Table: "orders", Columns: []string{"customer_id"},
+		ReferencedDatabase: "shop", ReferencedTable: "customers", ReferencedColumns: []string{"id"},
+	}
+	items := protocol.ForeignKey{
+		Database: "shop", Table: "order_items", Columns: []string{"order_id", "tenant_id"},
+		ReferencedDatabase: "shop", ReferencedTable: "orders", ReferencedColumns: []string{"id", "tenant_id"},
+	}
+
+	first := cascadeCondition(orders, "`id` = ?")
+	expected := "(`customer_id`) IN (SELECT `id` FROM `shop`.`customers` WHERE `id` = ?)"
+	if first != expected {
+		t.Errorf("Expected %s, got %s", expected, first)
+	}
+
+	second := cascadeCondition(items, first)
+	expected = "(`order_id`, `tenant_id`) IN (SELECT `id`, `tenant_id` FROM `shop`.`orders` WHERE " + first + ")"
+	if second != expected {
+		t.Errorf("Expected %s, got %s", expected, second)
+	}
+}
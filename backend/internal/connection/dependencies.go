@@ -0,0 +1,144 @@
+package connection
+
+import (
+	"context"
+	"sort"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// GetTableDependencyOrder orders tables of a database so that every table
+// comes after the tables its foreign keys reference. Inserting in that
+// order, and deleting in reverse, never violates a foreign key. With no
+// tables given, every table of the database is ordered. Keys referencing
+// tables outside the set are ignored.
+func (c *Connection) GetTableDependencyOrder(ctx context.Context, database string, tables []string) (*protocol.TableDependencyOrder, error) {
+	if len(tables) == 0 {
+		all, err := c.ListTablesContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range all {
+			tables = append(tables, table.Name)
+		}
+	}
+
+	keys, err := c.ListForeignKeys(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	references := make([][2]string, 0, len(keys))
+	for _, fk := range keys {
+		if c.NormalizeIdentifier(fk.ReferencedDatabase) != c.NormalizeIdentifier(database) {
+			continue
+		}
+		references = append(references, [2]string{
+			c.NormalizeIdentifier(fk.Table),
+			c.NormalizeIdentifier(fk.ReferencedTable),
+		})
+	}
+	return dependencyOrder(tables, references, c.NormalizeIdentifier), nil
+}
+
+// dependencyOrder sorts tables given references from a child table to the
+// parent table it references, both already normalized. Tables that
+// reference each other in a loop have no safe order; each loop is reported
+// in Cycles and its tables are placed together, by name, after everything
+// they depend on.
+func dependencyOrder(tables []string, references [][2]string, normalize func(string) string) *protocol.TableDependencyOrder {
+	result := &protocol.TableDependencyOrder{
+		InsertOrder: []string{},
+		DeleteOrder: []string{},
+		Cycles:      [][]string{},
+	}
+
+	// Tables are identified by normalized name and reported as given
+	names := make(map[string]string, len(tables))
+	nodes := make([]string, 0, len(tables))
+	for _, table := range tables {
+		key := normalize(table)
+		if _, ok := names[key]; ok {
+			continue
+		}
+		names[key] = table
+		nodes = append(nodes, key)
+	}
+	sort.Strings(nodes)
+
+	parents := make(map[string][]string)
+	selfReferencing := make(map[string]bool)
+	for _, ref := range references {
+		child, parent := ref[0], ref[1]
+		if _, ok := names[child]; !ok {
+			continue
+		}
+		if _, ok := names[parent]; !ok {
+			continue
+		}
+		if child == parent {
+			// Only matters for the order of rows within the table
+			if !selfReferencing[child] {
+				selfReferencing[child] = true
+				result.SelfReferencing = append(result.SelfReferencing, names[child])
+			}
+			continue
+		}
+		parents[child] = append(parents[child], parent)
+	}
+	for _, list := range parents {
+		sort.Strings(list)
+	}
+
+	// Tarjan's algorithm emits each strongly connected component after every
+	// component it references, which is parents first
+	index := make(map[string]int, len(nodes))
+	lowLink := make(map[string]int, len(nodes))
+	onStack := make(map[string]bool, len(nodes))
+	var stack []string
+	var visit func(node string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, parent := range parents[node] {
+			if _, seen := index[parent]; !seen {
+				visit(parent)
+				lowLink[node] = min(lowLink[node], lowLink[parent])
+			} else if onStack[parent] {
+				lowLink[node] = min(lowLink[node], index[parent])
+			}
+		}
+
+		if lowLink[node] != index[node] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, names[top])
+			if top == node {
+				break
+			}
+		}
+		sort.Strings(component)
+		if len(component) > 1 {
+			result.Cycles = append(result.Cycles, component)
+		}
+		result.InsertOrder = append(result.InsertOrder, component...)
+	}
+	for _, node := range nodes {
+		if _, seen := index[node]; !seen {
+			visit(node)
+		}
+	}
+
+	for i := len(result.InsertOrder) - 1; i >= 0; i-- {
+		result.DeleteOrder = append(result.DeleteOrder, result.InsertOrder[i])
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package connection
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDependencyOrder(t *testing.T) {
+	// orders -> customers, order_items -> orders and products
+	references := [][2]string{
+		{"orders", "customers"},
+		{"order_items", "orders"},
+		{"order_items", "products"},
+		{"orders", "warehouses"}, // not in the set
+	}
+	got := dependencyOrder([]string{"order_items", "orders", "products", "customers"}, references, strings.ToLower)
+
+	want := []string{"customers", "orders", "products", "order_items"}
+	if !reflect.DeepEqual(got.InsertOrder, want) {
+		t.Errorf("InsertOrder = %v, want %v", got.InsertOrder, want)
+	}
+	if !reflect.DeepEqual(got.DeleteOrder, []string{"order_items", "products", "orders", "customers"}) {
+		t.Errorf("Unexpected DeleteOrder: %v", got.DeleteOrder)
+	}
+	if len(got.Cycles) != 0 {
+		t.Errorf("Expected no cycles, got %v", got.Cycles)
+	}
+}
+
+func TestDependencyOrderCycles(t *testing.T) {
+	references := [][2]string{
+		{"a", "b"},
+		{"b", "a"},
+		{"c", "a"},
+		{"b", "base"},
+		{"employees", "employees"},
+	}
+	got := dependencyOrder([]string{"c", "B", "a", "base", "employees"}, references, strings.ToLower)
+
+	if want := []string{"base", "B", "a", "c", "employees"}; !reflect.DeepEqual(got.InsertOrder, want) {
+		t.Errorf("InsertOrder = %v, want %v", got.InsertOrder, want)
+	}
+	if want := [][]string{{"B", "a"}}; !reflect.DeepEqual(got.Cycles, want) {
+		t.Errorf("Cycles = %v, want %v", got.Cycles, want)
+	}
+	if want := []string{"employees"}; !reflect.DeepEqual(got.SelfReferencing, want) {
+		t.Errorf("SelfReferencing = %v, want %v", got.SelfReferencing, want)
+	}
+}
@@ -0,0 +1,46 @@
+// Package connection wraps a MySQL connection pool for the JSON-RPC server.
+//
+// # Session state
+//
+// Every JSON-RPC request runs in its own goroutine and all of them share one
+// *sql.DB per connection. Each pooled connection is a separate server session
+// with its own default database, variables, transactions, temporary tables
+// and locks, so methods fall into two groups:
+//
+// Pool-safe methods send self-contained statements that do not depend on or
+// change session state, and may land on any pooled connection: ListDatabases,
+// ListTables, ListColumns, ListColumnsBulk, DatabaseExists, HealthCheck,
+// GetVersion, GetAutocompleteSchema, GetAutoIncrement, GetDatabaseDDL,
+// CreateDatabase, DropDatabase, ListLocks, ListUsers, ListRoles,
+// GetPrivileges, GetReplicationStatus, GetSlowQueries, SampleTable,
+// GetServerTime, ListPartitions, DropPartition, TruncatePartition,
+// SetTableComment, SetColumnComment, RenameColumn, ListForeignKeys,
+// GetTableDependencyOrder, GetSQLMode, TopN, GetCurrentDatabase,
+// ExportSchema, GetRowsAround, GetInformationSchema, ExplainQuery,
+// AssertResultSchema, ScanTable, GetColumnCardinality, GetLongTransactions
+// and PoolStats.
+//
+// Session-safe methods pin one connection with pinConn for their whole
+// duration, so statements that depend on each other (USE, transactions,
+// temporary tables, SET profiling, CONNECTION_ID for KILL QUERY) always run
+// on the same session: ExecuteQuery, ExecuteQueryWithContext,
+// ExecuteQueryWithOptions, RunScript, CopyTable, PreviewDelete,
+// GetResultColumnMeta, GetExactRowCounts, SummarizeQuery, ProfileQuery,
+// ExplainProcess and ExplainAnalyze.
+//
+// A pinned connection is only returned to the pool if nothing it ran may have
+// changed session state (see releaseAfter). Otherwise it is discarded, so a
+// "USE other" or an unfinished "START TRANSACTION" in one request can never
+// affect another. State therefore does not carry over between requests
+// either: a script that needs a transaction must run as a single runScript
+// call. New code that needs session affinity must use pinConn rather than
+// issuing consecutive statements on c.db.
+//
+// # Read replicas
+//
+// When ReadReplicas are configured, ExecuteQuery and its variants send
+// SELECT and TABLE statements that neither lock rows nor touch session state
+// to the least busy replica; everything else, including every other method,
+// runs on the primary. Replica lag means a read can miss a write made just
+// before it, which QueryOptions.ForcePrimary avoids.
+package connection
@@ -0,0 +1,48 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// tableComment returns a table's own comment. Views have no engine and
+// report the placeholder comment "VIEW", which is not a real comment.
+func tableComment(engine, comment string) string {
+	if engine == "" && comment == "VIEW" {
+		return ""
+	}
+	return comment
+}
+
+// GetTableDocumentation returns a table's comment together with its columns
+// and their comments
+func (c *Connection) GetTableDocumentation(ctx context.Context, database, table string) (*protocol.TableDocumentation, error) {
+	var engine sql.NullString
+	var comment string
+	err := c.db.QueryRowContext(ctx,
+		`SELECT ENGINE, TABLE_COMMENT FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`,
+		database, table,
+	).Scan(&engine, &comment)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("table not found: %s.%s", database, table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table comment: %w", err)
+	}
+
+	columns, err := c.ListColumnsContext(ctx, database, table)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.TableDocumentation{
+		Database: database,
+		Table:    table,
+		Comment:  tableComment(engine.String, comment),
+		Columns:  columns,
+	}, nil
+}
@@ -0,0 +1,22 @@
+package connection
+
+import "testing"
+
+func TestTableComment(t *testing.T) {
+	testCases := []struct {
+		engine   string
+		comment  string
+		expected string
+	}{
+		{"InnoDB", "Customer orders", "Customer orders"},
+		{"InnoDB", "", ""},
+		{"", "VIEW", ""},
+		{"InnoDB", "VIEW", "VIEW"},
+	}
+
+	for _, tc := range testCases {
+		if got := tableComment(tc.engine, tc.comment); got != tc.expected {
+			t.Errorf("tableComment(%q, %q): expected %q, got %q", tc.engine, tc.comment, tc.expected, got)
+		}
+	}
+}
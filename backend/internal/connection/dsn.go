@@ -0,0 +1,106 @@
+package connection
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// buildDriverConfig builds the MySQL driver configuration for a connection
+// config, connecting to the given (already normalized) host
+func buildDriverConfig(config *protocol.ConnectionConfig, host string) (*mysql.Config, error) {
+	// Build DSN (Data Source Name)
+	// Add timeout and cancellation support
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&timeout=30s&readTimeout=30s&writeTimeout=30s",
+		config.Username,
+		config.Password,
+		host,
+		config.Port,
+		config.Database,
+	)
+
+	if config.SSL {
+		dsn += "&tls=true"
+	}
+
+	// LDAP and PAM accounts log in with the cleartext plugin, which sends the
+	// password as is; without SSL anyone on the network can read it
+	if config.AllowCleartextPasswords {
+		dsn += "&allowCleartextPasswords=true"
+	}
+	if config.AllowNativePasswords != nil && !*config.AllowNativePasswords {
+		dsn += "&allowNativePasswords=false"
+	}
+
+	// Client-side interpolation avoids a prepare round-trip per parameterized
+	// query; the default server-side prepare keeps values out of the SQL text
+	if config.InterpolateParams {
+		dsn += "&interpolateParams=true"
+	}
+
+	// Session time zone affects NOW() and TIMESTAMP conversion on the server
+	loc, sessionZone, err := resolveTimeZone(config.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	if sessionZone != "" {
+		dsn += "&time_zone=" + url.QueryEscape("'"+sessionZone+"'")
+	}
+
+	if config.SQLMode != "" {
+		if !sqlModePattern.MatchString(config.SQLMode) {
+			return nil, fmt.Errorf("invalid sql_mode: %q", config.SQLMode)
+		}
+		dsn += "&sql_mode=" + url.QueryEscape("'"+config.SQLMode+"'")
+	}
+
+	// User params come last so they take precedence over the defaults above
+	extra, err := encodeParams(config.Params)
+	if err != nil {
+		return nil, err
+	}
+	dsn += extra
+
+	dsnConfig, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MySQL: %w. Check that host '%s' and port %d are correct", err, config.Host, config.Port)
+	}
+	// The driver's loc parameter only accepts named zones, so set it directly
+	if loc != nil {
+		dsnConfig.Loc = loc
+	}
+
+	return dsnConfig, nil
+}
+
+// reservedParams are DSN parameters that value conversion depends on
+var reservedParams = map[string]bool{
+	"parseTime": true,
+	"loc":       true,
+}
+
+// encodeParams encodes extra DSN parameters as "&key=value" pairs in key
+// order, rejecting keys that could smuggle in additional parameters
+func encodeParams(params map[string]string) (string, error) {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key == "" || strings.ContainsAny(key, "&=?#/ \t\r\n") {
+			return "", fmt.Errorf("invalid connection parameter name: %q", key)
+		}
+		if reservedParams[key] {
+			return "", fmt.Errorf("connection parameter %s cannot be overridden", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString("&" + key + "=" + url.QueryEscape(params[key]))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,198 @@
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func baseConfig() *protocol.ConnectionConfig {
+	return &protocol.ConnectionConfig{
+		ID:       "conn-1",
+		Type:     "mysql",
+		Host:     "db.example.com",
+		Port:     3306,
+		Username: "app",
+		Password: "p@ss:word",
+		Database: "shop",
+	}
+}
+
+func TestBuildDriverConfigDefaults(t *testing.T) {
+	cfg, err := buildDriverConfig(baseConfig(), "db.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.Addr != "db.example.com:3306" {
+		t.Errorf("Unexpected address: %s", cfg.Addr)
+	}
+	if cfg.User != "app" || cfg.Passwd != "p@ss:word" {
+		t.Errorf("Unexpected credentials: %s / %s", cfg.User, cfg.Passwd)
+	}
+	if cfg.DBName != "shop" {
+		t.Errorf("Unexpected database: %s", cfg.DBName)
+	}
+	if !cfg.ParseTime {
+		t.Error("Expected parseTime to be enabled")
+	}
+	if cfg.InterpolateParams {
+		t.Error("Expected server-side prepares by default")
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("Unexpected dial timeout: %v", cfg.Timeout)
+	}
+	if cfg.Loc != time.UTC {
+		t.Errorf("Expected UTC location by default, got %v", cfg.Loc)
+	}
+}
+
+func TestBuildDriverConfigInterpolateParams(t *testing.T) {
+	config := baseConfig()
+	config.InterpolateParams = true
+
+	cfg, err := buildDriverConfig(config, config.Host)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.InterpolateParams {
+		t.Error("Expected interpolateParams to be enabled")
+	}
+}
+
+func TestBuildDriverConfigTimeZone(t *testing.T) {
+	config := baseConfig()
+	config.TimeZone = "-03:00"
+
+	cfg, err := buildDriverConfig(config, config.Host)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Params["time_zone"] != "'-03:00'" {
+		t.Errorf("Unexpected session time_zone param: %q", cfg.Params["time_zone"])
+	}
+	if _, offset := referenceTime.In(cfg.Loc).Zone(); offset != -3*3600 {
+		t.Errorf("Unexpected loc offset: %d", offset)
+	}
+}
+
+func TestBuildDriverConfigAuthPlugins(t *testing.T) {
+	cfg, err := buildDriverConfig(baseConfig(), "db.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.AllowCleartextPasswords || !cfg.AllowNativePasswords {
+		t.Errorf("Expected driver defaults, got cleartext=%v native=%v", cfg.AllowCleartextPasswords, cfg.AllowNativePasswords)
+	}
+
+	native := false
+	config := baseConfig()
+	config.SSL = true
+	config.AllowCleartextPasswords = true
+	config.AllowNativePasswords = &native
+
+	cfg, err = buildDriverConfig(config, "db.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.AllowCleartextPasswords {
+		t.Error("Expected allowCleartextPasswords to be enabled")
+	}
+	if cfg.AllowNativePasswords {
+		t.Error("Expected allowNativePasswords to be disabled")
+	}
+	// caching_sha2_password sends the password over TLS when it is set
+	if cfg.TLS == nil {
+		t.Error("Expected a TLS config when SSL is enabled")
+	}
+}
+
+func TestBuildDriverConfigSQLMode(t *testing.T) {
+	config := baseConfig()
+	config.SQLMode = "STRICT_TRANS_TABLES,NO_ZERO_DATE"
+
+	cfg, err := buildDriverConfig(config, config.Host)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Params["sql_mode"] != "'STRICT_TRANS_TABLES,NO_ZERO_DATE'" {
+		t.Errorf("Unexpected session sql_mode param: %q", cfg.Params["sql_mode"])
+	}
+
+	config.SQLMode = "ANSI'; DROP TABLE users; --"
+	if _, err := buildDriverConfig(config, config.Host); err == nil {
+		t.Error("Expected an invalid sql_mode to be rejected")
+	}
+}
+
+func TestBuildDriverConfigParams(t *testing.T) {
+	config := baseConfig()
+	config.Params = map[string]string{
+		"allowNativePasswords": "false",
+		"maxAllowedPacket":     "16777216",
+		"clientFoundRows":      "true",
+		"readTimeout":          "2m",
+		"sql_mode":             "'TRADITIONAL'",
+	}
+
+	cfg, err := buildDriverConfig(config, "db.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.AllowNativePasswords {
+		t.Error("Expected allowNativePasswords to be disabled")
+	}
+	if cfg.MaxAllowedPacket != 16777216 {
+		t.Errorf("Expected maxAllowedPacket 16777216, got %d", cfg.MaxAllowedPacket)
+	}
+	if !cfg.ClientFoundRows {
+		t.Error("Expected clientFoundRows to be enabled")
+	}
+	if cfg.ReadTimeout != 2*time.Minute {
+		t.Errorf("Expected user readTimeout to override the default, got %v", cfg.ReadTimeout)
+	}
+	if cfg.Params["sql_mode"] != "'TRADITIONAL'" {
+		t.Errorf("Expected unknown key to become a session variable, got %q", cfg.Params["sql_mode"])
+	}
+	if !cfg.ParseTime {
+		t.Error("parseTime must stay enabled")
+	}
+}
+
+func TestBuildDriverConfigRejectsBadParams(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params map[string]string
+	}{
+		{"Ampersand in key", map[string]string{"a&tls": "false"}},
+		{"Equals in key", map[string]string{"tls=false&x": "1"}},
+		{"Empty key", map[string]string{"": "1"}},
+		{"Reserved parseTime", map[string]string{"parseTime": "false"}},
+		{"Reserved loc", map[string]string{"loc": "Local"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := baseConfig()
+			config.Params = tc.params
+			if _, err := buildDriverConfig(config, "db.example.com"); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestBuildDriverConfigParamValueIsEscaped(t *testing.T) {
+	config := baseConfig()
+	config.Params = map[string]string{"connectionAttributes": "app:warden&tls=false"}
+
+	cfg, err := buildDriverConfig(config, "db.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.TLSConfig != "" {
+		t.Errorf("A parameter value must not inject other parameters, got tls=%q", cfg.TLSConfig)
+	}
+}
@@ -0,0 +1,85 @@
+package connection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers used to classify failures
+const (
+	errTooManyConnections   = 1040 // ER_CON_COUNT_ERROR
+	errDBAccessDenied       = 1044 // ER_DBACCESS_DENIED_ERROR
+	errAccessDenied         = 1045 // ER_ACCESS_DENIED_ERROR
+	errBadField             = 1054 // ER_BAD_FIELD_ERROR
+	errUnknownTable         = 1109 // ER_UNKNOWN_TABLE
+	errTableAccessDenied    = 1142 // ER_TABLEACCESS_DENIED_ERROR
+	errColumnAccessDenied   = 1143 // ER_COLUMNACCESS_DENIED_ERROR
+	errNoSuchTable          = 1146 // ER_NO_SUCH_TABLE
+	errNetReadError         = 1158 // ER_NET_READ_ERROR
+	errNetWriteInterrupted  = 1161 // ER_NET_WRITE_INTERRUPTED
+	errLockWaitTimeout      = 1205 // ER_LOCK_WAIT_TIMEOUT
+	errLockDeadlock         = 1213 // ER_LOCK_DEADLOCK
+	errSpecificAccessDenied = 1227 // ER_SPECIFIC_ACCESS_DENIED_ERROR
+	errProcAccessDenied     = 1370 // ER_PROCACCESS_DENIED_ERROR
+)
+
+// mysqlErrorNumber returns the server error number of err, or 0
+func mysqlErrorNumber(err error) uint16 {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number
+	}
+	return 0
+}
+
+// isPermissionError reports whether err is a MySQL access-denied error
+func isPermissionError(err error) bool {
+	switch mysqlErrorNumber(err) {
+	case errDBAccessDenied, errAccessDenied, errTableAccessDenied,
+		errColumnAccessDenied, errSpecificAccessDenied, errProcAccessDenied:
+		return true
+	}
+	return false
+}
+
+// isMissingObjectError reports whether err means a table or column does not
+// exist on this server version
+func isMissingObjectError(err error) bool {
+	switch mysqlErrorNumber(err) {
+	case errNoSuchTable, errBadField, errUnknownTable:
+		return true
+	}
+	return false
+}
+
+// IsTransientError reports whether err is likely to go away on retry: a
+// dropped or refused connection, a network timeout, too many connections,
+// or a lock wait timeout or deadlock. Access-denied and syntax errors are
+// not transient, and neither is a cancelled or expired context, although
+// context.DeadlineExceeded reports itself as a timeout.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch mysqlErrorNumber(err) {
+	case errTooManyConnections, errLockWaitTimeout, errLockDeadlock:
+		return true
+	}
+	if n := mysqlErrorNumber(err); n >= errNetReadError && n <= errNetWriteInterrupted {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsPermissionError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Table access denied", &mysql.MySQLError{Number: 1142, Message: "SELECT command denied"}, true},
+		{"Specific privilege denied", &mysql.MySQLError{Number: 1227, Message: "Access denied; you need the PROCESS privilege"}, true},
+		{"Wrapped access denied", fmt.Errorf("failed: %w", &mysql.MySQLError{Number: 1044}), true},
+		{"No such table", &mysql.MySQLError{Number: 1146}, false},
+		{"Plain error", errors.New("access denied"), false},
+		{"Nil error", nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isPermissionError(tc.err); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsMissingObjectError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{&mysql.MySQLError{Number: 1146, Message: "Table 'information_schema.INNODB_LOCKS' doesn't exist"}, true},
+		{&mysql.MySQLError{Number: 1109, Message: "Unknown table 'INNODB_LOCKS' in information_schema"}, true},
+		{&mysql.MySQLError{Number: 1054, Message: "Unknown column"}, true},
+		{&mysql.MySQLError{Number: 1227, Message: "Access denied"}, false},
+		{errors.New("connection refused"), false},
+	}
+
+	for _, tc := range testCases {
+		if got := isMissingObjectError(tc.err); got != tc.expected {
+			t.Errorf("isMissingObjectError(%v): expected %v, got %v", tc.err, tc.expected, got)
+		}
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Lock wait timeout", &mysql.MySQLError{Number: 1205}, true},
+		{"Deadlock", fmt.Errorf("failed to list tables: %w", &mysql.MySQLError{Number: 1213}), true},
+		{"Too many connections", &mysql.MySQLError{Number: 1040}, true},
+		{"Network read error", &mysql.MySQLError{Number: 1158}, true},
+		{"Bad connection", fmt.Errorf("failed: %w", driver.ErrBadConn), true},
+		{"Invalid connection", mysql.ErrInvalidConn, true},
+		{"Connection reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"Access denied", &mysql.MySQLError{Number: 1044}, false},
+		{"Syntax error", &mysql.MySQLError{Number: 1064}, false},
+		{"Context deadline", fmt.Errorf("failed: %w", context.DeadlineExceeded), false},
+		{"Context cancelled", context.Canceled, false},
+		{"Nil error", nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsTransientError(tc.err); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
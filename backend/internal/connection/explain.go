@@ -0,0 +1,129 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// ExplainProcess runs EXPLAIN against the statement a server thread is
+// currently executing, as shown in the process list
+func (c *Connection) ExplainProcess(ctx context.Context, threadID int64) (*protocol.ProcessExplain, error) {
+	result := &protocol.ProcessExplain{ThreadID: threadID}
+
+	var database, info sql.NullString
+	err := c.db.QueryRowContext(ctx,
+		"SELECT DB, INFO FROM information_schema.PROCESSLIST WHERE ID = ?",
+		threadID,
+	).Scan(&database, &info)
+	if err == sql.ErrNoRows {
+		result.Message = fmt.Sprintf("Thread %d no longer exists", threadID)
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process list: %w", err)
+	}
+
+	result.Database = database.String
+	result.SQL = info.String
+	if result.SQL == "" {
+		result.Message = fmt.Sprintf("Thread %d is not running a statement (it may have already finished)", threadID)
+		return result, nil
+	}
+	if !isExplainable(result.SQL) {
+		result.Message = fmt.Sprintf("%s statements cannot be explained", leadingKeyword(result.SQL))
+		return result, nil
+	}
+
+	plan, err := c.explainIn(ctx, result.Database, result.SQL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("explain cancelled: %w", ctx.Err())
+		}
+		// e.g. temporary tables only visible to the other session
+		result.Message = fmt.Sprintf("EXPLAIN failed: %v", err)
+		return result, nil
+	}
+
+	result.Explainable = true
+	result.Plan = plan
+	return result, nil
+}
+
+// explainIn runs EXPLAIN with the given default database, so unqualified
+// table names resolve as they do for the original session
+func (c *Connection) explainIn(ctx context.Context, database, sqlText string) (*protocol.QueryResult, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// USE changes session state, so never hand this connection back
+	defer discardConn(conn)
+
+	if database != "" {
+		if _, err := conn.ExecContext(ctx, "USE "+quoteIdentifier(database)); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, "EXPLAIN "+sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return c.readResult(ctx, rows, 8, nil, nil)
+}
+
+// ExplainAnalyze runs EXPLAIN ANALYZE, which executes the statement and
+// reports actual row counts and timings. Because the statement really runs,
+// only read-only statements are accepted, and cancellation kills it.
+func (c *Connection) ExplainAnalyze(ctx context.Context, sqlText string) (*protocol.ExplainAnalyzeResult, error) {
+	if !c.version.supportsExplainAnalyze() {
+		return &protocol.ExplainAnalyzeResult{
+			Message: fmt.Sprintf("EXPLAIN ANALYZE requires MySQL 8.0.18+ (server is %s)", c.version.Raw),
+		}, nil
+	}
+
+	if kind := statementKind(sqlText); kind != "SELECT" && kind != "TABLE" {
+		return nil, fmt.Errorf("EXPLAIN ANALYZE executes the statement, so only SELECT statements are allowed (got %s)", kind)
+	}
+	if err := c.checkStatement(sqlText); err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	rows, release, err := c.queryWithKill(ctx, "EXPLAIN ANALYZE "+sqlText)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to run EXPLAIN ANALYZE: %w", err)
+	}
+	defer release()
+
+	var plan strings.Builder
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		plan.WriteString(line)
+	}
+	if err := rows.Err(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, err
+	}
+
+	return &protocol.ExplainAnalyzeResult{
+		Supported:     true,
+		Plan:          plan.String(),
+		ExecutionTime: time.Since(startTime).Milliseconds(),
+	}, nil
+}
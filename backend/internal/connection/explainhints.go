@@ -0,0 +1,207 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// Code of the warning MySQL 5.7+ adds to EXPLAIN when a comparison's type
+// or collation conversion keeps an index from being used
+const warnIndexConversion = 1739
+
+var (
+	// conversionWarning matches warning 1739
+	conversionWarning = regexp.MustCompile("Cannot use (?:ref|range) access on index '([^']*)' due to type or collation conversion on field '([^']*)'")
+	// castColumn and convertColumn match a column wrapped in a conversion in
+	// the rewritten query of note 1003, e.g. cast(`shop`.`t`.`code` as double)
+	castColumn    = regexp.MustCompile("cast\\(((?:`(?:[^`]|``)*`\\.)*`(?:[^`]|``)*`) as ([a-z]+)")
+	convertColumn = regexp.MustCompile("convert\\(((?:`(?:[^`]|``)*`\\.)*`(?:[^`]|``)*`) using ([a-z0-9_]+)\\)")
+	quotedPart    = regexp.MustCompile("`((?:[^`]|``)*)`")
+)
+
+// explainPrefix returns the EXPLAIN form that leaves the rewritten query
+// and conversion warnings behind for SHOW WARNINGS. MySQL 5.7 does this
+// for plain EXPLAIN and removed EXPLAIN EXTENDED in 8.0; MariaDB and older
+// MySQL need EXTENDED.
+func (v ServerVersion) explainPrefix() string {
+	if v.IsMariaDB() || !v.AtLeast(5, 7) {
+		return "EXPLAIN EXTENDED "
+	}
+	return "EXPLAIN "
+}
+
+// ExplainQuery runs EXPLAIN on a statement and reads the warnings it
+// leaves on the session. Besides the plan, the result carries hints about
+// common mistakes the plan reveals, such as comparing an indexed string
+// column with a number. With tree set it also runs EXPLAIN FORMAT=JSON and
+// returns the plan as nested nodes.
+func (c *Connection) ExplainQuery(ctx context.Context, sqlText string, tree bool) (*protocol.QueryExplain, error) {
+	if !isExplainable(sqlText) {
+		return nil, fmt.Errorf("%s statements cannot be explained", leadingKeyword(sqlText))
+	}
+	if err := c.checkStatement(sqlText); err != nil {
+		return nil, err
+	}
+
+	// SHOW WARNINGS must run on the session that ran EXPLAIN
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer releaseAfter(ctx, conn, sqlText)
+
+	// EXPLAIN of a statement with subqueries in FROM can take as long as
+	// running them on older servers
+	stop := c.watchCancel(ctx, threadID)
+	defer stop()
+
+	rows, err := conn.QueryContext(ctx, c.version.explainPrefix()+sqlText)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("explain cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to run EXPLAIN: %w", err)
+	}
+	plan, err := c.readResult(ctx, rows, 8, nil, nil)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &protocol.QueryExplain{Plan: plan, Warnings: []protocol.ExplainWarning{}}
+	rows, err = conn.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EXPLAIN warnings: %w", err)
+	}
+	for rows.Next() {
+		var warning protocol.ExplainWarning
+		if err := rows.Scan(&warning.Level, &warning.Code, &warning.Message); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.Warnings = append(result.Warnings, warning)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Hints = explainHints(plan, result.Warnings)
+
+	// Run after SHOW WARNINGS, which this EXPLAIN would overwrite
+	if tree {
+		var document string
+		if err := conn.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+sqlText).Scan(&document); err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("explain cancelled: %w", ctx.Err())
+			}
+			return nil, fmt.Errorf("failed to run EXPLAIN FORMAT=JSON: %w", err)
+		}
+		if result.Tree, err = parsePlanTree(document); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// explainHints turns EXPLAIN output into advice. It looks for conversion
+// warnings, columns wrapped in CAST or CONVERT in the rewritten query, and
+// tables read in full although an index could apply.
+func explainHints(plan *protocol.QueryResult, warnings []protocol.ExplainWarning) []protocol.QueryHint {
+	hints := []protocol.QueryHint{}
+	seen := make(map[string]bool)
+	add := func(hint protocol.QueryHint) {
+		key := hint.Kind + "\x00" + hint.Table + "\x00" + hint.Column
+		if !seen[key] {
+			seen[key] = true
+			hints = append(hints, hint)
+		}
+	}
+
+	for _, warning := range warnings {
+		if warning.Code == warnIndexConversion {
+			if m := conversionWarning.FindStringSubmatch(warning.Message); m != nil {
+				add(protocol.QueryHint{
+					Kind:   protocol.HintImplicitConversion,
+					Column: m[2],
+					Index:  m[1],
+					Message: fmt.Sprintf("Index %s cannot be used for %s because it is compared with a value of another type or collation. Compare it with a value of the column's own type, e.g. quote numbers compared with a string column.",
+						quoteIdentifier(m[1]), m[2]),
+				})
+			}
+			continue
+		}
+
+		// Note 1003 holds the query as the optimizer rewrote it
+		for _, m := range castColumn.FindAllStringSubmatch(warning.Message, -1) {
+			table, column := columnReference(m[1])
+			add(protocol.QueryHint{
+				Kind:   protocol.HintImplicitConversion,
+				Table:  table,
+				Column: column,
+				Message: fmt.Sprintf("%s is converted to %s for a comparison, so no index on it can be used. If the column holds strings, quote the value it is compared with.",
+					column, strings.ToUpper(m[2])),
+			})
+		}
+		for _, m := range convertColumn.FindAllStringSubmatch(warning.Message, -1) {
+			table, column := columnReference(m[1])
+			add(protocol.QueryHint{
+				Kind:   protocol.HintCollationConversion,
+				Table:  table,
+				Column: column,
+				Message: fmt.Sprintf("%s is converted to %s for a comparison, so no index on it can be used. Compare it with a value in the column's character set, or give both sides the same collation.",
+					column, m[2]),
+			})
+		}
+	}
+
+	// A full scan with candidate indexes means the conditions could not
+	// use them; the hints above usually say why
+	table, access, possibleKeys, key := -1, -1, -1, -1
+	for i, name := range plan.Columns {
+		switch name {
+		case "table":
+			table = i
+		case "type":
+			access = i
+		case "possible_keys":
+			possibleKeys = i
+		case "key":
+			key = i
+		}
+	}
+	if table < 0 || access < 0 || possibleKeys < 0 || key < 0 {
+		return hints
+	}
+	for _, row := range plan.Rows {
+		if asString(row[access]) != "ALL" || asString(row[possibleKeys]) == "" || asString(row[key]) != "" {
+			continue
+		}
+		add(protocol.QueryHint{
+			Kind:  protocol.HintIndexNotUsed,
+			Table: asString(row[table]),
+			Index: asString(row[possibleKeys]),
+			Message: fmt.Sprintf("%s is read in full although indexes (%s) could apply. A type or collation conversion, a function around the column or a leading wildcard in LIKE keeps MySQL from using them.",
+				asString(row[table]), asString(row[possibleKeys])),
+		})
+	}
+	return hints
+}
+
+// columnReference splits a quoted `db`.`table`.`column` reference into its
+// table and column
+func columnReference(reference string) (table, column string) {
+	parts := quotedPart.FindAllStringSubmatch(reference, -1)
+	names := make([]string, len(parts))
+	for i, part := range parts {
+		names[i] = strings.ReplaceAll(part[1], "``", "`")
+	}
+	column = names[len(names)-1]
+	if len(names) >= 2 {
+		table = names[len(names)-2]
+	}
+	return table, column
+}
@@ -0,0 +1,83 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestExplainHints(t *testing.T) {
+	plan := &protocol.QueryResult{
+		Columns: []string{"id", "select_type", "table", "type", "possible_keys", "key", "rows", "Extra"},
+		Rows: [][]interface{}{
+			{int64(1), "SIMPLE", "orders", "ALL", "idx_code", nil, int64(5000), "Using where"},
+			{int64(1), "SIMPLE", "customers", "eq_ref", "PRIMARY", "PRIMARY", int64(1), nil},
+		},
+	}
+	warnings := []protocol.ExplainWarning{
+		{Level: "Warning", Code: 1739, Message: "Cannot use ref access on index 'idx_code' due to type or collation conversion on field 'code'"},
+		{Level: "Note", Code: 1003, Message: "/* select#1 */ select `shop`.`orders`.`id` AS `id` from `shop`.`orders` " +
+			"where ((cast(`shop`.`orders`.`code` as double) = 123) and (convert(`shop`.`orders`.`na``me` using utf8mb4) = 'x'))"},
+	}
+
+	hints := explainHints(plan, warnings)
+	if len(hints) != 4 {
+		t.Fatalf("Expected 4 hints, got %+v", hints)
+	}
+	if h := hints[0]; h.Kind != protocol.HintImplicitConversion || h.Index != "idx_code" || h.Column != "code" {
+		t.Errorf("Expected a hint for warning 1739, got %+v", h)
+	}
+	if h := hints[1]; h.Kind != protocol.HintImplicitConversion || h.Table != "orders" || h.Column != "code" ||
+		!strings.Contains(h.Message, "converted to DOUBLE") {
+		t.Errorf("Expected a hint for the CAST, got %+v", h)
+	}
+	if h := hints[2]; h.Kind != protocol.HintCollationConversion || h.Column != "na`me" || !strings.Contains(h.Message, "utf8mb4") {
+		t.Errorf("Expected a hint for the CONVERT, got %+v", h)
+	}
+	if h := hints[3]; h.Kind != protocol.HintIndexNotUsed || h.Table != "orders" || h.Index != "idx_code" {
+		t.Errorf("Expected a hint for the full scan, got %+v", h)
+	}
+
+	if hints := explainHints(&protocol.QueryResult{Columns: plan.Columns, Rows: plan.Rows[1:]}, nil); len(hints) != 0 {
+		t.Errorf("Expected no hints for an index lookup, got %+v", hints)
+	}
+}
+
+func TestExplainPrefix(t *testing.T) {
+	tests := []struct {
+		version  ServerVersion
+		expected string
+	}{
+		{ServerVersion{Major: 8, Minor: 0}, "EXPLAIN "},
+		{ServerVersion{Major: 5, Minor: 7}, "EXPLAIN "},
+		{ServerVersion{Major: 5, Minor: 6}, "EXPLAIN EXTENDED "},
+		{ServerVersion{Major: 10, Minor: 6, Flavor: FlavorMariaDB}, "EXPLAIN EXTENDED "},
+	}
+	for _, tt := range tests {
+		if got := tt.version.explainPrefix(); got != tt.expected {
+			t.Errorf("explainPrefix(%d.%d) = %q, want %q", tt.version.Major, tt.version.Minor, got, tt.expected)
+		}
+	}
+}
+
+func TestExplainQueryKillsCancelledExplain(t *testing.T) {
+	// The kill runs on a second connection
+	connector := &fakeSessionConnector{}
+	c := newFakeSessionConnectionFrom(t, connector, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.ExplainQuery(ctx, "SELECT SLEEP(60)", false); err == nil {
+		t.Fatal("Expected the cancelled EXPLAIN to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("EXPLAIN was not killed on cancel, took %v", elapsed)
+	}
+	if !connector.killed(1) {
+		t.Errorf("Expected a KILL QUERY for the EXPLAIN's thread, got %v", connector.kills)
+	}
+}
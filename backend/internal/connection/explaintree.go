@@ -0,0 +1,223 @@
+package connection
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// planSubqueryLists are EXPLAIN FORMAT=JSON arrays whose elements wrap a
+// query block with flags such as "dependent" and "cacheable"
+var planSubqueryLists = map[string]bool{
+	"query_specifications":      true,
+	"attached_subqueries":       true,
+	"optimized_away_subqueries": true,
+	"select_list_subqueries":    true,
+	"having_subqueries":         true,
+	"order_by_subqueries":       true,
+	"group_by_subqueries":       true,
+	"update_value_subqueries":   true,
+}
+
+// parsePlanTree turns the document of EXPLAIN FORMAT=JSON into a tree of
+// plan nodes rooted at its query_block
+func parsePlanTree(document string) (*protocol.PlanNode, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse EXPLAIN FORMAT=JSON output: %w", err)
+	}
+	block, ok := root["query_block"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("EXPLAIN FORMAT=JSON output has no query_block")
+	}
+	node := planNode("query_block", block)
+	return &node, nil
+}
+
+// planNode builds the node for one object of the plan. Objects and arrays
+// of objects become children, in key order; well-known scalars fill the
+// node's fields and the rest are kept in Attributes.
+func planNode(kind string, obj map[string]interface{}) protocol.PlanNode {
+	node := protocol.PlanNode{Kind: kind, Label: kind, Attributes: map[string]interface{}{}}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch value := obj[key].(type) {
+		case map[string]interface{}:
+			if key == "cost_info" {
+				setPlanCosts(&node, value)
+				continue
+			}
+			node.Children = append(node.Children, planNode(key, value))
+		case []interface{}:
+			if !planObjects(value) {
+				if key == "possible_keys" {
+					node.PossibleKeys = planStrings(value)
+				} else {
+					node.Attributes[key] = value
+				}
+				continue
+			}
+			list := protocol.PlanNode{Kind: key, Label: key}
+			for _, element := range value {
+				list.Children = append(list.Children, planListElement(key, element.(map[string]interface{}))...)
+			}
+			// A join keeps its own node; other lists only group children
+			if key == "nested_loop" {
+				node.Children = append(node.Children, list)
+			} else {
+				node.Children = append(node.Children, list.Children...)
+			}
+		default:
+			setPlanField(&node, key, value)
+		}
+	}
+
+	switch {
+	case kind == "query_block" && node.SelectID != 0:
+		node.Label = fmt.Sprintf("SELECT #%d", node.SelectID)
+	case node.Table != "":
+		node.Label = node.Table
+	}
+	if len(node.Attributes) == 0 {
+		node.Attributes = nil
+	}
+	return node
+}
+
+// planListElement builds the nodes of one element of an array in the plan.
+// Elements of nested_loop wrap a single table; subquery elements wrap a
+// query block whose flags are moved onto it.
+func planListElement(list string, element map[string]interface{}) []protocol.PlanNode {
+	var nodes []protocol.PlanNode
+	flags := map[string]interface{}{}
+	for key, value := range element {
+		if child, ok := value.(map[string]interface{}); ok {
+			nodes = append(nodes, planNode(key, child))
+		} else {
+			flags[key] = value
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Kind < nodes[j].Kind })
+
+	if len(nodes) == 0 {
+		// An element without a nested object is a node itself
+		return []protocol.PlanNode{planNode(strings.TrimSuffix(list, "s"), element)}
+	}
+	if planSubqueryLists[list] {
+		for i := range nodes {
+			nodes[i].Subquery = list
+			for key, value := range flags {
+				setPlanField(&nodes[i], key, value)
+			}
+		}
+	}
+	return nodes
+}
+
+// setPlanField stores a scalar of the plan in the node field it maps to,
+// or in Attributes
+func setPlanField(node *protocol.PlanNode, key string, value interface{}) {
+	switch key {
+	case "select_id":
+		if n, ok := planNumber(value); ok {
+			node.SelectID = int64(n)
+		}
+	case "table_name":
+		node.Table, _ = value.(string)
+	case "access_type":
+		node.AccessType, _ = value.(string)
+	case "key":
+		node.Key, _ = value.(string)
+	case "attached_condition":
+		node.Condition, _ = value.(string)
+	case "rows_examined_per_scan", "rows":
+		// MariaDB reports "rows"
+		if n, ok := planNumber(value); ok {
+			rows := int64(n)
+			node.RowsExamined = &rows
+		}
+	case "rows_produced_per_join":
+		if n, ok := planNumber(value); ok {
+			rows := int64(n)
+			node.RowsProduced = &rows
+		}
+	case "filtered":
+		if n, ok := planNumber(value); ok {
+			node.Filtered = &n
+		}
+	case "using_temporary_table":
+		node.UsingTemporary, _ = value.(bool)
+	case "using_filesort":
+		node.UsingFilesort, _ = value.(bool)
+	default:
+		if node.Attributes == nil {
+			node.Attributes = map[string]interface{}{}
+		}
+		node.Attributes[key] = value
+	}
+}
+
+// setPlanCosts reads a cost_info object. Cost is the query cost of a query
+// block, or the cumulative prefix cost of a table.
+func setPlanCosts(node *protocol.PlanNode, costs map[string]interface{}) {
+	for key, value := range costs {
+		n, ok := planNumber(value)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "query_cost", "prefix_cost":
+			node.Cost = &n
+		case "read_cost":
+			node.ReadCost = &n
+		case "eval_cost":
+			node.EvalCost = &n
+		}
+	}
+}
+
+// planNumber reads a number of the plan. MySQL writes costs and filtered
+// percentages as strings such as "12.50".
+func planNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// planObjects reports whether an array of the plan holds objects
+func planObjects(values []interface{}) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, value := range values {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func planStrings(values []interface{}) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package connection
+
+import (
+	"testing"
+)
+
+// A MySQL 8.0 plan of a join ordered with a filesort, with a dependent
+// subquery in the WHERE clause
+const sampleJSONPlan = `{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "1420.75"},
+    "ordering_operation": {
+      "using_temporary_table": true,
+      "using_filesort": true,
+      "nested_loop": [
+        {
+          "table": {
+            "table_name": "o",
+            "access_type": "ALL",
+            "possible_keys": ["idx_customer"],
+            "rows_examined_per_scan": 1000,
+            "rows_produced_per_join": 100,
+            "filtered": "10.00",
+            "cost_info": {"read_cost": "90.00", "eval_cost": "10.00", "prefix_cost": "100.00"},
+            "used_columns": ["id", "customer_id"],
+            "attached_condition": "(exists(/* select#2 */ select 1 from ` + "`shop`.`refunds`" + `))"
+          }
+        },
+        {
+          "table": {
+            "table_name": "c",
+            "access_type": "eq_ref",
+            "possible_keys": ["PRIMARY"],
+            "key": "PRIMARY",
+            "rows_examined_per_scan": 1,
+            "rows_produced_per_join": 100,
+            "filtered": "100.00",
+            "cost_info": {"read_cost": "25.00", "eval_cost": "10.00", "prefix_cost": "135.00"}
+          }
+        }
+      ],
+      "attached_subqueries": [
+        {
+          "dependent": true,
+          "cacheable": false,
+          "query_block": {
+            "select_id": 2,
+            "cost_info": {"query_cost": "0.35"},
+            "table": {"table_name": "refunds", "access_type": "ref", "key": "idx_order", "rows_examined_per_scan": 1}
+          }
+        }
+      ]
+    }
+  }
+}`
+
+func TestParsePlanTree(t *testing.T) {
+	root, err := parsePlanTree(sampleJSONPlan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if root.Label != "SELECT #1" || root.Cost == nil || *root.Cost != 1420.75 {
+		t.Errorf("Unexpected root: %+v", root)
+	}
+	if len(root.Children) != 1 || root.Children[0].Kind != "ordering_operation" {
+		t.Fatalf("Expected an ordering_operation under the root, got %+v", root.Children)
+	}
+	ordering := root.Children[0]
+	if !ordering.UsingFilesort || !ordering.UsingTemporary {
+		t.Errorf("Expected filesort and temporary flags, got %+v", ordering)
+	}
+	// The attached subquery and the join, in key order
+	if len(ordering.Children) != 2 {
+		t.Fatalf("Expected 2 children, got %+v", ordering.Children)
+	}
+
+	subquery := ordering.Children[0]
+	if subquery.Kind != "query_block" || subquery.Label != "SELECT #2" || subquery.Subquery != "attached_subqueries" ||
+		subquery.Attributes["dependent"] != true {
+		t.Errorf("Unexpected subquery: %+v", subquery)
+	}
+	if len(subquery.Children) != 1 || subquery.Children[0].Table != "refunds" || subquery.Children[0].Key != "idx_order" {
+		t.Errorf("Unexpected subquery table: %+v", subquery.Children)
+	}
+
+	join := ordering.Children[1]
+	if join.Kind != "nested_loop" || len(join.Children) != 2 {
+		t.Fatalf("Expected a nested_loop of 2 tables, got %+v", join)
+	}
+	orders, customers := join.Children[0], join.Children[1]
+	if orders.Label != "o" || orders.AccessType != "ALL" || len(orders.PossibleKeys) != 1 ||
+		*orders.RowsExamined != 1000 || *orders.RowsProduced != 100 || *orders.Filtered != 10 ||
+		*orders.Cost != 100 || *orders.ReadCost != 90 || orders.Condition == "" {
+		t.Errorf("Unexpected first table: %+v", orders)
+	}
+	if _, ok := orders.Attributes["used_columns"]; !ok {
+		t.Errorf("Expected used_columns to be kept in attributes, got %+v", orders.Attributes)
+	}
+	if customers.Label != "c" || customers.Key != "PRIMARY" || *customers.Cost != 135 {
+		t.Errorf("Unexpected second table: %+v", customers)
+	}
+}
+
+func TestParsePlanTreeRejectsOtherDocuments(t *testing.T) {
+	for _, document := range []string{"", "not json", `{"message": "no query_block"}`} {
+		if _, err := parsePlanTree(document); err == nil {
+			t.Errorf("Expected an error for %q", document)
+		}
+	}
+}
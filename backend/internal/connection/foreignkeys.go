@@ -0,0 +1,74 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// foreignKeysQuery selects foreign key columns; callers append a WHERE
+// clause. Columns are ordered by their position in the key so multi-column
+// keys pair up with the referenced columns.
+const foreignKeysQuery = `SELECT k.CONSTRAINT_NAME, k.TABLE_SCHEMA, k.TABLE_NAME,
+	k.COLUMN_NAME, k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,
+	r.UPDATE_RULE, r.DELETE_RULE
+	FROM information_schema.KEY_COLUMN_USAGE k
+	JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+		ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA
+		AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+		AND r.TABLE_NAME = k.TABLE_NAME
+	WHERE %s
+	ORDER BY k.TABLE_SCHEMA, k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION`
+
+// referencingForeignKeys returns the foreign keys in any database that
+// reference database.table
+func (c *Connection) referencingForeignKeys(ctx context.Context, conn *sql.Conn, database, table string) ([]protocol.ForeignKey, error) {
+	query := fmt.Sprintf(foreignKeysQuery, "k.REFERENCED_TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME = ?")
+	rows, err := conn.QueryContext(ctx, query, database, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+	defer rows.Close()
+	return scanForeignKeys(rows)
+}
+
+// ListForeignKeys returns the foreign keys declared on the tables of a
+// database, including those that reference other databases
+func (c *Connection) ListForeignKeys(ctx context.Context, database string) ([]protocol.ForeignKey, error) {
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(foreignKeysQuery, "k.TABLE_SCHEMA = ?"), database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+	defer rows.Close()
+	return scanForeignKeys(rows)
+}
+
+// scanForeignKeys reads the rows of foreignKeysQuery, one per key column,
+// into one ForeignKey per constraint
+func scanForeignKeys(rows *sql.Rows) ([]protocol.ForeignKey, error) {
+	keys := make([]protocol.ForeignKey, 0, 4)
+	for rows.Next() {
+		var fk protocol.ForeignKey
+		var column, referencedColumn string
+		if err := rows.Scan(&fk.Name, &fk.Database, &fk.Table, &column,
+			&fk.ReferencedDatabase, &fk.ReferencedTable, &referencedColumn,
+			&fk.OnUpdate, &fk.OnDelete); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+
+		// Each row is one column of a key
+		last := len(keys) - 1
+		if last >= 0 && keys[last].Name == fk.Name && keys[last].Database == fk.Database && keys[last].Table == fk.Table {
+			keys[last].Columns = append(keys[last].Columns, column)
+			keys[last].ReferencedColumns = append(keys[last].ReferencedColumns, referencedColumn)
+			continue
+		}
+		fk.Columns = []string{column}
+		fk.ReferencedColumns = []string{referencedColumn}
+		keys = append(keys, fk)
+	}
+
+	return keys, rows.Err()
+}
@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// isIntegerType reports whether a driver type name is an integer type
+func isIntegerType(typeName string) bool {
+	switch strings.TrimPrefix(typeName, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT":
+		return true
+	}
+	return false
+}
+
+// isTextType reports whether a driver type name holds character data
+func isTextType(typeName string) bool {
+	switch typeName {
+	case "CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT":
+		return true
+	}
+	return false
+}
+
+// formatHint suggests how to display a column from its name and type. The
+// column type wins over the name, and name patterns only apply to types
+// they make sense for: created_at as DATETIME is a datetime, as BIGINT a
+// Unix timestamp, and as VARCHAR a datetime string.
+func formatHint(name, typeName string) string {
+	switch typeName {
+	case "DATE":
+		return protocol.FormatHintDate
+	case "DATETIME", "TIMESTAMP":
+		return protocol.FormatHintDateTime
+	}
+
+	lower := strings.ToLower(name)
+	hasSuffix := func(suffixes ...string) bool {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(lower, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case hasSuffix("_at"):
+		if isIntegerType(typeName) {
+			return protocol.FormatHintUnixTime
+		}
+		if isTextType(typeName) {
+			return protocol.FormatHintDateTime
+		}
+	case hasSuffix("_date"):
+		if isTextType(typeName) {
+			return protocol.FormatHintDate
+		}
+	case hasSuffix("_bytes", "_size"):
+		if isIntegerType(typeName) {
+			return protocol.FormatHintBytes
+		}
+	case lower == "id" || lower == "uuid" || hasSuffix("_id", "_uuid"):
+		return protocol.FormatHintMonospace
+	}
+	return ""
+}
+
+// formatHints returns formatHint for each column, or nil when no column
+// has a hint
+func formatHints(names, typeNames []string) []string {
+	var hints []string
+	for i, name := range names {
+		if hint := formatHint(name, typeNames[i]); hint != "" {
+			if hints == nil {
+				hints = make([]string, len(names))
+			}
+			hints[i] = hint
+		}
+	}
+	return hints
+}
@@ -0,0 +1,45 @@
+package connection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestFormatHint(t *testing.T) {
+	tests := []struct {
+		name, typeName, expected string
+	}{
+		{"created_at", "DATETIME", protocol.FormatHintDateTime},
+		{"created_at", "BIGINT", protocol.FormatHintUnixTime},
+		{"Updated_At", "VARCHAR", protocol.FormatHintDateTime},
+		{"birthday", "DATE", protocol.FormatHintDate},
+		{"ship_date", "CHAR", protocol.FormatHintDate},
+		{"ship_date", "INT", ""},
+		{"file_size", "UNSIGNED BIGINT", protocol.FormatHintBytes},
+		{"heap_bytes", "INT", protocol.FormatHintBytes},
+		{"page_size", "VARCHAR", ""},
+		{"id", "INT", protocol.FormatHintMonospace},
+		{"customer_id", "BIGINT", protocol.FormatHintMonospace},
+		{"uuid", "BINARY", protocol.FormatHintMonospace},
+		{"amount", "DECIMAL", ""},
+	}
+	for _, tt := range tests {
+		if got := formatHint(tt.name, tt.typeName); got != tt.expected {
+			t.Errorf("formatHint(%q, %q) = %q, want %q", tt.name, tt.typeName, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatHints(t *testing.T) {
+	if hints := formatHints([]string{"name", "amount"}, []string{"VARCHAR", "DECIMAL"}); hints != nil {
+		t.Errorf("Expected nil without hints, got %v", hints)
+	}
+
+	got := formatHints([]string{"id", "name", "created_at"}, []string{"INT", "VARCHAR", "TIMESTAMP"})
+	expected := []string{protocol.FormatHintMonospace, "", protocol.FormatHintDateTime}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("formatHints() = %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,148 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// maxHierarchyRows bounds the rows read by GetHierarchy; a partial tree
+// would show missing parents as orphans, so larger tables are refused
+const maxHierarchyRows = 10000
+
+// GetHierarchy reads a self-referencing table and assembles its rows into a
+// tree by idColumn and parentColumn
+func (c *Connection) GetHierarchy(ctx context.Context, database, table, idColumn, parentColumn string) (*protocol.Hierarchy, error) {
+	if idColumn == "" || parentColumn == "" {
+		return nil, fmt.Errorf("idColumn and parentColumn are required")
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY %s LIMIT %d",
+		qualifiedTable(database, table), quoteIdentifier(idColumn), maxHierarchyRows+1)
+	result, err := c.ExecuteQueryWithContext(ctx, query, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rows) > maxHierarchyRows {
+		return nil, fmt.Errorf("table has more than %d rows, too many to build a hierarchy", maxHierarchyRows)
+	}
+
+	return buildHierarchy(result, idColumn, parentColumn)
+}
+
+// buildHierarchy nests rows under their parents. Rows with a NULL parent are
+// roots. Rows whose parent does not exist are orphans and become roots too.
+// Rows in a cycle, and any rows below one, cannot be reached from a root;
+// they are left out of the tree and the cycles are reported instead.
+func buildHierarchy(result *protocol.QueryResult, idColumn, parentColumn string) (*protocol.Hierarchy, error) {
+	idIdx, parentIdx := -1, -1
+	for i, name := range result.Columns {
+		switch name {
+		case idColumn:
+			idIdx = i
+		case parentColumn:
+			parentIdx = i
+		}
+	}
+	if idIdx < 0 {
+		return nil, fmt.Errorf("column not found: %s", idColumn)
+	}
+	if parentIdx < 0 {
+		return nil, fmt.Errorf("column not found: %s", parentColumn)
+	}
+
+	// IDs and parent references are compared by their text, so an INT id
+	// matches a parent read back as a string
+	key := func(value interface{}) string {
+		return fmt.Sprint(value)
+	}
+
+	index := make(map[string]int, len(result.Rows))
+	for i, row := range result.Rows {
+		if row[idIdx] == nil {
+			return nil, fmt.Errorf("row %d has a NULL %s", i+1, idColumn)
+		}
+		k := key(row[idIdx])
+		if _, ok := index[k]; ok {
+			return nil, fmt.Errorf("duplicate %s: %v", idColumn, row[idIdx])
+		}
+		index[k] = i
+	}
+
+	hierarchy := &protocol.Hierarchy{
+		Columns: result.Columns,
+		Roots:   []*protocol.HierarchyNode{},
+		Orphans: []interface{}{},
+		Cycles:  [][]interface{}{},
+	}
+
+	// parents[i] is the row index of row i's parent, or -1 for roots
+	parents := make([]int, len(result.Rows))
+	children := make([][]int, len(result.Rows))
+	var roots []int
+	for i, row := range result.Rows {
+		parents[i] = -1
+		parent := row[parentIdx]
+		if parent == nil {
+			roots = append(roots, i)
+			continue
+		}
+		p, ok := index[key(parent)]
+		if !ok {
+			hierarchy.Orphans = append(hierarchy.Orphans, row[idIdx])
+			roots = append(roots, i)
+			continue
+		}
+		parents[i] = p
+		children[p] = append(children[p], i)
+	}
+
+	// Walk down from the roots; a cycle is never reached from a root, so
+	// the nodes built here always form a tree
+	placed := make([]bool, len(result.Rows))
+	var build func(i int, orphan bool) *protocol.HierarchyNode
+	build = func(i int, orphan bool) *protocol.HierarchyNode {
+		placed[i] = true
+		hierarchy.NodeCount++
+		node := &protocol.HierarchyNode{
+			ID:       result.Rows[i][idIdx],
+			Values:   result.Rows[i],
+			Orphan:   orphan,
+			Children: make([]*protocol.HierarchyNode, 0, len(children[i])),
+		}
+		for _, child := range children[i] {
+			node.Children = append(node.Children, build(child, false))
+		}
+		return node
+	}
+	for _, i := range roots {
+		hierarchy.Roots = append(hierarchy.Roots, build(i, result.Rows[i][parentIdx] != nil))
+	}
+
+	// Every unplaced row leads up into a cycle; walk up from each to find it
+	visited := make([]int, len(result.Rows)) // 0 unvisited, else the walk that reached it
+	for start := range result.Rows {
+		if placed[start] || visited[start] != 0 {
+			continue
+		}
+		walk := start + 1
+		i := start
+		for visited[i] == 0 {
+			visited[i] = walk
+			i = parents[i]
+		}
+		if visited[i] != walk {
+			// Joined a path already explored from an earlier row
+			continue
+		}
+		cycle := []interface{}{result.Rows[i][idIdx]}
+		for j := parents[i]; j != i; j = parents[j] {
+			cycle = append(cycle, result.Rows[j][idIdx])
+		}
+		hierarchy.Cycles = append(hierarchy.Cycles, cycle)
+	}
+	hierarchy.Excluded = len(result.Rows) - hierarchy.NodeCount
+
+	return hierarchy, nil
+}
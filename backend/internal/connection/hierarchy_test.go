@@ -0,0 +1,68 @@
+package connection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestBuildHierarchy(t *testing.T) {
+	result := &protocol.QueryResult{
+		Columns: []string{"id", "name", "parent_id"},
+		Rows: [][]interface{}{
+			{int64(1), "Root", nil},
+			{int64(2), "Books", int64(1)},
+			{int64(3), "Fiction", "2"},
+			{int64(4), "Music", int64(1)},
+			{int64(5), "Lost", int64(99)},
+			{int64(6), "Loop A", int64(7)},
+			{int64(7), "Loop B", int64(6)},
+			{int64(8), "Below loop", int64(6)},
+			{int64(9), "Self", int64(9)},
+		},
+	}
+
+	h, err := buildHierarchy(result, "id", "parent_id")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(h.Roots) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(h.Roots))
+	}
+	root := h.Roots[0]
+	if root.ID != int64(1) || len(root.Children) != 2 {
+		t.Fatalf("Expected root 1 with 2 children, got %+v", root)
+	}
+	if books := root.Children[0]; books.ID != int64(2) || len(books.Children) != 1 || books.Children[0].ID != int64(3) {
+		t.Errorf("Expected Fiction under Books, got %+v", books)
+	}
+	if lost := h.Roots[1]; lost.ID != int64(5) || !lost.Orphan {
+		t.Errorf("Expected orphan 5 at the top level, got %+v", lost)
+	}
+
+	if !reflect.DeepEqual(h.Orphans, []interface{}{int64(5)}) {
+		t.Errorf("Expected orphans [5], got %v", h.Orphans)
+	}
+	expectedCycles := [][]interface{}{{int64(6), int64(7)}, {int64(9)}}
+	if !reflect.DeepEqual(h.Cycles, expectedCycles) {
+		t.Errorf("Expected cycles %v, got %v", expectedCycles, h.Cycles)
+	}
+	if h.NodeCount != 5 || h.Excluded != 4 {
+		t.Errorf("Expected 5 nodes and 4 excluded, got %d and %d", h.NodeCount, h.Excluded)
+	}
+}
+
+func TestBuildHierarchyErrors(t *testing.T) {
+	result := &protocol.QueryResult{
+		Columns: []string{"id", "parent_id"},
+		Rows:    [][]interface{}{{int64(1), nil}, {int64(1), nil}},
+	}
+	if _, err := buildHierarchy(result, "id", "parent_id"); err == nil {
+		t.Error("Expected error for duplicate ids")
+	}
+	if _, err := buildHierarchy(result, "id", "parent"); err == nil {
+		t.Error("Expected error for a missing parent column")
+	}
+}
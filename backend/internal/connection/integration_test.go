@@ -0,0 +1,330 @@
+package connection
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// referenceTime is a fixed instant used by tests that compare zone offsets
+var referenceTime = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// integrationConfig returns a config for a live MySQL server described by
+// DATA_WARDEN_TEST_MYSQL_* environment variables, skipping the test when
+// DATA_WARDEN_TEST_MYSQL_HOST is not set
+func integrationConfig(t *testing.T) *protocol.ConnectionConfig {
+	t.Helper()
+
+	host := os.Getenv("DATA_WARDEN_TEST_MYSQL_HOST")
+	if host == "" {
+		t.Skip("DATA_WARDEN_TEST_MYSQL_HOST not set, skipping integration test")
+	}
+
+	port := 3306
+	if p := os.Getenv("DATA_WARDEN_TEST_MYSQL_PORT"); p != "" {
+		var err error
+		if port, err = strconv.Atoi(p); err != nil {
+			t.Fatalf("Invalid DATA_WARDEN_TEST_MYSQL_PORT: %v", err)
+		}
+	}
+
+	user := os.Getenv("DATA_WARDEN_TEST_MYSQL_USER")
+	if user == "" {
+		user = "root"
+	}
+
+	return &protocol.ConnectionConfig{
+		ID:       "integration",
+		Type:     "mysql",
+		Host:     host,
+		Port:     port,
+		Username: user,
+		Password: os.Getenv("DATA_WARDEN_TEST_MYSQL_PASSWORD"),
+	}
+}
+
+func TestIntegrationTimestampInConfiguredZone(t *testing.T) {
+	config := integrationConfig(t)
+	config.TimeZone = "+05:30"
+
+	c, err := NewConnection(config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "CREATE TEMPORARY TABLE dw_tz_test (ts TIMESTAMP NULL)"); err != nil {
+		t.Fatalf("Failed to create temporary table: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO dw_tz_test VALUES (FROM_UNIXTIME(0))"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	var ts time.Time
+	var wall string
+	err = conn.QueryRowContext(ctx, "SELECT ts, CAST(ts AS CHAR) FROM dw_tz_test").Scan(&ts, &wall)
+	if err != nil {
+		t.Fatalf("Failed to select: %v", err)
+	}
+
+	// The server renders the epoch in the session zone...
+	if wall != "1970-01-01 05:30:00" {
+		t.Errorf("Expected server wall time '1970-01-01 05:30:00', got %q", wall)
+	}
+	// ...and the driver interprets it in the same zone, preserving the instant
+	if !ts.Equal(time.Unix(0, 0)) {
+		t.Errorf("Expected Unix epoch, got %v", ts)
+	}
+	if _, offset := ts.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("Expected +05:30 offset, got %d seconds", offset)
+	}
+}
+
+func TestIntegrationCancelDuringQueryContext(t *testing.T) {
+	config := integrationConfig(t)
+
+	c, err := NewConnection(config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = c.ExecuteQueryWithContext(ctx, "SELECT SLEEP(30) AS dw_cancel_test", 0, 0)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("Expected cancellation error, got nil")
+	}
+	if !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Expected cancellation error, got: %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected query to return within 2s of cancel, took %v", elapsed)
+	}
+
+	// The server-side statement must be killed, not left running
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		var running int
+		err := c.db.QueryRow(
+			"SELECT COUNT(*) FROM information_schema.PROCESSLIST WHERE INFO LIKE 'SELECT SLEEP(30) AS dw_cancel_test%'",
+		).Scan(&running)
+		if err != nil {
+			t.Fatalf("Failed to read processlist: %v", err)
+		}
+		if running == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Cancelled query is still running on the server")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestIntegrationUpdateReportsChangedRowsAndWarnings(t *testing.T) {
+	c, err := NewConnection(integrationConfig(t))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	// Writes run on their own pooled connection, so use a real table
+	setup := []string{
+		"CREATE DATABASE IF NOT EXISTS dw_integration",
+		"DROP TABLE IF EXISTS dw_integration.write_test",
+		"CREATE TABLE dw_integration.write_test (id INT PRIMARY KEY, v TINYINT)",
+		"INSERT INTO dw_integration.write_test VALUES (1, 1), (2, 1), (3, 2)",
+	}
+	for _, stmt := range setup {
+		if _, err := c.db.Exec(stmt); err != nil {
+			t.Fatalf("Setup failed (%s): %v", stmt, err)
+		}
+	}
+	defer c.db.Exec("DROP DATABASE dw_integration")
+
+	ctx := context.Background()
+	result, err := c.ExecuteQueryWithContext(ctx, "UPDATE dw_integration.write_test SET v = 2 WHERE id <= 3", 100, 0)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if result.RowsChanged == nil || *result.RowsChanged != 2 {
+		t.Errorf("Expected 2 changed rows, got %v", result.RowsChanged)
+	}
+	if result.Warnings == nil || *result.Warnings != 0 {
+		t.Errorf("Expected 0 warnings, got %v", result.Warnings)
+	}
+
+	// Out-of-range values are clamped with a warning outside strict mode
+	result, err = c.ExecuteQueryWithContext(ctx,
+		"UPDATE IGNORE dw_integration.write_test SET v = 1000 WHERE id = 1", 0, 0)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if result.Warnings == nil || *result.Warnings == 0 {
+		t.Errorf("Expected a warning for the clamped value, got %v", result.Warnings)
+	}
+}
+
+func TestIntegrationServerTimeReflectsSessionZone(t *testing.T) {
+	config := integrationConfig(t)
+	config.TimeZone = "+05:30"
+
+	c, err := NewConnection(config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	result, err := c.GetServerTime(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get server time: %v", err)
+	}
+	if result.TimeZone != "+05:30" {
+		t.Errorf("Expected session time zone +05:30, got %s", result.TimeZone)
+	}
+	if result.UTCOffsetSeconds != 5*3600+30*60 {
+		t.Errorf("Expected offset 19800s, got %d", result.UTCOffsetSeconds)
+	}
+	if !strings.Contains(result.UTCTime, "T") {
+		t.Errorf("Expected ISO-8601 UTC time, got %s", result.UTCTime)
+	}
+}
+
+func TestIntegrationListPartitions(t *testing.T) {
+	c, err := NewConnection(integrationConfig(t))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	setup := []string{
+		"CREATE DATABASE IF NOT EXISTS dw_integration",
+		"DROP TABLE IF EXISTS dw_integration.events, dw_integration.plain",
+		`CREATE TABLE dw_integration.events (id INT, created DATE)
+			PARTITION BY RANGE (YEAR(created)) (
+				PARTITION p2023 VALUES LESS THAN (2024),
+				PARTITION p2024 VALUES LESS THAN (2025),
+				PARTITION pmax VALUES LESS THAN MAXVALUE)`,
+		"CREATE TABLE dw_integration.plain (id INT)",
+	}
+	for _, stmt := range setup {
+		if _, err := c.db.Exec(stmt); err != nil {
+			t.Fatalf("Setup failed (%s): %v", stmt, err)
+		}
+	}
+	defer c.db.Exec("DROP DATABASE dw_integration")
+
+	partitions, err := c.ListPartitions(context.Background(), "dw_integration", "events")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(partitions) != 3 {
+		t.Fatalf("Expected 3 partitions, got %d", len(partitions))
+	}
+	first := partitions[0]
+	if first.Name != "p2023" || first.Method != "RANGE" || first.Description != "2024" {
+		t.Errorf("Unexpected first partition: %+v", first)
+	}
+	if partitions[2].Name != "pmax" || partitions[2].Description != "MAXVALUE" {
+		t.Errorf("Unexpected last partition: %+v", partitions[2])
+	}
+
+	plain, err := c.ListPartitions(context.Background(), "dw_integration", "plain")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(plain) != 0 {
+		t.Errorf("Expected no partitions for a plain table, got %d", len(plain))
+	}
+
+	if err := c.TruncatePartition(context.Background(), "dw_integration", "events", "P2024"); err != nil {
+		t.Errorf("Truncate failed: %v", err)
+	}
+	if err := c.DropPartition(context.Background(), "dw_integration", "events", "p2022"); err == nil {
+		t.Error("Expected error dropping a partition that does not exist")
+	}
+	if err := c.DropPartition(context.Background(), "dw_integration", "events", "p2023"); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+	if partitions, _ = c.ListPartitions(context.Background(), "dw_integration", "events"); len(partitions) != 2 {
+		t.Errorf("Expected 2 partitions after the drop, got %d", len(partitions))
+	}
+}
+
+func TestIntegrationResultColumnMeta(t *testing.T) {
+	c, err := NewConnection(integrationConfig(t))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	meta, err := c.GetResultColumnMeta(context.Background(),
+		"SELECT CONVERT('a' USING latin1) COLLATE latin1_bin AS l, _utf8mb4'b' AS u, 1 AS n;")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if meta.Message != "" {
+		t.Fatalf("Unexpected fallback: %s", meta.Message)
+	}
+	if len(meta.Columns) != 3 {
+		t.Fatalf("Expected 3 columns, got %+v", meta.Columns)
+	}
+	if l := meta.Columns[0]; l.CharacterSet != "latin1" || l.Collation != "latin1_bin" {
+		t.Errorf("Unexpected latin1 column: %+v", l)
+	}
+	if u := meta.Columns[1]; u.CharacterSet != "utf8mb4" {
+		t.Errorf("Unexpected utf8mb4 column: %+v", u)
+	}
+	if n := meta.Columns[2]; n.Collation != "" {
+		t.Errorf("Expected no collation for a number, got %+v", n)
+	}
+}
+
+func TestIntegrationExactRowCounts(t *testing.T) {
+	c, err := NewConnection(integrationConfig(t))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"CREATE DATABASE IF NOT EXISTS dw_integration",
+		"DROP TABLE IF EXISTS dw_integration.dw_counts",
+		"CREATE TABLE dw_integration.dw_counts (id INT PRIMARY KEY)",
+		"INSERT INTO dw_integration.dw_counts VALUES (1), (2), (3)",
+	} {
+		if _, err := c.db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+	defer c.db.Exec("DROP TABLE IF EXISTS dw_integration.dw_counts")
+
+	counts, err := c.GetExactRowCounts(ctx, "dw_integration", []string{"dw_counts", "dw_missing"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if counts.Counts["dw_counts"] != 3 {
+		t.Errorf("Expected 3 rows, got %v", counts.Counts)
+	}
+	if counts.Errors["dw_missing"] == "" {
+		t.Errorf("Expected an error for the missing table, got %v", counts.Errors)
+	}
+}
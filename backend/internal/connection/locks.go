@@ -0,0 +1,196 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const transactionsQuery = `SELECT trx_id, trx_mysql_thread_id, trx_state, trx_started, trx_query,
+	trx_rows_locked, trx_tables_locked
+	FROM information_schema.INNODB_TRX ORDER BY trx_started`
+
+// MySQL 8.0 moved lock details to performance_schema
+const (
+	dataLocksQuery = `SELECT ENGINE_TRANSACTION_ID, OBJECT_SCHEMA, OBJECT_NAME, INDEX_NAME,
+	LOCK_TYPE, LOCK_MODE, LOCK_STATUS, LOCK_DATA
+	FROM performance_schema.data_locks`
+	dataLockWaitsQuery = `SELECT REQUESTING_ENGINE_TRANSACTION_ID, BLOCKING_ENGINE_TRANSACTION_ID
+	FROM performance_schema.data_lock_waits`
+)
+
+// MySQL 5.7 and MariaDB only expose locks involved in a wait
+const (
+	innodbLocksQuery = `SELECT lock_trx_id, lock_table, lock_index, lock_type, lock_mode, lock_data
+	FROM information_schema.INNODB_LOCKS`
+	innodbLockWaitsQuery = `SELECT requesting_trx_id, blocking_trx_id
+	FROM information_schema.INNODB_LOCK_WAITS`
+)
+
+// ListLocks returns open InnoDB transactions, the locks they hold or wait
+// for, and which transactions block which
+func (c *Connection) ListLocks(ctx context.Context) (*protocol.LockInfo, error) {
+	info := &protocol.LockInfo{
+		Transactions: []protocol.Transaction{},
+		Locks:        []protocol.Lock{},
+		Waits:        []protocol.LockWait{},
+	}
+
+	transactions, err := c.listTransactions(ctx)
+	if err != nil {
+		if isPermissionError(err) {
+			info.Message = "The current user is not allowed to view InnoDB transactions (requires the PROCESS privilege)"
+			return info, nil
+		}
+		if isMissingObjectError(err) {
+			info.Message = "This server does not expose InnoDB transaction information"
+			return info, nil
+		}
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	info.Transactions = transactions
+	info.Available = true
+
+	usePerformanceSchema := !c.version.IsMariaDB() && c.version.AtLeast(8, 0)
+	if usePerformanceSchema {
+		info.Locks, err = c.listDataLocks(ctx)
+	} else {
+		info.Locks, err = c.listInnoDBLocks(ctx)
+	}
+	if err == nil {
+		if usePerformanceSchema {
+			info.Waits, err = c.listLockWaits(ctx, dataLockWaitsQuery)
+		} else {
+			info.Waits, err = c.listLockWaits(ctx, innodbLockWaitsQuery)
+		}
+	}
+	if err != nil {
+		if !isPermissionError(err) && !isMissingObjectError(err) {
+			return nil, fmt.Errorf("failed to list locks: %w", err)
+		}
+		// Transactions are still useful without lock details
+		info.Locks = []protocol.Lock{}
+		info.Waits = []protocol.LockWait{}
+		info.Message = "Lock details are unavailable: " + err.Error()
+		return info, nil
+	}
+
+	// Attach thread ids so blocking sessions can be found in the process list
+	threads := make(map[string]int64, len(transactions))
+	for _, trx := range transactions {
+		threads[trx.ID] = trx.ThreadID
+	}
+	for i := range info.Waits {
+		info.Waits[i].WaitingThreadID = threads[info.Waits[i].WaitingTransactionID]
+		info.Waits[i].BlockingThreadID = threads[info.Waits[i].BlockingTransactionID]
+	}
+
+	return info, nil
+}
+
+func (c *Connection) listTransactions(ctx context.Context) ([]protocol.Transaction, error) {
+	rows, err := c.db.QueryContext(ctx, transactionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]protocol.Transaction, 0, 8)
+	for rows.Next() {
+		var trx protocol.Transaction
+		var started sql.NullTime
+		var query sql.NullString
+		if err := rows.Scan(&trx.ID, &trx.ThreadID, &trx.State, &started, &query,
+			&trx.RowsLocked, &trx.TablesLocked); err != nil {
+			return nil, err
+		}
+		if started.Valid {
+			trx.Started = started.Time.Format(dateTimeLayout)
+		}
+		trx.Query = query.String
+		transactions = append(transactions, trx)
+	}
+
+	return transactions, rows.Err()
+}
+
+func (c *Connection) listDataLocks(ctx context.Context) ([]protocol.Lock, error) {
+	rows, err := c.db.QueryContext(ctx, dataLocksQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locks := make([]protocol.Lock, 0, 16)
+	for rows.Next() {
+		var lock protocol.Lock
+		var schema, table, index, data sql.NullString
+		if err := rows.Scan(&lock.TransactionID, &schema, &table, &index,
+			&lock.LockType, &lock.LockMode, &lock.LockStatus, &data); err != nil {
+			return nil, err
+		}
+		lock.Database = schema.String
+		lock.Table = table.String
+		lock.Index = index.String
+		lock.LockData = data.String
+		locks = append(locks, lock)
+	}
+
+	return locks, rows.Err()
+}
+
+func (c *Connection) listInnoDBLocks(ctx context.Context) ([]protocol.Lock, error) {
+	rows, err := c.db.QueryContext(ctx, innodbLocksQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locks := make([]protocol.Lock, 0, 16)
+	for rows.Next() {
+		var lock protocol.Lock
+		var table string
+		var index, data sql.NullString
+		if err := rows.Scan(&lock.TransactionID, &table, &index,
+			&lock.LockType, &lock.LockMode, &data); err != nil {
+			return nil, err
+		}
+		lock.Database, lock.Table = splitLockTable(table)
+		lock.Index = index.String
+		lock.LockData = data.String
+		locks = append(locks, lock)
+	}
+
+	return locks, rows.Err()
+}
+
+func (c *Connection) listLockWaits(ctx context.Context, query string) ([]protocol.LockWait, error) {
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	waits := make([]protocol.LockWait, 0, 8)
+	for rows.Next() {
+		var wait protocol.LockWait
+		if err := rows.Scan(&wait.WaitingTransactionID, &wait.BlockingTransactionID); err != nil {
+			return nil, err
+		}
+		waits = append(waits, wait)
+	}
+
+	return waits, rows.Err()
+}
+
+// splitLockTable splits an INNODB_LOCKS lock_table value such as
+// "`shop`.`orders`" into database and table
+func splitLockTable(s string) (database, table string) {
+	parts := splitTopLevel(s, '.')
+	if len(parts) != 2 {
+		return "", unquoteIdentifier(s)
+	}
+	return unquoteIdentifier(parts[0]), unquoteIdentifier(parts[1])
+}
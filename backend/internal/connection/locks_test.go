@@ -0,0 +1,22 @@
+package connection
+
+import "testing"
+
+func TestSplitLockTable(t *testing.T) {
+	testCases := []struct {
+		input    string
+		database string
+		table    string
+	}{
+		{"`shop`.`orders`", "shop", "orders"},
+		{"`my.db`.`order``s`", "my.db", "order`s"},
+		{"`orders`", "", "orders"},
+	}
+
+	for _, tc := range testCases {
+		database, table := splitLockTable(tc.input)
+		if database != tc.database || table != tc.table {
+			t.Errorf("splitLockTable(%q): expected %q.%q, got %q.%q", tc.input, tc.database, tc.table, database, table)
+		}
+	}
+}
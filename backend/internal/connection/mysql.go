@@ -4,16 +4,32 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/tazgreenwood/data-warden/internal/protocol"
 )
 
 type Connection struct {
 	config *protocol.ConnectionConfig
 	db     *sql.DB
+	// lowerCaseTableNames mirrors @@lower_case_table_names: 0 means table and
+	// database names are case-sensitive, 1 and 2 mean they compare
+	// case-insensitively
+	lowerCaseTableNames int
+	// version is the parsed server version, used where MySQL and MariaDB differ
+	version ServerVersion
+	// maxAllowedPacket mirrors @@max_allowed_packet; packetLimit is the
+	// largest statement that fits in a packet, or 0 if unknown
+	maxAllowedPacket int64
+	packetLimit      int64
+	// replicas serve plain reads; nextReplica rotates between them
+	replicas    []*Connection
+	nextReplica uint32
 }
 
 func NewConnection(config *protocol.ConnectionConfig) (*Connection, error) {
@@ -21,31 +37,22 @@ func NewConnection(config *protocol.ConnectionConfig) (*Connection, error) {
 		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
 	}
 
-	// Convert localhost to 127.0.0.1 to prefer IPv4
-	// This avoids issues on macOS where localhost resolves to ::1 (IPv6) first
-	host := config.Host
-	if host == "localhost" {
-		host = "127.0.0.1"
-	}
-
-	// Build DSN (Data Source Name)
-	// Add timeout and cancellation support
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&timeout=30s&readTimeout=30s&writeTimeout=30s",
-		config.Username,
-		config.Password,
-		host,
-		config.Port,
-		config.Database,
-	)
+	host := dialHost(config.Host)
 
-	if config.SSL {
-		dsn += "&tls=true"
+	dsnConfig, err := buildDriverConfig(config, host)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := sql.Open("mysql", dsn)
+	// Keeps the plugin name the driver logs when authentication fails
+	recorder := &pluginRecorder{}
+	dsnConfig.Logger = recorder
+
+	connector, err := mysql.NewConnector(dsnConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MySQL: %w. Check that host '%s' and port %d are correct", err, config.Host, config.Port)
 	}
+	db := sql.OpenDB(connector)
 
 	// Configure connection pool for better performance
 	// MaxOpenConns: Allow more concurrent queries
@@ -61,6 +68,9 @@ func NewConnection(config *protocol.ConnectionConfig) (*Connection, error) {
 	if err := db.Ping(); err != nil {
 		db.Close()
 		// Provide helpful error messages based on common issues
+		if authErr := authPluginError(err, recorder, config.SSL); authErr != nil {
+			return nil, authErr
+		}
 		errMsg := err.Error()
 		if strings.Contains(errMsg, "connection refused") {
 			return nil, fmt.Errorf("connection refused: MySQL server is not running on %s:%d, or the port is blocked by a firewall", host, config.Port)
@@ -68,23 +78,106 @@ func NewConnection(config *protocol.ConnectionConfig) (*Connection, error) {
 			return nil, fmt.Errorf("access denied: incorrect username '%s' or password. Check your credentials", config.Username)
 		} else if strings.Contains(errMsg, "Unknown database") {
 			return nil, fmt.Errorf("unknown database '%s': the database does not exist. Create it first or use a different database name", config.Database)
+		} else if strings.Contains(errMsg, "Unknown or incorrect time zone") {
+			return nil, fmt.Errorf("unknown time zone '%s': the server's time zone tables may not be loaded. Use an offset such as '+02:00' instead", config.TimeZone)
 		} else if strings.Contains(errMsg, "timeout") {
 			return nil, fmt.Errorf("connection timeout: could not reach %s:%d within 30 seconds. Check network connectivity", host, config.Port)
 		}
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &Connection{
+	conn := &Connection{
 		config: config,
 		db:     db,
-	}, nil
+	}
+
+	// Identifier case sensitivity depends on the server's filesystem setting
+	if err := db.QueryRow("SELECT @@lower_case_table_names").Scan(&conn.lowerCaseTableNames); err != nil {
+		log.Printf("Failed to read lower_case_table_names, assuming case-sensitive names: %v", err)
+	}
+
+	// Detect MySQL vs MariaDB for statements whose output differs
+	if raw, err := conn.GetVersion(context.Background()); err != nil {
+		log.Printf("Failed to read server version, assuming MySQL: %v", err)
+		conn.version = parseServerVersion("")
+	} else {
+		conn.version = parseServerVersion(raw)
+	}
+
+	// Oversized statements fail with an opaque error and a dropped
+	// connection, so check sizes up front instead
+	if err := db.QueryRow("SELECT @@max_allowed_packet").Scan(&conn.maxAllowedPacket); err != nil {
+		log.Printf("Failed to read max_allowed_packet: %v", err)
+	}
+	conn.packetLimit = effectivePacketLimit(conn.maxAllowedPacket, dsnConfig.MaxAllowedPacket)
+
+	conn.replicas = connectReplicas(config)
+
+	return conn, nil
+}
+
+// dialHost converts localhost to 127.0.0.1 to prefer IPv4. This avoids
+// issues on macOS where localhost resolves to ::1 (IPv6) first.
+func dialHost(host string) string {
+	if host == "localhost" {
+		return "127.0.0.1"
+	}
+	return host
+}
+
+// NormalizeIdentifier returns the form of a database or table name used for
+// comparisons and cache keys: lowercased on servers that compare names
+// case-insensitively, unchanged on case-sensitive servers
+func (c *Connection) NormalizeIdentifier(name string) string {
+	return normalizeIdentifier(name, c.lowerCaseTableNames)
+}
+
+// Version returns the parsed server version detected at connect time
+func (c *Connection) Version() ServerVersion {
+	return c.version
+}
+
+// Config returns the configuration the connection was opened with
+func (c *Connection) Config() *protocol.ConnectionConfig {
+	return c.config
 }
 
 func (c *Connection) Close() error {
+	replicaErr := c.closeReplicas()
 	if c.db != nil {
-		return c.db.Close()
+		if err := c.db.Close(); err != nil {
+			return err
+		}
+	}
+	return replicaErr
+}
+
+// ServerStatus returns the server settings detected at connect time
+func (c *Connection) ServerStatus() protocol.ServerStatus {
+	return protocol.ServerStatus{
+		Version:             c.version.Raw,
+		Flavor:              c.version.Flavor,
+		LowerCaseTableNames: c.lowerCaseTableNames,
+		MaxAllowedPacket:    c.maxAllowedPacket,
+		MaxStatementSize:    c.packetLimit,
+		Replicas:            c.Replicas(),
+	}
+}
+
+// PoolStats returns the connection pool statistics
+func (c *Connection) PoolStats() protocol.PoolStats {
+	stats := c.db.Stats()
+	return protocol.PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
 	}
-	return nil
 }
 
 // looksLikeUUID checks if a 16-byte slice looks like it could be a UUID
@@ -122,15 +215,30 @@ func looksLikeUUID(b []byte) bool {
 	return true
 }
 
-func (c *Connection) GetVersion() (string, error) {
+func (c *Connection) GetVersion(ctx context.Context) (string, error) {
 	var version string
-	err := c.db.QueryRow("SELECT VERSION()").Scan(&version)
+	err := c.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version)
 	return version, err
 }
 
-// HealthCheck verifies the connection is still alive
-func (c *Connection) HealthCheck() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// DatabaseExists reports whether a database exists and is visible to the
+// connected user
+func (c *Connection) DatabaseExists(ctx context.Context, name string) (bool, error) {
+	var count int
+	err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?",
+		name,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check database: %w", err)
+	}
+	return count > 0, nil
+}
+
+// HealthCheck verifies the connection is still alive, waiting at most five
+// seconds within ctx
+func (c *Connection) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if err := c.db.PingContext(ctx); err != nil {
@@ -143,7 +251,11 @@ func (c *Connection) HealthCheck() error {
 }
 
 func (c *Connection) ListDatabases() ([]protocol.Database, error) {
-	rows, err := c.db.Query("SHOW DATABASES")
+	return c.ListDatabasesContext(context.Background())
+}
+
+func (c *Connection) ListDatabasesContext(ctx context.Context) ([]protocol.Database, error) {
+	rows, err := c.db.QueryContext(ctx, "SHOW DATABASES")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list databases: %w", err)
 	}
@@ -163,77 +275,80 @@ func (c *Connection) ListDatabases() ([]protocol.Database, error) {
 }
 
 func (c *Connection) ListTables(database string) ([]protocol.Table, error) {
+	return c.ListTablesContext(context.Background(), database)
+}
+
+func (c *Connection) ListTablesContext(ctx context.Context, database string) ([]protocol.Table, error) {
 	query := fmt.Sprintf("SHOW TABLE STATUS FROM `%s`", database)
-	rows, err := c.db.Query(query)
+	rows, err := c.db.QueryContext(ctx, query)
 	if err != nil {
+		if isPermissionError(err) {
+			// Some managed servers deny the status query; the tree still
+			// needs the names
+			log.Printf("SHOW TABLE STATUS denied for %s, listing tables without sizes: %v", database, err)
+			return c.listTableNames(ctx, database)
+		}
 		return nil, fmt.Errorf("failed to list tables: %w", err)
 	}
 	defer rows.Close()
 
+	// The column set differs between MySQL and MariaDB versions, so read
+	// columns by name instead of position
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
 	// Pre-allocate with reasonable capacity for typical databases
 	tables := make([]protocol.Table, 0, 64)
 	for rows.Next() {
-		var name string
-		var engine sql.NullString
-		var rowCount, dataLength, indexLength sql.NullInt64
-		var ignore interface{}
-
-		// SHOW TABLE STATUS returns many columns, we capture the important ones
-		err := rows.Scan(
-			&name,        // Name
-			&engine,      // Engine
-			&ignore,      // Version
-			&ignore,      // Row_format
-			&rowCount,    // Rows
-			&ignore,      // Avg_row_length
-			&dataLength,  // Data_length (bytes)
-			&ignore,      // Max_data_length
-			&indexLength, // Index_length (bytes)
-			&ignore,      // Data_free
-			&ignore,      // Auto_increment
-			&ignore,      // Create_time
-			&ignore,      // Update_time
-			&ignore,      // Check_time
-			&ignore,      // Collation
-			&ignore,      // Checksum
-			&ignore,      // Create_options
-			&ignore,      // Comment
-		)
+		row, err := scanner.scan(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		var count, dataSize, indexSize int64
-		var engineStr string
-
-		if rowCount.Valid {
-			count = rowCount.Int64
-		}
-		if dataLength.Valid {
-			dataSize = dataLength.Int64
-		}
-		if indexLength.Valid {
-			indexSize = indexLength.Int64
-		}
-		if engine.Valid {
-			engineStr = engine.String
-		}
-
 		tables = append(tables, protocol.Table{
-			Name:        name,
-			RowCount:    count,
-			Engine:      engineStr,
-			DataLength:  dataSize,
-			IndexLength: indexSize,
+			Name:        asString(row["Name"]),
+			RowCount:    asInt64(row["Rows"]),
+			Engine:      asString(row["Engine"]),
+			DataLength:  asInt64(row["Data_length"]),
+			IndexLength: asInt64(row["Index_length"]),
+			Comment:     tableComment(asString(row["Engine"]), asString(row["Comment"])),
 		})
 	}
 
 	return tables, rows.Err()
 }
 
+// listTableNames lists a database's tables with SHOW FULL TABLES, which
+// needs no privilege beyond seeing the tables. Sizes, row counts and engines
+// are left zero and StatsUnavailable is set.
+func (c *Connection) listTableNames(ctx context.Context, database string) ([]protocol.Table, error) {
+	rows, err := c.db.QueryContext(ctx, "SHOW FULL TABLES FROM "+quoteIdentifier(database))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := make([]protocol.Table, 0, 64)
+	for rows.Next() {
+		var name, tableType string
+		if err := rows.Scan(&name, &tableType); err != nil {
+			return nil, err
+		}
+		tables = append(tables, protocol.Table{Name: name, StatsUnavailable: true})
+	}
+
+	return tables, rows.Err()
+}
+
 func (c *Connection) ListColumns(database, table string) ([]protocol.Column, error) {
+	return c.ListColumnsContext(context.Background(), database, table)
+}
+
+func (c *Connection) ListColumnsContext(ctx context.Context, database, table string) ([]protocol.Column, error) {
 	query := fmt.Sprintf("SHOW FULL COLUMNS FROM `%s`.`%s`", database, table)
-	rows, err := c.db.Query(query)
+	rows, err := c.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list columns: %w", err)
 	}
@@ -263,14 +378,57 @@ func (c *Connection) ListColumns(database, table string) ([]protocol.Column, err
 		}
 
 		col.Nullable = nullStr == "YES"
+		col.Collation = collation.String
+		setGenerated(&col)
 		if defaultVal.Valid {
 			col.Default = &defaultVal.String
 		}
 
 		columns = append(columns, col)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := c.addGenerationExpressions(ctx, database, table, columns); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// addGenerationExpressions fills in the expressions of generated columns,
+// which SHOW COLUMNS does not include. Failing to read them only leaves
+// them empty.
+func (c *Connection) addGenerationExpressions(ctx context.Context, database, table string, columns []protocol.Column) error {
+	generated := false
+	for _, col := range columns {
+		generated = generated || col.IsGenerated
+	}
+	if !generated || !c.version.hasGenerationExpression() {
+		return nil
+	}
 
-	return columns, rows.Err()
+	rows, err := c.db.QueryContext(ctx, `SELECT COLUMN_NAME, GENERATION_EXPRESSION
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND GENERATION_EXPRESSION <> ''`, database, table)
+	if err != nil {
+		log.Printf("Failed to read generation expressions of %s.%s: %v", database, table, err)
+		return nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, expression string
+		if err := rows.Scan(&name, &expression); err != nil {
+			return err
+		}
+		for i := range columns {
+			if columns[i].IsGenerated && strings.EqualFold(columns[i].Name, name) {
+				columns[i].GenerationExpression = expression
+			}
+		}
+	}
+	return rows.Err()
 }
 
 func (c *Connection) ExecuteQuery(sqlQuery string, limit, offset int) (*protocol.QueryResult, error) {
@@ -278,13 +436,95 @@ func (c *Connection) ExecuteQuery(sqlQuery string, limit, offset int) (*protocol
 }
 
 func (c *Connection) ExecuteQueryWithContext(ctx context.Context, sqlQuery string, limit, offset int) (*protocol.QueryResult, error) {
+	return c.ExecuteQueryWithOptions(ctx, sqlQuery, QueryOptions{Limit: limit, Offset: offset})
+}
+
+// defaultQueryTimeout returns the connection's DefaultQueryTimeoutSeconds as
+// a duration, 0 when unset
+func (c *Connection) defaultQueryTimeout() time.Duration {
+	if c.config == nil || c.config.DefaultQueryTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(c.config.DefaultQueryTimeoutSeconds) * time.Second
+}
+
+// withDefaultTimeout bounds ctx by the connection's default query timeout,
+// if one is set. finish must be called with the operation's error; it
+// releases the context and reports a stop caused by the timeout as such.
+func (c *Connection) withDefaultTimeout(parent context.Context, what string) (ctx context.Context, finish func(err error) error) {
+	timeout := c.defaultQueryTimeout()
+	if timeout == 0 {
+		return parent, func(err error) error { return err }
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return ctx, func(err error) error {
+		err = c.defaultTimeoutError(parent, ctx, what, err)
+		cancel()
+		return err
+	}
+}
+
+// defaultTimeoutError rewrites err when ctx, bounded by the default timeout,
+// stopped an operation that its caller's context had not
+func (c *Connection) defaultTimeoutError(parent, ctx context.Context, what string, err error) error {
+	if err == nil || ctx.Err() == nil || parent.Err() != nil {
+		return err
+	}
+	timeout := c.defaultQueryTimeout()
+	log.Printf("Query on %s stopped by the default timeout of %s: %s", c.config.ID, timeout, what)
+	return fmt.Errorf("query exceeded the connection's default timeout of %s: %w", timeout, err)
+}
+
+// ExecuteQueryWithOptions runs a query with pagination and optional progress
+// reporting. When the connection sets DefaultQueryTimeoutSeconds, the query
+// is bounded by that timeout whatever ctx allows.
+func (c *Connection) ExecuteQueryWithOptions(ctx context.Context, sqlQuery string, opts QueryOptions) (result *protocol.QueryResult, err error) {
+	ctx, finish := c.withDefaultTimeout(ctx, sqlQuery)
+	defer func() { err = finish(err) }()
+
+	result, err = c.executeQuery(ctx, sqlQuery, opts)
+	if err != nil || !opts.RowKeys || isWriteStatement(sqlQuery) {
+		return result, err
+	}
+	// Runs after the query's connection went back to the pool
+	c.addRowIdentity(ctx, sqlQuery, result)
+	return result, nil
+}
+
+func (c *Connection) executeQuery(ctx context.Context, sqlQuery string, opts QueryOptions) (*protocol.QueryResult, error) {
 	startTime := time.Now()
+	limit, offset := opts.Limit, opts.Offset
 
 	// Check if context is already cancelled
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("query cancelled before execution: %w", ctx.Err())
 	}
 
+	// Check the statement as written, before any LIMIT is appended
+	if err := c.checkStatement(sqlQuery); err != nil {
+		return nil, err
+	}
+
+	var args []interface{}
+	if opts.NamedArgs != nil {
+		var err error
+		sqlQuery, args, err = bindNamedParams(sqlQuery, opts.NamedArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Label after the statement is checked and bound, so the comment never
+	// affects how it is classified
+	sqlQuery = c.labelStatement(sqlQuery, opts.RequestID, opts.Label)
+	if err := c.checkPacketSize(sqlQuery); err != nil {
+		return nil, err
+	}
+
+	// Writes return no rows; report affected rows and warnings instead
+	if isWriteStatement(sqlQuery) {
+		return c.executeWrite(ctx, sqlQuery, args, opts)
+	}
+
 	// Apply limit and offset if provided
 	if limit > 0 {
 		sqlQuery = fmt.Sprintf("%s LIMIT %d", sqlQuery, limit)
@@ -293,7 +533,12 @@ func (c *Connection) ExecuteQueryWithContext(ctx context.Context, sqlQuery strin
 		}
 	}
 
-	rows, err := c.db.QueryContext(ctx, sqlQuery)
+	// Plain reads may go to a replica; the kill and progress queries must
+	// then run there too
+	target := c.readTarget(sqlQuery, opts.ForcePrimary)
+
+	var fetched int64
+	rows, release, err := target.queryWithKillWatch(ctx, target.progressWatch(ctx, opts, &fetched), sqlQuery, args...)
 	if err != nil {
 		// Check if it was a context cancellation
 		if ctx.Err() != nil {
@@ -301,23 +546,57 @@ func (c *Connection) ExecuteQueryWithContext(ctx context.Context, sqlQuery strin
 		}
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
-	defer rows.Close()
+	defer release()
+
+	// Prepare result with pre-allocated capacity for better performance
+	// Use limit as capacity hint, or default to 100 if no limit
+	capacity := 100
+	if limit > 0 {
+		capacity = limit
+	}
+	result, err := c.readResult(ctx, rows, capacity, &fetched, opts.RawRow)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	result.TotalRows = int64(len(result.Rows))
+	result.RowsAffected = result.TotalRows
+	if target != c {
+		result.Replica = replicaAddress(target.config)
+	}
+
+	return result, nil
+}
 
+// readResult reads all rows into a QueryResult, normalizing values per column
+// type. capacity is a hint for the number of rows; fetched, if not nil, is
+// incremented atomically per row. raw, if not nil, gets each row's values as
+// scanned.
+func (c *Connection) readResult(ctx context.Context, rows *sql.Rows, capacity int, fetched *int64, raw func(row []interface{})) (*protocol.QueryResult, error) {
 	// Get column names
 	columnNames, err := rows.Columns()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
-	// Prepare result with pre-allocated capacity for better performance
-	// Use limit as capacity hint, or default to 100 if no limit
-	capacity := 100
-	if limit > 0 {
-		capacity = limit
+	// Get column types so values can be normalized per type
+	typeNames, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
 	}
+
 	result := &protocol.QueryResult{
-		Columns: columnNames,
-		Rows:    make([][]interface{}, 0, capacity),
+		Columns:     columnNames,
+		Rows:        make([][]interface{}, 0, capacity),
+		ColumnTypes: typeNames,
+		FormatHints: formatHints(columnNames, typeNames),
+	}
+
+	// Joins without aliases can repeat names such as "id"
+	if unique, renamed := uniqueColumnNames(columnNames); renamed {
+		result.Columns = unique
+		result.OriginalColumns = columnNames
 	}
 
 	// Fetch rows
@@ -327,49 +606,86 @@ func (c *Connection) ExecuteQueryWithContext(ctx context.Context, sqlQuery strin
 			return nil, fmt.Errorf("query cancelled during fetch: %w", ctx.Err())
 		}
 
-		// Create a slice of interface{} to hold each column value
-		columns := make([]interface{}, len(columnNames))
-		columnPointers := make([]interface{}, len(columnNames))
-		for i := range columns {
-			columnPointers[i] = &columns[i]
-		}
-
-		if err := rows.Scan(columnPointers...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		// Convert byte slices - check if it's a binary16 UUID first
-		for i, col := range columns {
-			if b, ok := col.([]byte); ok {
-				// Check if it's a 16-byte binary that looks like a UUID
-				if len(b) == 16 && looksLikeUUID(b) {
-					// Convert to hex string with UUID format
-					hex := fmt.Sprintf("%x", b)
-					uuid := fmt.Sprintf("%s-%s-%s-%s-%s",
-						hex[0:8],
-						hex[8:12],
-						hex[12:16],
-						hex[16:20],
-						hex[20:32],
-					)
-					columns[i] = uuid
-				} else {
-					// Regular string conversion
-					columns[i] = string(b)
-				}
-			}
+		columns, err := scanRowWithRaw(rows, typeNames, c.valueOptions(), raw)
+		if err != nil {
+			return nil, err
 		}
 
 		result.Rows = append(result.Rows, columns)
+		if fetched != nil {
+			atomic.AddInt64(fetched, 1)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	result.ExecutionTime = time.Since(startTime).Milliseconds()
-	result.TotalRows = int64(len(result.Rows))
-	result.RowsAffected = result.TotalRows
-
 	return result, nil
 }
+
+// columnTypeNames returns the database type name of each result column
+func columnTypeNames(rows *sql.Rows) ([]string, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	typeNames := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		typeNames[i] = ct.DatabaseTypeName()
+	}
+	return typeNames, nil
+}
+
+// valueOptions are the connection's choices for rendering column values
+type valueOptions struct {
+	// BIGINT UNSIGNED values as strings rather than numbers
+	unsignedAsString bool
+	// SET values as arrays of members rather than comma-joined strings
+	setAsArray bool
+}
+
+// valueOptions returns how the connection renders column values
+func (c *Connection) valueOptions() valueOptions {
+	if c.config == nil {
+		return valueOptions{}
+	}
+	return valueOptions{
+		unsignedAsString: c.config.UnsignedBigintAsString,
+		setAsArray:       c.config.SetValuesAsArrays,
+	}
+}
+
+// scanRow scans the current row and normalizes driver values (temporal
+// types, UUIDs, byte slices) per column type
+func scanRow(rows *sql.Rows, typeNames []string, opts valueOptions) ([]interface{}, error) {
+	return scanRowWithRaw(rows, typeNames, opts, nil)
+}
+
+// scanRowWithRaw is scanRow that also passes a copy of the values as the
+// driver returned them to raw, if set
+func scanRowWithRaw(rows *sql.Rows, typeNames []string, opts valueOptions, raw func(row []interface{})) ([]interface{}, error) {
+	columns := make([]interface{}, len(typeNames))
+	columnPointers := make([]interface{}, len(typeNames))
+	for i := range columns {
+		columnPointers[i] = &columns[i]
+	}
+
+	if err := rows.Scan(columnPointers...); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	if raw != nil {
+		raw(append([]interface{}(nil), columns...))
+	}
+
+	for i, col := range columns {
+		columns[i] = convertValue(col, typeNames[i])
+		if n, ok := columns[i].(uint64); ok && opts.unsignedAsString && typeNames[i] == unsignedBigintType {
+			columns[i] = strconv.FormatUint(n, 10)
+		}
+		if opts.setAsArray && typeNames[i] == setType {
+			columns[i] = setMembers(columns[i])
+		}
+	}
+	return columns, nil
+}
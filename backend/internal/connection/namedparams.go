@@ -0,0 +1,89 @@
+package connection
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// bindNamedParams rewrites :name placeholders to positional ? placeholders
+// and returns the matching arguments in order. A name may appear more than
+// once. Placeholders inside string literals, quoted identifiers and comments
+// are left alone, as are := assignments.
+func bindNamedParams(sqlText string, namedArgs map[string]interface{}) (string, []interface{}, error) {
+	var out strings.Builder
+	var args []interface{}
+	var missing []string
+	seenMissing := make(map[string]bool)
+
+	start := 0
+	for i := 0; i < len(sqlText); {
+		rest := sqlText[i:]
+		ch := rest[0]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			i = len(sqlText) - len(skipQuoted(rest))
+		case startsComment(rest):
+			i = len(sqlText) - len(skipSpaceAndComments(rest))
+		case ch == '?':
+			return "", nil, fmt.Errorf("positional ? placeholders cannot be mixed with named parameters")
+		case ch == ':' && isNamedParamStart(sqlText, i):
+			end := i + 1
+			for end < len(sqlText) && isWordByte(sqlText[end]) {
+				end++
+			}
+			name := sqlText[i+1 : end]
+
+			value, ok := namedArgs[name]
+			if !ok {
+				if !seenMissing[name] {
+					seenMissing[name] = true
+					missing = append(missing, name)
+				}
+			}
+			args = append(args, namedArgValue(value))
+
+			out.WriteString(sqlText[start:i])
+			out.WriteByte('?')
+			i = end
+			start = end
+		default:
+			i++
+		}
+	}
+	out.WriteString(sqlText[start:])
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", nil, fmt.Errorf("missing named arguments: %s", strings.Join(missing, ", "))
+	}
+	return out.String(), args, nil
+}
+
+// isNamedParamStart reports whether the colon at i begins a :name
+// placeholder: it must be followed by a letter or underscore and not be part
+// of a word, a :: or a := assignment
+func isNamedParamStart(s string, i int) bool {
+	if i+1 >= len(s) {
+		return false
+	}
+	next := s[i+1]
+	if next != '_' && !(next >= 'a' && next <= 'z') && !(next >= 'A' && next <= 'Z') {
+		return false
+	}
+	if i > 0 && (isWordByte(s[i-1]) || s[i-1] == ':') {
+		return false
+	}
+	return true
+}
+
+// namedArgValue converts JSON-decoded values for the driver. JSON numbers
+// decode as float64; whole numbers are passed as integers so they work in
+// LIMIT clauses and compare exactly against integer columns.
+func namedArgValue(value interface{}) interface{} {
+	if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f)
+	}
+	return value
+}
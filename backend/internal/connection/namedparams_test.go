@@ -0,0 +1,79 @@
+package connection
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindNamedParams(t *testing.T) {
+	args := map[string]interface{}{
+		"userId": float64(42),
+		"status": "active",
+		"ratio":  0.5,
+		"note":   nil,
+	}
+
+	testCases := []struct {
+		name         string
+		sql          string
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{
+			name:         "Single parameter",
+			sql:          "SELECT * FROM users WHERE id = :userId",
+			expectedSQL:  "SELECT * FROM users WHERE id = ?",
+			expectedArgs: []interface{}{int64(42)},
+		},
+		{
+			name:         "Repeated parameter",
+			sql:          "SELECT * FROM orders WHERE buyer = :userId OR seller = :userId AND status = :status",
+			expectedSQL:  "SELECT * FROM orders WHERE buyer = ? OR seller = ? AND status = ?",
+			expectedArgs: []interface{}{int64(42), int64(42), "active"},
+		},
+		{
+			name:         "Placeholders in strings and comments",
+			sql:          "SELECT ':userId', `:status` -- :missing\nFROM t /* :other */ WHERE a = :ratio # :gone",
+			expectedSQL:  "SELECT ':userId', `:status` -- :missing\nFROM t /* :other */ WHERE a = ? # :gone",
+			expectedArgs: []interface{}{0.5},
+		},
+		{
+			name:         "Assignments and times are not placeholders",
+			sql:          "SELECT @n := :note, '12:30', a::b",
+			expectedSQL:  "SELECT @n := ?, '12:30', a::b",
+			expectedArgs: []interface{}{nil},
+		},
+		{
+			name:        "No parameters",
+			sql:         "SELECT 1",
+			expectedSQL: "SELECT 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, got, err := bindNamedParams(tc.sql, args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if sql != tc.expectedSQL {
+				t.Errorf("Expected SQL %q, got %q", tc.expectedSQL, sql)
+			}
+			if !reflect.DeepEqual(got, tc.expectedArgs) {
+				t.Errorf("Expected args %v, got %v", tc.expectedArgs, got)
+			}
+		})
+	}
+}
+
+func TestBindNamedParamsErrors(t *testing.T) {
+	_, _, err := bindNamedParams("SELECT :b, :a, :b FROM t WHERE x = :present", map[string]interface{}{"present": 1})
+	if err == nil || !strings.Contains(err.Error(), "missing named arguments: a, b") {
+		t.Errorf("Expected missing argument error naming a and b, got %v", err)
+	}
+
+	if _, _, err := bindNamedParams("SELECT * FROM t WHERE a = ? AND b = :b", map[string]interface{}{"b": 1}); err == nil {
+		t.Error("Expected error when mixing positional and named parameters")
+	}
+}
@@ -0,0 +1,48 @@
+package connection
+
+import "fmt"
+
+// packetHeadroom is kept free below max_allowed_packet for the command byte
+// and protocol overhead
+const packetHeadroom = 1024
+
+// effectivePacketLimit returns the largest statement that can be sent: the
+// smaller of the server's max_allowed_packet and the driver's own limit, less
+// headroom. Zero means unknown.
+func effectivePacketLimit(server int64, driverLimit int) int64 {
+	limit := server
+	if driverLimit > 0 && (limit <= 0 || int64(driverLimit) < limit) {
+		limit = int64(driverLimit)
+	}
+	if limit <= packetHeadroom {
+		return 0
+	}
+	return limit - packetHeadroom
+}
+
+// MaxAllowedPacket returns the server's @@max_allowed_packet detected at
+// connect time, or 0 if it could not be read
+func (c *Connection) MaxAllowedPacket() int64 {
+	return c.maxAllowedPacket
+}
+
+// checkPacketSize rejects a statement too large to send in one packet, with
+// an explanation instead of the driver's or server's terse error
+func (c *Connection) checkPacketSize(sqlText string) error {
+	if c.packetLimit <= 0 || int64(len(sqlText)) <= c.packetLimit {
+		return nil
+	}
+	return fmt.Errorf("statement is %s, larger than the %s allowed by max_allowed_packet; split it into smaller statements or raise max_allowed_packet on the server",
+		formatBytes(int64(len(sqlText))), formatBytes(c.packetLimit))
+}
+
+// formatBytes formats a byte count for error messages
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
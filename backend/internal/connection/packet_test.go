@@ -0,0 +1,47 @@
+package connection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEffectivePacketLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		server   int64
+		driver   int
+		expected int64
+	}{
+		{"Server smaller", 4 << 20, 64 << 20, 4<<20 - packetHeadroom},
+		{"Driver smaller", 1 << 30, 64 << 20, 64<<20 - packetHeadroom},
+		{"Server unknown", 0, 64 << 20, 64<<20 - packetHeadroom},
+		{"Both unknown", 0, 0, 0},
+		{"Driver fetches from server", 16 << 20, 0, 16<<20 - packetHeadroom},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := effectivePacketLimit(tc.server, tc.driver); got != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckPacketSize(t *testing.T) {
+	c := &Connection{packetLimit: 2048}
+
+	if err := c.checkPacketSize("SELECT 1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	err := c.checkPacketSize("INSERT INTO t VALUES ('" + strings.Repeat("x", 4096) + "')")
+	if err == nil || !strings.Contains(err.Error(), "max_allowed_packet") {
+		t.Errorf("Expected max_allowed_packet error, got %v", err)
+	}
+
+	// An unknown limit never rejects
+	if err := (&Connection{}).checkPacketSize(strings.Repeat("x", 1<<20)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// Non-partitioned tables have a single row with a NULL PARTITION_NAME
+const partitionsQuery = `SELECT PARTITION_NAME, SUBPARTITION_NAME, PARTITION_ORDINAL_POSITION,
+	PARTITION_METHOD, SUBPARTITION_METHOD, PARTITION_EXPRESSION, PARTITION_DESCRIPTION,
+	TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH, PARTITION_COMMENT
+	FROM information_schema.PARTITIONS
+	WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL
+	ORDER BY PARTITION_ORDINAL_POSITION, SUBPARTITION_ORDINAL_POSITION`
+
+// ListPartitions returns a table's partitions in order, one entry per
+// subpartition for subpartitioned tables. Row counts are InnoDB estimates.
+// The list is empty for tables that are not partitioned.
+func (c *Connection) ListPartitions(ctx context.Context, database, table string) ([]protocol.Partition, error) {
+	rows, err := c.db.QueryContext(ctx, partitionsQuery, database, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitions := make([]protocol.Partition, 0, 16)
+	for rows.Next() {
+		var p protocol.Partition
+		var subpartition, subMethod, expression, description, comment sql.NullString
+		var rowCount, dataLength, indexLength sql.NullInt64
+		if err := rows.Scan(&p.Name, &subpartition, &p.Position, &p.Method, &subMethod,
+			&expression, &description, &rowCount, &dataLength, &indexLength, &comment); err != nil {
+			return nil, fmt.Errorf("failed to scan partition: %w", err)
+		}
+		p.Subpartition = subpartition.String
+		p.SubpartitionMethod = subMethod.String
+		p.Expression = expression.String
+		p.Description = description.String
+		p.RowCount = rowCount.Int64
+		p.DataLength = dataLength.Int64
+		p.IndexLength = indexLength.Int64
+		p.Comment = comment.String
+		partitions = append(partitions, p)
+	}
+
+	return partitions, rows.Err()
+}
+
+// findPartition returns the actual name of the partition called name,
+// matching case-insensitively as MySQL does. Subpartition names only match
+// when allowSubpartitions is set.
+func findPartition(partitions []protocol.Partition, name string, allowSubpartitions bool) (protocol.Partition, string, error) {
+	for _, p := range partitions {
+		if strings.EqualFold(p.Name, name) {
+			return p, p.Name, nil
+		}
+		if allowSubpartitions && p.Subpartition != "" && strings.EqualFold(p.Subpartition, name) {
+			return p, p.Subpartition, nil
+		}
+	}
+	if len(partitions) == 0 {
+		return protocol.Partition{}, "", fmt.Errorf("table is not partitioned")
+	}
+	return protocol.Partition{}, "", fmt.Errorf("partition not found: %s", name)
+}
+
+// DropPartition drops a RANGE or LIST partition together with its rows.
+// The name is checked against the table's partitions first.
+func (c *Connection) DropPartition(ctx context.Context, database, table, partition string) error {
+	partitions, err := c.ListPartitions(ctx, database, table)
+	if err != nil {
+		return err
+	}
+	p, name, err := findPartition(partitions, partition, false)
+	if err != nil {
+		return err
+	}
+	// HASH and KEY partitions can only be merged with COALESCE PARTITION
+	if !strings.HasPrefix(p.Method, "RANGE") && !strings.HasPrefix(p.Method, "LIST") {
+		return fmt.Errorf("only RANGE and LIST partitions can be dropped, %s is partitioned by %s", table, p.Method)
+	}
+
+	// Dropping a partition destroys its rows, so a DROP block applies too
+	query := fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", qualifiedTable(database, table), quoteIdentifier(name))
+	if err := c.checkStatementAs(query, "DROP"); err != nil {
+		return err
+	}
+	if _, err := c.execWithKill(ctx, query); err != nil {
+		return fmt.Errorf("failed to drop partition: %w", err)
+	}
+	return nil
+}
+
+// TruncatePartition deletes every row in a partition or subpartition,
+// keeping the partition itself. The name is checked against the table's
+// partitions first.
+func (c *Connection) TruncatePartition(ctx context.Context, database, table, partition string) error {
+	partitions, err := c.ListPartitions(ctx, database, table)
+	if err != nil {
+		return err
+	}
+	_, name, err := findPartition(partitions, partition, true)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s TRUNCATE PARTITION %s", qualifiedTable(database, table), quoteIdentifier(name))
+	if err := c.checkStatementAs(query, "TRUNCATE"); err != nil {
+		return err
+	}
+	if _, err := c.execWithKill(ctx, query); err != nil {
+		return fmt.Errorf("failed to truncate partition: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestFindPartition(t *testing.T) {
+	partitions := []protocol.Partition{
+		{Name: "p2023", Subpartition: "p2023sp0", Method: "RANGE"},
+		{Name: "p2023", Subpartition: "p2023sp1", Method: "RANGE"},
+		{Name: "pMax", Subpartition: "pMaxsp0", Method: "RANGE"},
+	}
+
+	if _, name, err := findPartition(partitions, "PMAX", false); err != nil || name != "pMax" {
+		t.Errorf("Expected pMax, got %q (%v)", name, err)
+	}
+	if _, _, err := findPartition(partitions, "p2023sp1", false); err == nil {
+		t.Error("Expected subpartitions to be refused")
+	}
+	if _, name, err := findPartition(partitions, "p2023sp1", true); err != nil || name != "p2023sp1" {
+		t.Errorf("Expected p2023sp1, got %q (%v)", name, err)
+	}
+	if _, _, err := findPartition(partitions, "p2022", true); err == nil {
+		t.Error("Expected error for a missing partition")
+	}
+	if _, _, err := findPartition(nil, "p0", false); err == nil || err.Error() != "table is not partitioned" {
+		t.Errorf("Expected not partitioned error, got %v", err)
+	}
+}
+
+func TestPartitionStatementsFollowDropAndTruncateBlocks(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	c.config = &protocol.ConnectionConfig{BlockedStatements: []string{"DROP"}}
+	if err := c.DropPartition(context.Background(), "shop", "orders", "p2023"); err == nil || !strings.Contains(err.Error(), "DROP statements are blocked") {
+		t.Errorf("Expected DROP PARTITION to be blocked, got %v", err)
+	}
+	if err := c.TruncatePartition(context.Background(), "shop", "orders", "p2023"); err != nil {
+		t.Errorf("Expected TRUNCATE PARTITION to be allowed, got %v", err)
+	}
+
+	c.config = &protocol.ConnectionConfig{BlockedStatements: []string{"TRUNCATE"}}
+	if err := c.TruncatePartition(context.Background(), "shop", "orders", "p2023"); err == nil || !strings.Contains(err.Error(), "TRUNCATE statements are blocked") {
+		t.Errorf("Expected TRUNCATE PARTITION to be blocked, got %v", err)
+	}
+	if err := c.DropPartition(context.Background(), "shop", "orders", "p2023"); err != nil {
+		t.Errorf("Expected DROP PARTITION to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package connection
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPoolStats(t *testing.T) {
+	cfg, err := buildDriverConfig(baseConfig(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// OpenDB does not dial, so no server is needed
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(25)
+
+	stats := (&Connection{db: db}).PoolStats()
+	if stats.MaxOpenConnections != 25 {
+		t.Errorf("Expected MaxOpenConnections 25, got %d", stats.MaxOpenConnections)
+	}
+	if stats.OpenConnections != 0 || stats.InUse != 0 || stats.Idle != 0 {
+		t.Errorf("Expected an empty pool, got %+v", stats)
+	}
+}
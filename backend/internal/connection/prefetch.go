@@ -0,0 +1,125 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// MaxFetchAhead caps the batches a cursor reads ahead, which are held in
+// memory until fetched
+const MaxFetchAhead = 8
+
+// prefetchedBatch is a batch read ahead of Fetch. The last one has done
+// set, with the error that ended the result if any.
+type prefetchedBatch struct {
+	rows [][]interface{}
+	done bool
+	err  error
+}
+
+// FetchAhead makes the cursor read up to depth batches of batchRows rows
+// in the background, so the next rows arrive from MySQL while the caller
+// serializes and sends the current ones. It must be called before the first
+// Fetch; a depth of 0 or less leaves the cursor unbuffered. Fetch still
+// returns the number of rows it is asked for. Closing the cursor stops the
+// read-ahead and waits for it before releasing the connection.
+func (cur *Cursor) FetchAhead(depth, batchRows int) {
+	if depth <= 0 || batchRows <= 0 {
+		return
+	}
+	if depth > MaxFetchAhead {
+		depth = MaxFetchAhead
+	}
+
+	cur.mu.Lock()
+	defer cur.mu.Unlock()
+	if cur.closed || cur.prefetch != nil || cur.fetched > 0 {
+		return
+	}
+	cur.prefetch = make(chan prefetchedBatch, depth)
+	cur.prefetchDone = make(chan struct{})
+	go cur.readAhead(batchRows)
+}
+
+// readAhead reads the result into the prefetch channel until it ends or
+// the cursor's query is cancelled. It is the only reader of rows while it
+// runs.
+func (cur *Cursor) readAhead(batchRows int) {
+	defer close(cur.prefetchDone)
+
+	send := func(batch prefetchedBatch) bool {
+		select {
+		case cur.prefetch <- batch:
+			return true
+		case <-cur.ctx.Done():
+			return false
+		}
+	}
+	for {
+		batch := prefetchedBatch{rows: make([][]interface{}, 0, batchRows)}
+		for len(batch.rows) < batchRows {
+			if !cur.rows.Next() {
+				batch.done = true
+				batch.err = cur.rows.Err()
+				break
+			}
+			row, err := scanRow(cur.rows, cur.typeNames, cur.values)
+			if err != nil {
+				batch.done = true
+				batch.err = err
+				break
+			}
+			batch.rows = append(batch.rows, row)
+		}
+		if !send(batch) || batch.done {
+			return
+		}
+	}
+}
+
+// fetchAhead fills batch with up to n rows from the read-ahead batches.
+// Called with cur.mu held.
+func (cur *Cursor) fetchAhead(ctx context.Context, batch *protocol.CursorBatch, n int) (*protocol.CursorBatch, error) {
+	for len(batch.Rows) < n && !cur.done {
+		if len(cur.pending) == 0 && !cur.ended {
+			select {
+			case next := <-cur.prefetch:
+				cur.pending = next.rows
+				cur.ended = next.done
+				cur.endErr = next.err
+			case <-ctx.Done():
+				return nil, fmt.Errorf("fetch cancelled: %w", ctx.Err())
+			}
+		}
+
+		take := min(n-len(batch.Rows), len(cur.pending))
+		batch.Rows = append(batch.Rows, cur.pending[:take]...)
+		cur.pending = cur.pending[take:]
+		cur.fetched += int64(take)
+		if cur.ended && len(cur.pending) == 0 {
+			cur.done = true
+		}
+	}
+
+	if cur.done {
+		err := cur.endErr
+		cur.closeLocked()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating rows: %w", err)
+		}
+	}
+
+	batch.Done = cur.done
+	batch.RowsFetched = cur.fetched
+	return batch, nil
+}
+
+// waitPrefetch waits for the read-ahead goroutine, if any, to stop. The
+// cursor's query must be finished or cancelled.
+func (cur *Cursor) waitPrefetch() {
+	if cur.prefetchDone != nil {
+		<-cur.prefetchDone
+	}
+}
@@ -0,0 +1,378 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// writePrivileges are the privileges that allow modifying data or schema
+var writePrivileges = map[string]bool{
+	"ALL PRIVILEGES": true,
+	"INSERT":         true,
+	"UPDATE":         true,
+	"DELETE":         true,
+	"CREATE":         true,
+	"DROP":           true,
+	"ALTER":          true,
+	"INDEX":          true,
+	"CREATE VIEW":    true,
+	"CREATE ROUTINE": true,
+	"ALTER ROUTINE":  true,
+	"TRIGGER":        true,
+}
+
+// GetPrivileges returns the privileges granted to the current user. SHOW
+// GRANTS lists only direct grants, so when the user has roles the grants
+// are read again with USING, which adds the roles' privileges (MySQL 8).
+// Where that is not possible, ReadOnly is left unknown.
+func (c *Connection) GetPrivileges(ctx context.Context) (*protocol.PrivilegeInfo, error) {
+	info := &protocol.PrivilegeInfo{}
+
+	if err := c.db.QueryRowContext(ctx, "SELECT CURRENT_USER()").Scan(&info.User); err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	grants, roles, err := c.showGrants(ctx, "SHOW GRANTS")
+	if err != nil {
+		return nil, fmt.Errorf("failed to show grants: %w", err)
+	}
+	info.Grants = grants
+	info.Roles = roles
+
+	if len(roles) > 0 && !c.version.IsMariaDB() && c.version.AtLeast(8, 0) {
+		// Role names come from SHOW GRANTS and are already quoted
+		expanded, _, err := c.showGrants(ctx, "SHOW GRANTS FOR CURRENT_USER() USING "+strings.Join(roles, ", "))
+		if err != nil {
+			log.Printf("Failed to expand role grants for %s: %v", info.User, err)
+		} else {
+			info.Grants = expanded
+			info.RolesExpanded = true
+		}
+	}
+
+	if len(info.Roles) == 0 || info.RolesExpanded {
+		readOnly := true
+		for _, grant := range info.Grants {
+			for _, priv := range grant.Privileges {
+				if writePrivileges[priv] {
+					readOnly = false
+				}
+			}
+		}
+		info.ReadOnly = &readOnly
+	}
+
+	return info, nil
+}
+
+// showGrants runs a SHOW GRANTS statement and returns its privilege grants
+// and the roles granted
+func (c *Connection) showGrants(ctx context.Context, query string) ([]protocol.Grant, []string, error) {
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	grants := make([]protocol.Grant, 0, 4)
+	var roles []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, nil, err
+		}
+
+		grant, role, ok := parseGrant(line)
+		if !ok {
+			continue
+		}
+		if role != "" {
+			roles = append(roles, role)
+			continue
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, roles, rows.Err()
+}
+
+// parseGrant parses a single SHOW GRANTS line. Privilege grants are returned
+// as a Grant; role grants (MySQL 8 "GRANT `role`@`%` TO ...") are returned
+// as the role name instead. ok is false for lines that cannot be parsed.
+func parseGrant(line string) (grant protocol.Grant, role string, ok bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(strings.ToUpper(line), "GRANT ") {
+		return grant, "", false
+	}
+	body := line[len("GRANT "):]
+
+	onIdx := indexTopLevelKeyword(body, " ON ")
+	toIdx := indexTopLevelKeyword(body, " TO ")
+	if toIdx < 0 {
+		return grant, "", false
+	}
+
+	// Role grants have no ON clause
+	if onIdx < 0 || onIdx > toIdx {
+		return grant, strings.TrimSpace(body[:toIdx]), true
+	}
+
+	grant.Raw = line
+	for _, priv := range splitTopLevel(body[:onIdx], ',') {
+		// Column privileges such as "SELECT (id, name)" only cover the
+		// listed columns
+		var columns []string
+		if paren := strings.Index(priv, "("); paren >= 0 {
+			list := strings.TrimSuffix(strings.TrimSpace(priv[paren+1:]), ")")
+			for _, column := range splitTopLevel(list, ',') {
+				columns = append(columns, unquoteIdentifier(column))
+			}
+			priv = priv[:paren]
+		}
+		priv = normalizePrivilege(priv)
+		if priv == "" {
+			continue
+		}
+		if columns != nil {
+			if grant.Columns == nil {
+				grant.Columns = make(map[string][]string)
+			}
+			// A privilege can appear for several column lists
+			if _, seen := grant.Columns[priv]; !seen {
+				grant.Privileges = append(grant.Privileges, priv)
+			}
+			grant.Columns[priv] = append(grant.Columns[priv], columns...)
+			continue
+		}
+		grant.Privileges = append(grant.Privileges, priv)
+	}
+
+	// Object may be prefixed with TABLE, FUNCTION or PROCEDURE
+	object := strings.TrimSpace(body[onIdx+len(" ON ") : toIdx])
+	for _, kind := range []string{"TABLE ", "FUNCTION ", "PROCEDURE "} {
+		if strings.HasPrefix(strings.ToUpper(object), kind) {
+			object = strings.TrimSpace(object[len(kind):])
+			break
+		}
+	}
+	parts := splitTopLevel(object, '.')
+	switch len(parts) {
+	case 1:
+		grant.Database = unquoteIdentifier(parts[0])
+		grant.Table = "*"
+	case 2:
+		grant.Database = unquoteIdentifier(parts[0])
+		grant.Table = unquoteIdentifier(parts[1])
+	default:
+		return grant, "", false
+	}
+
+	grant.WithGrantOption = strings.Contains(strings.ToUpper(body[toIdx:]), "WITH GRANT OPTION")
+	return grant, "", true
+}
+
+// indexTopLevelKeyword finds a case-insensitive keyword outside of quoted
+// identifiers, strings and parentheses
+func indexTopLevelKeyword(s, keyword string) int {
+	upper := strings.ToUpper(s)
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '`' || ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(upper[i:], keyword):
+			return i
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s on sep, ignoring separators inside quotes or parentheses
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '`' || ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == sep && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// unquoteIdentifier removes surrounding backticks or quotes from an identifier
+func unquoteIdentifier(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 {
+		switch s[0] {
+		case '`':
+			if s[len(s)-1] == '`' {
+				return strings.ReplaceAll(s[1:len(s)-1], "``", "`")
+			}
+		case '\'', '"':
+			if s[len(s)-1] == s[0] {
+				return s[1 : len(s)-1]
+			}
+		}
+	}
+	return s
+}
+
+// normalizePrivilege returns a privilege name as SHOW GRANTS spells it
+func normalizePrivilege(privilege string) string {
+	privilege = strings.ToUpper(strings.Join(strings.Fields(privilege), " "))
+	if privilege == "ALL" {
+		return "ALL PRIVILEGES"
+	}
+	return privilege
+}
+
+// matchGrantName reports whether a database or table name from a grant
+// covers name. Database grants may use the LIKE wildcards % and _, with \
+// escaping them.
+func matchGrantName(pattern, name string, wildcards bool, equal func(a, b string) bool) bool {
+	if pattern == "*" {
+		return true
+	}
+	if !wildcards || !strings.ContainsAny(pattern, "%_") {
+		return equal(strings.ReplaceAll(pattern, `\`, ""), name)
+	}
+
+	// Translate the LIKE pattern to a matcher over runes
+	var match func(p, s []rune) bool
+	match = func(p, s []rune) bool {
+		for len(p) > 0 {
+			switch {
+			case p[0] == '%':
+				for i := 0; i <= len(s); i++ {
+					if match(p[1:], s[i:]) {
+						return true
+					}
+				}
+				return false
+			case p[0] == '_':
+				if len(s) == 0 {
+					return false
+				}
+			case p[0] == '\\' && len(p) > 1:
+				p = p[1:]
+				fallthrough
+			default:
+				if len(s) == 0 || !equal(string(p[0]), string(s[0])) {
+					return false
+				}
+			}
+			p, s = p[1:], s[1:]
+		}
+		return len(s) == 0
+	}
+	return match([]rune(pattern), []rune(name))
+}
+
+// grantFor returns the first grant that gives privilege on the scope:
+// globally when database is empty, on the whole database when table is
+// empty, or on database.table. ok is false if none does. Column grants do
+// not count; see columnGrantsFor.
+func grantFor(grants []protocol.Grant, privilege, database, table string, equal func(a, b string) bool) (grant protocol.Grant, ok bool) {
+	privilege = normalizePrivilege(privilege)
+	for _, g := range grants {
+		_, columnOnly := g.Columns[privilege]
+		if (!containsKeyword(g.Privileges, privilege) || columnOnly) && !containsKeyword(g.Privileges, "ALL PRIVILEGES") {
+			continue
+		}
+		switch {
+		case database == "":
+			if g.Database != "*" {
+				continue
+			}
+		case !matchGrantName(g.Database, database, true, equal):
+			continue
+		case table == "":
+			if g.Table != "*" {
+				continue
+			}
+		case !matchGrantName(g.Table, table, false, equal):
+			continue
+		}
+		return g, true
+	}
+	return protocol.Grant{}, false
+}
+
+// columnGrantsFor returns the columns of database.table that grants give
+// privilege on through column grants, and the first such grant
+func columnGrantsFor(grants []protocol.Grant, privilege, database, table string, equal func(a, b string) bool) (columns []string, grant protocol.Grant) {
+	privilege = normalizePrivilege(privilege)
+	for _, g := range grants {
+		cols, ok := g.Columns[privilege]
+		if !ok || !matchGrantName(g.Database, database, true, equal) || !matchGrantName(g.Table, table, false, equal) {
+			continue
+		}
+		if columns == nil {
+			grant = g
+		}
+		columns = append(columns, cols...)
+	}
+	return columns, grant
+}
+
+// CheckPrivilege reports whether info's grants include privilege on the
+// given scope. A privilege held only on some of a table's columns is
+// reported as not granted, with those columns. Privileges that only come
+// from roles are not visible in SHOW GRANTS, so a missing privilege is
+// flagged as uncertain when the user has roles.
+func (c *Connection) CheckPrivilege(info *protocol.PrivilegeInfo, privilege, database, table string) *protocol.PrivilegeCheck {
+	result := &protocol.PrivilegeCheck{
+		Privilege: normalizePrivilege(privilege),
+		Database:  database,
+		Table:     table,
+	}
+	equal := func(a, b string) bool {
+		return c.NormalizeIdentifier(a) == c.NormalizeIdentifier(b)
+	}
+	if grant, ok := grantFor(info.Grants, privilege, database, table, equal); ok {
+		result.Granted = true
+		result.Grant = grant.Raw
+		return result
+	}
+	if database != "" && table != "" {
+		if columns, grant := columnGrantsFor(info.Grants, privilege, database, table, equal); len(columns) > 0 {
+			result.Grant = grant.Raw
+			result.Columns = columns
+			result.Message = "Granted only on some columns"
+			return result
+		}
+	}
+	if len(info.Roles) > 0 && !info.RolesExpanded {
+		result.Message = "Not granted directly; it may be granted through a role"
+	}
+	return result
+}
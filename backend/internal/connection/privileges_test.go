@@ -0,0 +1,195 @@
+package connection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestParseGrant(t *testing.T) {
+	testCases := []struct {
+		name           string
+		line           string
+		expectOK       bool
+		expectRole     string
+		expectPrivs    []string
+		expectDatabase string
+		expectTable    string
+		expectGrantOpt bool
+		expectColumns  map[string][]string
+	}{
+		{
+			name:           "Global all privileges",
+			line:           "GRANT ALL PRIVILEGES ON *.* TO `root`@`localhost` WITH GRANT OPTION",
+			expectOK:       true,
+			expectPrivs:    []string{"ALL PRIVILEGES"},
+			expectDatabase: "*",
+			expectTable:    "*",
+			expectGrantOpt: true,
+		},
+		{
+			name:           "Short ALL form",
+			line:           "GRANT ALL ON `app`.* TO 'app'@'%'",
+			expectOK:       true,
+			expectPrivs:    []string{"ALL PRIVILEGES"},
+			expectDatabase: "app",
+			expectTable:    "*",
+		},
+		{
+			name:           "Database scoped grant",
+			line:           "GRANT SELECT, INSERT, UPDATE ON `shop`.* TO `writer`@`%`",
+			expectOK:       true,
+			expectPrivs:    []string{"SELECT", "INSERT", "UPDATE"},
+			expectDatabase: "shop",
+			expectTable:    "*",
+		},
+		{
+			name:           "Table grant with column privileges",
+			line:           "GRANT SELECT (`id`, `name`), UPDATE (`name`) ON `shop`.`users` TO `u`@`%`",
+			expectOK:       true,
+			expectPrivs:    []string{"SELECT", "UPDATE"},
+			expectDatabase: "shop",
+			expectTable:    "users",
+			expectColumns:  map[string][]string{"SELECT": {"id", "name"}, "UPDATE": {"name"}},
+		},
+		{
+			name:           "Table and column privileges together",
+			line:           "GRANT SELECT, INSERT (`note`) ON `shop`.`orders` TO `u`@`%`",
+			expectOK:       true,
+			expectPrivs:    []string{"SELECT", "INSERT"},
+			expectDatabase: "shop",
+			expectTable:    "orders",
+			expectColumns:  map[string][]string{"INSERT": {"note"}},
+		},
+		{
+			name:           "Usage only",
+			line:           "GRANT USAGE ON *.* TO `reader`@`%`",
+			expectOK:       true,
+			expectPrivs:    []string{"USAGE"},
+			expectDatabase: "*",
+			expectTable:    "*",
+		},
+		{
+			name:           "Dynamic privileges without spaces",
+			line:           "GRANT BACKUP_ADMIN,BINLOG_ADMIN ON *.* TO `root`@`localhost`",
+			expectOK:       true,
+			expectPrivs:    []string{"BACKUP_ADMIN", "BINLOG_ADMIN"},
+			expectDatabase: "*",
+			expectTable:    "*",
+		},
+		{
+			name:           "Routine grant",
+			line:           "GRANT EXECUTE ON PROCEDURE `shop`.`refresh` TO `u`@`%`",
+			expectOK:       true,
+			expectPrivs:    []string{"EXECUTE"},
+			expectDatabase: "shop",
+			expectTable:    "refresh",
+		},
+		{
+			name:           "MariaDB identified via clause",
+			line:           "GRANT ALL PRIVILEGES ON *.* TO `root`@`localhost` IDENTIFIED VIA unix_socket WITH GRANT OPTION",
+			expectOK:       true,
+			expectPrivs:    []string{"ALL PRIVILEGES"},
+			expectDatabase: "*",
+			expectTable:    "*",
+			expectGrantOpt: true,
+		},
+		{
+			name:       "Role grant",
+			line:       "GRANT `app_read`@`%` TO `u`@`%`",
+			expectOK:   true,
+			expectRole: "`app_read`@`%`",
+		},
+		{
+			name:     "Not a grant",
+			line:     "REVOKE SELECT ON *.* FROM `u`@`%`",
+			expectOK: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			grant, role, ok := parseGrant(tc.line)
+			if ok != tc.expectOK {
+				t.Fatalf("Expected ok=%v, got %v", tc.expectOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if role != tc.expectRole {
+				t.Errorf("Expected role %q, got %q", tc.expectRole, role)
+			}
+			if role != "" {
+				return
+			}
+			if !reflect.DeepEqual(grant.Privileges, tc.expectPrivs) {
+				t.Errorf("Expected privileges %v, got %v", tc.expectPrivs, grant.Privileges)
+			}
+			if grant.Database != tc.expectDatabase {
+				t.Errorf("Expected database %q, got %q", tc.expectDatabase, grant.Database)
+			}
+			if grant.Table != tc.expectTable {
+				t.Errorf("Expected table %q, got %q", tc.expectTable, grant.Table)
+			}
+			if grant.WithGrantOption != tc.expectGrantOpt {
+				t.Errorf("Expected withGrantOption=%v, got %v", tc.expectGrantOpt, grant.WithGrantOption)
+			}
+			if !reflect.DeepEqual(grant.Columns, tc.expectColumns) {
+				t.Errorf("Expected columns %v, got %v", tc.expectColumns, grant.Columns)
+			}
+		})
+	}
+}
+
+func TestCheckPrivilege(t *testing.T) {
+	c := &Connection{lowerCaseTableNames: 1}
+	info := &protocol.PrivilegeInfo{}
+	for _, line := range []string{
+		"GRANT USAGE ON *.* TO `app`@`%`",
+		"GRANT SELECT, INSERT ON `shop`.* TO `app`@`%`",
+		"GRANT ALL PRIVILEGES ON `scratch\\_%`.* TO `app`@`%`",
+		"GRANT UPDATE (`status`) ON `shop`.`orders` TO `app`@`%`",
+		"GRANT DELETE, UPDATE (`note`) ON `shop`.`notes` TO `app`@`%`",
+	} {
+		grant, _, ok := parseGrant(line)
+		if !ok {
+			t.Fatalf("Failed to parse %s", line)
+		}
+		info.Grants = append(info.Grants, grant)
+	}
+
+	tests := []struct {
+		privilege, database, table string
+		granted                    bool
+	}{
+		{"select", "shop", "", true},
+		{"SELECT", "SHOP", "customers", true},
+		{"CREATE", "shop", "", false},
+		// Column grants do not give the privilege on the whole table
+		{"update", "shop", "orders", false},
+		{"UPDATE", "shop", "", false},
+		{"DELETE", "shop", "notes", true},
+		{"UPDATE", "shop", "notes", false},
+		{"UPDATE", "shop", "customers", false},
+		{"CREATE", "scratch_1", "", true},
+		{"CREATE", "scratchy", "", false},
+		{"SELECT", "", "", false},
+	}
+	for _, tt := range tests {
+		got := c.CheckPrivilege(info, tt.privilege, tt.database, tt.table)
+		if got.Granted != tt.granted {
+			t.Errorf("CheckPrivilege(%s on %s.%s) = %v, want %v", tt.privilege, tt.database, tt.table, got.Granted, tt.granted)
+		}
+	}
+
+	got := c.CheckPrivilege(info, "UPDATE", "shop", "orders")
+	if !reflect.DeepEqual(got.Columns, []string{"status"}) || got.Grant == "" {
+		t.Errorf("Expected the column grant to be reported, got %+v", got)
+	}
+
+	info.Roles = []string{"`reporting`@`%`"}
+	if got := c.CheckPrivilege(info, "DELETE", "shop", ""); got.Granted || got.Message == "" {
+		t.Errorf("Expected an uncertain result with roles, got %+v", got)
+	}
+}
@@ -0,0 +1,50 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// ProbeHost checks that a TCP connection to host:port can be opened within
+// timeout, without speaking the MySQL protocol or authenticating. The
+// connection is closed immediately.
+func ProbeHost(ctx context.Context, host string, port int, timeout time.Duration) *protocol.ProbeResult {
+	address := net.JoinHostPort(dialHost(host), strconv.Itoa(port))
+	result := &protocol.ProbeResult{Address: address}
+
+	dialer := net.Dialer{Timeout: timeout}
+	startTime := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	result.Latency = time.Since(startTime).Milliseconds()
+	if err != nil {
+		result.Message = probeMessage(err, address, timeout)
+		return result
+	}
+	conn.Close()
+
+	result.Reachable = true
+	result.Message = fmt.Sprintf("%s is reachable", address)
+	return result
+}
+
+// probeMessage describes why a dial failed
+func probeMessage(err error, address string, timeout time.Duration) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("host not found: %s", dnsErr.Name)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Sprintf("connection refused: nothing is listening on %s, or the port is blocked by a firewall", address)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Sprintf("connection timeout: could not reach %s within %v", address, timeout)
+	}
+	return err.Error()
+}
@@ -0,0 +1,34 @@
+package connection
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeHost(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	result := ProbeHost(context.Background(), "localhost", port, time.Second)
+	if !result.Reachable {
+		t.Errorf("Expected the listener to be reachable: %s", result.Message)
+	}
+	if !strings.HasPrefix(result.Address, "127.0.0.1:") {
+		t.Errorf("Expected localhost to be dialed as 127.0.0.1, got %s", result.Address)
+	}
+
+	listener.Close()
+	result = ProbeHost(context.Background(), "127.0.0.1", port, time.Second)
+	if result.Reachable {
+		t.Fatal("Expected a closed port to be unreachable")
+	}
+	if !strings.Contains(result.Message, "connection refused") {
+		t.Errorf("Unexpected message: %s", result.Message)
+	}
+}
@@ -0,0 +1,121 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// errProfilingUnavailable explains why no profile could be collected
+var errProfilingUnavailable = fmt.Errorf("query profiling is not available on this server (SHOW PROFILE is deprecated and may be disabled); enable performance_schema stage instrumentation to analyze statement stages instead")
+
+// ProfileQuery runs a query with session profiling enabled and returns the
+// SHOW PROFILE stage timings. Profiling is per-session, so everything runs on
+// one pinned connection, which is discarded afterwards rather than returned
+// to the pool with profiling state.
+func (c *Connection) ProfileQuery(ctx context.Context, sqlQuery string) (_ *protocol.QueryProfile, err error) {
+	if err := c.checkStatement(sqlQuery); err != nil {
+		return nil, err
+	}
+	ctx, finish := c.withDefaultTimeout(ctx, sqlQuery)
+	defer func() { err = finish(err) }()
+
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer discardConn(conn)
+
+	if _, err := conn.ExecContext(ctx, "SET profiling = 1"); err != nil {
+		return nil, errProfilingUnavailable
+	}
+
+	stop := c.watchCancel(ctx, threadID)
+	rowCount, err := drainQuery(ctx, conn, sqlQuery)
+	stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	// The profiled statement is the most recent entry in SHOW PROFILES
+	queryID, err := lastProfiledQuery(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if queryID == 0 {
+		return nil, errProfilingUnavailable
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SHOW PROFILE FOR QUERY %d", queryID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	defer rows.Close()
+
+	profile := &protocol.QueryProfile{
+		SQL:      sqlQuery,
+		RowCount: rowCount,
+		Stages:   make([]protocol.ProfileStage, 0, 16),
+	}
+	for rows.Next() {
+		var stage protocol.ProfileStage
+		var duration string
+		if err := rows.Scan(&stage.Status, &duration); err != nil {
+			return nil, err
+		}
+		stage.Duration, _ = strconv.ParseFloat(duration, 64)
+		profile.TotalDuration += stage.Duration
+		profile.Stages = append(profile.Stages, stage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(profile.Stages) == 0 {
+		return nil, errProfilingUnavailable
+	}
+
+	return profile, nil
+}
+
+// drainQuery runs a query and discards its rows, returning the row count
+func drainQuery(ctx context.Context, conn *sql.Conn, sqlQuery string) (int64, error) {
+	rows, err := conn.QueryContext(ctx, sqlQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		count++
+	}
+	return count, rows.Err()
+}
+
+// lastProfiledQuery returns the highest Query_ID from SHOW PROFILES, or 0
+func lastProfiledQuery(ctx context.Context, conn *sql.Conn) (int64, error) {
+	rows, err := conn.QueryContext(ctx, "SHOW PROFILES")
+	if err != nil {
+		return 0, errProfilingUnavailable
+	}
+	defer rows.Close()
+
+	var last int64
+	for rows.Next() {
+		var id int64
+		var duration, query sql.NullString
+		if err := rows.Scan(&id, &duration, &query); err != nil {
+			return 0, err
+		}
+		if id > last {
+			last = id
+		}
+	}
+	return last, rows.Err()
+}
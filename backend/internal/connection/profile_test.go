@@ -0,0 +1,33 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestProfileQueryChecksStatement(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	c.config = &protocol.ConnectionConfig{BlockedStatements: []string{"DROP"}, SafeUpdates: true}
+
+	testCases := []struct {
+		sql      string
+		expected string
+	}{
+		{"DROP TABLE orders", "blocked"},
+		{"DELETE FROM orders", "WHERE"},
+	}
+	for _, tc := range testCases {
+		_, err := c.ProfileQuery(context.Background(), tc.sql)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected an error mentioning %q, got %v", tc.sql, tc.expected, err)
+		}
+	}
+
+	// Rejected statements never reach a connection
+	if open := c.db.Stats().OpenConnections; open != 0 {
+		t.Errorf("Expected no connections to be opened, got %d", open)
+	}
+}
@@ -0,0 +1,125 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// DefaultProgressInterval is how often query progress is reported
+const DefaultProgressInterval = time.Second
+
+const stageProgressQuery = `SELECT s.EVENT_NAME, s.WORK_COMPLETED, s.WORK_ESTIMATED
+	FROM performance_schema.events_stages_current s
+	JOIN performance_schema.threads t ON t.THREAD_ID = s.THREAD_ID
+	WHERE t.PROCESSLIST_ID = ?`
+
+// QueryOptions controls how ExecuteQueryWithOptions runs a query
+type QueryOptions struct {
+	Limit  int
+	Offset int
+	// NamedArgs, when not nil, binds :name placeholders in the query
+	NamedArgs map[string]interface{}
+	// ForcePrimary keeps reads off the read replicas
+	ForcePrimary bool
+	// RowKeys adds each row's primary key values to the result when the
+	// query is a simple single-table SELECT (see addRowIdentity)
+	RowKeys bool
+	// RequestID and Label are sent in a comment ahead of the statement
+	// when the connection enables QueryLabels (see labelStatement)
+	RequestID string
+	Label     string
+	// Progress, when set, is called every ProgressInterval while the query
+	// runs and its rows are fetched
+	Progress         func(protocol.QueryProgress)
+	ProgressInterval time.Duration
+	// RawRow, when set, is called with each row's values as scanned, before
+	// they are converted for display (see convertValue)
+	RawRow func(row []interface{})
+}
+
+// progressWatch returns a watch hook for queryWithKillWatch that reports
+// progress for the query's thread until stopped. fetched is read atomically.
+func (c *Connection) progressWatch(ctx context.Context, opts QueryOptions, fetched *int64) func(threadID uint64) func() {
+	if opts.Progress == nil {
+		return nil
+	}
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	return func(threadID uint64) func() {
+		start := time.Now()
+		done := make(chan struct{})
+		finished := make(chan struct{})
+
+		go func() {
+			defer close(finished)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			// Stage instrumentation needs MySQL 8 and performance_schema
+			stages := !c.version.IsMariaDB() && c.version.AtLeast(8, 0)
+			for {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				progress := protocol.QueryProgress{
+					Elapsed:     time.Since(start).Milliseconds(),
+					RowsFetched: atomic.LoadInt64(fetched),
+				}
+				if stages {
+					stages = c.stageProgress(ctx, threadID, &progress)
+				}
+				opts.Progress(progress)
+			}
+		}()
+
+		return func() {
+			close(done)
+			<-finished
+		}
+	}
+}
+
+// stageProgress fills in the current stage and completion percentage for a
+// thread. It reports whether polling should continue: errors such as missing
+// privileges or a disabled performance_schema stop stage polling, while no
+// current stage does not.
+func (c *Connection) stageProgress(ctx context.Context, threadID uint64, progress *protocol.QueryProgress) bool {
+	var name string
+	var completed, estimated *int64
+	err := c.db.QueryRowContext(ctx, stageProgressQuery, threadID).Scan(&name, &completed, &estimated)
+	if err != nil {
+		return ctx.Err() != nil || errors.Is(err, sql.ErrNoRows)
+	}
+
+	progress.Stage = strings.TrimPrefix(name, "stage/sql/")
+	if percent, ok := stagePercent(completed, estimated); ok {
+		progress.Percent = &percent
+	}
+	return true
+}
+
+// stagePercent converts stage work counters to a percentage
+func stagePercent(completed, estimated *int64) (float64, bool) {
+	if completed == nil || estimated == nil || *estimated <= 0 {
+		return 0, false
+	}
+	percent := float64(*completed) / float64(*estimated) * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent, true
+}
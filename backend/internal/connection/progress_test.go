@@ -0,0 +1,31 @@
+package connection
+
+import "testing"
+
+func TestStagePercent(t *testing.T) {
+	value := func(n int64) *int64 { return &n }
+
+	testCases := []struct {
+		name      string
+		completed *int64
+		estimated *int64
+		expected  float64
+		ok        bool
+	}{
+		{"Halfway", value(50), value(100), 50, true},
+		{"Done", value(100), value(100), 100, true},
+		{"Estimate exceeded", value(150), value(100), 100, true},
+		{"No estimate", value(10), nil, 0, false},
+		{"Zero estimate", value(0), value(0), 0, false},
+		{"Not instrumented", nil, nil, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := stagePercent(tc.completed, tc.estimated)
+			if ok != tc.ok || got != tc.expected {
+				t.Errorf("Expected %v/%v, got %v/%v", tc.expected, tc.ok, got, ok)
+			}
+		})
+	}
+}
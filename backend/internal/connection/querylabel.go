@@ -0,0 +1,49 @@
+package connection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxQueryLabelValue bounds each value in a query label comment
+const maxQueryLabelValue = 64
+
+// queryLabelValue reduces a label value to letters, digits, '_', '.' and
+// '-', so it can neither close the comment nor be read as a placeholder or
+// an assignment by the statement checks
+func queryLabelValue(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if b.Len() >= maxQueryLabelValue {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// labelStatement prepends a comment such as /* dw:requestId=42 label=report */
+// so the statement can be traced from SHOW PROCESSLIST, whose Info column
+// keeps comments. The comment goes before the first keyword rather than
+// after it because optimizer hints (SELECT /*+ ... */) must directly follow
+// the keyword; MySQL accepts a leading comment before any statement,
+// EXPLAIN included. Labels are only added when the connection's config
+// enables QueryLabels.
+func (c *Connection) labelStatement(sqlQuery, requestID, label string) string {
+	if c.config == nil || !c.config.QueryLabels || (requestID == "" && label == "") {
+		return sqlQuery
+	}
+	fields := []string{}
+	if requestID != "" {
+		fields = append(fields, "requestId="+queryLabelValue(requestID))
+	}
+	if label != "" {
+		fields = append(fields, "label="+queryLabelValue(label))
+	}
+	return fmt.Sprintf("/* dw:%s */ %s", strings.Join(fields, " "), sqlQuery)
+}
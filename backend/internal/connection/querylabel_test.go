@@ -0,0 +1,33 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestLabelStatement(t *testing.T) {
+	c := &Connection{config: &protocol.ConnectionConfig{QueryLabels: true}}
+
+	got := c.labelStatement("EXPLAIN SELECT 1", "42", "")
+	if got != "/* dw:requestId=42 */ EXPLAIN SELECT 1" {
+		t.Errorf("labelStatement() = %q", got)
+	}
+	if kind := statementKind(got); kind != "EXPLAIN" {
+		t.Errorf("Expected a labelled EXPLAIN to stay an EXPLAIN, got %q", kind)
+	}
+
+	got = c.labelStatement("SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t", "7", "tab 2 */ DROP ?")
+	want := "/* dw:requestId=7 label=tab_2____DROP__ */ SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t"
+	if got != want {
+		t.Errorf("labelStatement() = %q, want %q", got, want)
+	}
+	if !isReplicaSafe(got) || changesSessionState(got) {
+		t.Errorf("Expected the label not to change how %q is classified", got)
+	}
+
+	off := &Connection{config: &protocol.ConnectionConfig{}}
+	if got := off.labelStatement("SELECT 1", "42", "x"); got != "SELECT 1" {
+		t.Errorf("Expected no label when QueryLabels is off, got %q", got)
+	}
+}
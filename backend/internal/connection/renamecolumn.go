@@ -0,0 +1,68 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// renameColumnSQL builds the statement that renames a column. RENAME COLUMN
+// only changes the name; without it, CHANGE COLUMN must repeat the full
+// definition, which is rebuilt from the column's current details.
+func renameColumnSQL(database, table string, col protocol.Column, newName string, renameSyntax bool) (string, error) {
+	if renameSyntax {
+		return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+			qualifiedTable(database, table), quoteIdentifier(col.Name), quoteIdentifier(newName)), nil
+	}
+
+	oldName := col.Name
+	col.Name = newName
+	definition, err := columnDefinition(col)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ALTER TABLE %s CHANGE COLUMN %s %s",
+		qualifiedTable(database, table), quoteIdentifier(oldName), definition), nil
+}
+
+// RenameColumn renames a column and leaves the rest of its definition as it
+// is. Servers with RENAME COLUMN (MySQL 8.0.3, MariaDB 10.5.2) use it;
+// older ones get a CHANGE COLUMN that repeats the current type,
+// nullability, default, extras and comment.
+func (c *Connection) RenameColumn(ctx context.Context, database, table, column, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("new column name is required")
+	}
+
+	columns, err := c.ListColumnsContext(ctx, database, table)
+	if err != nil {
+		return err
+	}
+	col, ok := findColumn(columns, column)
+	if !ok {
+		return fmt.Errorf("column not found: %s.%s.%s", database, table, column)
+	}
+	if newName == col.Name {
+		return nil
+	}
+	// Only a change of case may reuse the name of the column itself
+	if existing, ok := findColumn(columns, newName); ok && existing.Name != col.Name {
+		return fmt.Errorf("column %s already exists in %s.%s", existing.Name, database, table)
+	}
+
+	query, err := renameColumnSQL(database, table, col, newName, c.version.supportsRenameColumn())
+	if err != nil {
+		return err
+	}
+	if err := c.checkStatement(query); err != nil {
+		return err
+	}
+
+	if _, err := c.execWithKill(ctx, query); err != nil {
+		return fmt.Errorf("failed to rename column: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestRenameColumnSQL(t *testing.T) {
+	def := "CURRENT_TIMESTAMP"
+	col := protocol.Column{
+		Name:     "created",
+		Type:     "timestamp",
+		Nullable: true,
+		Default:  &def,
+		Extra:    "DEFAULT_GENERATED on update CURRENT_TIMESTAMP",
+		Comment:  "Creation time",
+	}
+
+	got, err := renameColumnSQL("shop", "orders", col, "created_at", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "ALTER TABLE `shop`.`orders` RENAME COLUMN `created` TO `created_at`"; got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	got, err = renameColumnSQL("shop", "orders", col, "created_at", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "ALTER TABLE `shop`.`orders` CHANGE COLUMN `created` `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Creation time'"
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// A generated column without its expression cannot be restated
+	generated := protocol.Column{Name: "total", Type: "int", Extra: "VIRTUAL GENERATED"}
+	if _, err := renameColumnSQL("shop", "orders", generated, "sum", false); err == nil {
+		t.Error("Expected an error for a generated column without its expression")
+	}
+	if _, err := renameColumnSQL("shop", "orders", generated, "sum", true); err != nil {
+		t.Errorf("RENAME COLUMN should not need the definition: %v", err)
+	}
+}
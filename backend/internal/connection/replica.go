@@ -0,0 +1,112 @@
+package connection
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// connectReplicas opens a connection to each configured read replica.
+// Replicas that cannot be reached are skipped so the primary stays usable.
+func connectReplicas(config *protocol.ConnectionConfig) []*Connection {
+	var replicas []*Connection
+	for _, hp := range config.ReadReplicas {
+		replicaConfig := *config
+		replicaConfig.Host = hp.Host
+		replicaConfig.Port = hp.Port
+		if replicaConfig.Port == 0 {
+			replicaConfig.Port = config.Port
+		}
+		replicaConfig.ReadReplicas = nil
+
+		replica, err := NewConnection(&replicaConfig)
+		if err != nil {
+			log.Printf("Skipping read replica %s: %v", replicaAddress(&replicaConfig), err)
+			continue
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+// replicaAddress formats a replica's host and port for logs and results
+func replicaAddress(config *protocol.ConnectionConfig) string {
+	return net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+}
+
+// Replicas returns the addresses of the connected read replicas
+func (c *Connection) Replicas() []string {
+	addresses := make([]string, len(c.replicas))
+	for i, replica := range c.replicas {
+		addresses[i] = replicaAddress(replica.config)
+	}
+	return addresses
+}
+
+// readTarget picks the connection that should run a statement: a read
+// replica for plain reads, unless the caller forces the primary
+func (c *Connection) readTarget(sqlText string, forcePrimary bool) *Connection {
+	if forcePrimary || len(c.replicas) == 0 || !isReplicaSafe(sqlText) {
+		return c
+	}
+	return c.pickReplica()
+}
+
+// pickReplica returns the replica with the fewest queries in flight,
+// rotating the starting point so idle replicas share the load round-robin
+func (c *Connection) pickReplica() *Connection {
+	n := len(c.replicas)
+	start := int(atomic.AddUint32(&c.nextReplica, 1) % uint32(n))
+
+	best := c.replicas[start]
+	bestInUse := best.db.Stats().InUse
+	for i := 1; i < n; i++ {
+		replica := c.replicas[(start+i)%n]
+		if inUse := replica.db.Stats().InUse; inUse < bestInUse {
+			best, bestInUse = replica, inUse
+		}
+	}
+	return best
+}
+
+// isReplicaSafe reports whether a statement only reads, without locking
+// rows or touching session state, so any replica can serve it
+func isReplicaSafe(sqlText string) bool {
+	switch statementKind(sqlText) {
+	case "SELECT", "TABLE":
+	default:
+		return false
+	}
+	if changesSessionState(sqlText) {
+		return false
+	}
+
+	words := topLevelWords(sqlText)
+	for i, word := range words {
+		switch word {
+		case "LOCK":
+			// LOCK IN SHARE MODE
+			return false
+		case "FOR":
+			if i+1 < len(words) && (words[i+1] == "UPDATE" || words[i+1] == "SHARE") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// closeReplicas closes every replica connection
+func (c *Connection) closeReplicas() error {
+	var firstErr error
+	for _, replica := range c.replicas {
+		if err := replica.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close replica %s: %w", replicaAddress(replica.config), err)
+		}
+	}
+	return firstErr
+}
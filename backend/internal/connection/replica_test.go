@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestIsReplicaSafe(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		expected bool
+	}{
+		{"SELECT * FROM orders", true},
+		{"WITH recent AS (SELECT 1) SELECT * FROM recent", true},
+		{"TABLE orders", true},
+		{"SELECT 'FOR UPDATE' AS note FROM orders", true},
+		{"SELECT * FROM orders FOR UPDATE", false},
+		{"SELECT * FROM orders WHERE id = 1 FOR SHARE", false},
+		{"SELECT * FROM orders LOCK IN SHARE MODE", false},
+		{"SELECT COUNT(*) INTO @n FROM orders", false},
+		{"SELECT GET_LOCK('job', 1)", false},
+		{"UPDATE orders SET status = 'x' WHERE id = 1", false},
+		{"SHOW PROCESSLIST", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isReplicaSafe(tc.sql); got != tc.expected {
+			t.Errorf("isReplicaSafe(%q): expected %v, got %v", tc.sql, tc.expected, got)
+		}
+	}
+}
+
+func newFakeReplica(t *testing.T, host, database string) *Connection {
+	t.Helper()
+	db := sql.OpenDB(&fakeSessionConnector{database: database})
+	t.Cleanup(func() { db.Close() })
+	return &Connection{
+		db:     db,
+		config: &protocol.ConnectionConfig{Host: host, Port: 3306},
+	}
+}
+
+func TestReadsRouteToReplicas(t *testing.T) {
+	c := newFakeSessionConnection(t, 4)
+	c.replicas = []*Connection{
+		newFakeReplica(t, "replica-1", "replica_1"),
+		newFakeReplica(t, "replica-2", "replica_2"),
+	}
+	ctx := context.Background()
+
+	served := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		result, err := c.ExecuteQueryWithContext(ctx, "SELECT DATABASE()", 0, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Replica == "" {
+			t.Fatal("Expected a replica to serve the read")
+		}
+		served[result.Replica]++
+	}
+	if served["replica-1:3306"] != 2 || served["replica-2:3306"] != 2 {
+		t.Errorf("Expected reads to alternate between idle replicas, got %v", served)
+	}
+
+	result, err := c.ExecuteQueryWithOptions(ctx, "SELECT DATABASE()", QueryOptions{ForcePrimary: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Replica != "" || result.Rows[0][0] != "app" {
+		t.Errorf("Expected forcePrimary to read from the primary, got %q from %q", result.Rows[0][0], result.Replica)
+	}
+
+	result, err = c.ExecuteQueryWithContext(ctx, "SELECT * FROM orders FOR UPDATE", 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Replica != "" {
+		t.Errorf("Expected a locking read on the primary, got %q", result.Replica)
+	}
+}
@@ -0,0 +1,141 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// replicaStatusStatement returns the statement listing replication channels.
+// SHOW REPLICA STATUS replaced SHOW SLAVE STATUS in MySQL 8.0.22 and
+// MariaDB 10.5.1.
+func replicaStatusStatement(v ServerVersion) string {
+	if v.IsMariaDB() {
+		if v.AtLeast(10, 5) {
+			return "SHOW REPLICA STATUS"
+		}
+		return "SHOW SLAVE STATUS"
+	}
+	if v.AtLeast(8, 1) || (v.Major == 8 && v.Minor == 0 && v.Patch >= 22) {
+		return "SHOW REPLICA STATUS"
+	}
+	return "SHOW SLAVE STATUS"
+}
+
+// binlogStatusStatement returns the statement reporting the current binary
+// log position. MySQL 8.2 renamed SHOW MASTER STATUS and 8.4 removed it;
+// every MariaDB version still accepts it.
+func binlogStatusStatement(v ServerVersion) string {
+	if v.IsMariaDB() {
+		return "SHOW MASTER STATUS"
+	}
+	if v.AtLeast(8, 2) {
+		return "SHOW BINARY LOG STATUS"
+	}
+	return "SHOW MASTER STATUS"
+}
+
+// firstValue returns the first of names present in row, so both the current
+// Source/Replica and the legacy Master/Slave column names can be read
+func firstValue(row map[string]interface{}, names ...string) interface{} {
+	for _, name := range names {
+		if value, ok := row[name]; ok {
+			return value
+		}
+	}
+	return nil
+}
+
+// replicationChannel converts one row of SHOW REPLICA STATUS
+func replicationChannel(row map[string]interface{}) protocol.ReplicationChannel {
+	channel := protocol.ReplicationChannel{
+		Channel:            asString(firstValue(row, "Channel_Name", "Connection_name")),
+		SourceHost:         asString(firstValue(row, "Source_Host", "Master_Host")),
+		SourcePort:         asInt64(firstValue(row, "Source_Port", "Master_Port")),
+		IORunning:          asString(firstValue(row, "Replica_IO_Running", "Slave_IO_Running")),
+		SQLRunning:         asString(firstValue(row, "Replica_SQL_Running", "Slave_SQL_Running")),
+		SourceLogFile:      asString(firstValue(row, "Source_Log_File", "Master_Log_File")),
+		ReadSourceLogPos:   asInt64(firstValue(row, "Read_Source_Log_Pos", "Read_Master_Log_Pos")),
+		RelaySourceLogFile: asString(firstValue(row, "Relay_Source_Log_File", "Relay_Master_Log_File")),
+		ExecSourceLogPos:   asInt64(firstValue(row, "Exec_Source_Log_Pos", "Exec_Master_Log_Pos")),
+		LastIOError:        asString(row["Last_IO_Error"]),
+		LastSQLError:       asString(row["Last_SQL_Error"]),
+	}
+	// NULL while the replica is stopped or not connected
+	if lag := firstValue(row, "Seconds_Behind_Source", "Seconds_Behind_Master"); lag != nil {
+		seconds := asInt64(lag)
+		channel.SecondsBehindSource = &seconds
+	}
+	return channel
+}
+
+// GetReplicationStatus returns the server's replication channels, if it is
+// a replica, and its current binary log position, if binary logging is on.
+// Both need the REPLICATION CLIENT privilege; when it is missing, the
+// affected part is left empty and Message explains why.
+func (c *Connection) GetReplicationStatus(ctx context.Context) (*protocol.ReplicationStatus, error) {
+	status := &protocol.ReplicationStatus{Channels: []protocol.ReplicationChannel{}}
+
+	channels, err := c.readStatusRows(ctx, replicaStatusStatement(c.version))
+	if err != nil {
+		if !isPermissionError(err) {
+			return nil, fmt.Errorf("failed to read replica status: %w", err)
+		}
+		status.Message = "The current user is not allowed to view replication status (requires the REPLICATION CLIENT privilege)"
+		return status, nil
+	}
+	for _, row := range channels {
+		status.Channels = append(status.Channels, replicationChannel(row))
+	}
+	status.IsReplica = len(status.Channels) > 0
+
+	binlog, err := c.readStatusRows(ctx, binlogStatusStatement(c.version))
+	if err != nil {
+		if !isPermissionError(err) {
+			return nil, fmt.Errorf("failed to read binary log status: %w", err)
+		}
+		status.Message = "The current user is not allowed to view the binary log position"
+		return status, nil
+	}
+	// No row means binary logging is disabled
+	if len(binlog) > 0 {
+		status.Binlog = &protocol.BinlogPosition{
+			File:            asString(binlog[0]["File"]),
+			Position:        asInt64(binlog[0]["Position"]),
+			ExecutedGtidSet: asString(binlog[0]["Executed_Gtid_Set"]),
+		}
+	}
+
+	return status, nil
+}
+
+// readStatusRows runs a SHOW statement and returns its rows by column name.
+// The byte values are copied since the maps outlive the scan.
+func (c *Connection) readStatusRows(ctx context.Context, statement string) ([]map[string]interface{}, error) {
+	rows, err := c.db.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		row, err := scanner.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[name] = string(b)
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,61 @@
+package connection
+
+import "testing"
+
+func TestReplicationStatements(t *testing.T) {
+	testCases := []struct {
+		version string
+		replica string
+		binlog  string
+	}{
+		{"5.7.44-log", "SHOW SLAVE STATUS", "SHOW MASTER STATUS"},
+		{"8.0.21", "SHOW SLAVE STATUS", "SHOW MASTER STATUS"},
+		{"8.0.22", "SHOW REPLICA STATUS", "SHOW MASTER STATUS"},
+		{"8.4.0", "SHOW REPLICA STATUS", "SHOW BINARY LOG STATUS"},
+		{"10.4.32-MariaDB", "SHOW SLAVE STATUS", "SHOW MASTER STATUS"},
+		{"11.4.2-MariaDB", "SHOW REPLICA STATUS", "SHOW MASTER STATUS"},
+	}
+
+	for _, tc := range testCases {
+		v := parseServerVersion(tc.version)
+		if got := replicaStatusStatement(v); got != tc.replica {
+			t.Errorf("%s: expected %q, got %q", tc.version, tc.replica, got)
+		}
+		if got := binlogStatusStatement(v); got != tc.binlog {
+			t.Errorf("%s: expected %q, got %q", tc.version, tc.binlog, got)
+		}
+	}
+}
+
+func TestReplicationChannel(t *testing.T) {
+	legacy := replicationChannel(map[string]interface{}{
+		"Master_Host":           "db1",
+		"Master_Port":           int64(3306),
+		"Slave_IO_Running":      "Yes",
+		"Slave_SQL_Running":     "Yes",
+		"Seconds_Behind_Master": "12",
+		"Master_Log_File":       "binlog.000042",
+		"Read_Master_Log_Pos":   "1337",
+	})
+	if legacy.SourceHost != "db1" || legacy.SourcePort != 3306 || legacy.IORunning != "Yes" || legacy.SourceLogFile != "binlog.000042" || legacy.ReadSourceLogPos != 1337 {
+		t.Errorf("Legacy columns not read: %+v", legacy)
+	}
+	if legacy.SecondsBehindSource == nil || *legacy.SecondsBehindSource != 12 {
+		t.Errorf("Expected 12 seconds behind, got %v", legacy.SecondsBehindSource)
+	}
+
+	stopped := replicationChannel(map[string]interface{}{
+		"Channel_Name":          "eu",
+		"Source_Host":           "db2",
+		"Replica_IO_Running":    "No",
+		"Replica_SQL_Running":   "No",
+		"Seconds_Behind_Source": nil,
+		"Last_SQL_Error":        "Duplicate entry",
+	})
+	if stopped.Channel != "eu" || stopped.SourceHost != "db2" || stopped.SQLRunning != "No" || stopped.LastSQLError != "Duplicate entry" {
+		t.Errorf("Current columns not read: %+v", stopped)
+	}
+	if stopped.SecondsBehindSource != nil {
+		t.Errorf("Expected no lag while stopped, got %d", *stopped.SecondsBehindSource)
+	}
+}
@@ -0,0 +1,120 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// resultMetaTable is the temporary table GetResultColumnMeta describes. It
+// only exists on a session that is discarded afterwards.
+const resultMetaTable = "dw_result_meta"
+
+// resultMetaQuery wraps a SELECT so it returns its columns but no rows. The
+// newlines keep a trailing line comment in sqlText from hiding the closing
+// parenthesis.
+func resultMetaQuery(sqlText string) string {
+	sqlText = strings.TrimRight(strings.TrimSpace(sqlText), "; \t\r\n")
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS dw_result LIMIT 0", sqlText)
+}
+
+// charsetOfCollation returns the character set a collation belongs to.
+// MySQL character set names contain no underscore and every collation name
+// starts with its character set, except "binary" which is both.
+func charsetOfCollation(collation string) string {
+	charset, _, _ := strings.Cut(collation, "_")
+	return charset
+}
+
+// GetResultColumnMeta reports the type, character set and collation of each
+// column a SELECT would return, for debugging encoding mismatches. The
+// driver only exposes type names, so the query is materialized with no rows
+// into a temporary table that SHOW FULL COLUMNS can describe. When that is
+// not possible (no CREATE TEMPORARY TABLES privilege, duplicate column
+// names) the driver's type names are returned with empty collations and
+// Message says why.
+func (c *Connection) GetResultColumnMeta(ctx context.Context, sqlText string) (*protocol.ResultColumnMeta, error) {
+	if kind := statementKind(sqlText); kind != "SELECT" && kind != "TABLE" {
+		return nil, fmt.Errorf("only SELECT statements have result columns (got %s)", kind)
+	}
+	if err := c.checkStatement(sqlText); err != nil {
+		return nil, err
+	}
+
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	// The temporary table is session state, so the session is never reused
+	defer discardConn(conn)
+
+	stop := c.watchCancel(ctx, threadID)
+	defer stop()
+
+	query := resultMetaQuery(sqlText)
+	create := fmt.Sprintf("CREATE TEMPORARY TABLE `%s` AS %s", resultMetaTable, query)
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return driverColumnMeta(ctx, conn, query, err)
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SHOW FULL COLUMNS FROM `%s`", resultMetaTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe result columns: %w", err)
+	}
+	defer rows.Close()
+
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &protocol.ResultColumnMeta{Columns: []protocol.ResultColumn{}}
+	for rows.Next() {
+		row, err := scanner.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		collation := asString(row["Collation"])
+		meta.Columns = append(meta.Columns, protocol.ResultColumn{
+			Name:         asString(row["Field"]),
+			Type:         asString(row["Type"]),
+			CharacterSet: charsetOfCollation(collation),
+			Collation:    collation,
+		})
+	}
+	return meta, rows.Err()
+}
+
+// driverColumnMeta describes the result columns using only what the driver
+// reports, after the temporary table could not be created
+func driverColumnMeta(ctx context.Context, conn *sql.Conn, query string, cause error) (*protocol.ResultColumnMeta, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		// The query itself is invalid, which is more useful than cause
+		return nil, fmt.Errorf("failed to describe result columns: %w", err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &protocol.ResultColumnMeta{
+		Columns: make([]protocol.ResultColumn, 0, len(types)),
+		Message: fmt.Sprintf("Character sets and collations unavailable: %v", cause),
+	}
+	for _, t := range types {
+		meta.Columns = append(meta.Columns, protocol.ResultColumn{
+			Name: t.Name(),
+			Type: strings.ToLower(t.DatabaseTypeName()),
+		})
+	}
+	return meta, rows.Err()
+}
@@ -0,0 +1,38 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestResultMetaQuery(t *testing.T) {
+	got := resultMetaQuery("SELECT name FROM users -- trailing comment\n;  ")
+	want := "SELECT * FROM (\nSELECT name FROM users -- trailing comment\n) AS dw_result LIMIT 0"
+	if got != want {
+		t.Errorf("resultMetaQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestCharsetOfCollation(t *testing.T) {
+	tests := map[string]string{
+		"utf8mb4_0900_ai_ci": "utf8mb4",
+		"utf8mb3_general_ci": "utf8mb3",
+		"latin1_swedish_ci":  "latin1",
+		"binary":             "binary",
+		"":                   "",
+	}
+	for collation, want := range tests {
+		if got := charsetOfCollation(collation); got != want {
+			t.Errorf("charsetOfCollation(%q) = %q, want %q", collation, got, want)
+		}
+	}
+}
+
+func TestGetResultColumnMetaRejectsWrites(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	_, err := c.GetResultColumnMeta(context.Background(), "DELETE FROM users")
+	if err == nil || !strings.Contains(err.Error(), "DELETE") {
+		t.Errorf("Expected DELETE to be rejected, got %v", err)
+	}
+}
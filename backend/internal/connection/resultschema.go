@@ -0,0 +1,152 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// typeFamilies groups column types that are interchangeable for a result
+// schema check, e.g. a report column declared VARCHAR that becomes CHAR or
+// TEXT after a query change still holds strings. The driver reports
+// unsigned integers as "UNSIGNED BIGINT" and so on.
+var typeFamilies = map[string]string{
+	"char": "string", "varchar": "string", "tinytext": "string", "text": "string",
+	"mediumtext": "string", "longtext": "string", "enum": "string", "set": "string",
+	"binary": "binary", "varbinary": "binary", "tinyblob": "binary", "blob": "binary",
+	"mediumblob": "binary", "longblob": "binary", "bit": "binary",
+	"tinyint": "integer", "smallint": "integer", "mediumint": "integer", "int": "integer",
+	"integer": "integer", "bigint": "integer", "year": "integer",
+	"decimal": "decimal", "numeric": "decimal", "dec": "decimal", "fixed": "decimal",
+	"float": "float", "double": "float", "real": "float",
+	"date": "date", "datetime": "datetime", "timestamp": "datetime", "time": "time",
+	"json": "json", "geometry": "geometry",
+	"point": "geometry", "linestring": "geometry", "polygon": "geometry",
+	"multipoint": "geometry", "multilinestring": "geometry", "multipolygon": "geometry",
+	"geometrycollection": "geometry",
+}
+
+// baseTypeName reduces a type such as "VARCHAR(255)", "int unsigned" or
+// "UNSIGNED BIGINT" to its lower-case base name
+func baseTypeName(typeName string) string {
+	name := strings.ToLower(strings.TrimSpace(typeName))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "unsigned ")
+	name, _, _ = strings.Cut(name, " ")
+	return name
+}
+
+// typesCompatible reports whether an actual result column type satisfies
+// an expected one. Types match within their family; an expected type may
+// also name a family directly ("string", "integer"). Unknown types must
+// match by base name.
+func typesCompatible(expected, actual string) bool {
+	want, got := baseTypeName(expected), baseTypeName(actual)
+	if want == "" || want == got {
+		return true
+	}
+	family, known := typeFamilies[got]
+	if !known {
+		return false
+	}
+	return want == family || typeFamilies[want] == family
+}
+
+// compareResultSchema checks result columns against an expected list.
+// Names compare case-insensitively, as MySQL column names do. Unless
+// ignoreOrder is set, each expected column must be at its position; unless
+// allowExtra is set, every result column must be expected.
+func compareResultSchema(actual []protocol.ResultColumn, expected []protocol.ExpectedColumn, allowExtra, ignoreOrder bool) []protocol.SchemaMismatch {
+	mismatches := []protocol.SchemaMismatch{}
+	positions := make(map[string]int, len(actual))
+	for i, column := range actual {
+		key := strings.ToLower(column.Name)
+		if _, exists := positions[key]; !exists {
+			positions[key] = i
+		}
+	}
+
+	expectedNames := make(map[string]bool, len(expected))
+	for i, want := range expected {
+		expectedNames[strings.ToLower(want.Name)] = true
+		at, found := positions[strings.ToLower(want.Name)]
+		if !found {
+			mismatches = append(mismatches, protocol.SchemaMismatch{
+				Column: want.Name, Kind: protocol.MismatchMissing, Expected: want.Type,
+				Message: fmt.Sprintf("column %s is missing", want.Name),
+			})
+			continue
+		}
+		got := actual[at]
+		if !typesCompatible(want.Type, got.Type) {
+			mismatches = append(mismatches, protocol.SchemaMismatch{
+				Column: want.Name, Kind: protocol.MismatchType, Expected: want.Type, Actual: got.Type,
+				Message: fmt.Sprintf("column %s is %s, expected %s", want.Name, got.Type, want.Type),
+			})
+		}
+		if !ignoreOrder && at != i {
+			mismatches = append(mismatches, protocol.SchemaMismatch{
+				Column: want.Name, Kind: protocol.MismatchPosition,
+				Expected: fmt.Sprint(i + 1), Actual: fmt.Sprint(at + 1),
+				Message: fmt.Sprintf("column %s is at position %d, expected %d", want.Name, at+1, i+1),
+			})
+		}
+	}
+
+	if !allowExtra {
+		for _, column := range actual {
+			if !expectedNames[strings.ToLower(column.Name)] {
+				mismatches = append(mismatches, protocol.SchemaMismatch{
+					Column: column.Name, Kind: protocol.MismatchUnexpected, Actual: column.Type,
+					Message: fmt.Sprintf("column %s is not expected", column.Name),
+				})
+			}
+		}
+	}
+	return mismatches
+}
+
+// AssertResultSchema checks that the columns a SELECT returns match an
+// expected list of names and types, for data contracts and regression
+// checks. The query is wrapped to return no rows, so only its columns are
+// computed; types are the driver's type names.
+func (c *Connection) AssertResultSchema(ctx context.Context, sqlText string, expected []protocol.ExpectedColumn, allowExtra, ignoreOrder bool) (*protocol.SchemaAssertion, error) {
+	if kind := statementKind(sqlText); kind != "SELECT" && kind != "TABLE" {
+		return nil, fmt.Errorf("only SELECT statements have result columns (got %s)", kind)
+	}
+	if len(expected) == 0 {
+		return nil, fmt.Errorf("expected columns are required")
+	}
+	if err := c.checkStatement(sqlText); err != nil {
+		return nil, err
+	}
+
+	rows, release, err := c.queryWithKill(ctx, resultMetaQuery(sqlText))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer release()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	result := &protocol.SchemaAssertion{Columns: make([]protocol.ResultColumn, 0, len(types))}
+	for _, t := range types {
+		result.Columns = append(result.Columns, protocol.ResultColumn{
+			Name: t.Name(),
+			Type: strings.ToLower(t.DatabaseTypeName()),
+		})
+	}
+
+	result.Mismatches = compareResultSchema(result.Columns, expected, allowExtra, ignoreOrder)
+	result.Pass = len(result.Mismatches) == 0
+	return result, nil
+}
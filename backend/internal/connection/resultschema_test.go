@@ -0,0 +1,69 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestTypesCompatible(t *testing.T) {
+	tests := []struct {
+		expected, actual string
+		compatible       bool
+	}{
+		{"varchar(255)", "char", true},
+		{"VARCHAR", "text", true},
+		{"string", "varchar", true},
+		{"int unsigned", "unsigned bigint", true},
+		{"integer", "tinyint", true},
+		{"decimal(10,2)", "decimal", true},
+		{"datetime", "timestamp", true},
+		{"", "json", true},
+		{"int", "varchar", false},
+		{"date", "datetime", false},
+		{"varchar", "blob", false},
+		{"decimal", "double", false},
+	}
+	for _, tt := range tests {
+		if got := typesCompatible(tt.expected, tt.actual); got != tt.compatible {
+			t.Errorf("typesCompatible(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.compatible)
+		}
+	}
+}
+
+func TestCompareResultSchema(t *testing.T) {
+	actual := []protocol.ResultColumn{
+		{Name: "id", Type: "unsigned bigint"},
+		{Name: "Total", Type: "decimal"},
+		{Name: "name", Type: "varchar"},
+		{Name: "debug", Type: "json"},
+	}
+	expected := []protocol.ExpectedColumn{
+		{Name: "id", Type: "bigint"},
+		{Name: "name", Type: "string"},
+		{Name: "total", Type: "int"},
+		{Name: "created", Type: "datetime"},
+	}
+
+	mismatches := compareResultSchema(actual, expected, false, false)
+	want := []struct{ column, kind string }{
+		{"name", protocol.MismatchPosition},
+		{"total", protocol.MismatchType},
+		{"total", protocol.MismatchPosition},
+		{"created", protocol.MismatchMissing},
+		{"debug", protocol.MismatchUnexpected},
+	}
+	if len(mismatches) != len(want) {
+		t.Fatalf("Expected %d mismatches, got %+v", len(want), mismatches)
+	}
+	for i, w := range want {
+		if mismatches[i].Column != w.column || mismatches[i].Kind != w.kind {
+			t.Errorf("Mismatch %d = %+v, want %s of %s", i, mismatches[i], w.kind, w.column)
+		}
+	}
+
+	mismatches = compareResultSchema(actual, expected[:3], true, true)
+	if len(mismatches) != 1 || mismatches[0].Kind != protocol.MismatchType {
+		t.Errorf("Expected only the type mismatch with allowExtra and ignoreOrder, got %+v", mismatches)
+	}
+}
@@ -0,0 +1,116 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// rowCountBatchSize is the tables counted per UNION ALL statement
+const rowCountBatchSize = 25
+
+// rowCountQuery counts the rows of each table in one statement. Rows are
+// tagged with the table's index rather than its name so the result does not
+// depend on the connection's character set.
+func rowCountQuery(database string, tables []string) string {
+	parts := make([]string, len(tables))
+	for i, table := range tables {
+		parts[i] = fmt.Sprintf("SELECT %d, COUNT(*) FROM %s", i, qualifiedTable(database, table))
+	}
+	return strings.Join(parts, " UNION ALL ")
+}
+
+// GetExactRowCounts counts the rows of tables in a database with COUNT(*),
+// in batches of one UNION ALL statement each. With no tables given, every
+// base table is counted. A table that cannot be counted (dropped meanwhile,
+// no privilege) is reported in Errors instead of failing the others.
+// Cancelling ctx kills the running count.
+func (c *Connection) GetExactRowCounts(ctx context.Context, database string, tables []string) (*protocol.RowCounts, error) {
+	startTime := time.Now()
+
+	if len(tables) == 0 {
+		var err error
+		if tables, err = c.baseTables(ctx, database); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &protocol.RowCounts{
+		Database: database,
+		Counts:   make(map[string]int64, len(tables)),
+		Errors:   map[string]string{},
+	}
+	for start := 0; start < len(tables); start += rowCountBatchSize {
+		end := min(start+rowCountBatchSize, len(tables))
+		batch := tables[start:end]
+
+		err := c.countRows(ctx, database, batch, result.Counts)
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("row counts cancelled: %w", ctx.Err())
+		case len(batch) == 1:
+			result.Errors[batch[0]] = err.Error()
+		case isMissingObjectError(err) || isPermissionError(err):
+			// Count one at a time to find the tables that fail
+			for _, table := range batch {
+				if err := c.countRows(ctx, database, []string{table}, result.Counts); err != nil {
+					if ctx.Err() != nil {
+						return nil, fmt.Errorf("row counts cancelled: %w", ctx.Err())
+					}
+					result.Errors[table] = err.Error()
+				}
+			}
+		default:
+			return nil, fmt.Errorf("failed to count rows: %w", err)
+		}
+	}
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// countRows runs one rowCountQuery and stores the counts by table name
+func (c *Connection) countRows(ctx context.Context, database string, tables []string, counts map[string]int64) error {
+	rows, release, err := c.queryWithKill(ctx, rowCountQuery(database, tables))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for rows.Next() {
+		var index int
+		var count int64
+		if err := rows.Scan(&index, &count); err != nil {
+			return err
+		}
+		if index >= 0 && index < len(tables) {
+			counts[tables[index]] = count
+		}
+	}
+	return rows.Err()
+}
+
+// baseTables returns the names of a database's tables, leaving out views
+func (c *Connection) baseTables(ctx context.Context, database string) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
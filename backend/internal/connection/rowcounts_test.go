@@ -0,0 +1,11 @@
+package connection
+
+import "testing"
+
+func TestRowCountQuery(t *testing.T) {
+	got := rowCountQuery("shop", []string{"orders", "order`items"})
+	want := "SELECT 0, COUNT(*) FROM `shop`.`orders` UNION ALL SELECT 1, COUNT(*) FROM `shop`.`order``items`"
+	if got != want {
+		t.Errorf("rowCountQuery() = %s, want %s", got, want)
+	}
+}
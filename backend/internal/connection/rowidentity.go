@@ -0,0 +1,325 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// sqlToken is a top-level token of a statement. Words keep their spelling
+// in text and are upper-cased in upper; quoted identifiers are unquoted and
+// have an empty upper so they never match a keyword. A parenthesized group
+// is a single "(" token and a string literal a single "'" token.
+type sqlToken struct {
+	text  string
+	upper string
+	// identifier is set for quoted identifiers and words that are not
+	// numbers
+	identifier bool
+}
+
+// topLevelTokens splits a statement into sqlTokens, skipping comments and
+// collapsing anything inside parentheses
+func topLevelTokens(sqlText string) []sqlToken {
+	var tokens []sqlToken
+	s := sqlText
+	for len(s) > 0 {
+		ch := s[0]
+		switch {
+		case ch == '`':
+			rest := skipQuoted(s)
+			name := s[1 : len(s)-len(rest)]
+			name = strings.TrimSuffix(name, "`")
+			tokens = append(tokens, sqlToken{text: strings.ReplaceAll(name, "``", "`"), identifier: true})
+			s = rest
+		case ch == '\'' || ch == '"':
+			tokens = append(tokens, sqlToken{text: "'"})
+			s = skipQuoted(s)
+		case startsComment(s):
+			s = skipSpaceAndComments(s)
+		case ch == '(':
+			tokens = append(tokens, sqlToken{text: "("})
+			s = skipParenthesized(s)
+		case isWordByte(ch):
+			end := 1
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+			word := s[:end]
+			numeric := strings.TrimLeft(word, "0123456789") == ""
+			tokens = append(tokens, sqlToken{text: word, upper: strings.ToUpper(word), identifier: !numeric})
+			s = s[end:]
+		case unicode.IsSpace(rune(ch)):
+			s = s[1:]
+		default:
+			tokens = append(tokens, sqlToken{text: s[:1]})
+			s = s[1:]
+		}
+	}
+	return tokens
+}
+
+// skipParenthesized skips a parenthesized group, including nested groups
+// and quoted text inside it
+func skipParenthesized(s string) string {
+	depth := 0
+	for len(s) > 0 {
+		switch ch := s[0]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			s = skipQuoted(s)
+			continue
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth == 0 {
+				return s[1:]
+			}
+		}
+		s = s[1:]
+	}
+	return ""
+}
+
+// selectSource is the table a simple SELECT reads and how its columns are
+// named in the result
+type selectSource struct {
+	database string
+	table    string
+	// star is set when the select list includes * or t.*
+	star bool
+	// columns maps a selected column (lower-cased) to its result name
+	columns map[string]string
+}
+
+// resultName returns the result column name of a table column, or false if
+// it is not selected as a plain column
+func (s selectSource) resultName(column string) (string, bool) {
+	if name, ok := s.columns[strings.ToLower(column)]; ok {
+		return name, true
+	}
+	if s.star {
+		return column, true
+	}
+	return "", false
+}
+
+// rowCollapsingWords make a SELECT return rows that do not correspond to
+// single table rows, or read more than one table
+var rowCollapsingWords = map[string]bool{
+	"DISTINCT": true, "DISTINCTROW": true, "GROUP": true, "HAVING": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true, "JOIN": true,
+	"STRAIGHT_JOIN": true, "INTO": true,
+}
+
+// aggregateFunctions collapse all rows into one without GROUP BY
+var aggregateFunctions = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+	"GROUP_CONCAT": true, "JSON_ARRAYAGG": true, "JSON_OBJECTAGG": true,
+	"STD": true, "STDDEV": true, "STDDEV_POP": true, "STDDEV_SAMP": true,
+	"VARIANCE": true, "VAR_POP": true, "VAR_SAMP": true,
+	"BIT_AND": true, "BIT_OR": true, "BIT_XOR": true,
+}
+
+// tableClauseEnd lists the keywords that may follow the table of a simple
+// SELECT
+var tableClauseEnd = map[string]bool{
+	"WHERE": true, "ORDER": true, "LIMIT": true, "FOR": true, "LOCK": true,
+}
+
+// parseSimpleSelect recognizes a SELECT from exactly one table whose rows
+// map one-to-one to table rows. Otherwise it explains why not.
+func parseSimpleSelect(sqlText string) (selectSource, string) {
+	tokens := topLevelTokens(sqlText)
+	if len(tokens) == 0 || tokens[0].upper != "SELECT" {
+		return selectSource{}, "only SELECT results can be edited"
+	}
+
+	from := -1
+	for i, token := range tokens {
+		switch {
+		case rowCollapsingWords[token.upper]:
+			return selectSource{}, fmt.Sprintf("results of queries using %s cannot be edited", token.upper)
+		case aggregateFunctions[token.upper] && i+1 < len(tokens) && tokens[i+1].text == "(":
+			return selectSource{}, fmt.Sprintf("results of queries using %s() cannot be edited", token.upper)
+		case token.upper == "FROM" && from < 0:
+			from = i
+		}
+	}
+	if from < 0 {
+		return selectSource{}, "the query does not read a table"
+	}
+
+	source := selectSource{columns: make(map[string]string)}
+
+	// Table reference: name or database.name, an optional alias, and then
+	// nothing but the clauses of a single-table read
+	rest := tokens[from+1:]
+	if len(rest) == 0 || !rest[0].identifier {
+		return selectSource{}, "the query does not read a table"
+	}
+	source.table, rest = rest[0].text, rest[1:]
+	if len(rest) >= 2 && rest[0].text == "." && rest[1].identifier {
+		source.database, source.table, rest = source.table, rest[1].text, rest[2:]
+	}
+	if len(rest) > 0 && rest[0].upper == "AS" {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && rest[0].identifier && !tableClauseEnd[rest[0].upper] {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && !tableClauseEnd[rest[0].upper] && rest[0].text != ";" {
+		return selectSource{}, "results of queries reading more than one table cannot be edited"
+	}
+
+	for _, item := range splitTokens(tokens[1:from], ",") {
+		// Leading modifiers such as SQL_NO_CACHE belong to the first item
+		for len(item) > 0 && (item[0].upper == "ALL" || item[0].upper == "HIGH_PRIORITY" || strings.HasPrefix(item[0].upper, "SQL_")) {
+			item = item[1:]
+		}
+		addSelectItem(&source, item)
+	}
+	return source, ""
+}
+
+// addSelectItem records a select list item that is *, t.*, or a plain
+// column reference with an optional alias. Expressions are ignored.
+func addSelectItem(source *selectSource, item []sqlToken) {
+	// Qualifiers: t.col or db.t.col
+	for len(item) >= 3 && item[0].identifier && item[1].text == "." {
+		item = item[2:]
+	}
+	if len(item) == 1 && item[0].text == "*" {
+		source.star = true
+		return
+	}
+	if len(item) == 0 || !item[0].identifier {
+		return
+	}
+
+	column, alias := item[0].text, item[0].text
+	switch {
+	case len(item) == 1:
+	case len(item) == 2 && item[1].identifier:
+		alias = item[1].text
+	case len(item) == 3 && item[1].upper == "AS" && item[2].identifier:
+		alias = item[2].text
+	default:
+		return
+	}
+	key := strings.ToLower(column)
+	if _, ok := source.columns[key]; !ok {
+		source.columns[key] = alias
+	}
+}
+
+// splitTokens splits tokens at every separator token
+func splitTokens(tokens []sqlToken, separator string) [][]sqlToken {
+	var parts [][]sqlToken
+	start := 0
+	for i, token := range tokens {
+		if token.text == separator {
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// primaryKeyColumns returns a table's primary key columns in key order
+func (c *Connection) primaryKeyColumns(ctx context.Context, database, table string) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION`,
+		database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// addRowIdentity fills in the primary key values of each row when the
+// result comes from a simple single-table SELECT that selects the whole
+// key. Otherwise the result is marked read-only with the reason.
+func (c *Connection) addRowIdentity(ctx context.Context, sqlText string, result *protocol.QueryResult) {
+	readOnly := func(reason string) {
+		result.ReadOnly = true
+		result.ReadOnlyReason = reason
+	}
+
+	source, reason := parseSimpleSelect(sqlText)
+	if reason != "" {
+		readOnly(reason)
+		return
+	}
+	if source.database == "" && c.config != nil {
+		source.database = c.config.Database
+	}
+	if source.database == "" {
+		readOnly("the table's database is unknown; qualify the table name")
+		return
+	}
+
+	keyColumns, err := c.primaryKeyColumns(ctx, source.database, source.table)
+	if err != nil {
+		readOnly(fmt.Sprintf("could not read the primary key: %v", err))
+		return
+	}
+	if len(keyColumns) == 0 {
+		readOnly(fmt.Sprintf("%s.%s has no primary key", source.database, source.table))
+		return
+	}
+
+	names := result.Columns
+	if result.OriginalColumns != nil {
+		names = result.OriginalColumns
+	}
+	indexes := make([]int, len(keyColumns))
+	for k, column := range keyColumns {
+		name, ok := source.resultName(column)
+		if !ok {
+			readOnly(fmt.Sprintf("the primary key column %s is not selected", column))
+			return
+		}
+		indexes[k] = -1
+		for i, candidate := range names {
+			if !strings.EqualFold(candidate, name) {
+				continue
+			}
+			if indexes[k] >= 0 {
+				readOnly(fmt.Sprintf("the primary key column %s appears more than once", column))
+				return
+			}
+			indexes[k] = i
+		}
+		if indexes[k] < 0 {
+			readOnly(fmt.Sprintf("the primary key column %s is not selected", column))
+			return
+		}
+	}
+
+	result.EditTable = &protocol.TableRef{Database: source.database, Table: source.table}
+	result.RowKeyColumns = keyColumns
+	result.RowKeys = make([][]interface{}, len(result.Rows))
+	for r, row := range result.Rows {
+		key := make([]interface{}, len(indexes))
+		for k, i := range indexes {
+			key[k] = row[i]
+		}
+		result.RowKeys[r] = key
+	}
+}
@@ -0,0 +1,87 @@
+package connection
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestParseSimpleSelect(t *testing.T) {
+	tests := []struct {
+		sql      string
+		editable bool
+		database string
+		table    string
+	}{
+		{"SELECT * FROM users", true, "", "users"},
+		{"select id, name from `app`.`users` u where u.id > 5 order by name limit 10", true, "app", "users"},
+		{"SELECT SQL_NO_CACHE u.* FROM users AS u FOR UPDATE", true, "", "users"},
+		{"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders GROUP BY user_id)", true, "", "users"},
+		{"SELECT EXTRACT(YEAR FROM created) AS y, id FROM users;", true, "", "users"},
+		{"SELECT * FROM users u JOIN orders o ON o.user_id = u.id", false, "", ""},
+		{"SELECT * FROM users, orders", false, "", ""},
+		{"SELECT status, COUNT(*) FROM users GROUP BY status", false, "", ""},
+		{"SELECT COUNT(*) FROM users", false, "", ""},
+		{"SELECT DISTINCT name FROM users", false, "", ""},
+		{"SELECT * FROM (SELECT * FROM users) AS t", false, "", ""},
+		{"SELECT 1", false, "", ""},
+		{"UPDATE users SET name = 'x'", false, "", ""},
+	}
+	for _, tt := range tests {
+		source, reason := parseSimpleSelect(tt.sql)
+		if (reason == "") != tt.editable {
+			t.Errorf("parseSimpleSelect(%q) reason = %q, want editable %v", tt.sql, reason, tt.editable)
+			continue
+		}
+		if tt.editable && (source.database != tt.database || source.table != tt.table) {
+			t.Errorf("parseSimpleSelect(%q) = %s.%s, want %s.%s", tt.sql, source.database, source.table, tt.database, tt.table)
+		}
+	}
+}
+
+func TestSelectSourceResultName(t *testing.T) {
+	source, reason := parseSimpleSelect("SELECT name AS id, u.id AS user_id, id + 1 AS next FROM users u")
+	if reason != "" {
+		t.Fatalf("Unexpected reason: %s", reason)
+	}
+	if name, ok := source.resultName("ID"); !ok || name != "user_id" {
+		t.Errorf("resultName(ID) = %q, %v; want user_id", name, ok)
+	}
+	if name, ok := source.resultName("name"); !ok || name != "id" {
+		t.Errorf("resultName(name) = %q, %v; want id", name, ok)
+	}
+	if _, ok := source.resultName("email"); ok {
+		t.Error("Expected an unselected column to have no result name")
+	}
+}
+
+func TestExecuteQueryRowKeys(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	c.config = &protocol.ConnectionConfig{Database: "app"}
+	ctx := context.Background()
+
+	result, err := c.ExecuteQueryWithOptions(ctx, "SELECT n FROM seq_3", QueryOptions{RowKeys: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.ReadOnly {
+		t.Fatalf("Expected an editable result, got %s", result.ReadOnlyReason)
+	}
+	expected := [][]interface{}{{int64(1)}, {int64(2)}, {int64(3)}}
+	if !reflect.DeepEqual(result.RowKeys, expected) {
+		t.Errorf("RowKeys = %v, want %v", result.RowKeys, expected)
+	}
+	if result.EditTable == nil || result.EditTable.Database != "app" || result.EditTable.Table != "seq_3" {
+		t.Errorf("Unexpected edit table: %+v", result.EditTable)
+	}
+
+	result, err = c.ExecuteQueryWithOptions(ctx, "SELECT n AS other FROM seq_3", QueryOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.RowKeys != nil || result.ReadOnly {
+		t.Error("Expected no row identity unless requested")
+	}
+}
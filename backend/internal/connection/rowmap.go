@@ -0,0 +1,82 @@
+package connection
+
+import (
+	"database/sql"
+	"strconv"
+)
+
+// rowScanner scans rows into maps keyed by column name, for SHOW statements
+// whose column set varies between server flavors and versions
+type rowScanner struct {
+	columns []string
+	values  []interface{}
+	ptrs    []interface{}
+}
+
+func newRowScanner(rows *sql.Rows) (*rowScanner, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &rowScanner{
+		columns: columns,
+		values:  make([]interface{}, len(columns)),
+		ptrs:    make([]interface{}, len(columns)),
+	}
+	for i := range s.values {
+		s.ptrs[i] = &s.values[i]
+	}
+	return s, nil
+}
+
+// scan reads the current row. The returned map is only valid until the next
+// call because byte slices may be reused by the driver.
+func (s *rowScanner) scan(rows *sql.Rows) (map[string]interface{}, error) {
+	if err := rows.Scan(s.ptrs...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]interface{}, len(s.columns))
+	for i, name := range s.columns {
+		row[name] = s.values[i]
+	}
+	return row, nil
+}
+
+// asString converts a scanned value to a string, returning "" for NULL
+func asString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	}
+	return ""
+}
+
+// asInt64 converts a scanned numeric value to an int64, returning 0 for NULL
+// or non-numeric values
+func asInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case []byte:
+		n, _ := strconv.ParseInt(string(v), 10, 64)
+		return n
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
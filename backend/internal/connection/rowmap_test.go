@@ -0,0 +1,43 @@
+package connection
+
+import "testing"
+
+func TestAsString(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, ""},
+		{[]byte("InnoDB"), "InnoDB"},
+		{"MyISAM", "MyISAM"},
+		{int64(10), "10"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+	}
+
+	for _, tc := range testCases {
+		if got := asString(tc.value); got != tc.expected {
+			t.Errorf("asString(%#v): expected %q, got %q", tc.value, tc.expected, got)
+		}
+	}
+}
+
+func TestAsInt64(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		expected int64
+	}{
+		{nil, 0},
+		{int64(42), 42},
+		{uint64(42), 42},
+		{float64(42), 42},
+		{[]byte("16384"), 16384},
+		{"16384", 16384},
+		{[]byte("not a number"), 0},
+	}
+
+	for _, tc := range testCases {
+		if got := asInt64(tc.value); got != tc.expected {
+			t.Errorf("asInt64(%#v): expected %d, got %d", tc.value, tc.expected, got)
+		}
+	}
+}
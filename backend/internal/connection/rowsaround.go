@@ -0,0 +1,115 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultRowsAroundWindow = 10
+	maxRowsAroundWindow     = 500
+)
+
+// rowsAroundQueries builds the queries for GetRowsAround: up to window rows
+// before the key (newest first, so the LIMIT keeps the nearest), the key
+// itself and up to window rows after it, and whether the key exists. Keys
+// are compared as row constructors, (a, b) < (:k0, :k1), which MySQL
+// resolves with a range scan on the primary key.
+func rowsAroundQueries(database, table string, keyColumns []string, window int) (before, after, exists string) {
+	quoted := make([]string, len(keyColumns))
+	params := make([]string, len(keyColumns))
+	desc := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		quoted[i] = quoteIdentifier(column)
+		params[i] = fmt.Sprintf(":k%d", i)
+		desc[i] = quoted[i] + " DESC"
+	}
+	key := "(" + strings.Join(quoted, ", ") + ")"
+	value := "(" + strings.Join(params, ", ") + ")"
+	from := qualifiedTable(database, table)
+
+	before = fmt.Sprintf("SELECT * FROM %s WHERE %s < %s ORDER BY %s LIMIT %d",
+		from, key, value, strings.Join(desc, ", "), window)
+	after = fmt.Sprintf("SELECT * FROM %s WHERE %s >= %s ORDER BY %s LIMIT %d",
+		from, key, value, strings.Join(quoted, ", "), window+1)
+	exists = fmt.Sprintf("SELECT 1 FROM %s WHERE %s = %s LIMIT 1", from, key, value)
+	return before, after, exists
+}
+
+// GetRowsAround returns up to window rows on each side of the row with the
+// given primary key, in key order, so a grid can center on one record. key
+// holds one value per primary key column, in key order. When no row has
+// the key, the nearest rows on either side are returned and TargetIndex is
+// -1; InsertIndex is where the row would be.
+func (c *Connection) GetRowsAround(ctx context.Context, database, table string, key []interface{}, window int) (*protocol.RowsAround, error) {
+	if window <= 0 {
+		window = defaultRowsAroundWindow
+	}
+	if window > maxRowsAroundWindow {
+		window = maxRowsAroundWindow
+	}
+
+	keyColumns, err := c.primaryKeyColumns(ctx, database, table)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to read the primary key: %w", err)
+	}
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("%s.%s has no primary key", database, table)
+	}
+	if len(key) != len(keyColumns) {
+		return nil, fmt.Errorf("key has %d values but the primary key (%s) has %d columns",
+			len(key), strings.Join(keyColumns, ", "), len(keyColumns))
+	}
+
+	args := make(map[string]interface{}, len(key))
+	for i, value := range key {
+		args[fmt.Sprintf("k%d", i)] = value
+	}
+	// Replicas may lag each other; all three reads must see the same rows
+	opts := QueryOptions{NamedArgs: args, ForcePrimary: true}
+
+	beforeSQL, afterSQL, existsSQL := rowsAroundQueries(database, table, keyColumns, window)
+	target, err := c.ExecuteQueryWithOptions(ctx, existsSQL, opts)
+	if err != nil {
+		return nil, err
+	}
+	before, err := c.ExecuteQueryWithOptions(ctx, beforeSQL, opts)
+	if err != nil {
+		return nil, err
+	}
+	after, err := c.ExecuteQueryWithOptions(ctx, afterSQL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	found := len(target.Rows) > 0
+	if !found && len(after.Rows) > window {
+		after.Rows = after.Rows[:window]
+	}
+
+	result := &protocol.RowsAround{
+		QueryResult: *after,
+		KeyColumns:  keyColumns,
+		TargetIndex: -1,
+		InsertIndex: len(before.Rows),
+	}
+	rows := make([][]interface{}, 0, len(before.Rows)+len(after.Rows))
+	for i := len(before.Rows) - 1; i >= 0; i-- {
+		rows = append(rows, before.Rows[i])
+	}
+	rows = append(rows, after.Rows...)
+	result.Rows = rows
+	result.TotalRows = int64(len(rows))
+	result.RowsAffected = result.TotalRows
+	result.ExecutionTime = target.ExecutionTime + before.ExecutionTime + after.ExecutionTime
+	if found {
+		result.TargetIndex = len(before.Rows)
+	}
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRowsAroundQueries(t *testing.T) {
+	before, after, exists := rowsAroundQueries("shop", "order_items", []string{"order_id", "line"}, 5)
+
+	if want := "SELECT * FROM `shop`.`order_items` WHERE (`order_id`, `line`) < (:k0, :k1) ORDER BY `order_id` DESC, `line` DESC LIMIT 5"; before != want {
+		t.Errorf("before = %q, want %q", before, want)
+	}
+	if want := "SELECT * FROM `shop`.`order_items` WHERE (`order_id`, `line`) >= (:k0, :k1) ORDER BY `order_id`, `line` LIMIT 6"; after != want {
+		t.Errorf("after = %q, want %q", after, want)
+	}
+	if want := "SELECT 1 FROM `shop`.`order_items` WHERE (`order_id`, `line`) = (:k0, :k1) LIMIT 1"; exists != want {
+		t.Errorf("exists = %q, want %q", exists, want)
+	}
+}
+
+func TestGetRowsAroundChecksKeyLength(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	// The fake server reports a single primary key column, n
+	_, err := c.GetRowsAround(context.Background(), "shop", "t", []interface{}{1, 2}, 5)
+	if err == nil || !strings.Contains(err.Error(), "2 values but the primary key (n) has 1 columns") {
+		t.Errorf("Expected a key length error, got %v", err)
+	}
+}
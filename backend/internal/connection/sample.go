@@ -0,0 +1,46 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultSampleSize = 10
+	maxSampleSize     = 1000
+)
+
+// SampleTable returns up to n rows from a table. By default the first rows in
+// storage order are returned, which is cheap but not representative. With
+// random set, ORDER BY RAND() is used, which scans and sorts the whole table.
+func (c *Connection) SampleTable(ctx context.Context, database, table string, n int, random bool) (*protocol.SampleResult, error) {
+	if n <= 0 {
+		n = defaultSampleSize
+	}
+	if n > maxSampleSize {
+		n = maxSampleSize
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s", qualifiedTable(database, table))
+	strategy := "first"
+	note := "Rows are the first in storage order (usually primary key order) and may not be representative of the whole table."
+	if random {
+		query += " ORDER BY RAND()"
+		strategy = "random"
+		note = "Rows were chosen with ORDER BY RAND(), which reads and sorts the entire table and can be slow on large tables."
+	}
+	query += fmt.Sprintf(" LIMIT %d", n)
+
+	result, err := c.ExecuteQueryWithContext(ctx, query, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.SampleResult{
+		QueryResult: *result,
+		Strategy:    strategy,
+		Note:        note,
+	}, nil
+}
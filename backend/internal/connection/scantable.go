@@ -0,0 +1,198 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultScanBatchRows = 1000
+	maxScanBatchRows     = 10000
+)
+
+// scanTableQuery builds the query for one batch of ScanTable: up to
+// batchRows rows in primary key order, after the :k0, :k1... key when
+// afterKey is set
+func scanTableQuery(database, table string, keyColumns []string, afterKey bool, batchRows int) string {
+	quoted := make([]string, len(keyColumns))
+	params := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		quoted[i] = quoteIdentifier(column)
+		params[i] = fmt.Sprintf(":k%d", i)
+	}
+
+	where := ""
+	if afterKey {
+		where = fmt.Sprintf(" WHERE (%s) > (%s)", strings.Join(quoted, ", "), strings.Join(params, ", "))
+	}
+	return fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s LIMIT %d",
+		qualifiedTable(database, table), where, strings.Join(quoted, ", "), batchRows)
+}
+
+// scanKeyValue turns a client-supplied key value, as it appears in a
+// result, back into a query argument. binaryLength is the column's length
+// when it is BINARY or VARBINARY, and 0 otherwise. 16-byte values are shown
+// as UUIDs (see convertValue) and must be compared as the original bytes;
+// a column that can hold 36 bytes may store the UUID text itself, so its
+// values are left as they are.
+func scanKeyValue(value interface{}, binaryLength int64) interface{} {
+	s, ok := value.(string)
+	if !ok || binaryLength < 16 || binaryLength >= 36 || len(s) != 36 || strings.Count(s, "-") != 4 {
+		return value
+	}
+	if b, err := hex.DecodeString(strings.ReplaceAll(s, "-", "")); err == nil && len(b) == 16 {
+		return b
+	}
+	return value
+}
+
+// binaryKeyLengths returns the lengths of the BINARY and VARBINARY columns
+// among a table's key columns, by lower-cased column name
+func (c *Connection) binaryKeyLengths(ctx context.Context, database, table string) (map[string]int64, error) {
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT COLUMN_NAME, DATA_TYPE, CHARACTER_OCTET_LENGTH FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND DATA_TYPE IN ('binary', 'varbinary')`,
+		database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lengths := make(map[string]int64)
+	for rows.Next() {
+		var name, dataType string
+		var length sql.NullInt64
+		if err := rows.Scan(&name, &dataType, &length); err != nil {
+			return nil, err
+		}
+		lengths[strings.ToLower(name)] = length.Int64
+	}
+	return lengths, rows.Err()
+}
+
+// ScanTable reads a whole table in primary key order, batchRows rows at a
+// time, and passes each batch to emit with the key of its last row, as
+// shown in the rows, which can be passed back as afterKey. Every batch is a
+// separate keyset query seeking past the raw key of the previous batch's
+// last row, so no connection is held between batches and each row is read
+// exactly once even while rows are inserted or deleted; rows inserted
+// behind the scan are not seen. afterKey, when set, resumes a scan after
+// that key. emit is called at least once, so an empty table still reports
+// its columns; an error from it stops the scan. It returns the primary key
+// columns.
+func (c *Connection) ScanTable(ctx context.Context, database, table string, afterKey []interface{}, batchRows int, emit func(batch *protocol.QueryResult, lastKey []interface{}) error) ([]string, error) {
+	if batchRows <= 0 {
+		batchRows = defaultScanBatchRows
+	}
+	if batchRows > maxScanBatchRows {
+		batchRows = maxScanBatchRows
+	}
+
+	keyColumns, err := c.primaryKeyColumns(ctx, database, table)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to read the primary key: %w", err)
+	}
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("%s.%s has no primary key; tables can only be scanned in key order", database, table)
+	}
+	if afterKey != nil && len(afterKey) != len(keyColumns) {
+		return nil, fmt.Errorf("afterKey has %d values but the primary key (%s) has %d columns",
+			len(afterKey), strings.Join(keyColumns, ", "), len(keyColumns))
+	}
+
+	key := afterKey
+	if afterKey != nil {
+		lengths, err := c.binaryKeyLengths(ctx, database, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the primary key types: %w", err)
+		}
+		key = make([]interface{}, len(afterKey))
+		for i, value := range afterKey {
+			key[i] = scanKeyValue(value, lengths[strings.ToLower(keyColumns[i])])
+		}
+	}
+
+	var indexes []int
+	var lastRaw []interface{}
+	first := true
+	for {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("table scan cancelled: %w", ctx.Err())
+		}
+
+		// Batches must not move between replicas that lag each other
+		opts := QueryOptions{ForcePrimary: true, Label: "scanTable"}
+		opts.RawRow = func(row []interface{}) { lastRaw = row }
+		if key != nil {
+			opts.NamedArgs = make(map[string]interface{}, len(key))
+			for i, value := range key {
+				opts.NamedArgs[fmt.Sprintf("k%d", i)] = value
+			}
+		}
+		batch, err := c.ExecuteQueryWithOptions(ctx, scanTableQuery(database, table, keyColumns, key != nil, batchRows), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if indexes == nil {
+			if indexes, err = keyColumnIndexes(batch, keyColumns); err != nil {
+				return nil, err
+			}
+		}
+		// The next batch seeks with the values as scanned; the display
+		// values can differ from them, such as binary UUIDs
+		lastKey := afterKey
+		if len(batch.Rows) > 0 {
+			last := batch.Rows[len(batch.Rows)-1]
+			key = make([]interface{}, len(indexes))
+			lastKey = make([]interface{}, len(indexes))
+			for k, i := range indexes {
+				key[k] = lastRaw[i]
+				lastKey[k] = last[i]
+			}
+		}
+
+		// A table whose size is a multiple of batchRows ends with an empty
+		// batch; only an empty table sends one
+		if len(batch.Rows) == 0 && !first {
+			return keyColumns, nil
+		}
+		first = false
+		if err := emit(batch, lastKey); err != nil {
+			return nil, err
+		}
+		if len(batch.Rows) < batchRows {
+			return keyColumns, nil
+		}
+	}
+}
+
+// keyColumnIndexes finds the primary key columns among a result's columns
+func keyColumnIndexes(result *protocol.QueryResult, keyColumns []string) ([]int, error) {
+	names := result.Columns
+	if result.OriginalColumns != nil {
+		names = result.OriginalColumns
+	}
+	indexes := make([]int, len(keyColumns))
+	for k, column := range keyColumns {
+		indexes[k] = -1
+		for i, name := range names {
+			if strings.EqualFold(name, column) {
+				indexes[k] = i
+				break
+			}
+		}
+		if indexes[k] < 0 {
+			return nil, fmt.Errorf("the primary key column %s is missing from the result", column)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,159 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestScanTableQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		afterKey bool
+		expected string
+	}{
+		{"First batch", false, "SELECT * FROM `shop`.`order_items` ORDER BY `order_id`, `line` LIMIT 500"},
+		{"Later batch", true, "SELECT * FROM `shop`.`order_items` WHERE (`order_id`, `line`) > (:k0, :k1) ORDER BY `order_id`, `line` LIMIT 500"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := scanTableQuery("shop", "order_items", []string{"order_id", "line"}, tc.afterKey, 500); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestScanKeyValue(t *testing.T) {
+	uuid := "0f8fad5b-d9cb-469f-a165-70867728950e"
+	if got, ok := scanKeyValue(uuid, 16).([]byte); !ok || len(got) != 16 || got[0] != 0x0f {
+		t.Errorf("Expected a BINARY(16) UUID key to be decoded to 16 bytes, got %v", got)
+	}
+	if got := scanKeyValue(uuid, 36); got != uuid {
+		t.Errorf("Expected a VARBINARY(36) key to be unchanged, got %v", got)
+	}
+	if got := scanKeyValue(uuid, 0); got != uuid {
+		t.Errorf("Expected a CHAR key to be unchanged, got %v", got)
+	}
+	if got := scanKeyValue(int64(42), 0); got != int64(42) {
+		t.Errorf("Expected an INT key to be unchanged, got %v", got)
+	}
+}
+
+func TestScanTable(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	testCases := []struct {
+		name      string
+		table     string
+		afterKey  []interface{}
+		expected  []int
+		totalRows int64
+	}{
+		{"Partial last batch", "seq_7", nil, []int{3, 3, 1}, 7},
+		{"Exact multiple of the batch", "seq_6", nil, []int{3, 3}, 6},
+		{"Empty table", "seq_0", nil, []int{0}, 0},
+		{"Resumed after a key", "seq_7", []interface{}{int64(5)}, []int{2}, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sizes []int
+			var total int64
+			var next int64
+			if tc.afterKey != nil {
+				next = tc.afterKey[0].(int64)
+			}
+			keyColumns, err := c.ScanTable(context.Background(), "scan", tc.table, tc.afterKey, 3,
+				func(batch *protocol.QueryResult, lastKey []interface{}) error {
+					for _, row := range batch.Rows {
+						next++
+						if row[0] != next {
+							t.Errorf("Expected row %d, got %v", next, row[0])
+						}
+					}
+					if len(batch.Rows) > 0 && lastKey[0] != next {
+						t.Errorf("Expected last key %d, got %v", next, lastKey)
+					}
+					sizes = append(sizes, len(batch.Rows))
+					total += int64(len(batch.Rows))
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("ScanTable failed: %v", err)
+			}
+			if !reflect.DeepEqual(keyColumns, []string{"n"}) {
+				t.Errorf("Expected key columns [n], got %v", keyColumns)
+			}
+			if !reflect.DeepEqual(sizes, tc.expected) {
+				t.Errorf("Expected batches %v, got %v", tc.expected, sizes)
+			}
+			if total != tc.totalRows {
+				t.Errorf("Expected %d rows, got %d", tc.totalRows, total)
+			}
+		})
+	}
+}
+
+func TestScanTableTextUUIDKey(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	testCases := []struct {
+		name     string
+		afterKey []interface{}
+		expected []string
+	}{
+		{"Whole table", nil, fakeScanUUIDs},
+		{"Resumed after a key", []interface{}{fakeScanUUIDs[1]}, fakeScanUUIDs[2:]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			_, err := c.ScanTable(context.Background(), "scan", "uuids", tc.afterKey, 2,
+				func(batch *protocol.QueryResult, lastKey []interface{}) error {
+					if len(got) > len(fakeScanUUIDs) {
+						return errors.New("the scan did not move past its last key")
+					}
+					for _, row := range batch.Rows {
+						got = append(got, row[0].(string))
+					}
+					if len(batch.Rows) > 0 && lastKey[0] != got[len(got)-1] {
+						t.Errorf("Expected last key %s, got %v", got[len(got)-1], lastKey)
+					}
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("ScanTable failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected rows %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestScanTableStopsOnError(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	stop := errors.New("client went away")
+
+	batches := 0
+	_, err := c.ScanTable(context.Background(), "scan", "seq_10", nil, 3,
+		func(*protocol.QueryResult, []interface{}) error {
+			batches++
+			return stop
+		})
+	if !errors.Is(err, stop) || batches != 1 {
+		t.Errorf("Expected the scan to stop after the first batch, got %d batches and %v", batches, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ScanTable(ctx, "scan", "seq_10", nil, 3, func(*protocol.QueryResult, []interface{}) error { return nil }); err == nil {
+		t.Error("Expected a cancelled scan to fail")
+	}
+}
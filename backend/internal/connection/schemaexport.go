@@ -0,0 +1,279 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// autoIncrementOption matches the table option that carries the next
+// AUTO_INCREMENT value, which a structure-only export should not preserve
+var autoIncrementOption = regexp.MustCompile(`\s+AUTO_INCREMENT=\d+`)
+
+// schemaObject is a view, routine or trigger of a database
+type schemaObject struct {
+	kind string // "VIEW", "PROCEDURE", "FUNCTION" or "TRIGGER"
+	name string
+}
+
+// ExportSchema returns a script that recreates the structure of a database:
+// tables in foreign key dependency order, then views, routines and
+// triggers. Routine and trigger bodies are wrapped in DELIMITER ;; as in
+// mysqldump, so the script is meant for the mysql client. Tables are
+// created unqualified, so the script can be replayed into another database,
+// but view definitions name the original database as MySQL stores them.
+// AUTO_INCREMENT counters are not carried over. Objects the user may not
+// read are skipped and listed in Warnings.
+// Cancelling ctx stops the export between statements.
+func (c *Connection) ExportSchema(ctx context.Context, database string) (*protocol.SchemaExport, error) {
+	startTime := time.Now()
+
+	tables, views, err := c.tablesAndViews(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	// GetTableDependencyOrder reads every table, views included, when given
+	// none, which would export a views-only database's views twice
+	order := dependencyOrder(nil, nil, c.NormalizeIdentifier)
+	if len(tables) > 0 {
+		if order, err = c.GetTableDependencyOrder(ctx, database, tables); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &protocol.SchemaExport{
+		Database: database,
+		Cycles:   order.Cycles,
+		Warnings: []string{},
+	}
+	var script strings.Builder
+	fmt.Fprintf(&script, "-- Schema of %s, exported %s\n\n", quoteIdentifier(database), time.Now().UTC().Format(time.RFC3339))
+	// Tables in a foreign key cycle cannot all be created after the
+	// tables they reference
+	script.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n\n")
+
+	cancelled := func() error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("schema export cancelled: %w", ctx.Err())
+		}
+		return nil
+	}
+
+	for _, table := range order.InsertOrder {
+		if err := cancelled(); err != nil {
+			return nil, err
+		}
+		ddl, err := c.showCreate(ctx, "TABLE", database, table)
+		if err != nil {
+			if ctx.Err() == nil && (isPermissionError(err) || isMissingObjectError(err)) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("table %s skipped: %v", table, err))
+				continue
+			}
+			return nil, cancelledOr(ctx, err)
+		}
+		script.WriteString(schemaStatement("TABLE", table, ddl))
+		result.Tables++
+	}
+
+	objects, err := c.viewOrder(ctx, database, views)
+	if err != nil {
+		return nil, cancelledOr(ctx, err)
+	}
+	routines, err := c.schemaObjects(ctx,
+		"SELECT ROUTINE_TYPE, ROUTINE_NAME FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ? ORDER BY ROUTINE_TYPE, ROUTINE_NAME",
+		database)
+	if err != nil {
+		return nil, cancelledOr(ctx, err)
+	}
+	triggers, err := c.schemaObjects(ctx,
+		"SELECT 'TRIGGER', TRIGGER_NAME FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ? ORDER BY EVENT_OBJECT_TABLE, ACTION_ORDER",
+		database)
+	if err != nil {
+		return nil, cancelledOr(ctx, err)
+	}
+	objects = append(objects, routines...)
+	objects = append(objects, triggers...)
+
+	for _, object := range objects {
+		if err := cancelled(); err != nil {
+			return nil, err
+		}
+		ddl, err := c.showCreate(ctx, object.kind, database, object.name)
+		if err == nil && ddl == "" {
+			// SHOW CREATE returns NULL for routines of other definers
+			// without the privilege to see their body
+			err = fmt.Errorf("definition is not visible to the current user")
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s skipped: %v", strings.ToLower(object.kind), object.name, err))
+				continue
+			}
+			return nil, cancelledOr(ctx, err)
+		}
+
+		script.WriteString(schemaStatement(object.kind, object.name, ddl))
+		switch object.kind {
+		case "VIEW":
+			result.Views++
+		case "TRIGGER":
+			result.Triggers++
+		default:
+			result.Routines++
+		}
+	}
+
+	script.WriteString("SET FOREIGN_KEY_CHECKS = 1;\n")
+	result.Script = script.String()
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// schemaStatement formats one object of an exported schema: a DROP IF
+// EXISTS followed by its CREATE statement. Bodies of routines and triggers
+// contain semicolons, so they are wrapped in DELIMITER ;;.
+func schemaStatement(kind, name, ddl string) string {
+	drop := fmt.Sprintf("DROP %s IF EXISTS %s;\n", kind, quoteIdentifier(name))
+	switch kind {
+	case "TABLE":
+		return drop + autoIncrementOption.ReplaceAllString(ddl, "") + ";\n\n"
+	case "VIEW":
+		return drop + ddl + ";\n\n"
+	}
+	return drop + "DELIMITER ;;\n" + ddl + ";;\nDELIMITER ;\n\n"
+}
+
+// cancelledOr reports a cancellation instead of the error it caused
+func cancelledOr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("schema export cancelled: %w", ctx.Err())
+	}
+	return fmt.Errorf("failed to export schema: %w", err)
+}
+
+// tablesAndViews lists a database's base tables and views by name
+func (c *Connection) tablesAndViews(ctx context.Context, database string) (tables, views []string, err error) {
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME",
+		database)
+	if err != nil {
+		return nil, nil, cancelledOr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, tableType string
+		if err := rows.Scan(&name, &tableType); err != nil {
+			return nil, nil, err
+		}
+		if tableType == "VIEW" {
+			views = append(views, name)
+		} else {
+			tables = append(tables, name)
+		}
+	}
+	return tables, views, rows.Err()
+}
+
+// viewOrder orders views so each comes after the views it selects from.
+// Dependencies are found by looking for the other views' quoted names in
+// each definition, which MySQL always stores fully qualified and quoted.
+func (c *Connection) viewOrder(ctx context.Context, database string, views []string) ([]schemaObject, error) {
+	if len(views) == 0 {
+		return nil, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT TABLE_NAME, VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = ?",
+		database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := make(map[string]string, len(views))
+	for rows.Next() {
+		var name, definition string
+		if err := rows.Scan(&name, &definition); err != nil {
+			return nil, err
+		}
+		definitions[c.NormalizeIdentifier(name)] = definition
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var references [][2]string
+	for _, view := range views {
+		definition := definitions[c.NormalizeIdentifier(view)]
+		for _, other := range views {
+			if other != view && strings.Contains(definition, quoteIdentifier(other)) {
+				references = append(references, [2]string{c.NormalizeIdentifier(view), c.NormalizeIdentifier(other)})
+			}
+		}
+	}
+
+	objects := make([]schemaObject, 0, len(views))
+	for _, view := range dependencyOrder(views, references, c.NormalizeIdentifier).InsertOrder {
+		objects = append(objects, schemaObject{kind: "VIEW", name: view})
+	}
+	return objects, nil
+}
+
+// schemaObjects reads (kind, name) pairs with query
+func (c *Connection) schemaObjects(ctx context.Context, query string, args ...interface{}) ([]schemaObject, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []schemaObject
+	for rows.Next() {
+		var object schemaObject
+		if err := rows.Scan(&object.kind, &object.name); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+// showCreate returns the CREATE statement of a schema object from
+// SHOW CREATE <kind>. Columns are read by name since their number differs
+// per kind; the statement is "" when the server returns NULL.
+func (c *Connection) showCreate(ctx context.Context, kind, database, name string) (string, error) {
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf("SHOW CREATE %s %s", kind, qualifiedTable(database, name)))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%s %s not found", strings.ToLower(kind), name)
+	}
+	row, err := scanner.scan(rows)
+	if err != nil {
+		return "", err
+	}
+
+	column := map[string]string{
+		"TABLE":     "Create Table",
+		"VIEW":      "Create View",
+		"PROCEDURE": "Create Procedure",
+		"FUNCTION":  "Create Function",
+		"TRIGGER":   "SQL Original Statement",
+	}[kind]
+	return asString(row[column]), nil
+}
@@ -0,0 +1,52 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSchemaStatement(t *testing.T) {
+	tests := []struct {
+		kind, name, ddl, expected string
+	}{
+		{
+			"TABLE", "orders",
+			"CREATE TABLE `orders` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4",
+			"DROP TABLE IF EXISTS `orders`;\nCREATE TABLE `orders` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n\n",
+		},
+		{
+			"VIEW", "open_orders",
+			"CREATE VIEW `open_orders` AS select 1",
+			"DROP VIEW IF EXISTS `open_orders`;\nCREATE VIEW `open_orders` AS select 1;\n\n",
+		},
+		{
+			"PROCEDURE", "archive",
+			"CREATE PROCEDURE `archive`() BEGIN DELETE FROM t; END",
+			"DROP PROCEDURE IF EXISTS `archive`;\nDELIMITER ;;\nCREATE PROCEDURE `archive`() BEGIN DELETE FROM t; END;;\nDELIMITER ;\n\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := schemaStatement(tt.kind, tt.name, tt.ddl); got != tt.expected {
+			t.Errorf("schemaStatement(%s %s) =\n%s\nwant\n%s", tt.kind, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestExportSchemaViewsOnly(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	export, err := c.ExportSchema(context.Background(), "views_only")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if export.Tables != 0 || export.Views != 1 {
+		t.Errorf("Expected no tables and one view, got %d tables and %d views", export.Tables, export.Views)
+	}
+	if n := strings.Count(export.Script, "CREATE VIEW"); n != 1 {
+		t.Errorf("Expected the view to be created once, got %d times:\n%s", n, export.Script)
+	}
+	if export.Cycles == nil {
+		t.Error("Expected an empty cycle list, got nil")
+	}
+}
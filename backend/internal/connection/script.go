@@ -0,0 +1,154 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// ScriptOptions controls how RunScript executes a script
+type ScriptOptions struct {
+	// Transaction wraps the script in a transaction that is rolled back on
+	// failure or cancellation
+	Transaction bool
+	// DisableForeignKeyChecks turns foreign key checks off on the script's
+	// connection only, for loading dumps whose rows are not in dependency
+	// order. The previous setting is restored afterwards, even on failure.
+	DisableForeignKeyChecks bool
+}
+
+// RunScript executes a semicolon-separated script one statement at a time on
+// a single connection, calling progress after each statement. Cancelling ctx
+// kills the running statement and, in a transaction, rolls back.
+func (c *Connection) RunScript(ctx context.Context, script string, options ScriptOptions, progress func(protocol.ScriptProgress)) (_ *protocol.ScriptResult, err error) {
+	startTime := time.Now()
+
+	statements := splitStatements(script)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("script contains no statements")
+	}
+	for i, stmt := range statements {
+		if err := c.checkStatement(stmt); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		// Fail before anything runs rather than partway through
+		if err := c.checkPacketSize(stmt); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+
+	// The default query timeout bounds the whole script
+	ctx, finish := c.withDefaultTimeout(ctx, fmt.Sprintf("script of %d statements", len(statements)))
+	defer func() { err = finish(err) }()
+
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	// A session whose foreign key checks could not be restored is never
+	// returned to the pool
+	discard := false
+	defer func() {
+		if discard {
+			discardConn(conn)
+			return
+		}
+		releaseAfter(ctx, conn, statements...)
+	}()
+
+	stop := c.watchCancel(ctx, threadID)
+	defer stop()
+
+	if options.DisableForeignKeyChecks {
+		restore, err := disableForeignKeyChecks(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := restore(); err != nil {
+				log.Printf("Failed to restore foreign key checks, discarding connection: %v", err)
+				discard = true
+			}
+		}()
+	}
+
+	transaction := options.Transaction
+	if transaction {
+		if _, err := conn.ExecContext(ctx, "START TRANSACTION"); err != nil {
+			return nil, fmt.Errorf("failed to start transaction: %w", err)
+		}
+	}
+
+	result := &protocol.ScriptResult{Total: len(statements)}
+	for i, stmt := range statements {
+		res, err := conn.ExecContext(ctx, stmt)
+		if err != nil {
+			if transaction {
+				rollback(conn)
+			}
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("script cancelled at statement %d of %d: %w", i+1, len(statements), ctx.Err())
+			}
+			return nil, fmt.Errorf("statement %d of %d failed: %w", i+1, len(statements), err)
+		}
+
+		if affected, err := res.RowsAffected(); err == nil {
+			result.RowsAffected += affected
+		}
+		result.StatementsExecuted = i + 1
+
+		if progress != nil {
+			progress(protocol.ScriptProgress{
+				Statement:    i + 1,
+				Total:        len(statements),
+				RowsAffected: result.RowsAffected,
+			})
+		}
+	}
+
+	if transaction {
+		if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+			rollback(conn)
+			return nil, fmt.Errorf("failed to commit script: %w", err)
+		}
+	}
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// disableForeignKeyChecks turns off foreign key checks for the session and
+// returns a function that restores the previous setting. The restore uses
+// its own timeout so it also runs after ctx is cancelled.
+func disableForeignKeyChecks(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	var previous int
+	if err := conn.QueryRowContext(ctx, "SELECT @@SESSION.foreign_key_checks").Scan(&previous); err != nil {
+		return nil, fmt.Errorf("failed to read foreign_key_checks: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return nil, fmt.Errorf("failed to disable foreign key checks: %w", err)
+	}
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("SET FOREIGN_KEY_CHECKS = %d", previous))
+		return err
+	}, nil
+}
+
+// rollback rolls back the open transaction, even after cancellation. If the
+// driver already closed the connection on cancellation, the server rolls the
+// transaction back when the session ends.
+func rollback(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		log.Printf("Failed to roll back script transaction: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func foreignKeyChecks(t *testing.T, c *Connection) string {
+	t.Helper()
+	result, err := c.ExecuteQueryWithContext(context.Background(), "SELECT @@SESSION.foreign_key_checks", 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return fmt.Sprint(result.Rows[0][0])
+}
+
+func TestRunScriptDisableForeignKeyChecks(t *testing.T) {
+	// A single pooled connection makes every request reuse the script's session
+	c := newFakeSessionConnection(t, 1)
+	ctx := context.Background()
+
+	if _, err := c.RunScript(ctx, "INSERT INTO child VALUES (1)", ScriptOptions{}, nil); err == nil {
+		t.Fatal("Expected the foreign key to reject the row")
+	}
+
+	options := ScriptOptions{DisableForeignKeyChecks: true}
+	result, err := c.RunScript(ctx, "INSERT INTO child VALUES (1); INSERT INTO child VALUES (2)", options, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.StatementsExecuted != 2 {
+		t.Errorf("Expected 2 statements executed, got %d", result.StatementsExecuted)
+	}
+	if checks := foreignKeyChecks(t, c); checks != "1" {
+		t.Errorf("Expected foreign key checks restored, got %s", checks)
+	}
+	if opened := c.db.Stats().OpenConnections; opened != 1 {
+		t.Errorf("Expected the restored session to return to the pool, got %d open", opened)
+	}
+
+	if _, err := c.RunScript(ctx, "INSERT INTO child VALUES (3); FAIL", options, nil); err == nil {
+		t.Fatal("Expected the script to fail")
+	}
+	if checks := foreignKeyChecks(t, c); checks != "1" {
+		t.Errorf("Expected foreign key checks restored after a failure, got %s", checks)
+	}
+}
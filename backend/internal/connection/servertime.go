@@ -0,0 +1,41 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// serverTimeLayout matches CAST(NOW(6) AS CHAR)
+const serverTimeLayout = "2006-01-02 15:04:05.999999"
+
+// GetServerTime returns the server's clock and time zone settings along with
+// the skew from the backend's clock
+func (c *Connection) GetServerTime(ctx context.Context) (*protocol.ServerTime, error) {
+	var result protocol.ServerTime
+	var now, utc string
+
+	sent := time.Now()
+	// Cast to text so the session wall clock is not reinterpreted by the
+	// driver's loc setting
+	err := c.db.QueryRowContext(ctx, `SELECT CAST(NOW(6) AS CHAR), CAST(UTC_TIMESTAMP(6) AS CHAR),
+		@@time_zone, @@system_time_zone, TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), NOW())`,
+	).Scan(&now, &utc, &result.TimeZone, &result.SystemTimeZone, &result.UTCOffsetSeconds)
+	received := time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server time: %w", err)
+	}
+
+	result.ServerTime = formatTemporalString(now, "DATETIME")
+	result.UTCTime = formatTemporalString(utc, "DATETIME")
+
+	midpoint := sent.Add(received.Sub(sent) / 2).UTC()
+	result.BackendUTCTime = midpoint.Format(dateTimeLayout)
+	if serverUTC, err := time.Parse(serverTimeLayout, utc); err == nil {
+		result.SkewMilliseconds = serverUTC.Sub(midpoint).Milliseconds()
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,592 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// fakeSessionConnector opens fake driver connections that track a default
+// database per session, so tests can observe state leaking through the pool
+type fakeSessionConnector struct {
+	mu     sync.Mutex
+	nextID int64
+	// database is the initial default database, "app" if empty
+	database string
+	// kills are the thread IDs named by KILL QUERY statements
+	kills []int64
+}
+
+// killed reports whether a KILL QUERY was issued for thread id
+func (f *fakeSessionConnector) killed(id int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, kill := range f.kills {
+		if kill == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeSessionConnector) Connect(context.Context) (driver.Conn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	database := f.database
+	if database == "" {
+		database = "app"
+	}
+	return &fakeSessionConn{id: f.nextID, database: database, foreignKeyChecks: 1, connector: f}, nil
+}
+
+func (f *fakeSessionConnector) Driver() driver.Driver {
+	return fakeSessionDriver{}
+}
+
+type fakeSessionDriver struct{}
+
+func (fakeSessionDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type fakeSessionConn struct {
+	id               int64
+	database         string
+	foreignKeyChecks int64
+	connector        *fakeSessionConnector
+}
+
+func (c *fakeSessionConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *fakeSessionConn) Close() error {
+	return nil
+}
+
+func (c *fakeSessionConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *fakeSessionConn) ExecContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case query == "DO SLEEP(60)":
+		// Runs until cancelled
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case query == "FAIL":
+		return nil, errors.New("statement failed")
+	case query == "ALTER TABLE `shop`.`big` FORCE":
+		// Ignores ctx like a server that keeps rebuilding the table, and
+		// only stops when its thread is killed
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(5 * time.Millisecond) {
+			if c.connector.killed(c.id) {
+				return nil, errors.New("query execution was interrupted")
+			}
+		}
+		return nil, errors.New("statement was never killed")
+	case strings.HasPrefix(query, "KILL QUERY "):
+		var id int64
+		fmt.Sscanf(query, "KILL QUERY %d", &id)
+		c.connector.mu.Lock()
+		c.connector.kills = append(c.connector.kills, id)
+		c.connector.mu.Unlock()
+		return driver.RowsAffected(0), nil
+	case (strings.HasPrefix(query, "INSERT INTO child") || strings.HasPrefix(query, "INSERT INTO `fk`.")) && c.foreignKeyChecks == 1:
+		// The parent row never exists
+		return nil, errors.New("cannot add or update a child row: a foreign key constraint fails")
+	}
+	c.apply(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeSessionConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch query {
+	case "SELECT SLEEP(60)":
+		// Runs until cancelled
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case "EXPLAIN EXTENDED SELECT SLEEP(60)":
+		// Ignores ctx like a server that keeps running the statement, and
+		// only stops when its thread is killed
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(5 * time.Millisecond) {
+			if c.connector.killed(c.id) {
+				return nil, errors.New("query execution was interrupted")
+			}
+		}
+		return nil, errors.New("query was never killed")
+	case "SELECT CONNECTION_ID()":
+		return &fakeSessionRows{columns: []string{"CONNECTION_ID()"}, data: [][]driver.Value{{c.id}}}, nil
+	case "SELECT DATABASE()":
+		return &fakeSessionRows{columns: []string{"DATABASE()"}, data: [][]driver.Value{{c.database}}}, nil
+	case "SELECT @@SESSION.foreign_key_checks":
+		return &fakeSessionRows{columns: []string{"@@SESSION.foreign_key_checks"}, data: [][]driver.Value{{c.foreignKeyChecks}}}, nil
+	case "SHOW TABLE STATUS FROM `restricted`":
+		return nil, &mysql.MySQLError{Number: 1142, Message: "SHOW command denied to user"}
+	case "SHOW FULL TABLES FROM `restricted`":
+		return &fakeSessionRows{
+			columns: []string{"Tables_in_restricted", "Table_type"},
+			data:    [][]driver.Value{{"orders", "BASE TABLE"}, {"order_totals", "VIEW"}},
+		}, nil
+	case infoSchemaTablesQuery:
+		created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		return &fakeSessionRows{
+			columns: make([]string, 15),
+			data: [][]driver.Value{
+				{"orders", "BASE TABLE", "InnoDB", "Dynamic", int64(120), int64(136), int64(16384), int64(0), int64(0), int64(121),
+					created, nil, "utf8mb4_0900_ai_ci", "", "Customer orders"},
+				{"order_totals", "VIEW", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "VIEW"},
+			},
+		}, nil
+	case fmt.Sprintf(infoSchemaColumnsQuery, "'' AS GENERATION_EXPRESSION"):
+		return &fakeSessionRows{
+			columns: make([]string, 17),
+			data: [][]driver.Value{
+				{"order_totals", "total", int64(1), nil, "YES", "decimal", "decimal(12,2)", nil, int64(12), int64(2), nil, nil, nil, "", "", "", ""},
+				{"orders", "id", int64(1), nil, "NO", "int", "int", nil, int64(10), int64(0), nil, nil, nil, "PRI", "auto_increment", "", ""},
+				{"orders", "note", int64(2), "none", "YES", "varchar", "varchar(50)", int64(50), nil, nil, nil, "utf8mb4", "utf8mb4_0900_ai_ci", "", "", "", ""},
+			},
+		}, nil
+	case "SELECT set_values":
+		return &fakeSessionRows{
+			columns: []string{"perms"},
+			types:   []string{"SET"},
+			data:    [][]driver.Value{{[]byte("read,write")}, {[]byte("")}, {nil}},
+		}, nil
+	case "SELECT CURRENT_USER()":
+		return &fakeSessionRows{columns: []string{"CURRENT_USER()"}, data: [][]driver.Value{{"app@%"}}}, nil
+	case "SHOW GRANTS":
+		return &fakeSessionRows{columns: []string{"Grants"}, data: [][]driver.Value{
+			{"GRANT USAGE ON *.* TO `app`@`%`"},
+			{"GRANT `writer`@`%` TO `app`@`%`"},
+		}}, nil
+	case "SHOW GRANTS FOR CURRENT_USER() USING `writer`@`%`":
+		return &fakeSessionRows{columns: []string{"Grants"}, data: [][]driver.Value{
+			{"GRANT USAGE ON *.* TO `app`@`%`"},
+			{"GRANT SELECT, INSERT ON `shop`.* TO `app`@`%`"},
+			{"GRANT `writer`@`%` TO `app`@`%`"},
+		}}, nil
+	case partitionsQuery:
+		return &fakeSessionRows{
+			columns: make([]string, 11),
+			data:    [][]driver.Value{{"p2023", nil, int64(1), "RANGE", nil, "year(created)", "2024", int64(10), int64(16384), int64(0), ""}},
+		}, nil
+	case longTransactionsQuery:
+		started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		return &fakeSessionRows{
+			columns: make([]string, 13),
+			data: [][]driver.Value{
+				{"4211", int64(17), "RUNNING", started, int64(5400), nil, nil, int64(3), int64(2), int64(1), "app", "10.0.0.5:51234", "shop"},
+			},
+		}, nil
+	case historyListLengthQuery:
+		return nil, &mysql.MySQLError{Number: 1227, Message: "Access denied; you need the PROCESS privilege"}
+	case "SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME":
+		// Schema export of `views_only`, which has a single view
+		return &fakeSessionRows{columns: []string{"TABLE_NAME", "TABLE_TYPE"}, data: [][]driver.Value{{"order_totals", "VIEW"}}}, nil
+	case "SHOW TABLE STATUS FROM `views_only`":
+		return &fakeSessionRows{columns: []string{"Name", "Comment"}, data: [][]driver.Value{{"order_totals", "VIEW"}}}, nil
+	case "SELECT TABLE_NAME, VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = ?":
+		return &fakeSessionRows{columns: []string{"TABLE_NAME", "VIEW_DEFINITION"}, data: [][]driver.Value{{"order_totals", "select 1"}}}, nil
+	case "SHOW CREATE TABLE `views_only`.`order_totals`", "SHOW CREATE VIEW `views_only`.`order_totals`":
+		// MySQL answers SHOW CREATE TABLE for a view with the view
+		return &fakeSessionRows{
+			columns: []string{"View", "Create View"},
+			data:    [][]driver.Value{{"order_totals", "CREATE VIEW `order_totals` AS select 1"}},
+		}, nil
+	case "SELECT unsigned_max":
+		// Prepared statements return large BIGINT UNSIGNED values as text
+		return &fakeSessionRows{
+			columns: []string{"text", "prepared", "small"},
+			types:   []string{"UNSIGNED BIGINT", "UNSIGNED BIGINT", "UNSIGNED BIGINT"},
+			data:    [][]driver.Value{{uint64(18446744073709551615), []byte("18446744073709551615"), int64(7)}},
+		}, nil
+	}
+
+	// Every table's primary key is its n column
+	if strings.HasPrefix(query, "SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE") {
+		return &fakeSessionRows{columns: []string{"COLUMN_NAME"}, data: [][]driver.Value{{"n"}}}, nil
+	}
+
+	// "SELECT n FROM seq_N" returns the rows 1 to N
+	var n int
+	if _, err := fmt.Sscanf(query, "SELECT n FROM seq_%d", &n); err == nil {
+		rows := &fakeSessionRows{columns: []string{"n"}}
+		for i := 1; i <= n; i++ {
+			rows.data = append(rows.data, []driver.Value{int64(i)})
+		}
+		return rows, nil
+	}
+
+	// `scan`.`uuids` is keyed by UUIDs stored as text in a VARBINARY(36)
+	// column, which are shown as they are
+	if strings.HasPrefix(query, "SELECT COLUMN_NAME, DATA_TYPE, CHARACTER_OCTET_LENGTH FROM information_schema.COLUMNS") {
+		rows := &fakeSessionRows{columns: []string{"COLUMN_NAME", "DATA_TYPE", "CHARACTER_OCTET_LENGTH"}}
+		if args[1].Value == "uuids" {
+			rows.data = [][]driver.Value{{"n", "varbinary", int64(36)}}
+		}
+		return rows, nil
+	}
+	if strings.HasPrefix(query, "SELECT * FROM `scan`.`uuids`") {
+		var after string
+		if len(args) > 0 {
+			switch v := args[0].Value.(type) {
+			case []byte:
+				after = string(v)
+			case string:
+				after = v
+			}
+		}
+		var limit int
+		fmt.Sscanf(query[strings.LastIndex(query, "LIMIT"):], "LIMIT %d", &limit)
+		rows := &fakeSessionRows{columns: []string{"n", "label"}, types: []string{"VARBINARY", "VARCHAR"}}
+		for i, id := range fakeScanUUIDs {
+			if (len(args) == 0 || id > after) && len(rows.data) < limit {
+				rows.data = append(rows.data, []driver.Value{[]byte(id), fmt.Sprintf("row %d", i+1)})
+			}
+		}
+		return rows, nil
+	}
+
+	// Keyset batches of ScanTable over `scan`.`seq_N`, keyed by n
+	var after, limit int64
+	if _, err := fmt.Sscanf(query, "SELECT * FROM `scan`.`seq_%d`", &n); err == nil {
+		if len(args) > 0 {
+			after = args[0].Value.(int64)
+		}
+		fmt.Sscanf(query[strings.LastIndex(query, "LIMIT"):], "LIMIT %d", &limit)
+		rows := &fakeSessionRows{columns: []string{"n", "label"}}
+		for i := after + 1; i <= int64(n) && int64(len(rows.data)) < limit; i++ {
+			rows.data = append(rows.data, []driver.Value{i, fmt.Sprintf("row %d", i)})
+		}
+		return rows, nil
+	}
+
+	c.apply(query)
+	return &fakeSessionRows{}, nil
+}
+
+func (c *fakeSessionConn) apply(query string) {
+	if name, ok := strings.CutPrefix(query, "USE "); ok {
+		c.database = name
+	}
+	fmt.Sscanf(query, "SET FOREIGN_KEY_CHECKS = %d", &c.foreignKeyChecks)
+}
+
+// fakeScanUUIDs are the keys of `scan`.`uuids`, in order
+var fakeScanUUIDs = []string{
+	"0f8fad5b-d9cb-469f-a165-70867728950e",
+	"1b4e28ba-2fa1-11d2-883f-0016d3cca427",
+	"6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"7c9e6679-7425-40de-944b-e07fc1f90ae7",
+	"f47ac10b-58cc-4372-a567-0e02b2c3d479",
+}
+
+type fakeSessionRows struct {
+	columns []string
+	// types are the database type names of columns, if set
+	types []string
+	data  [][]driver.Value
+}
+
+func (r *fakeSessionRows) ColumnTypeDatabaseTypeName(index int) string {
+	if r.types == nil {
+		return ""
+	}
+	return r.types[index]
+}
+
+func (r *fakeSessionRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeSessionRows) Close() error {
+	return nil
+}
+
+func (r *fakeSessionRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
+
+func newFakeSessionConnection(t *testing.T, maxOpen int) *Connection {
+	t.Helper()
+	return newFakeSessionConnectionFrom(t, &fakeSessionConnector{}, maxOpen)
+}
+
+// newFakeSessionConnectionFrom is newFakeSessionConnection with a connector the
+// test can inspect
+func newFakeSessionConnectionFrom(t *testing.T, connector *fakeSessionConnector, maxOpen int) *Connection {
+	t.Helper()
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(maxOpen)
+	t.Cleanup(func() { db.Close() })
+	return &Connection{db: db}
+}
+
+func currentDatabase(t *testing.T, c *Connection) string {
+	t.Helper()
+	result, err := c.ExecuteQueryWithContext(context.Background(), "SELECT DATABASE()", 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+	}
+	return fmt.Sprint(result.Rows[0][0])
+}
+
+func TestSessionStateDoesNotLeakIntoPool(t *testing.T) {
+	// A single pooled connection guarantees the next request would reuse it
+	c := newFakeSessionConnection(t, 1)
+	ctx := context.Background()
+
+	if _, err := c.ExecuteQueryWithContext(ctx, "USE other", 0, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if db := currentDatabase(t, c); db != "app" {
+		t.Errorf("USE from a query leaked into the pool: database is %q", db)
+	}
+
+	if _, err := c.RunScript(ctx, "SELECT 1; USE other", ScriptOptions{}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if db := currentDatabase(t, c); db != "app" {
+		t.Errorf("USE from a script leaked into the pool: database is %q", db)
+	}
+}
+
+func TestConcurrentRequestsDoNotShareSessionState(t *testing.T) {
+	c := newFakeSessionConnection(t, 4)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := c.ExecuteQueryWithContext(ctx, fmt.Sprintf("USE db_%d", i), 0, 0); err != nil {
+				errs <- err
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			result, err := c.ExecuteQueryWithContext(ctx, "SELECT DATABASE()", 0, 0)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if db := fmt.Sprint(result.Rows[0][0]); db != "app" {
+				errs <- fmt.Errorf("request saw another request's database %q", db)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestPoolSafeStatementsKeepConnection(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.ExecuteQueryWithContext(ctx, "SELECT 1", 0, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if closed := c.db.Stats().MaxIdleClosed + c.db.Stats().MaxLifetimeClosed; closed != 0 {
+		t.Errorf("Expected no connections closed, got %d", closed)
+	}
+	if opened := c.db.Stats().OpenConnections; opened != 1 {
+		t.Errorf("Expected the pooled connection to be reused, got %d open", opened)
+	}
+}
+
+func TestListTablesFallsBackWhenStatusDenied(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	tables, err := c.ListTablesContext(context.Background(), "restricted")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tables) != 2 || tables[0].Name != "orders" || tables[1].Name != "order_totals" {
+		t.Fatalf("Unexpected tables: %+v", tables)
+	}
+	for _, table := range tables {
+		if !table.StatsUnavailable {
+			t.Errorf("Expected StatsUnavailable for %s", table.Name)
+		}
+	}
+}
+
+func TestGetCurrentDatabaseIgnoresUseFromOtherRequests(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	ctx := context.Background()
+
+	if _, err := c.ExecuteQueryWithContext(ctx, "USE other", 0, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	current, err := c.GetCurrentDatabase(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !current.Selected || current.Database != "app" {
+		t.Errorf("Expected the configured database app, got %+v", current)
+	}
+}
+
+func TestDefaultQueryTimeout(t *testing.T) {
+	c := newFakeSessionConnection(t, 2)
+	c.config = &protocol.ConnectionConfig{ID: "conn1", DefaultQueryTimeoutSeconds: 1}
+
+	start := time.Now()
+	_, err := c.ExecuteQueryWithContext(context.Background(), "SELECT SLEEP(60)", 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "default timeout of 1s") {
+		t.Fatalf("Expected the default timeout to stop the query, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the query to stop after about 1s, took %s", elapsed)
+	}
+
+	// A caller's cancellation is reported as such
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.ExecuteQueryWithContext(ctx, "SELECT SLEEP(60)", 0, 0)
+	if err == nil || strings.Contains(err.Error(), "default timeout") {
+		t.Errorf("Expected a plain cancellation, got %v", err)
+	}
+
+	// Cursors, scripts and profiling are bounded too
+	others := map[string]func() error{
+		"cursor": func() error {
+			_, err := c.OpenCursor("SELECT SLEEP(60)", nil)
+			return err
+		},
+		"script": func() error {
+			_, err := c.RunScript(context.Background(), "DO SLEEP(60);", ScriptOptions{}, nil)
+			return err
+		},
+		"profile": func() error {
+			_, err := c.ProfileQuery(context.Background(), "SELECT SLEEP(60)")
+			return err
+		},
+	}
+	for name, run := range others {
+		start := time.Now()
+		if err := run(); err == nil || !strings.Contains(err.Error(), "default timeout of 1s") {
+			t.Errorf("%s: expected the default timeout to stop it, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("%s: expected it to stop after about 1s, took %s", name, elapsed)
+		}
+	}
+}
+
+func TestGetInformationSchema(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	schema, err := c.GetInformationSchema(context.Background(), "shop")
+	if err != nil {
+		t.Fatalf("GetInformationSchema() error = %v", err)
+	}
+	if len(schema.Tables) != 2 {
+		t.Fatalf("Expected 2 tables, got %d", len(schema.Tables))
+	}
+
+	orders := schema.Tables[0]
+	if orders.Name != "orders" || orders.Engine != "InnoDB" || orders.Rows == nil || *orders.Rows != 120 ||
+		orders.CreateTime != "2024-03-01T12:00:00" || orders.UpdateTime != "" || orders.Comment != "Customer orders" {
+		t.Errorf("Unexpected orders table: %+v", orders)
+	}
+	if len(orders.Columns) != 2 || orders.Columns[1].Name != "note" {
+		t.Fatalf("Expected the columns of orders in order, got %+v", orders.Columns)
+	}
+	note := orders.Columns[1]
+	if note.Default == nil || *note.Default != "none" || !note.Nullable || note.CharacterMaximumLength == nil ||
+		*note.CharacterMaximumLength != 50 || note.NumericPrecision != nil || note.Collation != "utf8mb4_0900_ai_ci" {
+		t.Errorf("Unexpected note column: %+v", note)
+	}
+	if id := orders.Columns[0]; id.Default != nil || id.Nullable || id.Key != "PRI" {
+		t.Errorf("Unexpected id column: %+v", id)
+	}
+
+	view := schema.Tables[1]
+	if view.Type != "VIEW" || view.Rows != nil || view.DataLength != nil || len(view.Columns) != 1 {
+		t.Errorf("Expected sizes of a view to be NULL, got %+v", view)
+	}
+}
+
+func TestGetLongTransactions(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+
+	result, err := c.GetLongTransactions(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Available || result.MinSeconds != defaultLongTransactionSeconds {
+		t.Errorf("Expected an available result with the default threshold, got %+v", result)
+	}
+	// A history list length the user cannot read is left out
+	if result.HistoryListLength != nil {
+		t.Errorf("Expected no history list length, got %d", *result.HistoryListLength)
+	}
+	if len(result.Transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(result.Transactions))
+	}
+	trx := result.Transactions[0]
+	if trx.ID != "4211" || trx.ThreadID != 17 || trx.AgeSeconds != 5400 || trx.RowsModified != 2 ||
+		trx.User != "app" || trx.Database != "shop" || trx.Started != "2024-03-01T12:00:00" {
+		t.Errorf("Unexpected transaction: %+v", trx)
+	}
+}
+
+func TestGetPrivilegesExpandsRoles(t *testing.T) {
+	c := newFakeSessionConnection(t, 1)
+	c.version = parseServerVersion("8.0.35")
+
+	info, err := c.GetPrivileges(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !info.RolesExpanded || len(info.Roles) != 1 || len(info.Grants) != 2 {
+		t.Fatalf("Expected the role's grants to be included, got %+v", info)
+	}
+	// INSERT only comes from the role
+	if info.ReadOnly == nil || *info.ReadOnly {
+		t.Errorf("Expected a writable user, got readOnly %v", info.ReadOnly)
+	}
+	if got := c.CheckPrivilege(info, "INSERT", "shop", "orders"); !got.Granted || got.Message != "" {
+		t.Errorf("Expected INSERT through the role, got %+v", got)
+	}
+
+	// MariaDB has no USING, so privileges from roles stay unknown
+	c.version = parseServerVersion("10.11.6-MariaDB")
+	info, err = c.GetPrivileges(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.RolesExpanded || info.ReadOnly != nil {
+		t.Errorf("Expected readOnly to be unknown, got %+v", info)
+	}
+}
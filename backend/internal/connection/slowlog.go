@@ -0,0 +1,99 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultSlowQueryLimit = 50
+	maxSlowQueryLimit     = 1000
+	defaultSlowQueryHours = 24
+)
+
+// TIME_TO_SEC keeps the microseconds of query_time and lock_time
+const slowLogQuery = `SELECT start_time, user_host, TIME_TO_SEC(query_time), TIME_TO_SEC(lock_time),
+	rows_sent, rows_examined, db, CONVERT(sql_text USING utf8mb4)
+	FROM mysql.slow_log
+	WHERE start_time >= NOW() - INTERVAL ? HOUR
+	ORDER BY query_time DESC
+	LIMIT ?`
+
+// slowLogUnavailableMessage explains why mysql.slow_log has nothing to read,
+// or returns "" when the log is enabled and written to the table
+func slowLogUnavailableMessage(enabled bool, logOutput string) string {
+	if !enabled {
+		return "The slow query log is disabled (slow_query_log = OFF)"
+	}
+	for _, output := range strings.Split(logOutput, ",") {
+		if strings.EqualFold(strings.TrimSpace(output), "TABLE") {
+			return ""
+		}
+	}
+	return fmt.Sprintf("The slow query log is written to a file (log_output = %s); set log_output to include TABLE to read it here", logOutput)
+}
+
+// GetSlowQueries returns the slowest queries logged to mysql.slow_log in the
+// last hours, slowest first. When the log is disabled, written only to a
+// file, or not readable by the current user, Available is false and Message
+// explains why.
+func (c *Connection) GetSlowQueries(ctx context.Context, limit, hours int) (*protocol.SlowQueryLog, error) {
+	if limit <= 0 {
+		limit = defaultSlowQueryLimit
+	}
+	if limit > maxSlowQueryLimit {
+		limit = maxSlowQueryLimit
+	}
+	if hours <= 0 {
+		hours = defaultSlowQueryHours
+	}
+
+	result := &protocol.SlowQueryLog{Queries: []protocol.SlowQuery{}}
+
+	var enabled bool
+	var logOutput string
+	if err := c.db.QueryRowContext(ctx, "SELECT @@slow_query_log, @@log_output, @@long_query_time").
+		Scan(&enabled, &logOutput, &result.LongQueryTime); err != nil {
+		return nil, fmt.Errorf("failed to read slow log settings: %w", err)
+	}
+	if message := slowLogUnavailableMessage(enabled, logOutput); message != "" {
+		result.Message = message
+		return result, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, slowLogQuery, hours, limit)
+	if err != nil {
+		if isPermissionError(err) {
+			result.Message = "The current user is not allowed to read mysql.slow_log"
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to read slow log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q protocol.SlowQuery
+		var started sql.NullTime
+		var database, sqlText sql.NullString
+		if err := rows.Scan(&started, &q.UserHost, &q.QueryTime, &q.LockTime,
+			&q.RowsSent, &q.RowsExamined, &database, &sqlText); err != nil {
+			return nil, fmt.Errorf("failed to scan slow log: %w", err)
+		}
+		if started.Valid {
+			q.StartTime = started.Time.Format(dateTimeLayout)
+		}
+		q.Database = database.String
+		q.SQL = sqlText.String
+		result.Queries = append(result.Queries, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read slow log: %w", err)
+	}
+
+	result.Available = true
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package connection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlowLogUnavailableMessage(t *testing.T) {
+	testCases := []struct {
+		enabled   bool
+		logOutput string
+		available bool
+		contains  string
+	}{
+		{true, "TABLE", true, ""},
+		{true, "FILE,TABLE", true, ""},
+		{true, "file, table", true, ""},
+		{true, "FILE", false, "written to a file"},
+		{true, "NONE", false, "log_output = NONE"},
+		{false, "TABLE", false, "disabled"},
+	}
+
+	for _, tc := range testCases {
+		message := slowLogUnavailableMessage(tc.enabled, tc.logOutput)
+		if (message == "") != tc.available {
+			t.Errorf("enabled=%v log_output=%q: unexpected message %q", tc.enabled, tc.logOutput, message)
+		}
+		if !strings.Contains(message, tc.contains) {
+			t.Errorf("enabled=%v log_output=%q: expected message to contain %q, got %q", tc.enabled, tc.logOutput, tc.contains, message)
+		}
+	}
+}
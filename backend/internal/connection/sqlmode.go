@@ -0,0 +1,45 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// sqlModePattern matches a comma-separated list of sql_mode flags
+var sqlModePattern = regexp.MustCompile(`^[A-Za-z0-9_]*(,[A-Za-z0-9_]+)*$`)
+
+// parseSQLMode splits an sql_mode value into its flags
+func parseSQLMode(mode string) []string {
+	flags := []string{}
+	for _, flag := range strings.Split(mode, ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			flags = append(flags, strings.ToUpper(flag))
+		}
+	}
+	return flags
+}
+
+// isStrictMode reports whether flags reject invalid or truncated values
+// instead of adjusting them with a warning
+func isStrictMode(flags []string) bool {
+	return containsKeyword(flags, "STRICT_TRANS_TABLES") || containsKeyword(flags, "STRICT_ALL_TABLES")
+}
+
+// GetSQLMode returns the session and global sql_mode. The session value is
+// the one queries on this connection run with: the server default or
+// ConnectionConfig.SQLMode.
+func (c *Connection) GetSQLMode(ctx context.Context) (*protocol.SQLMode, error) {
+	var result protocol.SQLMode
+	if err := c.db.QueryRowContext(ctx, "SELECT @@SESSION.sql_mode, @@GLOBAL.sql_mode").Scan(&result.Session, &result.Global); err != nil {
+		return nil, fmt.Errorf("failed to read sql_mode: %w", err)
+	}
+
+	result.SessionFlags = parseSQLMode(result.Session)
+	result.GlobalFlags = parseSQLMode(result.Global)
+	result.Strict = isStrictMode(result.SessionFlags)
+	return &result, nil
+}
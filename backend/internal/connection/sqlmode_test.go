@@ -0,0 +1,24 @@
+package connection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSQLMode(t *testing.T) {
+	flags := parseSQLMode("ONLY_FULL_GROUP_BY,strict_trans_tables, NO_ZERO_DATE")
+	want := []string{"ONLY_FULL_GROUP_BY", "STRICT_TRANS_TABLES", "NO_ZERO_DATE"}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("parseSQLMode() = %v, want %v", flags, want)
+	}
+	if !isStrictMode(flags) {
+		t.Error("Expected STRICT_TRANS_TABLES to be strict")
+	}
+
+	if flags := parseSQLMode(""); len(flags) != 0 {
+		t.Errorf("Expected no flags for an empty mode, got %v", flags)
+	}
+	if isStrictMode(parseSQLMode("ANSI_QUOTES")) {
+		t.Error("Expected ANSI_QUOTES alone not to be strict")
+	}
+}
@@ -0,0 +1,66 @@
+package connection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteIdentifier quotes a MySQL identifier with backticks, escaping any
+// embedded backticks
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteString returns a single-quoted MySQL string literal. Quotes are
+// doubled and backslashes escaped; under NO_BACKSLASH_ESCAPES a backslash
+// would then be stored twice, but the literal can never be broken out of.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// qualifiedTable returns a quoted `database`.`table` reference
+func qualifiedTable(database, table string) string {
+	if database == "" {
+		return quoteIdentifier(table)
+	}
+	return quoteIdentifier(database) + "." + quoteIdentifier(table)
+}
+
+// normalizeIdentifier lowercases a name when lower_case_table_names is
+// non-zero, matching how the server compares database and table names
+func normalizeIdentifier(name string, lowerCaseTableNames int) string {
+	if lowerCaseTableNames == 0 {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+// uniqueColumnNames renames repeated result column names ("id", "id" becomes
+// "id", "id_2") so each column can be addressed by name. The driver does not
+// expose a column's originating table, so the source of a duplicate cannot be
+// reported; suffixing is the only disambiguation available. It reports
+// whether any name was changed.
+func uniqueColumnNames(columns []string) ([]string, bool) {
+	seen := make(map[string]bool, len(columns))
+	for _, name := range columns {
+		seen[name] = true
+	}
+
+	unique := make([]string, len(columns))
+	used := make(map[string]bool, len(columns))
+	renamed := false
+	for i, name := range columns {
+		candidate := name
+		// Skip suffixes taken by other columns, e.g. an explicit "id_2"
+		for n := 2; used[candidate] || (candidate != name && seen[candidate]); n++ {
+			candidate = fmt.Sprintf("%s_%d", name, n)
+		}
+		if candidate != name {
+			renamed = true
+		}
+		used[candidate] = true
+		unique[i] = candidate
+	}
+	return unique, renamed
+}
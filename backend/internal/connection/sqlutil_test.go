@@ -0,0 +1,93 @@
+package connection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Simple name", "users", "`users`"},
+		{"Name with space", "order items", "`order items`"},
+		{"Embedded backtick", "we`ird", "`we``ird`"},
+		{"Injection attempt", "users`; DROP TABLE x; --", "`users``; DROP TABLE x; --`"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := quoteIdentifier(tc.input); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestQualifiedTable(t *testing.T) {
+	if got := qualifiedTable("shop", "users"); got != "`shop`.`users`" {
+		t.Errorf("Unexpected qualified name: %s", got)
+	}
+	if got := qualifiedTable("", "users"); got != "`users`" {
+		t.Errorf("Unexpected unqualified name: %s", got)
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	testCases := []struct {
+		name                string
+		input               string
+		lowerCaseTableNames int
+		expected            string
+	}{
+		{"Case-sensitive server keeps case", "Users", 0, "Users"},
+		{"Stored lowercase", "Users", 1, "users"},
+		{"Stored as given, compared lowercase", "Users", 2, "users"},
+		{"Already lowercase", "orders", 1, "orders"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := normalizeIdentifier(tc.input, tc.lowerCaseTableNames); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+
+	// Users and users resolve to the same key only on case-insensitive servers
+	if normalizeIdentifier("Users", 0) == normalizeIdentifier("users", 0) {
+		t.Error("Case-sensitive servers must keep Users and users distinct")
+	}
+	if normalizeIdentifier("Users", 1) != normalizeIdentifier("users", 1) {
+		t.Error("Case-insensitive servers must resolve Users and users consistently")
+	}
+}
+
+func TestUniqueColumnNames(t *testing.T) {
+	testCases := []struct {
+		name     string
+		columns  []string
+		expected []string
+		renamed  bool
+	}{
+		{"No duplicates", []string{"id", "name"}, []string{"id", "name"}, false},
+		{"Join on id", []string{"id", "name", "id"}, []string{"id", "name", "id_2"}, true},
+		{"Three duplicates", []string{"id", "id", "id"}, []string{"id", "id_2", "id_3"}, true},
+		{"Suffix already taken", []string{"id", "id_2", "id"}, []string{"id", "id_2", "id_3"}, true},
+		{"Suffix taken later", []string{"id", "id", "id_2"}, []string{"id", "id_3", "id_2"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, renamed := uniqueColumnNames(tc.columns)
+			if renamed != tc.renamed {
+				t.Errorf("Expected renamed=%v, got %v", tc.renamed, renamed)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package connection
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// leadingKeyword returns the first keyword of a statement in upper case,
+// skipping leading whitespace, comments and opening parentheses
+func leadingKeyword(sqlText string) string {
+	s := skipSpaceAndComments(sqlText)
+	for strings.HasPrefix(s, "(") {
+		s = skipSpaceAndComments(s[1:])
+	}
+
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return strings.ToUpper(s[:end])
+}
+
+// startsComment reports whether s starts with a comment, or with the */
+// that closes an executable comment
+func startsComment(s string) bool {
+	return strings.HasPrefix(s, "#") || strings.HasPrefix(s, "/*") || strings.HasPrefix(s, "*/") ||
+		(strings.HasPrefix(s, "--") && (len(s) == 2 || unicode.IsSpace(rune(s[2]))))
+}
+
+// skipSpaceAndComments strips leading whitespace and --, # and /* */
+// comments. MySQL runs the contents of executable comments (/*! ... */,
+// optionally with a version, and MariaDB's /*M! ... */), so only their
+// markers are stripped and the contents are read as code.
+func skipSpaceAndComments(s string) string {
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		switch {
+		case strings.HasPrefix(s, "--") && (len(s) == 2 || unicode.IsSpace(rune(s[2]))):
+			s = skipLine(s)
+		case strings.HasPrefix(s, "#"):
+			s = skipLine(s)
+		case strings.HasPrefix(s, "/*!"), strings.HasPrefix(s, "/*M!"):
+			s = s[strings.IndexByte(s, '!')+1:]
+			s = strings.TrimLeft(s, "0123456789")
+		case strings.HasPrefix(s, "*/"):
+			s = s[2:]
+		case strings.HasPrefix(s, "/*"):
+			end := strings.Index(s[2:], "*/")
+			if end < 0 {
+				return ""
+			}
+			s = s[end+4:]
+		default:
+			return s
+		}
+	}
+}
+
+func skipLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return ""
+}
+
+// topLevelWords returns the upper-cased words of a statement that are outside
+// string literals, quoted identifiers, comments and parentheses
+func topLevelWords(sqlText string) []string {
+	var words []string
+	depth := 0
+	s := sqlText
+	for len(s) > 0 {
+		ch := s[0]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			s = skipQuoted(s)
+		case startsComment(s):
+			s = skipSpaceAndComments(s)
+		case ch == '(':
+			depth++
+			s = s[1:]
+		case ch == ')':
+			depth--
+			s = s[1:]
+		case isWordByte(ch):
+			end := 1
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+			if depth == 0 {
+				words = append(words, strings.ToUpper(s[:end]))
+			}
+			s = s[end:]
+		default:
+			s = s[1:]
+		}
+	}
+	return words
+}
+
+// skipQuoted skips a quoted string or identifier, honoring doubled quotes
+// and backslash escapes in string literals
+func skipQuoted(s string) string {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return s[i+1:]
+		}
+	}
+	return ""
+}
+
+func isWordByte(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= '0' && ch <= '9') ||
+		(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= 0x80
+}
+
+// statementKind returns the statement's main keyword, looking past a leading
+// WITH clause so "WITH x AS (...) DELETE ..." is reported as DELETE
+func statementKind(sqlText string) string {
+	keyword := leadingKeyword(sqlText)
+	if keyword != "WITH" {
+		return keyword
+	}
+	for _, word := range topLevelWords(sqlText) {
+		switch word {
+		case "SELECT", "UPDATE", "DELETE", "INSERT", "REPLACE", "TABLE":
+			return word
+		}
+	}
+	return keyword
+}
+
+// checkSafeUpdate rejects UPDATE and DELETE statements that lack a top-level
+// WHERE or LIMIT. A WHERE inside a subquery or string does not count.
+func checkSafeUpdate(sqlText string) error {
+	kind := statementKind(sqlText)
+	if kind != "UPDATE" && kind != "DELETE" {
+		return nil
+	}
+	for _, word := range topLevelWords(sqlText) {
+		if word == "WHERE" || word == "LIMIT" {
+			return nil
+		}
+	}
+	return fmt.Errorf("safe updates: %s without a WHERE or LIMIT clause is not allowed on this connection", kind)
+}
+
+// schemaChangingStatements are statements that alter database metadata
+var schemaChangingStatements = map[string]bool{
+	"CREATE":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"RENAME":   true,
+	"TRUNCATE": true,
+}
+
+// IsSchemaChange reports whether a statement is DDL that may invalidate
+// cached metadata
+func IsSchemaChange(sqlText string) bool {
+	return schemaChangingStatements[leadingKeyword(sqlText)]
+}
+
+// sessionStatements are statements that leave state behind on the session
+// that runs them: default database, variables, transactions, locks, prepared
+// statements and open handlers
+var sessionStatements = map[string]bool{
+	"USE":       true,
+	"SET":       true,
+	"BEGIN":     true,
+	"START":     true,
+	"SAVEPOINT": true,
+	"LOCK":      true,
+	"XA":        true,
+	"PREPARE":   true,
+	"HANDLER":   true,
+	"CALL":      true,
+}
+
+// changesSessionState reports whether a statement may change session state
+// that must not be inherited by the pool's next user. It errs on the side of
+// yes; the cost of a false positive is one reconnect.
+func changesSessionState(sqlText string) bool {
+	if sessionStatements[leadingKeyword(sqlText)] {
+		return true
+	}
+	// User variable assignment, e.g. SELECT @n := @n + 1
+	if strings.Contains(sqlText, ":=") {
+		return true
+	}
+	isSelect := statementKind(sqlText) == "SELECT"
+	for _, word := range topLevelWords(sqlText) {
+		switch {
+		case word == "TEMPORARY", word == "GET_LOCK":
+			// CREATE TEMPORARY TABLE and named locks
+			return true
+		case word == "INTO" && isSelect:
+			// SELECT ... INTO @var
+			return true
+		}
+	}
+	return false
+}
+
+// splitStatements splits a script on top-level semicolons, ignoring
+// semicolons inside strings, quoted identifiers and comments. Empty and
+// comment-only statements are dropped. DELIMITER directives are not supported.
+func splitStatements(script string) []string {
+	var statements []string
+	add := func(stmt string) {
+		if skipSpaceAndComments(stmt) != "" {
+			statements = append(statements, strings.TrimSpace(stmt))
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(script); {
+		rest := script[i:]
+		ch := rest[0]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			i = len(script) - len(skipQuoted(rest))
+		case startsComment(rest):
+			i = len(script) - len(skipSpaceAndComments(rest))
+		case ch == ';':
+			add(script[start:i])
+			i++
+			start = i
+		default:
+			i++
+		}
+	}
+	add(script[start:])
+
+	return statements
+}
+
+// explainableStatements are the statements EXPLAIN accepts
+var explainableStatements = map[string]bool{
+	"SELECT":  true,
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"REPLACE": true,
+	"TABLE":   true,
+	"WITH":    true,
+}
+
+// isExplainable reports whether EXPLAIN can be run against a statement
+func isExplainable(sqlText string) bool {
+	return explainableStatements[leadingKeyword(sqlText)]
+}
+
+// checkStatement applies the connection's statement restrictions before a
+// query is sent to the server
+func (c *Connection) checkStatement(sqlText string) error {
+	if c.config == nil {
+		return nil
+	}
+	if err := checkStatementPolicy(sqlText, c.config.AllowedStatements, c.config.BlockedStatements); err != nil {
+		return err
+	}
+	if c.config.SafeUpdates {
+		if err := checkSafeUpdate(sqlText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStatementAs applies checkStatement and also checks the statement
+// policy as if sqlText were a kind statement, for statements that do the
+// work of another kind, such as ALTER TABLE ... DROP PARTITION
+func (c *Connection) checkStatementAs(sqlText, kind string) error {
+	if err := c.checkStatement(sqlText); err != nil {
+		return err
+	}
+	if c.config == nil {
+		return nil
+	}
+	return checkStatementPolicy(kind, c.config.AllowedStatements, c.config.BlockedStatements)
+}
+
+// checkStatementPolicy enforces allow and block lists of leading keywords.
+// Both the leading keyword and, for WITH statements, the main statement
+// keyword are checked, so "WITH ... DELETE" cannot bypass a DELETE block.
+// A statement whose leading keyword cannot be read is rejected, since
+// neither list can be checked against it.
+func checkStatementPolicy(sqlText string, allowed, blocked []string) error {
+	if len(allowed) == 0 && len(blocked) == 0 {
+		return nil
+	}
+
+	keywords := []string{leadingKeyword(sqlText)}
+	if keywords[0] == "" {
+		return fmt.Errorf("statements without a recognizable leading keyword are not allowed on this connection")
+	}
+	if kind := statementKind(sqlText); kind != keywords[0] {
+		keywords = append(keywords, kind)
+	}
+
+	for _, keyword := range keywords {
+		if containsKeyword(blocked, keyword) {
+			return fmt.Errorf("%s statements are blocked on this connection", keyword)
+		}
+	}
+	if len(allowed) > 0 {
+		for _, keyword := range keywords {
+			if !containsKeyword(allowed, keyword) {
+				return fmt.Errorf("%s statements are not allowed on this connection (allowed: %s)",
+					keyword, strings.Join(allowed, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// containsKeyword reports whether list contains keyword, ignoring case
+func containsKeyword(list []string, keyword string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), keyword) {
+			return true
+		}
+	}
+	return false
+}
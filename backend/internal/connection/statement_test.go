@@ -0,0 +1,240 @@
+package connection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLeadingKeyword(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		expected string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"  select * from t", "SELECT"},
+		{"\n\tUpdate t SET a = 1", "UPDATE"},
+		{"-- comment\nDELETE FROM t", "DELETE"},
+		{"# comment\nINSERT INTO t VALUES (1)", "INSERT"},
+		{"/* multi\nline */ WITH x AS (SELECT 1) SELECT * FROM x", "WITH"},
+		{"/*!40101 SET NAMES utf8 */; SELECT 1", "SET"},
+		{"/*! DELETE FROM users */", "DELETE"},
+		{"/*M!100100 DROP TABLE t */", "DROP"},
+		{"(/*!50000 SELECT 1 */)", "SELECT"},
+		{"(SELECT 1) UNION (SELECT 2)", "SELECT"},
+		{"CREATE TABLE t (id INT)", "CREATE"},
+		{"", ""},
+		{"/* unterminated", ""},
+		{"--not a comment", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := leadingKeyword(tc.sql); got != tc.expected {
+			t.Errorf("leadingKeyword(%q): expected %q, got %q", tc.sql, tc.expected, got)
+		}
+	}
+}
+
+func TestIsExplainable(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		expected bool
+	}{
+		{"SELECT * FROM orders", true},
+		{"update orders set status = 'x'", true},
+		{"WITH a AS (SELECT 1) SELECT * FROM a", true},
+		{"ALTER TABLE orders ADD COLUMN x INT", false},
+		{"SHOW PROCESSLIST", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isExplainable(tc.sql); got != tc.expected {
+			t.Errorf("isExplainable(%q): expected %v, got %v", tc.sql, tc.expected, got)
+		}
+	}
+}
+
+func TestTopLevelWords(t *testing.T) {
+	got := topLevelWords("UPDATE t SET a = 'where' /* WHERE */ -- where\n, b = (SELECT x FROM y WHERE z) # where")
+	for _, word := range got {
+		if word == "WHERE" {
+			t.Errorf("WHERE inside a string, comment or subquery leaked to top level: %v", got)
+		}
+	}
+	if len(got) == 0 || got[0] != "UPDATE" {
+		t.Errorf("Expected UPDATE first, got %v", got)
+	}
+}
+
+func TestCheckSafeUpdate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		sql     string
+		allowed bool
+	}{
+		{"Select", "SELECT * FROM orders", true},
+		{"Update with WHERE", "UPDATE orders SET status = 'x' WHERE id = 1", true},
+		{"Update with LIMIT", "UPDATE orders SET status = 'x' LIMIT 10", true},
+		{"Delete with WHERE", "delete from orders where id in (1, 2)", true},
+		{"Multi-table delete with WHERE", "DELETE o FROM orders o JOIN users u ON u.id = o.user_id WHERE u.banned = 1", true},
+		{"Update without WHERE", "UPDATE orders SET status = 'x'", false},
+		{"Delete without WHERE", "DELETE FROM orders", false},
+		{"WHERE in string literal", "UPDATE orders SET note = 'fix WHERE clause'", false},
+		{"WHERE in escaped string", `UPDATE orders SET note = 'it\'s WHERE'`, false},
+		{"WHERE in subquery", "UPDATE orders SET total = (SELECT SUM(x) FROM items WHERE items.o = 1)", false},
+		{"WHERE in comment", "DELETE FROM orders /* WHERE id = 1 */", false},
+		{"WHERE in quoted identifier", "DELETE FROM `where`", false},
+		{"CTE delete without WHERE", "WITH old AS (SELECT id FROM orders WHERE y < 2000) DELETE FROM orders", false},
+		{"Leading comment", "-- cleanup\nDELETE FROM orders", false},
+		{"Executable comment", "/*! DELETE FROM users */", false},
+		{"Versioned executable comment", "/*!50000 UPDATE users SET admin = 1 */", false},
+		{"Executable comment with WHERE", "/*!50000 DELETE FROM users WHERE id = 1 */", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSafeUpdate(tc.sql)
+			if tc.allowed && err != nil {
+				t.Errorf("Expected statement to be allowed, got %v", err)
+			}
+			if !tc.allowed && err == nil {
+				t.Error("Expected statement to be rejected")
+			}
+		})
+	}
+}
+
+func TestIsSchemaChange(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		expected bool
+	}{
+		{"CREATE TABLE t (id INT)", true},
+		{"alter table t add column x int", true},
+		{"/* cleanup */ DROP TABLE t", true},
+		{"RENAME TABLE a TO b", true},
+		{"TRUNCATE TABLE t", true},
+		{"/*!50000 DROP TABLE users */", true},
+		{"SELECT * FROM created", false},
+		{"UPDATE t SET a = 'DROP'", false},
+	}
+
+	for _, tc := range testCases {
+		if got := IsSchemaChange(tc.sql); got != tc.expected {
+			t.Errorf("IsSchemaChange(%q): expected %v, got %v", tc.sql, tc.expected, got)
+		}
+	}
+}
+
+func TestChangesSessionState(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		expected bool
+	}{
+		{"USE shop", true},
+		{"set @total = 0", true},
+		{"SET SESSION sql_mode = ''", true},
+		{"START TRANSACTION", true},
+		{"begin", true},
+		{"LOCK TABLES orders READ", true},
+		{"CREATE TEMPORARY TABLE tmp (id INT)", true},
+		{"SELECT COUNT(*) INTO @n FROM orders", true},
+		{"SELECT @n := @n + 1 FROM orders", true},
+		{"SELECT GET_LOCK('job', 10)", true},
+		{"CALL refresh_totals()", true},
+		{"SELECT * FROM orders", false},
+		{"SELECT 'USE shop' AS text", false},
+		{"INSERT INTO orders VALUES (1)", false},
+		{"CREATE TABLE temporary_orders (id INT)", false},
+		{"UPDATE orders SET status = 'into'", false},
+	}
+
+	for _, tc := range testCases {
+		if got := changesSessionState(tc.sql); got != tc.expected {
+			t.Errorf("changesSessionState(%q): expected %v, got %v", tc.sql, tc.expected, got)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- seed data
+INSERT INTO t VALUES (1, 'a;b');
+UPDATE t SET v = "x;" WHERE id = 1; /* trailing; comment */
+# only a comment;
+DELETE FROM ` + "`odd;name`" + ` WHERE id = 2
+`
+	got := splitStatements(script)
+	expected := []string{
+		"-- seed data\nINSERT INTO t VALUES (1, 'a;b')",
+		`UPDATE t SET v = "x;" WHERE id = 1`,
+		"/* trailing; comment */\n# only a comment;\nDELETE FROM `odd;name` WHERE id = 2",
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d statements, got %d: %q", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Statement %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestSplitStatementsEmpty(t *testing.T) {
+	for _, script := range []string{"", ";;", "  -- nothing here\n", "/* a */;"} {
+		if got := splitStatements(script); len(got) != 0 {
+			t.Errorf("splitStatements(%q): expected no statements, got %q", script, got)
+		}
+	}
+}
+
+func TestCheckStatementPolicy(t *testing.T) {
+	readOnly := []string{"SELECT", "show", "EXPLAIN", "WITH"}
+	blocked := []string{"DROP", "TRUNCATE", "DELETE"}
+
+	testCases := []struct {
+		name    string
+		sql     string
+		allowed []string
+		blocked []string
+		ok      bool
+	}{
+		{"No policy", "DROP DATABASE shop", nil, nil, true},
+		{"Allowed select", "SELECT 1", readOnly, nil, true},
+		{"Allowed case-insensitive", "show tables", readOnly, nil, true},
+		{"Not in allowlist", "UPDATE t SET a = 1 WHERE id = 1", readOnly, nil, false},
+		{"CTE delete not in allowlist", "WITH x AS (SELECT 1) DELETE FROM t WHERE id = 1", readOnly, nil, false},
+		{"CTE select in allowlist", "WITH x AS (SELECT 1) SELECT * FROM x", readOnly, nil, true},
+		{"Blocked drop", "/* oops */ DROP TABLE t", nil, blocked, false},
+		{"Blocked truncate", "truncate t", nil, blocked, false},
+		{"Blocked CTE delete", "WITH x AS (SELECT 1) DELETE FROM t WHERE id = 1", nil, blocked, false},
+		{"Not blocked", "UPDATE t SET a = 1 WHERE id = 1", nil, blocked, true},
+		{"Block wins over allow", "DROP TABLE t", []string{"DROP"}, []string{"DROP"}, false},
+		{"Blocked in executable comment", "/*! DELETE FROM users */", nil, blocked, false},
+		{"Blocked in versioned executable comment", "/*!50000 DROP TABLE users */", nil, blocked, false},
+		{"Not allowed in executable comment", "/*!50000 DROP TABLE users */", readOnly, nil, false},
+		{"No leading keyword with blocklist", "/* unterminated DROP TABLE t", nil, blocked, false},
+		{"No leading keyword with allowlist", "1 + 1", readOnly, nil, false},
+		{"No leading keyword without policy", "1 + 1", nil, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkStatementPolicy(tc.sql, tc.allowed, tc.blocked)
+			if tc.ok && err != nil {
+				t.Errorf("Expected statement to pass, got %v", err)
+			}
+			if !tc.ok && err == nil {
+				t.Error("Expected statement to be rejected")
+			}
+		})
+	}
+}
+
+func TestCheckStatementPolicyNamesKeyword(t *testing.T) {
+	err := checkStatementPolicy("TRUNCATE TABLE t", nil, []string{"TRUNCATE"})
+	if err == nil || !strings.Contains(err.Error(), "TRUNCATE") {
+		t.Errorf("Expected rejection naming TRUNCATE, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultSummaryMaxRows = 1000000
+	maxSummaryMaxRows     = 10000000
+	// maxSummaryDistinct bounds the distinct values kept per string column;
+	// past it the distinct count is reported as a lower bound
+	maxSummaryDistinct = 10000
+)
+
+// Column kinds reported by SummarizeQuery
+const (
+	summaryNumeric = "numeric"
+	summaryString  = "string"
+	summaryOther   = "other"
+)
+
+// summaryKind classifies a driver type name
+func summaryKind(typeName string) string {
+	switch strings.TrimPrefix(typeName, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "DECIMAL", "FLOAT", "DOUBLE":
+		return summaryNumeric
+	case "CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM", "SET":
+		return summaryString
+	}
+	return summaryOther
+}
+
+// summaryNumber converts a scanned numeric value to a float64. DECIMAL
+// values arrive as strings and may lose precision.
+func summaryNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// columnSummarizer accumulates per-column statistics one row at a time, so
+// the result is never held in memory. Only distinct string values are kept,
+// up to maxSummaryDistinct per column.
+type columnSummarizer struct {
+	columns  []protocol.ColumnSummary
+	kinds    []string
+	distinct []map[string]struct{}
+}
+
+func newColumnSummarizer(names, typeNames []string) *columnSummarizer {
+	s := &columnSummarizer{
+		columns:  make([]protocol.ColumnSummary, len(names)),
+		kinds:    make([]string, len(names)),
+		distinct: make([]map[string]struct{}, len(names)),
+	}
+	for i, name := range names {
+		s.kinds[i] = summaryKind(typeNames[i])
+		s.columns[i] = protocol.ColumnSummary{Name: name, Type: typeNames[i], Kind: s.kinds[i]}
+		if s.kinds[i] == summaryString {
+			s.distinct[i] = make(map[string]struct{})
+		}
+	}
+	return s
+}
+
+func (s *columnSummarizer) add(row []interface{}) {
+	for i, value := range row {
+		col := &s.columns[i]
+		if value == nil {
+			col.NullCount++
+			continue
+		}
+		col.Count++
+
+		switch s.kinds[i] {
+		case summaryNumeric:
+			n, ok := summaryNumber(value)
+			if !ok {
+				continue
+			}
+			if col.Sum == nil {
+				col.Min, col.Max, col.Sum = new(float64), new(float64), new(float64)
+				*col.Min, *col.Max = n, n
+			}
+			*col.Min = min(*col.Min, n)
+			*col.Max = max(*col.Max, n)
+			*col.Sum += n
+		case summaryString:
+			if len(s.distinct[i]) < maxSummaryDistinct {
+				s.distinct[i][fmt.Sprint(value)] = struct{}{}
+				continue
+			}
+			// Full: stop counting once a value is new
+			if _, seen := s.distinct[i][fmt.Sprint(value)]; !seen {
+				col.DistinctAtLeast = true
+			}
+		}
+	}
+}
+
+// result finishes the averages and distinct counts
+func (s *columnSummarizer) result() []protocol.ColumnSummary {
+	for i := range s.columns {
+		col := &s.columns[i]
+		switch s.kinds[i] {
+		case summaryNumeric:
+			if col.Sum != nil {
+				avg := *col.Sum / float64(col.Count)
+				col.Avg = &avg
+			}
+		case summaryString:
+			distinct := int64(len(s.distinct[i]))
+			col.DistinctCount = &distinct
+		}
+	}
+	return s.columns
+}
+
+// summaryQuery limits a SELECT to n rows without editing it
+func summaryQuery(sqlText string, n int64) string {
+	sqlText = strings.TrimRight(strings.TrimSpace(sqlText), "; \t\r\n")
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS dw_summary LIMIT %d", sqlText, n)
+}
+
+// SummarizeQuery runs a SELECT and profiles its whole result: count, min,
+// max, avg and sum for numeric columns, distinct and null counts for string
+// columns, with distinct counts past 10,000 reported as a lower bound. Unlike a result page this reads every row the query returns, up
+// to maxRows (default 1,000,000), so it costs as much as running the query
+// to completion.
+// Rows are summarized as they arrive and never held in memory. Cancelling
+// ctx kills the query.
+func (c *Connection) SummarizeQuery(ctx context.Context, sqlText string, maxRows int64) (*protocol.QuerySummary, error) {
+	startTime := time.Now()
+
+	if kind := statementKind(sqlText); kind != "SELECT" && kind != "TABLE" {
+		return nil, fmt.Errorf("only SELECT statements can be summarized (got %s)", kind)
+	}
+	if err := c.checkStatement(sqlText); err != nil {
+		return nil, err
+	}
+	if maxRows <= 0 {
+		maxRows = defaultSummaryMaxRows
+	}
+	if maxRows > maxSummaryMaxRows {
+		maxRows = maxSummaryMaxRows
+	}
+
+	// Ask for one row more than the limit to detect truncation without
+	// draining the rest of the result
+	rows, release, err := c.queryWithKill(ctx, summaryQuery(sqlText, maxRows+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer release()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	typeNames, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	summarizer := newColumnSummarizer(names, typeNames)
+	summary := &protocol.QuerySummary{}
+	for rows.Next() {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled during fetch: %w", ctx.Err())
+		}
+		if summary.RowCount == maxRows {
+			summary.Truncated = true
+			summary.Message = fmt.Sprintf("The result has more than %d rows; only the first %d were summarized", maxRows, maxRows)
+			break
+		}
+		row, err := scanRow(rows, typeNames, valueOptions{})
+		if err != nil {
+			return nil, err
+		}
+		summarizer.add(row)
+		summary.RowCount++
+	}
+	if err := rows.Err(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	summary.Columns = summarizer.result()
+	summary.ExecutionTime = time.Since(startTime).Milliseconds()
+	return summary, nil
+}
@@ -0,0 +1,86 @@
+package connection
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSummaryKind(t *testing.T) {
+	tests := map[string]string{
+		"INT":             summaryNumeric,
+		"UNSIGNED BIGINT": summaryNumeric,
+		"DECIMAL":         summaryNumeric,
+		"VARCHAR":         summaryString,
+		"ENUM":            summaryString,
+		"DATETIME":        summaryOther,
+		"BLOB":            summaryOther,
+	}
+	for typeName, want := range tests {
+		if got := summaryKind(typeName); got != want {
+			t.Errorf("summaryKind(%q) = %q, want %q", typeName, got, want)
+		}
+	}
+}
+
+func TestColumnSummarizer(t *testing.T) {
+	s := newColumnSummarizer(
+		[]string{"id", "price", "status", "created"},
+		[]string{"BIGINT", "DECIMAL", "VARCHAR", "DATETIME"},
+	)
+	s.add([]interface{}{int64(1), "9.50", "open", "2024-01-01 00:00:00"})
+	s.add([]interface{}{int64(2), nil, "closed", nil})
+	s.add([]interface{}{int64(3), "0.50", "open", "2024-01-02 00:00:00"})
+
+	columns := s.result()
+
+	id := columns[0]
+	if id.Count != 3 || *id.Min != 1 || *id.Max != 3 || *id.Sum != 6 || *id.Avg != 2 {
+		t.Errorf("Unexpected id summary: %+v", id)
+	}
+
+	price := columns[1]
+	if price.Count != 2 || price.NullCount != 1 || *price.Sum != 10 || *price.Avg != 5 || *price.Min != 0.5 {
+		t.Errorf("Unexpected price summary: %+v", price)
+	}
+
+	status := columns[2]
+	if status.DistinctCount == nil || *status.DistinctCount != 2 || status.Min != nil {
+		t.Errorf("Unexpected status summary: %+v", status)
+	}
+
+	created := columns[3]
+	if created.Count != 2 || created.NullCount != 1 || created.DistinctCount != nil || created.Sum != nil {
+		t.Errorf("Unexpected created summary: %+v", created)
+	}
+}
+
+func TestColumnSummarizerAllNull(t *testing.T) {
+	s := newColumnSummarizer([]string{"n"}, []string{"INT"})
+	s.add([]interface{}{nil})
+
+	n := s.result()[0]
+	if n.NullCount != 1 || n.Avg != nil || n.Min != nil {
+		t.Errorf("Expected no statistics for an all-NULL column, got %+v", n)
+	}
+}
+
+func TestColumnSummarizerCapsDistinctValues(t *testing.T) {
+	s := newColumnSummarizer([]string{"code", "status"}, []string{"VARCHAR", "VARCHAR"})
+	for i := 0; i < maxSummaryDistinct+5; i++ {
+		s.add([]interface{}{strconv.Itoa(i), "open"})
+	}
+	// Values already counted don't mark the count as a lower bound
+	s.add([]interface{}{"0", "open"})
+
+	columns := s.result()
+	code := columns[0]
+	if code.DistinctCount == nil || *code.DistinctCount != maxSummaryDistinct || !code.DistinctAtLeast {
+		t.Errorf("Expected a capped lower bound of %d, got %+v", maxSummaryDistinct, code)
+	}
+	if len(s.distinct[0]) > maxSummaryDistinct {
+		t.Errorf("Expected at most %d values kept, got %d", maxSummaryDistinct, len(s.distinct[0]))
+	}
+	if status := columns[1]; *status.DistinctCount != 1 || status.DistinctAtLeast {
+		t.Errorf("Unexpected status summary: %+v", status)
+	}
+}
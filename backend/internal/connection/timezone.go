@@ -0,0 +1,45 @@
+package connection
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// offsetPattern matches fixed UTC offsets such as "+02:00" or "-05:30"
+var offsetPattern = regexp.MustCompile(`^([+-])(\d{2}):(\d{2})$`)
+
+// resolveTimeZone parses a configured time zone into the location used by the
+// driver for time.Time values and the value for the session time_zone.
+// An empty zone returns a nil location and empty session value.
+func resolveTimeZone(tz string) (*time.Location, string, error) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return nil, "", nil
+	}
+
+	if strings.EqualFold(tz, "UTC") || tz == "Z" {
+		return time.UTC, "+00:00", nil
+	}
+
+	if m := offsetPattern.FindStringSubmatch(tz); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, "", fmt.Errorf("invalid time zone offset '%s'", tz)
+		}
+		seconds := hours*3600 + minutes*60
+		if m[1] == "-" {
+			seconds = -seconds
+		}
+		return time.FixedZone(tz, seconds), tz, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid time zone '%s': use an IANA name like 'Europe/Berlin' or an offset like '+02:00'", tz)
+	}
+	return loc, tz, nil
+}
@@ -0,0 +1,52 @@
+package connection
+
+import "testing"
+
+func TestResolveTimeZone(t *testing.T) {
+	testCases := []struct {
+		name          string
+		tz            string
+		expectSession string
+		expectOffset  int
+		expectNilLoc  bool
+		expectError   bool
+	}{
+		{name: "Empty keeps defaults", tz: "", expectNilLoc: true},
+		{name: "UTC", tz: "UTC", expectSession: "+00:00", expectOffset: 0},
+		{name: "Positive offset", tz: "+05:30", expectSession: "+05:30", expectOffset: 5*3600 + 30*60},
+		{name: "Negative offset", tz: "-08:00", expectSession: "-08:00", expectOffset: -8 * 3600},
+		{name: "Named zone", tz: "Asia/Tokyo", expectSession: "Asia/Tokyo", expectOffset: 9 * 3600},
+		{name: "Out of range offset", tz: "+15:00", expectError: true},
+		{name: "Unknown zone", tz: "Mars/Olympus", expectError: true},
+		{name: "Injection attempt", tz: "'; DROP TABLE users; --", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			loc, session, err := resolveTimeZone(tc.tz)
+			if tc.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tc.expectNilLoc {
+				if loc != nil || session != "" {
+					t.Errorf("Expected no location, got %v / %q", loc, session)
+				}
+				return
+			}
+			if session != tc.expectSession {
+				t.Errorf("Expected session zone %q, got %q", tc.expectSession, session)
+			}
+			// Asia/Tokyo has no DST, so the offset is stable
+			_, offset := referenceTime.In(loc).Zone()
+			if offset != tc.expectOffset {
+				t.Errorf("Expected offset %d, got %d", tc.expectOffset, offset)
+			}
+		})
+	}
+}
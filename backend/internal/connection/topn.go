@@ -0,0 +1,126 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultTopN = 10
+	maxTopN     = 1000
+)
+
+// topNQuery builds the SELECT for TopN. direction must already be ASC or
+// DESC and nulls valid for orderByColumn.
+func topNQuery(database, table, orderBy, direction, nulls string, n int) string {
+	return fmt.Sprintf("SELECT * FROM %s ORDER BY %s LIMIT %d",
+		qualifiedTable(database, table), orderByColumn(orderBy, direction, nulls), n)
+}
+
+// topNRecommendation returns an index suggestion when the plan for a TopN
+// query sorts the table instead of reading an index in order. key and extra
+// are the EXPLAIN columns of the same name.
+func topNRecommendation(database, table, orderBy, key, extra string) string {
+	if !strings.Contains(extra, "Using filesort") {
+		return ""
+	}
+	prefix := "The table is read in full and sorted"
+	if key != "" {
+		prefix = fmt.Sprintf("The rows found through index %s are sorted", quoteIdentifier(key))
+	}
+	index := "idx_" + strings.ToLower(orderBy)
+	return fmt.Sprintf("%s before the limit applies. An index on %s lets MySQL read the first rows in order: CREATE INDEX %s ON %s (%s)",
+		prefix, quoteIdentifier(orderBy), quoteIdentifier(index), qualifiedTable(database, table), quoteIdentifier(orderBy))
+}
+
+// TopN returns the first n rows of a table ordered by one column, for quick
+// leaderboards. The plan is checked with EXPLAIN first, and when MySQL would
+// sort the table rather than read an index in order the result recommends an
+// index. direction is ASC or DESC (the default); nulls places NULLs first
+// or last (see orderByColumn).
+func (c *Connection) TopN(ctx context.Context, database, table, orderBy, direction, nulls string, n int) (*protocol.TopNResult, error) {
+	if orderBy == "" {
+		return nil, fmt.Errorf("orderBy is required")
+	}
+	direction = strings.ToUpper(strings.TrimSpace(direction))
+	if direction == "" {
+		direction = "DESC"
+	}
+	if direction != "ASC" && direction != "DESC" {
+		return nil, fmt.Errorf("invalid direction: %q (use ASC or DESC)", direction)
+	}
+	nulls = strings.ToLower(strings.TrimSpace(nulls))
+	if nulls != "" && nulls != protocol.NullsFirst && nulls != protocol.NullsLast {
+		return nil, fmt.Errorf("invalid nulls: %q (use %s or %s)", nulls, protocol.NullsFirst, protocol.NullsLast)
+	}
+	if n <= 0 {
+		n = defaultTopN
+	}
+	if n > maxTopN {
+		n = maxTopN
+	}
+
+	query := topNQuery(database, table, orderBy, direction, nulls, n)
+	key, extra, err := c.explainSingleTable(ctx, query)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+	}
+	// Any other EXPLAIN failure (unknown column, no privilege) is reported
+	// by the query itself
+
+	result, err := c.ExecuteQueryWithContext(ctx, query, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.TopNResult{
+		QueryResult:    *result,
+		SQL:            query,
+		Index:          key,
+		SortsTable:     strings.Contains(extra, "Using filesort"),
+		Recommendation: topNRecommendation(database, table, orderBy, key, extra),
+	}, nil
+}
+
+// orderByColumn returns the ORDER BY terms sorting by column in direction
+// (ASC or DESC) with NULLs placed as nulls asks. MySQL has no NULLS
+// FIRST/LAST and sorts NULL as the smallest value, so when the requested
+// placement differs from that default a leading "column IS NULL" term (1
+// for NULL, 0 otherwise) moves them. The extra term keeps MySQL from
+// reading an index in order, so it is only added when needed.
+func orderByColumn(column, direction, nulls string) string {
+	name := quoteIdentifier(column)
+	switch {
+	case direction == "ASC" && nulls == protocol.NullsLast:
+		return fmt.Sprintf("%s IS NULL, %s ASC", name, name)
+	case direction == "DESC" && nulls == protocol.NullsFirst:
+		return fmt.Sprintf("%s IS NULL DESC, %s DESC", name, name)
+	}
+	return name + " " + direction
+}
+
+// explainSingleTable returns the key and Extra columns of the first EXPLAIN
+// row for a statement
+func (c *Connection) explainSingleTable(ctx context.Context, query string) (key, extra string, err error) {
+	rows, err := c.db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", "", err
+	}
+	defer rows.Close()
+
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return "", "", err
+	}
+	if rows.Next() {
+		row, err := scanner.scan(rows)
+		if err != nil {
+			return "", "", err
+		}
+		key, extra = asString(row["key"]), asString(row["Extra"])
+	}
+	return key, extra, rows.Err()
+}
@@ -0,0 +1,93 @@
+package connection
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestTopNQuery(t *testing.T) {
+	got := topNQuery("shop", "orders", "total`s", "DESC", "", 10)
+	want := "SELECT * FROM `shop`.`orders` ORDER BY `total``s` DESC LIMIT 10"
+	if got != want {
+		t.Errorf("topNQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestOrderByColumn(t *testing.T) {
+	tests := []struct {
+		direction, nulls string
+		expected         string
+		// order is the expected order of 2, NULL and 1
+		order string
+	}{
+		{"ASC", "", "`v` ASC", "N12"},
+		{"ASC", protocol.NullsFirst, "`v` ASC", "N12"},
+		{"ASC", protocol.NullsLast, "`v` IS NULL, `v` ASC", "12N"},
+		{"DESC", "", "`v` DESC", "21N"},
+		{"DESC", protocol.NullsFirst, "`v` IS NULL DESC, `v` DESC", "N21"},
+		{"DESC", protocol.NullsLast, "`v` DESC", "21N"},
+	}
+	for _, tt := range tests {
+		got := orderByColumn("v", tt.direction, tt.nulls)
+		if got != tt.expected {
+			t.Errorf("orderByColumn(%s, %q) = %q, want %q", tt.direction, tt.nulls, got, tt.expected)
+		}
+		if order := sortLikeMySQL(got, []string{"2", "N", "1"}); order != tt.order {
+			t.Errorf("ORDER BY %s sorts 2, NULL, 1 as %s, want %s", got, order, tt.order)
+		}
+	}
+}
+
+// sortLikeMySQL sorts single-digit values ("N" is NULL) by the terms of an
+// ORDER BY clause as MySQL would: NULL is smaller than any value, and
+// "x IS NULL" is 1 for NULL and 0 otherwise
+func sortLikeMySQL(orderBy string, values []string) string {
+	type term struct{ isNull, desc bool }
+	var terms []term
+	for _, text := range strings.Split(orderBy, ", ") {
+		terms = append(terms, term{strings.Contains(text, " IS NULL"), strings.HasSuffix(text, " DESC")})
+	}
+	key := func(value string, t term) int {
+		switch {
+		case t.isNull && value == "N":
+			return 1
+		case t.isNull:
+			return 0
+		case value == "N":
+			return -1
+		}
+		return int(value[0] - '0')
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		for _, t := range terms {
+			a, b := key(values[i], t), key(values[j], t)
+			if a != b {
+				return a < b != t.desc
+			}
+		}
+		return false
+	})
+	return strings.Join(values, "")
+}
+
+func TestTopNRecommendation(t *testing.T) {
+	if got := topNRecommendation("shop", "orders", "total", "idx_total", "Backward index scan"); got != "" {
+		t.Errorf("Expected no recommendation when an index is read in order, got %q", got)
+	}
+
+	got := topNRecommendation("shop", "orders", "Total", "", "Using filesort")
+	if !strings.Contains(got, "CREATE INDEX `idx_total` ON `shop`.`orders` (`Total`)") {
+		t.Errorf("Expected a CREATE INDEX suggestion, got %q", got)
+	}
+	if !strings.HasPrefix(got, "The table is read in full") {
+		t.Errorf("Expected a full-sort explanation, got %q", got)
+	}
+
+	got = topNRecommendation("shop", "orders", "total", "idx_status", "Using where; Using filesort")
+	if !strings.Contains(got, "index `idx_status` are sorted") {
+		t.Errorf("Expected the used index to be named, got %q", got)
+	}
+}
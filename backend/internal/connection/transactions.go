@@ -0,0 +1,104 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const defaultLongTransactionSeconds = 60
+
+// Ages are measured on the server clock, so they do not depend on the
+// client's time zone or drift
+const longTransactionsQuery = `SELECT t.trx_id, t.trx_mysql_thread_id, t.trx_state, t.trx_started,
+	TIMESTAMPDIFF(SECOND, t.trx_started, NOW()), t.trx_query, t.trx_operation_state,
+	t.trx_rows_locked, t.trx_rows_modified, t.trx_tables_locked, p.USER, p.HOST, p.DB
+	FROM information_schema.INNODB_TRX t
+	LEFT JOIN information_schema.PROCESSLIST p ON p.ID = t.trx_mysql_thread_id
+	WHERE t.trx_started <= NOW() - INTERVAL ? SECOND
+	ORDER BY t.trx_started`
+
+// historyListLengthQuery reads the number of undo log entries not yet
+// purged, which grows while old transactions stay open
+const historyListLengthQuery = `SELECT COUNT FROM information_schema.INNODB_METRICS
+	WHERE NAME = 'trx_rseg_history_len'`
+
+// GetLongTransactions returns InnoDB transactions open for at least
+// minSeconds (60 by default), oldest first, with the purge history list
+// length when the server reports it. When InnoDB transactions cannot be read,
+// Available is false and Message explains why.
+func (c *Connection) GetLongTransactions(ctx context.Context, minSeconds int) (*protocol.LongTransactionList, error) {
+	if minSeconds <= 0 {
+		minSeconds = defaultLongTransactionSeconds
+	}
+	result := &protocol.LongTransactionList{
+		Transactions: []protocol.LongTransaction{},
+		MinSeconds:   minSeconds,
+	}
+
+	transactions, err := c.listLongTransactions(ctx, minSeconds)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		if isPermissionError(err) {
+			result.Message = "The current user is not allowed to view InnoDB transactions (requires the PROCESS privilege)"
+			return result, nil
+		}
+		if isMissingObjectError(err) {
+			result.Message = "This server does not expose InnoDB transaction information"
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to list long transactions: %w", err)
+	}
+	result.Transactions = transactions
+	result.Available = true
+
+	// The history list length is context only; the metric may be disabled
+	var length int64
+	err = c.db.QueryRowContext(ctx, historyListLengthQuery).Scan(&length)
+	switch {
+	case err == nil:
+		result.HistoryListLength = &length
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+	case !errors.Is(err, sql.ErrNoRows) && !isPermissionError(err) && !isMissingObjectError(err):
+		return nil, fmt.Errorf("failed to read the history list length: %w", err)
+	}
+
+	return result, nil
+}
+
+func (c *Connection) listLongTransactions(ctx context.Context, minSeconds int) ([]protocol.LongTransaction, error) {
+	rows, err := c.db.QueryContext(ctx, longTransactionsQuery, minSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]protocol.LongTransaction, 0, 8)
+	for rows.Next() {
+		var trx protocol.LongTransaction
+		var started sql.NullTime
+		var query, operationState, user, host, database sql.NullString
+		if err := rows.Scan(&trx.ID, &trx.ThreadID, &trx.State, &started, &trx.AgeSeconds,
+			&query, &operationState, &trx.RowsLocked, &trx.RowsModified, &trx.TablesLocked,
+			&user, &host, &database); err != nil {
+			return nil, err
+		}
+		if started.Valid {
+			trx.Started = started.Time.Format(dateTimeLayout)
+		}
+		trx.Query = query.String
+		trx.OperationState = operationState.String
+		trx.User = user.String
+		trx.Host = host.String
+		trx.Database = database.String
+		transactions = append(transactions, trx)
+	}
+
+	return transactions, rows.Err()
+}
@@ -0,0 +1,184 @@
+package connection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedBigintType is the driver's type name for BIGINT UNSIGNED columns
+const unsignedBigintType = "UNSIGNED BIGINT"
+
+// setType is the driver's type name for SET columns
+const setType = "SET"
+
+// ISO-8601 layouts used for temporal column values
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02T15:04:05.999999"
+)
+
+// convertValue converts a scanned column value into a JSON-friendly form.
+// typeName is the driver's database type name for the column (e.g. "DATE").
+func convertValue(value interface{}, typeName string) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch typeName {
+	case "DATE", "DATETIME", "TIMESTAMP", "TIME", "YEAR":
+		return formatTemporal(value, typeName)
+	case unsignedBigintType:
+		return unsignedBigint(value)
+	}
+
+	if b, ok := value.([]byte); ok {
+		// Check if it's a 16-byte binary that looks like a UUID
+		if len(b) == 16 && looksLikeUUID(b) {
+			// Convert to hex string with UUID format
+			hex := fmt.Sprintf("%x", b)
+			return fmt.Sprintf("%s-%s-%s-%s-%s",
+				hex[0:8],
+				hex[8:12],
+				hex[12:16],
+				hex[16:20],
+				hex[20:32],
+			)
+		}
+		// Regular string conversion
+		return string(b)
+	}
+
+	return value
+}
+
+// setMembers splits a SET value into its members. MySQL stores members in
+// definition order, comma-joined, and member names cannot contain commas.
+// The empty set is an empty array, never [""].
+func setMembers(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// enumValues parses the allowed values of an ENUM or SET column type such
+// as "set('read','write')", or returns nil for other types
+func enumValues(columnType string) []string {
+	lower := strings.ToLower(columnType)
+	var rest string
+	switch {
+	case strings.HasPrefix(lower, "enum("):
+		rest = columnType[len("enum("):]
+	case strings.HasPrefix(lower, "set("):
+		rest = columnType[len("set("):]
+	default:
+		return nil
+	}
+
+	values := []string{}
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if !strings.HasPrefix(rest, "'") {
+			return values
+		}
+		// Values are quoted as SQL strings: a quote is doubled and a
+		// backslash escaped
+		var value strings.Builder
+		i := 1
+		for ; i < len(rest); i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+				value.WriteByte(rest[i])
+				continue
+			}
+			if rest[i] == '\'' {
+				if i+1 < len(rest) && rest[i+1] == '\'' {
+					value.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			value.WriteByte(rest[i])
+		}
+		values = append(values, value.String())
+		if i+1 >= len(rest) {
+			return values
+		}
+		rest = strings.TrimLeft(rest[i+1:], " ")
+		if !strings.HasPrefix(rest, ",") {
+			return values
+		}
+		rest = rest[1:]
+	}
+}
+
+// unsignedBigint returns a BIGINT UNSIGNED value as a uint64. The driver
+// returns uint64 for plain queries, but for prepared statements int64, or a
+// decimal string once the value exceeds math.MaxInt64.
+func unsignedBigint(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return uint64(v)
+	case []byte:
+		if n, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return n
+		}
+		return string(v)
+	case string:
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// formatTemporal normalizes DATE, TIME, DATETIME, TIMESTAMP and YEAR values to
+// ISO-8601 strings regardless of whether the driver returned time.Time, []byte
+// or an integer
+func formatTemporal(value interface{}, typeName string) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			// parseTime maps MySQL zero dates to the zero time.Time
+			if typeName == "DATE" {
+				return "0000-00-00"
+			}
+			return "0000-00-00T00:00:00"
+		}
+		if typeName == "DATE" {
+			return v.Format(dateLayout)
+		}
+		return v.Format(dateTimeLayout)
+
+	case []byte:
+		return formatTemporalString(string(v), typeName)
+
+	case string:
+		return formatTemporalString(v, typeName)
+
+	case int64:
+		// YEAR columns arrive as integers
+		return fmt.Sprintf("%04d", v)
+	}
+
+	return value
+}
+
+// formatTemporalString normalizes a textual temporal value from the server
+func formatTemporalString(s, typeName string) string {
+	switch typeName {
+	case "DATETIME", "TIMESTAMP":
+		// "2024-01-02 03:04:05.123" -> "2024-01-02T03:04:05.123"
+		return strings.Replace(s, " ", "T", 1)
+	default:
+		// DATE, TIME and YEAR text is already in ISO-8601 form
+		return s
+	}
+}
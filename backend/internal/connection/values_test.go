@@ -0,0 +1,167 @@
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestConvertValueTemporal(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+
+	testCases := []struct {
+		name     string
+		typeName string
+		value    interface{}
+		expected interface{}
+	}{
+		// DATE
+		{"DATE as time.Time", "DATE", time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), "2024-03-09"},
+		{"DATE in non-UTC location", "DATE", time.Date(2024, 3, 9, 0, 0, 0, 0, loc), "2024-03-09"},
+		{"DATE as bytes", "DATE", []byte("2024-03-09"), "2024-03-09"},
+		{"DATE zero value", "DATE", time.Time{}, "0000-00-00"},
+
+		// TIME
+		{"TIME as bytes", "TIME", []byte("13:45:07"), "13:45:07"},
+		{"TIME with fraction", "TIME", []byte("13:45:07.250000"), "13:45:07.250000"},
+		{"TIME negative duration", "TIME", []byte("-838:59:59"), "-838:59:59"},
+
+		// DATETIME
+		{"DATETIME as time.Time", "DATETIME", time.Date(2024, 3, 9, 13, 45, 7, 0, time.UTC), "2024-03-09T13:45:07"},
+		{"DATETIME with microseconds", "DATETIME", time.Date(2024, 3, 9, 13, 45, 7, 123456000, time.UTC), "2024-03-09T13:45:07.123456"},
+		{"DATETIME as bytes", "DATETIME", []byte("2024-03-09 13:45:07"), "2024-03-09T13:45:07"},
+		{"DATETIME zero value", "DATETIME", time.Time{}, "0000-00-00T00:00:00"},
+
+		// TIMESTAMP
+		{"TIMESTAMP as time.Time", "TIMESTAMP", time.Date(2024, 3, 9, 13, 45, 7, 0, time.UTC), "2024-03-09T13:45:07"},
+		{"TIMESTAMP keeps wall clock of location", "TIMESTAMP", time.Date(2024, 3, 9, 13, 45, 7, 0, loc), "2024-03-09T13:45:07"},
+		{"TIMESTAMP as bytes with fraction", "TIMESTAMP", []byte("2024-03-09 13:45:07.5"), "2024-03-09T13:45:07.5"},
+
+		// YEAR
+		{"YEAR as int64", "YEAR", int64(2024), "2024"},
+		{"YEAR zero", "YEAR", int64(0), "0000"},
+		{"YEAR as bytes", "YEAR", []byte("1999"), "1999"},
+
+		// NULL
+		{"NULL temporal", "DATETIME", nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := convertValue(tc.value, tc.typeName)
+			if got != tc.expected {
+				t.Errorf("Expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestConvertValueNonTemporal(t *testing.T) {
+	uuidBytes := []byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+	testCases := []struct {
+		name     string
+		typeName string
+		value    interface{}
+		expected interface{}
+	}{
+		{"VARCHAR bytes", "VARCHAR", []byte("hello"), "hello"},
+		{"Binary UUID", "BINARY", uuidBytes, "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{"Integer passthrough", "INT", int64(42), int64(42)},
+		{"Unsigned bigint", "UNSIGNED BIGINT", uint64(18446744073709551615), uint64(18446744073709551615)},
+		{"Unsigned bigint from prepared statement", "UNSIGNED BIGINT", int64(42), uint64(42)},
+		{"Unsigned bigint above int64", "UNSIGNED BIGINT", []byte("18446744073709551615"), uint64(18446744073709551615)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := convertValue(tc.value, tc.typeName)
+			if got != tc.expected {
+				t.Errorf("Expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnsignedBigintResults(t *testing.T) {
+	const max = "18446744073709551615"
+
+	for _, asString := range []bool{false, true} {
+		c := newFakeSessionConnection(t, 1)
+		c.config = &protocol.ConnectionConfig{UnsignedBigintAsString: asString}
+
+		result, err := c.ExecuteQueryWithContext(context.Background(), "SELECT unsigned_max", 0, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, err := json.Marshal(result.Rows)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := `[[` + max + `,` + max + `,7]]`
+		if asString {
+			expected = `[["` + max + `","` + max + `","7"]]`
+		}
+		if string(data) != expected {
+			t.Errorf("asString=%v: expected %s, got %s", asString, expected, data)
+		}
+	}
+}
+
+func TestSetValuesAsArrays(t *testing.T) {
+	for _, asArray := range []bool{false, true} {
+		c := newFakeSessionConnection(t, 1)
+		c.config = &protocol.ConnectionConfig{SetValuesAsArrays: asArray}
+
+		result, err := c.ExecuteQueryWithContext(context.Background(), "SELECT set_values", 0, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, err := json.Marshal(result.Rows)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := `[["read,write"],[""],[null]]`
+		if asArray {
+			expected = `[[["read","write"]],[[]],[null]]`
+		}
+		if string(data) != expected {
+			t.Errorf("asArray=%v: expected %s, got %s", asArray, expected, data)
+		}
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	testCases := []struct {
+		name       string
+		columnType string
+		expected   []string
+	}{
+		{"Set", "set('read','write','admin')", []string{"read", "write", "admin"}},
+		{"Enum", "ENUM('small', 'large')", []string{"small", "large"}},
+		{"Doubled quote", "enum('it''s','plain')", []string{"it's", "plain"}},
+		{"Escaped backslash", `enum('a\\b')`, []string{`a\b`}},
+		{"Comma inside an enum value", "enum('a,b','c')", []string{"a,b", "c"}},
+		{"Empty value", "enum('','x')", []string{"", "x"}},
+		{"Not an enum", "varchar(20)", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := enumValues(tc.columnType)
+			if (got == nil) != (tc.expected == nil) || len(got) != len(tc.expected) {
+				t.Fatalf("Expected %q, got %q", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("Expected %q, got %q", tc.expected, got)
+				}
+			}
+		})
+	}
+}
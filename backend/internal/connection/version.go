@@ -0,0 +1,91 @@
+package connection
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Server flavors detected from the version string
+const (
+	FlavorMySQL   = "mysql"
+	FlavorMariaDB = "mariadb"
+)
+
+// ServerVersion is the parsed result of SELECT VERSION()
+type ServerVersion struct {
+	Raw    string
+	Flavor string
+	Major  int
+	Minor  int
+	Patch  int
+}
+
+// parseServerVersion parses version strings such as "8.0.35-0ubuntu0.22.04.1",
+// "10.11.6-MariaDB-log" or the replication-compatible
+// "5.5.5-10.6.12-MariaDB-1:10.6.12+maria~ubu2004" form
+func parseServerVersion(raw string) ServerVersion {
+	v := ServerVersion{Raw: raw, Flavor: FlavorMySQL}
+
+	s := strings.TrimSpace(raw)
+	if strings.Contains(strings.ToLower(s), "mariadb") {
+		v.Flavor = FlavorMariaDB
+		// Older clients require a 5.x version, so MariaDB 10+ may advertise
+		// itself as 5.5.5 followed by the real version
+		s = strings.TrimPrefix(s, "5.5.5-")
+	}
+
+	// Only the leading dotted number matters; suffixes carry distro details
+	if end := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); end >= 0 {
+		s = s[:end]
+	}
+
+	parts := strings.SplitN(s, ".", 3)
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		*numbers[i] = n
+	}
+
+	return v
+}
+
+// IsMariaDB reports whether the server is MariaDB rather than MySQL
+func (v ServerVersion) IsMariaDB() bool {
+	return v.Flavor == FlavorMariaDB
+}
+
+// AtLeast reports whether the version is major.minor or newer
+func (v ServerVersion) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+// supportsRenameColumn reports whether the server has ALTER TABLE ... RENAME
+// COLUMN, added in MySQL 8.0.3 and MariaDB 10.5.2
+func (v ServerVersion) supportsRenameColumn() bool {
+	if v.IsMariaDB() {
+		return v.AtLeast(10, 6) || (v.Major == 10 && v.Minor == 5 && v.Patch >= 2)
+	}
+	return v.AtLeast(8, 1) || (v.Major == 8 && v.Minor == 0 && v.Patch >= 3)
+}
+
+// supportsExplainAnalyze reports whether the server has EXPLAIN ANALYZE,
+// added in MySQL 8.0.18. MariaDB's ANALYZE statement has different output.
+func (v ServerVersion) supportsExplainAnalyze() bool {
+	if v.IsMariaDB() {
+		return false
+	}
+	return v.AtLeast(8, 1) || (v.Major == 8 && v.Minor == 0 && v.Patch >= 18)
+}
+
+// hasGenerationExpression reports whether information_schema.COLUMNS has
+// GENERATION_EXPRESSION, added with generated columns in MySQL 5.7 and
+// MariaDB 10.2
+func (v ServerVersion) hasGenerationExpression() bool {
+	if v.IsMariaDB() {
+		return v.AtLeast(10, 2)
+	}
+	return v.AtLeast(5, 7)
+}
@@ -0,0 +1,91 @@
+package connection
+
+import "testing"
+
+func TestParseServerVersion(t *testing.T) {
+	testCases := []struct {
+		raw                 string
+		flavor              string
+		major, minor, patch int
+	}{
+		{"8.0.35", FlavorMySQL, 8, 0, 35},
+		{"8.0.35-0ubuntu0.22.04.1", FlavorMySQL, 8, 0, 35},
+		{"5.7.44-log", FlavorMySQL, 5, 7, 44},
+		{"8.4.0-commercial", FlavorMySQL, 8, 4, 0},
+		{"8.0.34-26", FlavorMySQL, 8, 0, 34}, // Percona Server
+		{"10.11.6-MariaDB", FlavorMariaDB, 10, 11, 6},
+		{"10.6.12-MariaDB-log", FlavorMariaDB, 10, 6, 12},
+		{"5.5.5-10.6.12-MariaDB-1:10.6.12+maria~ubu2004", FlavorMariaDB, 10, 6, 12},
+		{"10.2.44-MariaDB", FlavorMariaDB, 10, 2, 44},
+		{"11.4.2-MariaDB-ubu2404", FlavorMariaDB, 11, 4, 2},
+		{"", FlavorMySQL, 0, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			v := parseServerVersion(tc.raw)
+			if v.Flavor != tc.flavor {
+				t.Errorf("Expected flavor %s, got %s", tc.flavor, v.Flavor)
+			}
+			if v.Major != tc.major || v.Minor != tc.minor || v.Patch != tc.patch {
+				t.Errorf("Expected %d.%d.%d, got %d.%d.%d", tc.major, tc.minor, tc.patch, v.Major, v.Minor, v.Patch)
+			}
+			if v.Raw != tc.raw {
+				t.Errorf("Expected raw version to be preserved, got %q", v.Raw)
+			}
+		})
+	}
+}
+
+func TestServerVersionAtLeast(t *testing.T) {
+	v := parseServerVersion("8.0.35")
+	if !v.AtLeast(8, 0) || !v.AtLeast(5, 7) {
+		t.Error("8.0.35 should be at least 8.0 and 5.7")
+	}
+	if v.AtLeast(8, 1) || v.AtLeast(9, 0) {
+		t.Error("8.0.35 should not be at least 8.1 or 9.0")
+	}
+}
+
+func TestSupportsExplainAnalyze(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected bool
+	}{
+		{"8.0.17", false},
+		{"8.0.18", true},
+		{"8.0.35-0ubuntu0.22.04.1", true},
+		{"8.4.0", true},
+		{"9.0.1", true},
+		{"5.7.44-log", false},
+		{"10.11.6-MariaDB", false},
+	}
+
+	for _, tc := range testCases {
+		if got := parseServerVersion(tc.raw).supportsExplainAnalyze(); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.raw, tc.expected, got)
+		}
+	}
+}
+
+func TestSupportsRenameColumn(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected bool
+	}{
+		{"8.0.2", false},
+		{"8.0.3", true},
+		{"8.4.0", true},
+		{"5.7.44-log", false},
+		{"10.5.1-MariaDB", false},
+		{"10.5.2-MariaDB", true},
+		{"10.11.6-MariaDB", true},
+		{"10.4.32-MariaDB", false},
+	}
+
+	for _, tc := range testCases {
+		if got := parseServerVersion(tc.raw).supportsRenameColumn(); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.raw, tc.expected, got)
+		}
+	}
+}
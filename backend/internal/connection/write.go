@@ -0,0 +1,79 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// writeStatements are run with Exec so affected rows and warnings can be
+// reported instead of an empty result set
+var writeStatements = map[string]bool{
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"REPLACE": true,
+}
+
+// isWriteStatement reports whether a statement modifies rows
+func isWriteStatement(sqlText string) bool {
+	return writeStatements[statementKind(sqlText)]
+}
+
+// executeWrite runs a data-modifying statement on a pinned connection and
+// reads @@warning_count from the same session
+func (c *Connection) executeWrite(ctx context.Context, sqlQuery string, args []interface{}, opts QueryOptions) (*protocol.QueryResult, error) {
+	startTime := time.Now()
+
+	conn, threadID, err := c.pinConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer releaseAfter(ctx, conn, sqlQuery)
+
+	stop := c.watchCancel(ctx, threadID)
+	stopProgress := func() {}
+	var fetched int64
+	if watch := c.progressWatch(ctx, opts, &fetched); watch != nil {
+		stopProgress = watch(threadID)
+	}
+	res, err := conn.ExecContext(ctx, sqlQuery, args...)
+	stopProgress()
+	stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	result := &protocol.QueryResult{
+		Columns: []string{},
+		Rows:    [][]interface{}{},
+	}
+
+	affected, err := res.RowsAffected()
+	if err == nil {
+		result.RowsAffected = affected
+		// With clientFoundRows the server reports matched rather than
+		// changed rows
+		if c.config != nil && c.config.Params["clientFoundRows"] == "true" {
+			result.RowsMatched = &affected
+		} else {
+			result.RowsChanged = &affected
+		}
+	}
+	if id, err := res.LastInsertId(); err == nil && id != 0 {
+		result.LastInsertID = &id
+	}
+
+	var warnings int64
+	if err := conn.QueryRowContext(ctx, "SELECT @@warning_count").Scan(&warnings); err == nil {
+		result.Warnings = &warnings
+	}
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package connection
+
+import "testing"
+
+func TestIsWriteStatement(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		expected bool
+	}{
+		{"UPDATE t SET a = 1 WHERE id = 2", true},
+		{"insert into t values (1)", true},
+		{"REPLACE INTO t VALUES (1)", true},
+		{"DELETE FROM t WHERE id = 1", true},
+		{"WITH x AS (SELECT 1) DELETE FROM t WHERE id IN (SELECT * FROM x)", true},
+		{"SELECT * FROM updates", false},
+		{"SHOW TABLES", false},
+		{"CREATE TABLE t (id INT)", false},
+		{"/*! DELETE FROM users */", true},
+		{"/*!50000 SELECT 1 */", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isWriteStatement(tc.sql); got != tc.expected {
+			t.Errorf("isWriteStatement(%q): expected %v, got %v", tc.sql, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Arrow column types used for MySQL result columns. DECIMAL is sent as
+// text to keep its precision; temporal values are already ISO-8601 text.
+const (
+	arrowInt64 = iota
+	arrowUint64
+	arrowFloat64
+	arrowUtf8
+	arrowBinary
+)
+
+// Arrow IPC constants from Schema.fbs and Message.fbs
+const (
+	arrowMetadataV5        = 4
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeBinary        = 4
+	arrowTypeUtf8          = 5
+	arrowPrecisionDouble   = 2
+)
+
+// arrowColumnType maps a driver database type name to an Arrow column type
+func arrowColumnType(typeName string) int {
+	switch typeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT":
+		return arrowInt64
+	case "UNSIGNED BIGINT":
+		return arrowUint64
+	case "FLOAT", "DOUBLE":
+		return arrowFloat64
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		return arrowBinary
+	}
+	return arrowUtf8
+}
+
+// EncodeArrow serializes rows as an Arrow IPC stream: a schema message, one
+// record batch and the end-of-stream marker. types are the driver database
+// type names of the columns; columns without one are sent as text. Every
+// column is nullable.
+func EncodeArrow(columns, types []string, rows [][]interface{}) ([]byte, error) {
+	kinds := make([]int, len(columns))
+	for i := range columns {
+		kinds[i] = arrowUtf8
+		if i < len(types) {
+			kinds[i] = arrowColumnType(types[i])
+		}
+	}
+
+	var body []byte
+	var nodes, buffers []byte
+	addBuffer := func(data []byte) {
+		buffers = appendInt64s(buffers, int64(len(body)), int64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	for col, kind := range kinds {
+		validity := make([]byte, (len(rows)+7)/8)
+		nullCount := 0
+		var data []byte
+		offsets := make([]byte, 4, 4*(len(rows)+1))
+
+		for r, row := range rows {
+			var value interface{}
+			if col < len(row) {
+				value = row[col]
+			}
+			if value == nil {
+				nullCount++
+			} else {
+				validity[r/8] |= 1 << (r % 8)
+			}
+
+			switch kind {
+			case arrowInt64, arrowUint64, arrowFloat64:
+				bits, err := arrowFixedValue(value, kind)
+				if err != nil {
+					return nil, fmt.Errorf("column %s: %w", columns[col], err)
+				}
+				data = binary.LittleEndian.AppendUint64(data, bits)
+			default:
+				if value != nil {
+					data = append(data, arrowBytes(value)...)
+				}
+				if len(data) > math.MaxInt32 {
+					return nil, fmt.Errorf("column %s: too much data for an Arrow batch", columns[col])
+				}
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+			}
+		}
+
+		nodes = appendInt64s(nodes, int64(len(rows)), int64(nullCount))
+		if nullCount == 0 {
+			validity = nil
+		}
+		addBuffer(validity)
+		if kind == arrowUtf8 || kind == arrowBinary {
+			addBuffer(offsets)
+		}
+		addBuffer(data)
+	}
+
+	stream := arrowMessage(nil, arrowSchema(columns, kinds), nil)
+	batch := fbTable{
+		fbScalar(8, uint64(len(rows))),
+		fbChild(fbStructVector{data: nodes, count: len(kinds)}),
+		fbChild(fbStructVector{data: buffers, count: len(buffers) / 16}),
+	}
+	stream = arrowMessage(stream, fbTable{
+		fbScalar(2, arrowMetadataV5),
+		fbScalar(1, arrowHeaderRecordBatch),
+		fbChild(batch),
+		fbScalar(8, uint64(len(body))),
+	}, body)
+
+	// End-of-stream marker
+	stream = binary.LittleEndian.AppendUint32(stream, 0xFFFFFFFF)
+	return binary.LittleEndian.AppendUint32(stream, 0), nil
+}
+
+// arrowSchema builds the Schema message for the columns
+func arrowSchema(columns []string, kinds []int) fbTable {
+	fields := make([]fbObject, len(columns))
+	for i, name := range columns {
+		var typeID uint64
+		var typeTable fbTable
+		switch kinds[i] {
+		case arrowInt64:
+			typeID, typeTable = arrowTypeInt, fbTable{fbScalar(4, 64), fbScalar(1, 1)}
+		case arrowUint64:
+			typeID, typeTable = arrowTypeInt, fbTable{fbScalar(4, 64), fbScalar(1, 0)}
+		case arrowFloat64:
+			typeID, typeTable = arrowTypeFloatingPoint, fbTable{fbScalar(2, arrowPrecisionDouble)}
+		case arrowBinary:
+			typeID, typeTable = arrowTypeBinary, fbTable{}
+		default:
+			typeID, typeTable = arrowTypeUtf8, fbTable{}
+		}
+		fields[i] = fbTable{
+			fbChild(fbString(name)),
+			fbScalar(1, 1), // nullable
+			fbScalar(1, typeID),
+			fbChild(typeTable),
+			{},                       // dictionary
+			fbChild(fbTableVector{}), // children, required by readers
+		}
+	}
+
+	return fbTable{
+		fbScalar(2, arrowMetadataV5),
+		fbScalar(1, arrowHeaderSchema),
+		fbChild(fbTable{
+			fbScalar(2, 0), // little endian
+			fbChild(fbTableVector(fields)),
+		}),
+		fbScalar(8, 0),
+	}
+}
+
+// arrowMessage appends an encapsulated IPC message: the continuation
+// marker, the metadata length, the Message flatbuffer padded to 8 bytes,
+// then the body
+func arrowMessage(stream []byte, message fbTable, body []byte) []byte {
+	metadata := fbFinish(message)
+	for len(metadata)%8 != 0 {
+		metadata = append(metadata, 0)
+	}
+	stream = binary.LittleEndian.AppendUint32(stream, 0xFFFFFFFF)
+	stream = binary.LittleEndian.AppendUint32(stream, uint32(len(metadata)))
+	stream = append(stream, metadata...)
+	return append(stream, body...)
+}
+
+// arrowFixedValue returns the little-endian bits of a value in an integer
+// or floating point column. NULL is stored as zero.
+func arrowFixedValue(value interface{}, kind int) (uint64, error) {
+	if value == nil {
+		return 0, nil
+	}
+
+	text := ""
+	switch v := value.(type) {
+	case int64:
+		if kind == arrowFloat64 {
+			return math.Float64bits(float64(v)), nil
+		}
+		return uint64(v), nil
+	case uint64:
+		if kind == arrowFloat64 {
+			return math.Float64bits(float64(v)), nil
+		}
+		return v, nil
+	case float64:
+		if kind == arrowFloat64 {
+			return math.Float64bits(v), nil
+		}
+	case float32:
+		if kind == arrowFloat64 {
+			return math.Float64bits(float64(v)), nil
+		}
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	}
+
+	var err error
+	switch kind {
+	case arrowInt64:
+		var n int64
+		if n, err = strconv.ParseInt(text, 10, 64); err == nil {
+			return uint64(n), nil
+		}
+	case arrowUint64:
+		var n uint64
+		if n, err = strconv.ParseUint(text, 10, 64); err == nil {
+			return n, nil
+		}
+	case arrowFloat64:
+		var f float64
+		if f, err = strconv.ParseFloat(text, 64); err == nil {
+			return math.Float64bits(f), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot encode %T value %v as a number", value, value)
+}
+
+// arrowBytes returns the bytes of a value in a text or binary column
+func arrowBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	case []string:
+		// SET members, joined back as MySQL writes them
+		return []byte(strings.Join(v, ","))
+	}
+	return []byte(fmt.Sprint(value))
+}
+
+func appendInt64s(b []byte, values ...int64) []byte {
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint64(b, uint64(v))
+	}
+	return b
+}
+
+// A minimal flatbuffers writer for the Arrow metadata. Objects are laid out
+// front to back: each table is followed by the objects it references, so
+// every offset points forward as the format requires.
+
+// fbObject is a table, string or vector referenced by offset
+type fbObject interface {
+	write(b *fbBuilder) int
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) patchOffset(at, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+// fbField is a table field: a scalar of size bytes, an offset to child, or
+// absent when both are zero
+type fbField struct {
+	size  int
+	value uint64
+	child fbObject
+}
+
+func fbScalar(size int, value uint64) fbField {
+	return fbField{size: size, value: value}
+}
+
+func fbChild(child fbObject) fbField {
+	return fbField{size: 4, child: child}
+}
+
+// fbTable is a table whose fields are in field id order
+type fbTable []fbField
+
+func (t fbTable) write(b *fbBuilder) int {
+	// Place the largest fields first after the vtable offset so each is
+	// aligned to its size within the 8-aligned table
+	order := make([]int, 0, len(t))
+	for i, f := range t {
+		if f.size > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return t[order[i]].size > t[order[j]].size })
+
+	offsets := make([]int, len(t))
+	size := 4
+	for _, i := range order {
+		for size%t[i].size != 0 {
+			size++
+		}
+		offsets[i] = size
+		size += t[i].size
+	}
+
+	b.pad(2)
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(4+2*len(t)))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(size))
+	for _, off := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(off))
+	}
+
+	b.pad(8)
+	table := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[table:], uint32(int32(table-vtable)))
+	for _, i := range order {
+		at := table + offsets[i]
+		switch t[i].size {
+		case 1:
+			b.buf[at] = byte(t[i].value)
+		case 2:
+			binary.LittleEndian.PutUint16(b.buf[at:], uint16(t[i].value))
+		case 4:
+			binary.LittleEndian.PutUint32(b.buf[at:], uint32(t[i].value))
+		case 8:
+			binary.LittleEndian.PutUint64(b.buf[at:], t[i].value)
+		}
+	}
+	for _, i := range order {
+		if t[i].child != nil {
+			at := table + offsets[i]
+			b.patchOffset(at, t[i].child.write(b))
+		}
+	}
+	return table
+}
+
+type fbString string
+
+func (s fbString) write(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+// fbTableVector is a vector of offsets to tables
+type fbTableVector []fbObject
+
+func (v fbTableVector) write(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+	slots := len(b.buf)
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, item := range v {
+		b.patchOffset(slots+4*i, item.write(b))
+	}
+	return pos
+}
+
+// fbStructVector is a vector of count structs of 8-byte fields, stored
+// inline so its elements must be 8-aligned
+type fbStructVector struct {
+	data  []byte
+	count int
+}
+
+func (v fbStructVector) write(b *fbBuilder) int {
+	for (len(b.buf)+4)%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v.count))
+	b.buf = append(b.buf, v.data...)
+	return pos
+}
+
+// fbFinish lays out a root table behind the root offset
+func fbFinish(root fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	b.patchOffset(0, root.write(b))
+	return b.buf
+}
@@ -0,0 +1,109 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestArrowColumnType(t *testing.T) {
+	testCases := []struct {
+		typeName string
+		expected int
+	}{
+		{"INT", arrowInt64},
+		{"UNSIGNED INT", arrowInt64},
+		{"UNSIGNED BIGINT", arrowUint64},
+		{"DOUBLE", arrowFloat64},
+		{"DECIMAL", arrowUtf8},
+		{"DATETIME", arrowUtf8},
+		{"VARBINARY", arrowBinary},
+		{"", arrowUtf8},
+	}
+
+	for _, tc := range testCases {
+		if got := arrowColumnType(tc.typeName); got != tc.expected {
+			t.Errorf("%q: expected %d, got %d", tc.typeName, tc.expected, got)
+		}
+	}
+}
+
+// arrowMessages splits an IPC stream into its messages' metadata and body
+// bytes, checking the framing along the way
+func arrowMessages(t *testing.T, stream []byte, bodyLengths ...int) [][2][]byte {
+	t.Helper()
+	var messages [][2][]byte
+	pos := 0
+	for i := 0; ; i++ {
+		if binary.LittleEndian.Uint32(stream[pos:]) != 0xFFFFFFFF {
+			t.Fatalf("Message %d: missing continuation marker", i)
+		}
+		length := int(binary.LittleEndian.Uint32(stream[pos+4:]))
+		pos += 8
+		if length == 0 {
+			break
+		}
+		if length%8 != 0 {
+			t.Errorf("Message %d: metadata length %d is not 8-byte aligned", i, length)
+		}
+		metadata := stream[pos : pos+length]
+		pos += length
+		body := stream[pos : pos+bodyLengths[i]]
+		pos += bodyLengths[i]
+		messages = append(messages, [2][]byte{metadata, body})
+	}
+	if pos != len(stream) {
+		t.Errorf("Expected the stream to end after the end-of-stream marker, %d bytes left", len(stream)-pos)
+	}
+	return messages
+}
+
+func TestEncodeArrow(t *testing.T) {
+	columns := []string{"id", "big", "name"}
+	types := []string{"INT", "UNSIGNED BIGINT", "VARCHAR"}
+	rows := [][]interface{}{
+		{int64(7), uint64(18446744073709551615), "héllo"},
+		{nil, "42", nil},
+	}
+
+	stream, err := EncodeArrow(columns, types, rows)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// id: validity, values; big: values; name: validity, offsets, data
+	bodyLength := 8 + 16 + 16 + 8 + 16 + 8
+	messages := arrowMessages(t, stream, 0, bodyLength)
+	if len(messages) != 2 {
+		t.Fatalf("Expected schema and record batch messages, got %d", len(messages))
+	}
+	for _, name := range columns {
+		if !bytes.Contains(messages[0][0], []byte(name)) {
+			t.Errorf("Schema does not name column %s", name)
+		}
+	}
+
+	body := messages[1][1]
+	if body[0] != 0b01 {
+		t.Errorf("Expected id validity 0b01, got %08b", body[0])
+	}
+	if id := binary.LittleEndian.Uint64(body[8:]); id != 7 {
+		t.Errorf("Expected id 7, got %d", id)
+	}
+	if big := binary.LittleEndian.Uint64(body[24:]); big != 18446744073709551615 {
+		t.Errorf("Expected the largest BIGINT UNSIGNED, got %d", big)
+	}
+	if big := binary.LittleEndian.Uint64(body[32:]); big != 42 {
+		t.Errorf("Expected 42 parsed from text, got %d", big)
+	}
+	if !bytes.Contains(body, []byte("héllo")) {
+		t.Error("Expected the name data in the body")
+	}
+}
+
+func TestEncodeArrowRejectsNonNumericValues(t *testing.T) {
+	_, err := EncodeArrow([]string{"n"}, []string{"BIGINT"}, [][]interface{}{{"abc"}})
+	if err == nil {
+		t.Error("Expected error for text in an integer column")
+	}
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// EncodingGzip marks a result that was gzip-compressed and base64-encoded
+	EncodingGzip = "gzip+base64"
+	// CompressionThreshold is the marshalled result size above which
+	// compression is worth the CPU cost
+	CompressionThreshold = 32 * 1024
+)
+
+// CompressResult replaces the response's result with a base64-encoded gzip
+// payload when the marshalled result is larger than threshold bytes.
+// Responses with errors or small results are left untouched.
+func CompressResult(resp *Response, threshold int) error {
+	if resp.Error != nil || resp.Result == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	if len(data) <= threshold {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return fmt.Errorf("failed to compress result: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress result: %w", err)
+	}
+
+	resp.Result = nil
+	resp.Encoding = EncodingGzip
+	resp.Payload = base64.StdEncoding.EncodeToString(buf.Bytes())
+	return nil
+}
+
+// DecompressPayload returns the raw JSON result of a compressed response
+func DecompressPayload(resp *Response) (json.RawMessage, error) {
+	if resp.Encoding != EncodingGzip {
+		return nil, fmt.Errorf("unsupported encoding: %s", resp.Encoding)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(resp.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	defer gz.Close()
+
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return json.RawMessage(data), nil
+}
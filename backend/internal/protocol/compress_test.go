@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompressResultRoundTrip(t *testing.T) {
+	rows := make([][]interface{}, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		rows = append(rows, []interface{}{i, "repetitive value", nil})
+	}
+	result := QueryResult{Columns: []string{"id", "name", "note"}, Rows: rows}
+
+	resp := &Response{JSONRPC: "2.0", ID: "1", Result: result}
+	if err := CompressResult(resp, CompressionThreshold); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resp.Encoding != EncodingGzip {
+		t.Fatalf("Expected %s encoding, got %q", EncodingGzip, resp.Encoding)
+	}
+	if resp.Result != nil {
+		t.Error("Expected result to be replaced by payload")
+	}
+
+	original, _ := json.Marshal(result)
+	if len(resp.Payload) >= len(original) {
+		t.Errorf("Expected compressed payload (%d) to be smaller than original (%d)", len(resp.Payload), len(original))
+	}
+
+	raw, err := DecompressPayload(resp)
+	if err != nil {
+		t.Fatalf("Failed to decompress: %v", err)
+	}
+	var decoded QueryResult
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if len(decoded.Rows) != 2000 || decoded.Columns[1] != "name" {
+		t.Errorf("Decoded result does not match original")
+	}
+}
+
+func TestCompressResultSkipsSmallAndErrorResponses(t *testing.T) {
+	small := &Response{JSONRPC: "2.0", ID: "1", Result: map[string]string{"status": "ok"}}
+	if err := CompressResult(small, CompressionThreshold); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if small.Encoding != "" || small.Result == nil {
+		t.Error("Small results should not be compressed")
+	}
+
+	failed := &Response{JSONRPC: "2.0", ID: "2", Error: &Error{Code: InternalError, Message: strings.Repeat("x", 100000)}}
+	if err := CompressResult(failed, CompressionThreshold); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if failed.Encoding != "" {
+		t.Error("Error responses should not be compressed")
+	}
+}
@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Export formats for exportQueryStream
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// ExportEncoder serializes result rows for export, one chunk at a time.
+// CSV follows RFC 4180 with a header row and NULL written as an empty
+// field. JSON is JSON Lines: one object per row with keys in column order.
+type ExportEncoder struct {
+	format  string
+	columns []string
+	// keys are the JSON-encoded column names
+	keys [][]byte
+}
+
+// NewExportEncoder returns an encoder for rows with the given columns
+func NewExportEncoder(format string, columns []string) (*ExportEncoder, error) {
+	e := &ExportEncoder{format: format, columns: columns}
+	switch format {
+	case ExportFormatCSV:
+	case ExportFormatJSON:
+		for _, name := range columns {
+			key, err := json.Marshal(name)
+			if err != nil {
+				return nil, err
+			}
+			e.keys = append(e.keys, key)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported export format: %q (use %q or %q)", format, ExportFormatCSV, ExportFormatJSON)
+	}
+	return e, nil
+}
+
+// Header returns the text that precedes the first row: the CSV header line,
+// or nothing for JSON Lines
+func (e *ExportEncoder) Header() ([]byte, error) {
+	if e.format != ExportFormatCSV {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(e.columns)
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Encode serializes rows, each terminated by a newline
+func (e *ExportEncoder) Encode(rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if e.format == ExportFormatCSV {
+		w := csv.NewWriter(&buf)
+		record := make([]string, len(e.columns))
+		for _, row := range rows {
+			for i, value := range row {
+				record[i] = csvField(value)
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	}
+
+	for _, row := range rows {
+		buf.WriteByte('{')
+		for i, value := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(e.keys[i])
+			buf.WriteByte(':')
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode column %s: %w", e.columns[i], err)
+			}
+			buf.Write(encoded)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// csvField formats a converted result value as a CSV field
+func csvField(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		// SET members, joined back as MySQL writes them
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestExportEncoderCSV(t *testing.T) {
+	e, err := NewExportEncoder(ExportFormatCSV, []string{"id", "name", "note"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	header, err := e.Header()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(header) != "id,name,note\n" {
+		t.Errorf("Unexpected header: %q", header)
+	}
+
+	data, err := e.Encode([][]interface{}{
+		{int64(1), "Smith, Jane", nil},
+		{uint64(18446744073709551615), `say "hi"`, "line\nbreak"},
+		{int64(2), []string{"read", "write"}, []string{}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "1,\"Smith, Jane\",\n18446744073709551615,\"say \"\"hi\"\"\",\"line\nbreak\"\n2,\"read,write\",\n"
+	if string(data) != want {
+		t.Errorf("Encode() = %q, want %q", data, want)
+	}
+}
+
+func TestExportEncoderJSONLines(t *testing.T) {
+	e, err := NewExportEncoder(ExportFormatJSON, []string{"z", "a"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if header, _ := e.Header(); len(header) != 0 {
+		t.Errorf("Expected no header, got %q", header)
+	}
+
+	data, err := e.Encode([][]interface{}{
+		{int64(1), "x"},
+		{nil, 2.5},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Keys keep column order rather than being sorted
+	want := "{\"z\":1,\"a\":\"x\"}\n{\"z\":null,\"a\":2.5}\n"
+	if string(data) != want {
+		t.Errorf("Encode() = %q, want %q", data, want)
+	}
+}
+
+func TestExportEncoderRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewExportEncoder("xml", nil); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
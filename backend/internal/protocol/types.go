@@ -1,6 +1,9 @@
 package protocol
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // JSON-RPC 2.0 types
 type Request struct {
@@ -8,6 +11,15 @@ type Request struct {
 	ID      string          `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
+	// Compress requests a compressed result ("gzip") for large responses
+	Compress string `json:"compress,omitempty"`
+	// DeadlineMs bounds how long the request may run. Values below 1e12
+	// are milliseconds from receipt; larger values are an absolute Unix
+	// time in milliseconds. Past the deadline the request fails with
+	// RequestTimeout and its running statements are cancelled. Opening a
+	// connection is bounded by its connect timeout instead, and a cursor
+	// from openCursor lives until it is closed.
+	DeadlineMs int64 `json:"deadlineMs,omitempty"`
 }
 
 type Response struct {
@@ -15,6 +27,17 @@ type Response struct {
 	ID      string      `json:"id"`
 	Result  interface{} `json:"result,omitempty"`
 	Error   *Error      `json:"error,omitempty"`
+	// Encoding is set when the result was compressed into Payload
+	Encoding string `json:"encoding,omitempty"`
+	Payload  string `json:"payload,omitempty"`
+}
+
+// Notification is a JSON-RPC 2.0 notification sent by the backend without
+// a preceding request (progress updates, events)
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
 }
 
 type Error struct {
@@ -30,6 +53,9 @@ const (
 	MethodNotFound = -32601
 	InvalidParams  = -32602
 	InternalError  = -32603
+	// RequestTimeout means the request's deadlineMs passed; Data holds the
+	// method name
+	RequestTimeout = -32001
 )
 
 // Connection types
@@ -43,6 +69,70 @@ type ConnectionConfig struct {
 	Password string `json:"password"`
 	Database string `json:"database"`
 	SSL      bool   `json:"ssl"`
+	// AllowCleartextPasswords enables the mysql_clear_password plugin that
+	// LDAP and PAM accounts use. It sends the password unencrypted, so use
+	// it with SSL. AllowNativePasswords set to false refuses
+	// mysql_native_password; it is allowed by default. caching_sha2_password
+	// needs no flag: with SSL the full authentication runs over TLS,
+	// otherwise the password is encrypted with the server's RSA public key.
+	AllowCleartextPasswords bool  `json:"allowCleartextPasswords,omitempty"`
+	AllowNativePasswords    *bool `json:"allowNativePasswords,omitempty"`
+	// TimeZone is an IANA name ("Europe/Berlin") or offset ("+02:00"). It sets
+	// the session time_zone, so NOW() and TIMESTAMP columns are converted by
+	// the server, and the driver loc used to build time.Time values
+	// (parseTime is always enabled). Named zones require the server's time
+	// zone tables to be loaded. Empty keeps the server default and UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// InterpolateParams switches parameterized queries from server-side
+	// prepared statements (the default) to client-side interpolation, saving
+	// a round-trip per query. Interpolated values are escaped by the driver
+	// and sent inline in the SQL text, so the server never sees them as
+	// separate parameters; keep it off unless the latency matters.
+	InterpolateParams bool `json:"interpolateParams,omitempty"`
+	// SQLMode, when set, replaces the session sql_mode on every pooled
+	// connection, e.g. "STRICT_TRANS_TABLES,NO_ZERO_DATE". Leave it empty
+	// to keep the server default.
+	SQLMode string `json:"sqlMode,omitempty"`
+	// Params are extra driver DSN parameters (e.g. allowNativePasswords,
+	// maxAllowedPacket, clientFoundRows). They are applied after the built-in
+	// parameters, so they override the default timeouts, tls,
+	// interpolateParams, time_zone and sql_mode; parseTime and loc are
+	// reserved. Keys the driver does not recognize are sent as session
+	// variables.
+	Params map[string]string `json:"params,omitempty"`
+	// SafeUpdates rejects UPDATE and DELETE statements without a top-level
+	// WHERE or LIMIT clause, like the mysql client's --safe-updates
+	SafeUpdates bool `json:"safeUpdates,omitempty"`
+	// AllowedStatements, when set, limits statements to these leading
+	// keywords (e.g. SELECT, SHOW, EXPLAIN). BlockedStatements rejects the
+	// listed keywords (e.g. DROP, TRUNCATE) and takes precedence.
+	AllowedStatements []string `json:"allowedStatements,omitempty"`
+	BlockedStatements []string `json:"blockedStatements,omitempty"`
+	// ReadReplicas receive plain SELECTs from executeQuery; everything else
+	// goes to Host. They share the primary's credentials and settings.
+	ReadReplicas []HostPort `json:"readReplicas,omitempty"`
+	// UnsignedBigintAsString returns BIGINT UNSIGNED values as decimal
+	// strings. By default they are JSON numbers, which JavaScript clients
+	// read as doubles and round above 2^53.
+	UnsignedBigintAsString bool `json:"unsignedBigintAsString,omitempty"`
+	// SetValuesAsArrays returns SET values as arrays of their members, e.g.
+	// ["a", "c"] instead of "a,c", and the empty set as []
+	SetValuesAsArrays bool `json:"setValuesAsArrays,omitempty"`
+	// QueryLabels prefixes statements run by executeQuery with a comment
+	// naming the request, e.g. /* dw:requestId=42 label=report */, so DBAs
+	// can trace a statement in SHOW PROCESSLIST back to Data Warden
+	QueryLabels bool `json:"queryLabels,omitempty"`
+	// DefaultQueryTimeoutSeconds bounds every query run on this connection
+	// by executeQuery, profileQuery and runScript, even when the client sets
+	// no deadline, so a query left behind by a disconnected client cannot
+	// run forever. It bounds a whole script, and the whole lifetime of a
+	// cursor or streamed export. Metadata reads are not bounded. 0 disables it.
+	DefaultQueryTimeoutSeconds int `json:"defaultQueryTimeoutSeconds,omitempty"`
+}
+
+type HostPort struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
 }
 
 type ConnectionTestResult struct {
@@ -51,7 +141,40 @@ type ConnectionTestResult struct {
 	Version string `json:"version,omitempty"`
 }
 
+// ProbeRequest is the probeHost request. Port defaults to 3306 and
+// TimeoutMs to 3000 (at most 10000).
+type ProbeRequest struct {
+	Host      string `json:"host"`
+	Port      int    `json:"port,omitempty"`
+	TimeoutMs int    `json:"timeoutMs,omitempty"`
+}
+
+// ProbeResult reports whether a TCP connection to a host could be opened
+type ProbeResult struct {
+	Reachable bool   `json:"reachable"`
+	Address   string `json:"address"`
+	Latency   int64  `json:"latency"` // milliseconds
+	Message   string `json:"message"`
+}
+
+// CredentialsTestResult separates "can't reach/authenticate" from
+// "database missing" when validating a connection config
+type CredentialsTestResult struct {
+	Success        bool   `json:"success"` // Host reachable and credentials accepted
+	Message        string `json:"message"`
+	Version        string `json:"version,omitempty"`
+	Database       string `json:"database,omitempty"`
+	DatabaseExists *bool  `json:"databaseExists,omitempty"` // Omitted when no database was named
+}
+
 // Schema types
+// CurrentDatabase is returned by getCurrentDatabase. Selected is false when
+// the connection has no default database (DATABASE() is NULL).
+type CurrentDatabase struct {
+	Database string `json:"database"`
+	Selected bool   `json:"selected"`
+}
+
 type Database struct {
 	Name string `json:"name"`
 }
@@ -62,6 +185,10 @@ type Table struct {
 	Engine     string `json:"engine,omitempty"`
 	DataLength int64  `json:"dataLength"`   // Size in bytes
 	IndexLength int64 `json:"indexLength"`  // Index size in bytes
+	Comment    string `json:"comment,omitempty"`
+	// StatsUnavailable is set when SHOW TABLE STATUS was denied, so
+	// RowCount, Engine and the lengths are unknown rather than zero
+	StatsUnavailable bool `json:"statsUnavailable,omitempty"`
 }
 
 type Column struct {
@@ -72,6 +199,17 @@ type Column struct {
 	Default      *string `json:"default"`
 	Extra        string  `json:"extra"`
 	Comment      string  `json:"comment,omitempty"`
+	// Collation is set for character columns
+	Collation string `json:"collation,omitempty"`
+	// Generated columns (GENERATED ALWAYS AS) cannot be inserted into.
+	// GeneratedType is "VIRTUAL" or "STORED"; GenerationExpression is empty
+	// if the server does not report it.
+	IsGenerated          bool   `json:"isGenerated,omitempty"`
+	GenerationExpression string `json:"generationExpression,omitempty"`
+	GeneratedType        string `json:"generatedType,omitempty"`
+	// Values are the allowed values of ENUM and SET columns, in definition
+	// order, for rendering a select or checkboxes
+	Values []string `json:"values,omitempty"`
 }
 
 // Query types
@@ -80,6 +218,23 @@ type QueryRequest struct {
 	SQL          string `json:"sql"`
 	Limit        int    `json:"limit,omitempty"`
 	Offset       int    `json:"offset,omitempty"`
+	// Checksum requests a stable hash of the result ("crc32" or "sha256");
+	// ChecksumOnly omits the rows so only the hash is transferred
+	Checksum     string `json:"checksum,omitempty"`
+	ChecksumOnly bool   `json:"checksumOnly,omitempty"`
+	// RowFormat is "array" (default), "object", or "arrow" once negotiated
+	// by initialize
+	RowFormat string `json:"rowFormat,omitempty"`
+	// NamedArgs binds :name placeholders in SQL; every placeholder must
+	// have a value
+	NamedArgs map[string]interface{} `json:"namedArgs,omitempty"`
+	// ForcePrimary skips read replicas, e.g. to read back a recent write
+	ForcePrimary bool `json:"forcePrimary,omitempty"`
+	// RowKeys asks for each row's primary key values, for editable grids
+	RowKeys bool `json:"rowKeys,omitempty"`
+	// Label is added to the statement's comment when the connection has
+	// QueryLabels enabled, e.g. the editor tab that ran it
+	Label string `json:"label,omitempty"`
 }
 
 type QueryResult struct {
@@ -88,4 +243,1187 @@ type QueryResult struct {
 	RowsAffected int64           `json:"rowsAffected"`
 	ExecutionTime int64          `json:"executionTime"` // milliseconds
 	TotalRows    int64           `json:"totalRows,omitempty"`
+	// OriginalColumns holds the server's column names when duplicates were
+	// renamed in Columns (e.g. "id", "id" becomes "id", "id_2")
+	OriginalColumns []string `json:"originalColumns,omitempty"`
+	// Checksum is "<algorithm>:<hex>" when requested
+	Checksum string `json:"checksum,omitempty"`
+	// RowFormat "object" serializes each row as an object keyed by column
+	// name instead of an array. Duplicate column names have already been
+	// suffixed in Columns, so no values are lost. With "arrow", Rows is
+	// empty and Arrow holds the rows as an Arrow IPC stream.
+	RowFormat string `json:"rowFormat,omitempty"`
+	Arrow     []byte `json:"arrow,omitempty"` // base64 in JSON
+	// ColumnTypes are the driver's database type names of the columns
+	ColumnTypes []string `json:"-"`
+	// Write statement details. RowsChanged counts rows actually modified;
+	// RowsMatched is only known when the connection sets the clientFoundRows
+	// param, because the driver does not expose the server's info string.
+	RowsMatched  *int64 `json:"rowsMatched,omitempty"`
+	RowsChanged  *int64 `json:"rowsChanged,omitempty"`
+	Warnings     *int64 `json:"warnings,omitempty"`
+	LastInsertID *int64 `json:"lastInsertId,omitempty"`
+	// Replica is the host:port of the read replica that served the query,
+	// empty when the primary did
+	Replica string `json:"replica,omitempty"`
+	// FormatHints suggests a display format per column (one of the
+	// FormatHint constants, or "" for none). Omitted when no column has one.
+	FormatHints []string `json:"formatHints,omitempty"`
+	// Row identity, when requested with rowKeys. For a SELECT from one
+	// table that selects its whole primary key, RowKeys holds each row's
+	// key values in RowKeyColumns order and EditTable is the table edits
+	// should target. Otherwise ReadOnly is set and ReadOnlyReason says why
+	// (joins, aggregates, no primary key).
+	EditTable      *TableRef       `json:"editTable,omitempty"`
+	RowKeyColumns  []string        `json:"rowKeyColumns,omitempty"`
+	RowKeys        [][]interface{} `json:"rowKeys,omitempty"`
+	ReadOnly       bool            `json:"readOnly,omitempty"`
+	ReadOnlyReason string          `json:"readOnlyReason,omitempty"`
+}
+
+// Row formats for QueryRequest.RowFormat
+const (
+	RowFormatArray  = "array"
+	RowFormatObject = "object"
+	RowFormatArrow  = "arrow"
+)
+
+// Display formats suggested in QueryResult.FormatHints
+const (
+	FormatHintDate      = "date"
+	FormatHintDateTime  = "datetime"
+	FormatHintUnixTime  = "unixTime"  // Integer seconds since the epoch
+	FormatHintBytes     = "bytes"     // Human-readable size, e.g. 1.5 MB
+	FormatHintMonospace = "monospace" // Identifiers and UUIDs
+)
+
+// MarshalJSON encodes rows as objects when RowFormat is "object"
+func (r QueryResult) MarshalJSON() ([]byte, error) {
+	type plain QueryResult
+	if r.RowFormat != RowFormatObject {
+		return json.Marshal(plain(r))
+	}
+
+	objects := make([]map[string]interface{}, len(r.Rows))
+	for i, row := range r.Rows {
+		object := make(map[string]interface{}, len(r.Columns))
+		for j, name := range r.Columns {
+			if j < len(row) {
+				object[name] = row[j]
+			}
+		}
+		objects[i] = object
+	}
+
+	// The outer Rows field shadows the embedded one
+	return json.Marshal(struct {
+		plain
+		Rows []map[string]interface{} `json:"rows"`
+	}{plain(r), objects})
+}
+
+// Privilege types
+type Grant struct {
+	Privileges      []string `json:"privileges"`
+	Database        string   `json:"database"` // "*" means all databases
+	Table           string   `json:"table"`    // "*" means all tables
+	WithGrantOption bool     `json:"withGrantOption"`
+	Raw             string   `json:"raw"`
+	// Columns lists, per privilege, the columns it is limited to, for
+	// column grants such as "UPDATE (status)"
+	Columns map[string][]string `json:"columns,omitempty"`
+}
+
+// PrivilegeCheck is returned by checkPrivilege. An empty Database checks a
+// global grant and an empty Table a database-wide one. Grant is the SHOW
+// GRANTS line that gives the privilege. When the privilege is held only on
+// some of the table's columns, Granted is false and Columns lists them.
+type PrivilegeCheck struct {
+	Privilege string   `json:"privilege"`
+	Database  string   `json:"database,omitempty"`
+	Table     string   `json:"table,omitempty"`
+	Granted   bool     `json:"granted"`
+	Grant     string   `json:"grant,omitempty"`
+	Columns   []string `json:"columns,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+// PrivilegeInfo is returned by getPrivileges. When the user has roles,
+// Grants include the roles' privileges if RolesExpanded is set; otherwise
+// ReadOnly is null because privileges from roles are unknown.
+type PrivilegeInfo struct {
+	User          string   `json:"user"`
+	Grants        []Grant  `json:"grants"`
+	Roles         []string `json:"roles,omitempty"`
+	RolesExpanded bool     `json:"rolesExpanded,omitempty"`
+	ReadOnly      *bool    `json:"readOnly"` // No write or DDL privilege at any scope
+}
+
+// Sample types
+type SampleRequest struct {
+	ConnectionID string `json:"connectionId"`
+	Database     string `json:"database"`
+	Table        string `json:"table"`
+	Limit        int    `json:"limit,omitempty"`
+	Random       bool   `json:"random,omitempty"` // Opt-in: uses ORDER BY RAND()
+}
+
+type SampleResult struct {
+	QueryResult
+	Strategy string `json:"strategy"` // "first" or "random"
+	Note     string `json:"note"`     // Explains the cost/representativeness tradeoff
+}
+
+// Top-N types
+type TopNRequest struct {
+	ConnectionID string `json:"connectionId"`
+	Database     string `json:"database"`
+	Table        string `json:"table"`
+	OrderBy      string `json:"orderBy"`
+	Direction    string `json:"direction,omitempty"` // ASC or DESC (default)
+	N            int    `json:"n,omitempty"`         // Default 10, max 1000
+	Nulls        string `json:"nulls,omitempty"`     // NullsFirst or NullsLast; default is MySQL's
+}
+
+// Placement of NULLs in generated ORDER BY clauses. MySQL sorts NULLs
+// first for ASC and last for DESC when neither is given.
+const (
+	NullsFirst = "first"
+	NullsLast  = "last"
+)
+
+type TopNResult struct {
+	QueryResult
+	SQL        string `json:"sql"`
+	Index      string `json:"index,omitempty"` // Index the plan reads, if any
+	SortsTable bool   `json:"sortsTable"`      // EXPLAIN reported Using filesort
+	// Recommendation suggests an index on the order column when the table
+	// has to be sorted
+	Recommendation string `json:"recommendation,omitempty"`
+}
+
+// Query summary types
+type SummaryRequest struct {
+	ConnectionID string `json:"connectionId"`
+	SQL          string `json:"sql"`
+	MaxRows      int64  `json:"maxRows,omitempty"` // Default 1,000,000, max 10,000,000
+}
+
+type ColumnSummary struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Kind      string `json:"kind"`  // "numeric", "string" or "other"
+	Count     int64  `json:"count"` // Non-NULL values
+	NullCount int64  `json:"nullCount"`
+	// Numeric columns only; nil when every value is NULL
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	Avg *float64 `json:"avg,omitempty"`
+	Sum *float64 `json:"sum,omitempty"`
+	// String columns only. DistinctAtLeast is set when there were too many
+	// distinct values to count, making DistinctCount a lower bound.
+	DistinctCount   *int64 `json:"distinctCount,omitempty"`
+	DistinctAtLeast bool   `json:"distinctAtLeast,omitempty"`
+}
+
+type QuerySummary struct {
+	Columns       []ColumnSummary `json:"columns"`
+	RowCount      int64           `json:"rowCount"`  // Rows summarized
+	Truncated     bool            `json:"truncated"` // The result exceeded maxRows
+	Message       string          `json:"message,omitempty"`
+	ExecutionTime int64           `json:"executionTime"` // milliseconds
+}
+
+// SchemaExport is returned by exportSchema. Script recreates the
+// database's tables, views, routines and triggers; Warnings lists objects
+// that were skipped, and Cycles the tables whose foreign keys form loops.
+type SchemaExport struct {
+	Database      string     `json:"database"`
+	Script        string     `json:"script"`
+	Tables        int        `json:"tables"`
+	Views         int        `json:"views"`
+	Routines      int        `json:"routines"`
+	Triggers      int        `json:"triggers"`
+	Cycles        [][]string `json:"cycles"`
+	Warnings      []string   `json:"warnings"`
+	ExecutionTime int64      `json:"executionTime"` // milliseconds
+}
+
+// CacheStats is returned by getCacheStats. Hits and Misses count cache
+// lookups since the backend started.
+type CacheStats struct {
+	Count   int               `json:"count"`
+	Expired int               `json:"expired"` // Entries past their TTL, no longer served
+	Hits    int64             `json:"hits"`
+	Misses  int64             `json:"misses"`
+	HitRate float64           `json:"hitRate"` // 0-1; 0 before any lookup
+	Entries []CacheEntryStats `json:"entries"` // Oldest first
+}
+
+type CacheEntryStats struct {
+	Method      string `json:"method"`        // Request that cached the entry, e.g. listTables
+	Key         string `json:"key,omitempty"` // Only with includeKeys
+	AgeMs       int64  `json:"ageMs"`
+	TTLMs       int64  `json:"ttlMs"`
+	RemainingMs int64  `json:"remainingMs"`
+	Expired     bool   `json:"expired"`
+}
+
+// RowsAround is returned by getRowsAround: rows in primary key order
+// centered on a key. TargetIndex is the key's row in Rows, or -1 when no row
+// has the key; InsertIndex is where that row is or would be.
+type RowsAround struct {
+	QueryResult
+	KeyColumns  []string `json:"keyColumns"`
+	TargetIndex int      `json:"targetIndex"`
+	InsertIndex int      `json:"insertIndex"`
+}
+
+// ColumnCardinality is returned by getColumnCardinality: the optimizer's
+// estimate of distinct values of some columns, taken from an index that
+// starts with them, next to the exact counts when they were requested.
+// Selectivity is distinct values per row; values near 1 make good indexes.
+type ColumnCardinality struct {
+	Database             string   `json:"database"`
+	Table                string   `json:"table"`
+	Columns              []string `json:"columns"`
+	Index                string   `json:"index,omitempty"` // Index the estimate comes from
+	EstimatedDistinct    *int64   `json:"estimatedDistinct"`
+	EstimatedRows        int64    `json:"estimatedRows"`
+	EstimatedSelectivity *float64 `json:"estimatedSelectivity"`
+	ActualDistinct       *int64   `json:"actualDistinct,omitempty"`
+	ActualRows           *int64   `json:"actualRows,omitempty"`
+	ActualSelectivity    *float64 `json:"actualSelectivity,omitempty"`
+	Advice               []string `json:"advice"`
+	ExecutionTime        int64    `json:"executionTime"` // milliseconds
+}
+
+// InformationSchema is returned by getInformationSchema: the
+// information_schema.TABLES and COLUMNS rows of one database. Nil numbers
+// are NULL in information_schema (views, or types without that property).
+type InformationSchema struct {
+	Database string            `json:"database"`
+	Tables   []InfoSchemaTable `json:"tables"`
+}
+
+type InfoSchemaTable struct {
+	Name          string             `json:"name"`
+	Type          string             `json:"type"` // BASE TABLE, VIEW or SYSTEM VIEW
+	Engine        string             `json:"engine,omitempty"`
+	RowFormat     string             `json:"rowFormat,omitempty"`
+	Rows          *int64             `json:"rows"` // Estimate for InnoDB
+	AvgRowLength  *int64             `json:"avgRowLength"`
+	DataLength    *int64             `json:"dataLength"`
+	IndexLength   *int64             `json:"indexLength"`
+	DataFree      *int64             `json:"dataFree"`
+	AutoIncrement *int64             `json:"autoIncrement"`
+	CreateTime    string             `json:"createTime,omitempty"`
+	UpdateTime    string             `json:"updateTime,omitempty"`
+	Collation     string             `json:"collation,omitempty"`
+	CreateOptions string             `json:"createOptions,omitempty"`
+	Comment       string             `json:"comment,omitempty"`
+	Columns       []InfoSchemaColumn `json:"columns"`
+}
+
+type InfoSchemaColumn struct {
+	Name                   string  `json:"name"`
+	Position               int64   `json:"position"`
+	Default                *string `json:"default"`
+	Nullable               bool    `json:"nullable"`
+	DataType               string  `json:"dataType"`   // e.g. varchar
+	ColumnType             string  `json:"columnType"` // e.g. varchar(255)
+	CharacterMaximumLength *int64  `json:"characterMaximumLength"`
+	NumericPrecision       *int64  `json:"numericPrecision"`
+	NumericScale           *int64  `json:"numericScale"`
+	DatetimePrecision      *int64  `json:"datetimePrecision"`
+	CharacterSet           string  `json:"characterSet,omitempty"`
+	Collation              string  `json:"collation,omitempty"`
+	Key                    string  `json:"key"`
+	Extra                  string  `json:"extra"`
+	Comment                string  `json:"comment,omitempty"`
+	GenerationExpression   string  `json:"generationExpression,omitempty"`
+}
+
+// HostConnection is an open connection found by findConnectionsByHost.
+// Replica is set when the host is one of its read replicas rather than its
+// primary.
+type HostConnection struct {
+	ConnectionID string `json:"connectionId"`
+	Name         string `json:"name"`
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+	Replica      bool   `json:"replica,omitempty"`
+}
+
+// ExpectedColumn is one column of an assertResultSchema contract. Type may
+// be a column type ("varchar(20)"), which matches any type of the same
+// family (CHAR, VARCHAR and TEXT are all strings), a family name
+// ("string", "binary", "integer", "decimal", "float", "date", "datetime",
+// "time", "json", "geometry"), or empty to check only the name.
+type ExpectedColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// SchemaAssertion is returned by assertResultSchema. Columns are the
+// result's actual columns.
+type SchemaAssertion struct {
+	Pass       bool             `json:"pass"`
+	Columns    []ResultColumn   `json:"columns"`
+	Mismatches []SchemaMismatch `json:"mismatches"`
+}
+
+// Kinds of SchemaMismatch
+const (
+	MismatchMissing    = "missing"
+	MismatchUnexpected = "unexpected"
+	MismatchType       = "type"
+	MismatchPosition   = "position"
+)
+
+type SchemaMismatch struct {
+	Column   string `json:"column"`
+	Kind     string `json:"kind"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Message  string `json:"message"`
+}
+
+// History types
+type HistoryEntry struct {
+	ConnectionID  string    `json:"connectionId"`
+	SQL           string    `json:"sql"`
+	ExecutedAt    time.Time `json:"executedAt"`
+	ExecutionTime int64     `json:"executionTime"` // milliseconds
+	RowCount      int64     `json:"rowCount"`
+	Error         string    `json:"error,omitempty"`
+}
+
+type HistoryRequest struct {
+	ConnectionID string `json:"connectionId,omitempty"` // Optional filter
+	Before       string `json:"before,omitempty"`       // Cursor from a previous page
+	Limit        int    `json:"limit,omitempty"`
+}
+
+type HistoryPage struct {
+	Entries    []HistoryEntry `json:"entries"`              // Newest first
+	NextCursor string         `json:"nextCursor,omitempty"` // Empty when no older entries remain
+}
+
+// Account types
+type Account struct {
+	User          string `json:"user"`
+	Host          string `json:"host"`
+	AccountLocked bool   `json:"accountLocked"`
+}
+
+// AccountList is returned by listUsers and listRoles. When the current user
+// cannot read the grant tables, Available is false and Message explains why.
+type AccountList struct {
+	Accounts  []Account `json:"accounts"`
+	Available bool      `json:"available"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Diff types
+type QuerySpec struct {
+	ConnectionID string `json:"connectionId"`
+	SQL          string `json:"sql"`
+}
+
+type DiffRequest struct {
+	Left       QuerySpec `json:"left"`
+	Right      QuerySpec `json:"right"`
+	KeyColumns []string  `json:"keyColumns"`
+}
+
+type RowDifference struct {
+	Key            []interface{} `json:"key"`
+	Left           []interface{} `json:"left"`
+	Right          []interface{} `json:"right"`
+	ChangedColumns []string      `json:"changedColumns"`
+}
+
+// ResultDiff compares two result sets row by row using key columns. Rows in
+// OnlyInRight and Right use the left result's column order.
+type ResultDiff struct {
+	Columns      []string        `json:"columns"`
+	KeyColumns   []string        `json:"keyColumns"`
+	OnlyInLeft   [][]interface{} `json:"onlyInLeft"`
+	OnlyInRight  [][]interface{} `json:"onlyInRight"`
+	Changed      []RowDifference `json:"changed"`
+	MatchingRows int64           `json:"matchingRows"`
+	LeftRows     int64           `json:"leftRows"`
+	RightRows    int64           `json:"rightRows"`
+}
+
+// TableLoadProgress is sent as a listAllTablesProgress notification after
+// each database is loaded
+type TableLoadProgress struct {
+	RequestID string `json:"requestId"`
+	Database  string `json:"database"`
+	Loaded    int    `json:"loaded"`
+	Total     int    `json:"total"`
+	Error     string `json:"error,omitempty"`
+	// Retries counts attempts repeated after transient errors
+	Retries int `json:"retries,omitempty"`
+}
+
+// AllTables is returned by listAllTables when includeStatus is set. Retried
+// maps the databases that needed retries after transient errors to how
+// many, and Failed maps the databases left out of Tables to their error.
+type AllTables struct {
+	Tables  map[string][]Table `json:"tables"`
+	Retried map[string]int     `json:"retried,omitempty"`
+	Failed  map[string]string  `json:"failed,omitempty"`
+}
+
+// Profile types
+type ProfileStage struct {
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"` // seconds
+}
+
+type QueryProfile struct {
+	SQL           string         `json:"sql"`
+	RowCount      int64          `json:"rowCount"`
+	TotalDuration float64        `json:"totalDuration"` // seconds
+	Stages        []ProfileStage `json:"stages"`
+}
+
+// Connection states reported by connectionStateChanged notifications
+const (
+	ConnectionStateConnected   = "connected"
+	ConnectionStateLost        = "lost"
+	ConnectionStateReconnected = "reconnected"
+	ConnectionStateClosed      = "closed"
+)
+
+// ConnectionHealth is the outcome of one connection's health check
+type ConnectionHealth struct {
+	ConnectionID string `json:"connectionId"`
+	Reachable    bool   `json:"reachable"`
+	LatencyMs    int64  `json:"latencyMs"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Readiness is returned by readiness. Status is "healthy", or "degraded"
+// when there are connections but none of them is reachable.
+type Readiness struct {
+	Status      string             `json:"status"`
+	Total       int                `json:"total"`
+	Reachable   int                `json:"reachable"`
+	Connections []ConnectionHealth `json:"connections"`
+}
+
+// ReconnectResult is the outcome of re-establishing one connection
+type ReconnectResult struct {
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ConnectionStateEvent struct {
+	ConnectionID string `json:"connectionId"`
+	State        string `json:"state"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Pivot types
+type PivotRequest struct {
+	ConnectionID string `json:"connectionId"`
+	SQL          string `json:"sql"`
+	RowKey       string `json:"rowKey"`
+	ColumnKey    string `json:"columnKey"`
+	ValueColumn  string `json:"valueColumn"`
+	Aggregate    string `json:"aggregate,omitempty"` // "first" (default), "sum" or "count"
+}
+
+type PivotResult struct {
+	RowKeys       []string                          `json:"rowKeys"`    // In query order
+	ColumnKeys    []string                          `json:"columnKeys"` // Sorted
+	Cells         map[string]map[string]interface{} `json:"cells"`      // Missing cells are null
+	Aggregate     string                            `json:"aggregate"`
+	ExecutionTime int64                             `json:"executionTime"` // milliseconds
+}
+
+// HierarchyNode is one row of a hierarchy with its child rows. Values are
+// in the order of Hierarchy.Columns. Orphan marks a row whose parent does not
+// exist, placed at the top level.
+type HierarchyNode struct {
+	ID       interface{}      `json:"id"`
+	Values   []interface{}    `json:"values"`
+	Orphan   bool             `json:"orphan,omitempty"`
+	Children []*HierarchyNode `json:"children"`
+}
+
+// Hierarchy is returned by getHierarchy. Orphans lists the IDs of rows whose
+// parent does not exist. Cycles lists the IDs in each parent cycle; rows in
+// or below a cycle are left out of the tree and counted in Excluded.
+type Hierarchy struct {
+	Columns   []string         `json:"columns"`
+	Roots     []*HierarchyNode `json:"roots"`
+	NodeCount int              `json:"nodeCount"`
+	Orphans   []interface{}    `json:"orphans"`
+	Cycles    [][]interface{}  `json:"cycles"`
+	Excluded  int              `json:"excluded"`
+}
+
+// Partition is one partition, or subpartition, of a partitioned table.
+// Description holds the RANGE bound or LIST values. RowCount is an estimate.
+type Partition struct {
+	Name               string `json:"name"`
+	Subpartition       string `json:"subpartition,omitempty"`
+	Position           int64  `json:"position"`
+	Method             string `json:"method"`
+	SubpartitionMethod string `json:"subpartitionMethod,omitempty"`
+	Expression         string `json:"expression"`
+	Description        string `json:"description,omitempty"`
+	RowCount           int64  `json:"rowCount"`
+	DataLength         int64  `json:"dataLength"`
+	IndexLength        int64  `json:"indexLength"`
+	Comment            string `json:"comment,omitempty"`
+}
+
+// Lock types
+type Transaction struct {
+	ID           string `json:"id"`
+	ThreadID     int64  `json:"threadId"`
+	State        string `json:"state"`
+	Started      string `json:"started"`
+	Query        string `json:"query,omitempty"`
+	RowsLocked   int64  `json:"rowsLocked"`
+	TablesLocked int64  `json:"tablesLocked"`
+}
+
+type Lock struct {
+	TransactionID string `json:"transactionId"`
+	Database      string `json:"database"`
+	Table         string `json:"table"`
+	Index         string `json:"index,omitempty"`
+	LockType      string `json:"lockType"` // RECORD or TABLE
+	LockMode      string `json:"lockMode"`
+	LockStatus    string `json:"lockStatus,omitempty"` // GRANTED or WAITING (MySQL 8+ only)
+	LockData      string `json:"lockData,omitempty"`
+}
+
+type LockWait struct {
+	WaitingTransactionID  string `json:"waitingTransactionId"`
+	WaitingThreadID       int64  `json:"waitingThreadId"`
+	BlockingTransactionID string `json:"blockingTransactionId"`
+	BlockingThreadID      int64  `json:"blockingThreadId"`
+}
+
+// LockInfo is returned by listLocks. When InnoDB transaction tables cannot be
+// read, Available is false and Message explains why.
+type LockInfo struct {
+	Transactions []Transaction `json:"transactions"`
+	Locks        []Lock        `json:"locks"`
+	Waits        []LockWait    `json:"waits"`
+	Available    bool          `json:"available"`
+	Message      string        `json:"message,omitempty"`
+}
+
+// LongTransaction is an InnoDB transaction open longer than the threshold
+// of getLongTransactions. AgeSeconds counts from Started; User, Host and
+// Database come from the process list of its thread.
+type LongTransaction struct {
+	Transaction
+	AgeSeconds     int64  `json:"ageSeconds"`
+	OperationState string `json:"operationState,omitempty"`
+	RowsModified   int64  `json:"rowsModified"`
+	User           string `json:"user,omitempty"`
+	Host           string `json:"host,omitempty"`
+	Database       string `json:"database,omitempty"`
+}
+
+// LongTransactionList is returned by getLongTransactions, oldest first.
+// HistoryListLength is the number of undo log entries waiting for purge,
+// when the server reports it. When InnoDB transactions cannot be read,
+// Available is false and Message explains why.
+type LongTransactionList struct {
+	Transactions      []LongTransaction `json:"transactions"`
+	MinSeconds        int               `json:"minSeconds"`
+	HistoryListLength *int64            `json:"historyListLength,omitempty"`
+	Available         bool              `json:"available"`
+	Message           string            `json:"message,omitempty"`
+}
+
+// SlowQuery is one entry of the slow query log. QueryTime and LockTime are
+// in seconds.
+type SlowQuery struct {
+	StartTime    string  `json:"startTime"`
+	UserHost     string  `json:"userHost"`
+	Database     string  `json:"database,omitempty"`
+	QueryTime    float64 `json:"queryTime"`
+	LockTime     float64 `json:"lockTime"`
+	RowsSent     int64   `json:"rowsSent"`
+	RowsExamined int64   `json:"rowsExamined"`
+	SQL          string  `json:"sql"`
+}
+
+// SlowQueryLog is returned by getSlowQueries. When the slow log cannot be
+// read from mysql.slow_log, Available is false and Message explains why.
+type SlowQueryLog struct {
+	Queries       []SlowQuery `json:"queries"`
+	LongQueryTime float64     `json:"longQueryTime"`
+	Available     bool        `json:"available"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// ReplicationChannel is one replication channel of a replica, as reported
+// by SHOW REPLICA STATUS. IORunning and SQLRunning are "Yes", "No" or
+// "Connecting". SecondsBehindSource is nil while replication is stopped.
+type ReplicationChannel struct {
+	Channel             string `json:"channel,omitempty"`
+	SourceHost          string `json:"sourceHost"`
+	SourcePort          int64  `json:"sourcePort"`
+	IORunning           string `json:"ioRunning"`
+	SQLRunning          string `json:"sqlRunning"`
+	SecondsBehindSource *int64 `json:"secondsBehindSource"`
+	SourceLogFile       string `json:"sourceLogFile"`
+	ReadSourceLogPos    int64  `json:"readSourceLogPos"`
+	RelaySourceLogFile  string `json:"relaySourceLogFile"`
+	ExecSourceLogPos    int64  `json:"execSourceLogPos"`
+	LastIOError         string `json:"lastIoError,omitempty"`
+	LastSQLError        string `json:"lastSqlError,omitempty"`
+}
+
+// BinlogPosition is the server's current binary log file and position
+type BinlogPosition struct {
+	File            string `json:"file"`
+	Position        int64  `json:"position"`
+	ExecutedGtidSet string `json:"executedGtidSet,omitempty"`
+}
+
+// ReplicationStatus is returned by getReplicationStatus. Binlog is nil when
+// binary logging is disabled. Message explains missing parts when the
+// status could not be read.
+type ReplicationStatus struct {
+	IsReplica bool                 `json:"isReplica"`
+	Channels  []ReplicationChannel `json:"channels"`
+	Binlog    *BinlogPosition      `json:"binlog"`
+	Message   string               `json:"message,omitempty"`
+}
+
+type DatabaseDDL struct {
+	Database string `json:"database"`
+	DDL      string `json:"ddl"`
+}
+
+// PoolStats mirrors database/sql DBStats for a connection's pool
+type PoolStats struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitDuration       int64 `json:"waitDuration"` // milliseconds
+	MaxIdleClosed      int64 `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64 `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64 `json:"maxLifetimeClosed"`
+}
+
+// ProcessExplain is returned by explainProcess. When the thread's statement
+// cannot be explained, Explainable is false and Message explains why.
+type ProcessExplain struct {
+	ThreadID    int64        `json:"threadId"`
+	Database    string       `json:"database,omitempty"`
+	SQL         string       `json:"sql,omitempty"`
+	Explainable bool         `json:"explainable"`
+	Plan        *QueryResult `json:"plan,omitempty"`
+	Message     string       `json:"message,omitempty"`
+}
+
+// AutoIncrementStatus reports how much of an auto-increment column's range is
+// used. NextValue is nil when the table has no auto-increment counter.
+type AutoIncrementStatus struct {
+	Database    string  `json:"database"`
+	Table       string  `json:"table"`
+	Column      string  `json:"column,omitempty"`
+	ColumnType  string  `json:"columnType,omitempty"`
+	PrimaryKey  bool    `json:"primaryKey"`
+	NextValue   *uint64 `json:"nextValue"`
+	MaxValue    uint64  `json:"maxValue,omitempty"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// ExplainAnalyzeResult is returned by explainAnalyze. When the server does not
+// support EXPLAIN ANALYZE, Supported is false and Message explains why.
+type ExplainAnalyzeResult struct {
+	Supported     bool   `json:"supported"`
+	Plan          string `json:"plan,omitempty"` // Tree format with actual rows and timings
+	Message       string `json:"message,omitempty"`
+	ExecutionTime int64  `json:"executionTime"` // milliseconds
+}
+
+// QueryExplain is returned by explainQuery: the EXPLAIN plan, the warnings
+// EXPLAIN left behind (including the optimizer's rewritten query, note
+// 1003), and hints about mistakes they reveal
+type QueryExplain struct {
+	Plan     *QueryResult     `json:"plan"`
+	Warnings []ExplainWarning `json:"warnings"`
+	Hints    []QueryHint      `json:"hints"`
+	// Tree is the plan from EXPLAIN FORMAT=JSON as nested nodes, when
+	// requested
+	Tree *PlanNode `json:"tree,omitempty"`
+}
+
+// PlanNode is one node of a query plan tree: a query block, a table, a
+// join (nested_loop) or an operation such as ordering_operation. Cost is
+// the query cost of a query block and the cumulative prefix cost of a
+// table. Scalars without a field of their own are kept in Attributes.
+type PlanNode struct {
+	Kind           string                 `json:"kind"`
+	Label          string                 `json:"label"`
+	SelectID       int64                  `json:"selectId,omitempty"`
+	Table          string                 `json:"table,omitempty"`
+	AccessType     string                 `json:"accessType,omitempty"`
+	PossibleKeys   []string               `json:"possibleKeys,omitempty"`
+	Key            string                 `json:"key,omitempty"`
+	RowsExamined   *int64                 `json:"rowsExamined,omitempty"`
+	RowsProduced   *int64                 `json:"rowsProduced,omitempty"`
+	Filtered       *float64               `json:"filtered,omitempty"` // percent
+	Cost           *float64               `json:"cost,omitempty"`
+	ReadCost       *float64               `json:"readCost,omitempty"`
+	EvalCost       *float64               `json:"evalCost,omitempty"`
+	Condition      string                 `json:"condition,omitempty"`
+	UsingTemporary bool                   `json:"usingTemporary,omitempty"`
+	UsingFilesort  bool                   `json:"usingFilesort,omitempty"`
+	// Subquery names the list a subquery's block came from, such as
+	// attached_subqueries
+	Subquery   string                 `json:"subquery,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Children   []PlanNode             `json:"children,omitempty"`
+}
+
+type ExplainWarning struct {
+	Level   string `json:"level"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Kinds of QueryHint
+const (
+	HintImplicitConversion  = "implicitConversion"  // Type conversion on an indexed column
+	HintCollationConversion = "collationConversion" // Character set or collation conversion
+	HintIndexNotUsed        = "indexNotUsed"        // Full scan despite candidate indexes
+)
+
+type QueryHint struct {
+	Kind    string `json:"kind"`
+	Table   string `json:"table,omitempty"`
+	Column  string `json:"column,omitempty"`
+	Index   string `json:"index,omitempty"`
+	Message string `json:"message"`
+}
+
+// ResultColumn describes one column of a query result. CharacterSet and
+// Collation are empty for non-character columns and when the server could
+// not report them.
+type ResultColumn struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"` // Full column type, e.g. "varchar(20)"
+	CharacterSet string `json:"characterSet,omitempty"`
+	Collation    string `json:"collation,omitempty"`
+}
+
+// ResultColumnMeta is returned by getResultColumnMeta
+type ResultColumnMeta struct {
+	Columns []ResultColumn `json:"columns"`
+	Message string         `json:"message,omitempty"`
+}
+
+// TableRef identifies a table within a database
+type TableRef struct {
+	Database string `json:"database"`
+	Table    string `json:"table"`
+}
+
+// ForeignKey is a foreign key on Database.Table. Columns and
+// ReferencedColumns are in key order. OnUpdate and OnDelete are the
+// referential actions, such as CASCADE or RESTRICT.
+type ForeignKey struct {
+	Name               string   `json:"name"`
+	Database           string   `json:"database"`
+	Table              string   `json:"table"`
+	Columns            []string `json:"columns"`
+	ReferencedDatabase string   `json:"referencedDatabase"`
+	ReferencedTable    string   `json:"referencedTable"`
+	ReferencedColumns  []string `json:"referencedColumns"`
+	OnUpdate           string   `json:"onUpdate"`
+	OnDelete           string   `json:"onDelete"`
+}
+
+// TableDependencyOrder is returned by getTableDependencyOrder. Inserting in
+// InsertOrder puts referenced tables before the tables that reference them;
+// DeleteOrder is the reverse. Tables in a Cycle reference each other and
+// cannot be loaded in any order with foreign key checks on.
+// SelfReferencing tables need their own rows inserted parents first.
+type TableDependencyOrder struct {
+	InsertOrder     []string   `json:"insertOrder"`
+	DeleteOrder     []string   `json:"deleteOrder"`
+	Cycles          [][]string `json:"cycles"`
+	SelfReferencing []string   `json:"selfReferencing,omitempty"`
+}
+
+// DeleteEffect is what a delete would do to the rows of one table through
+// one foreign key. Depth is 1 for direct children of the deleted rows.
+type DeleteEffect struct {
+	Database   string `json:"database"`
+	Table      string `json:"table"`
+	ForeignKey string `json:"foreignKey"`
+	Action     string `json:"action"`
+	Rows       int64  `json:"rows"`
+	Depth      int    `json:"depth"`
+}
+
+// DeletePreview is returned by previewDelete. CascadeRows and CascadeTables
+// total the rows that ON DELETE CASCADE would also remove. Blocked means a
+// RESTRICT or NO ACTION foreign key would make the delete fail, and
+// Truncated that cascades went deeper than were followed.
+type DeletePreview struct {
+	Database      string         `json:"database"`
+	Table         string         `json:"table"`
+	Rows          int64          `json:"rows"`
+	Effects       []DeleteEffect `json:"effects"`
+	CascadeRows   int64          `json:"cascadeRows"`
+	CascadeTables int            `json:"cascadeTables"`
+	Blocked       bool           `json:"blocked"`
+	Truncated     bool           `json:"truncated,omitempty"`
+}
+
+// Autocomplete types
+type AutocompleteColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // DATA_TYPE, e.g. "varchar"
+}
+
+// AutocompleteSchema maps database -> table -> columns for editor completion
+type AutocompleteSchema struct {
+	Databases map[string]map[string][]AutocompleteColumn `json:"databases"`
+	Keywords  []string                                   `json:"keywords"`
+}
+
+// Script types
+type ScriptRequest struct {
+	ConnectionID string `json:"connectionId"`
+	Script       string `json:"script"`
+	// Transaction wraps the script in a transaction that is rolled back on
+	// failure or cancellation. DDL statements commit implicitly in MySQL.
+	Transaction bool `json:"transaction,omitempty"`
+	// DisableForeignKeyChecks sets FOREIGN_KEY_CHECKS=0 for the script's
+	// session only, restoring it afterwards, for dumps whose rows are not
+	// in dependency order
+	DisableForeignKeyChecks bool `json:"disableForeignKeyChecks,omitempty"`
+}
+
+// ScriptProgress is sent as a scriptProgress notification after each statement
+type ScriptProgress struct {
+	RequestID    string `json:"requestId"`
+	Statement    int    `json:"statement"` // 1-based index of the completed statement
+	Total        int    `json:"total"`
+	RowsAffected int64  `json:"rowsAffected"` // Running total
+}
+
+type ScriptResult struct {
+	StatementsExecuted int   `json:"statementsExecuted"`
+	Total              int   `json:"total"`
+	RowsAffected       int64 `json:"rowsAffected"`
+	ExecutionTime      int64 `json:"executionTime"` // milliseconds
+}
+
+// ServerTime is returned by getServerTime. Times are ISO-8601 wall clock
+// values without an offset; UTCOffsetSeconds relates ServerTime to UTCTime.
+type ServerTime struct {
+	ServerTime       string `json:"serverTime"` // NOW() in the session time zone
+	UTCTime          string `json:"utcTime"`    // UTC_TIMESTAMP()
+	TimeZone         string `json:"timeZone"`   // @@time_zone
+	SystemTimeZone   string `json:"systemTimeZone"`
+	UTCOffsetSeconds int64  `json:"utcOffsetSeconds"`
+	BackendUTCTime   string `json:"backendUtcTime"`
+	// SkewMilliseconds is the server's UTC clock minus the backend's,
+	// measured at the midpoint of the round trip
+	SkewMilliseconds int64 `json:"skewMilliseconds"`
+}
+
+// QueryProgress is sent as a queryProgress notification while a query runs.
+// Percent is set only when the server reports stage progress (MySQL 8
+// performance_schema, mostly for ALTER TABLE and similar long stages).
+type QueryProgress struct {
+	RequestID   string   `json:"requestId"`
+	Elapsed     int64    `json:"elapsed"` // milliseconds
+	RowsFetched int64    `json:"rowsFetched"`
+	Stage       string   `json:"stage,omitempty"`
+	Percent     *float64 `json:"percent,omitempty"`
+}
+
+// ServerStatus describes server settings detected at connect time
+type ServerStatus struct {
+	Version             string `json:"version"`
+	Flavor              string `json:"flavor"` // "mysql" or "mariadb"
+	LowerCaseTableNames int    `json:"lowerCaseTableNames"`
+	// MaxAllowedPacket is @@max_allowed_packet in bytes, 0 if unknown;
+	// MaxStatementSize is the largest statement the backend will send
+	MaxAllowedPacket int64 `json:"maxAllowedPacket"`
+	MaxStatementSize int64 `json:"maxStatementSize"`
+	// Replicas are the connected read replicas as host:port
+	Replicas []string `json:"replicas,omitempty"`
+}
+
+// MethodList is returned by listMethods for client feature detection
+type MethodList struct {
+	Version string   `json:"version"`
+	Methods []string `json:"methods"`
+}
+
+// ProtocolVersion is the JSON-RPC protocol revision negotiated by initialize
+const ProtocolVersion = 1
+
+// ClientCapabilities are the options a client asks for in initialize
+type ClientCapabilities struct {
+	Streaming bool `json:"streaming,omitempty"`
+	// Compression lists the result encodings the client can decode, e.g. "gzip"
+	Compression []string `json:"compression,omitempty"`
+	// MaxBatchSize is the most rows the client wants per streamed batch
+	MaxBatchSize int `json:"maxBatchSize,omitempty"`
+	// ResultFormats lists the result formats beyond JSON the client can
+	// decode, e.g. "arrow"
+	ResultFormats []string `json:"resultFormats,omitempty"`
+}
+
+// ServerCapabilities is the feature set both sides agreed on
+type ServerCapabilities struct {
+	Streaming    bool   `json:"streaming"`
+	Compression  string `json:"compression,omitempty"` // "gzip" or empty
+	MaxBatchSize int    `json:"maxBatchSize"`
+	// ResultFormats are the granted formats a query's rowFormat may request
+	ResultFormats []string `json:"resultFormats,omitempty"`
+}
+
+type InitializeRequest struct {
+	ProtocolVersion int                `json:"protocolVersion"`
+	ClientName      string             `json:"clientName,omitempty"`
+	ClientVersion   string             `json:"clientVersion,omitempty"`
+	Capabilities    ClientCapabilities `json:"capabilities"`
+}
+
+type InitializeResult struct {
+	ServerVersion   string             `json:"serverVersion"`
+	ProtocolVersion int                `json:"protocolVersion"`
+	Capabilities    ServerCapabilities `json:"capabilities"`
+}
+
+// Cursor types
+type OpenCursorRequest struct {
+	ConnectionID string                 `json:"connectionId"`
+	SQL          string                 `json:"sql"`
+	NamedArgs    map[string]interface{} `json:"namedArgs,omitempty"`
+	// FetchAhead is how many batches to read ahead of fetchCursor (max 8);
+	// 0 reads rows only when they are fetched
+	FetchAhead int `json:"fetchAhead,omitempty"`
+}
+
+type CursorInfo struct {
+	CursorID        string   `json:"cursorId"`
+	Columns         []string `json:"columns"`
+	OriginalColumns []string `json:"originalColumns,omitempty"`
+}
+
+// CursorBatch is one fetchCursor page. When Done is set the cursor has been
+// closed and its ID is no longer valid.
+type CursorBatch struct {
+	Rows        [][]interface{} `json:"rows"`
+	Done        bool            `json:"done"`
+	RowsFetched int64           `json:"rowsFetched"`
+}
+
+// ExportRequest is the exportQueryStream request. Rows are sent as
+// exportChunk notifications of ChunkRows rows each, followed by an
+// exportComplete notification that repeats the method's result.
+type ExportRequest struct {
+	ConnectionID string                 `json:"connectionId"`
+	SQL          string                 `json:"sql"`
+	Format       string                 `json:"format"` // "csv" or "json" (JSON Lines)
+	ChunkRows    int                    `json:"chunkRows,omitempty"`
+	NamedArgs    map[string]interface{} `json:"namedArgs,omitempty"`
+	// MaxRows stops the export after this many rows; 0 exports them all
+	MaxRows int64 `json:"maxRows,omitempty"`
+	// FetchAhead is how many chunks to read from MySQL ahead of the one
+	// being sent (max 8)
+	FetchAhead int `json:"fetchAhead,omitempty"`
+}
+
+// ExportChunk carries serialized rows of an export. Sequence starts at 1 and
+// Data of successive chunks concatenates to the complete file.
+type ExportChunk struct {
+	RequestID string `json:"requestId"`
+	Sequence  int    `json:"sequence"`
+	Data      string `json:"data"`
+	Rows      int    `json:"rows"`
+}
+
+// QueryComplete summarizes a query whose rows were streamed rather than
+// returned in one QueryResult: the statement, its columns, how many rows
+// were sent, and whether a row cap cut the result short. Field names match
+// QueryResult. It ends every streamed result, so clients can show totals
+// such as "42,318 rows in 1.2s" without counting chunks.
+type QueryComplete struct {
+	RequestID     string   `json:"requestId"`
+	SQL           string   `json:"sql"`
+	Columns       []string `json:"columns"`
+	TotalRows     int64    `json:"totalRows"`
+	Truncated     bool     `json:"truncated"`
+	MaxRows       int64    `json:"maxRows,omitempty"` // The cap that truncated the result
+	ExecutionTime int64    `json:"executionTime"`     // milliseconds
+}
+
+// ExportComplete reports the totals of a finished export
+type ExportComplete struct {
+	QueryComplete
+	Format     string `json:"format"`
+	TotalBytes int64  `json:"totalBytes"`
+	Chunks     int    `json:"chunks"`
+}
+
+// ScanTableRequest asks for a whole table in primary key order. AfterKey,
+// one value per key column, resumes a scan after that row.
+type ScanTableRequest struct {
+	ConnectionID string        `json:"connectionId"`
+	Database     string        `json:"database"`
+	Table        string        `json:"table"`
+	BatchRows    int           `json:"batchRows,omitempty"` // default 1000, max 10000
+	AfterKey     []interface{} `json:"afterKey,omitempty"`
+}
+
+// TableScanChunk carries one batch of a table scan. Sequence starts at 1;
+// Columns and ColumnTypes are only set on the first chunk. LastKey is the
+// key of the last row sent so far, to resume an interrupted scan.
+type TableScanChunk struct {
+	RequestID   string          `json:"requestId"`
+	Sequence    int             `json:"sequence"`
+	Columns     []string        `json:"columns,omitempty"`
+	ColumnTypes []string        `json:"columnTypes,omitempty"`
+	Rows        [][]interface{} `json:"rows"`
+	LastKey     []interface{}   `json:"lastKey,omitempty"`
+}
+
+// TableScanComplete reports the totals of a finished table scan
+type TableScanComplete struct {
+	QueryComplete
+	Database   string        `json:"database"`
+	Table      string        `json:"table"`
+	KeyColumns []string      `json:"keyColumns"`
+	Chunks     int           `json:"chunks"`
+	LastKey    []interface{} `json:"lastKey,omitempty"`
+}
+
+// RowCounts is returned by getExactRowCounts. Counts maps table name to
+// its exact row count; tables that could not be counted are in Errors.
+type RowCounts struct {
+	Database      string            `json:"database"`
+	Counts        map[string]int64  `json:"counts"`
+	Errors        map[string]string `json:"errors,omitempty"`
+	ExecutionTime int64             `json:"executionTime"` // milliseconds
+}
+
+// SQLMode is returned by getSqlMode. Session applies to this connection's
+// queries; Strict is set when the session rejects invalid values instead
+// of adjusting them with a warning.
+type SQLMode struct {
+	Session      string   `json:"session"`
+	Global       string   `json:"global"`
+	SessionFlags []string `json:"sessionFlags"`
+	GlobalFlags  []string `json:"globalFlags"`
+	Strict       bool     `json:"strict"`
+}
+
+// Table size snapshot types
+type TableSize struct {
+	Name        string `json:"name"`
+	RowCount    int64  `json:"rowCount"`
+	DataLength  int64  `json:"dataLength"`
+	IndexLength int64  `json:"indexLength"`
+}
+
+type TableSizeSnapshot struct {
+	ID           int64       `json:"id"`
+	ConnectionID string      `json:"connectionId"`
+	Database     string      `json:"database"`
+	TakenAt      time.Time   `json:"takenAt"`
+	Tables       []TableSize `json:"tables,omitempty"`
+}
+
+// SavedQuery is a named query in the saved query library. ConnectionID
+// optionally associates it with one connection.
+type SavedQuery struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	SQL          string    `json:"sql"`
+	ConnectionID string    `json:"connectionId,omitempty"`
+	Tags         []string  `json:"tags"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// SavedConnection is a connection config from the encrypted connection
+// store. Config.Password is always empty; HasPassword tells whether one is
+// stored. Pinned connections are listed first, then by SortOrder; 0 means
+// the connection was never placed.
+type SavedConnection struct {
+	Config      ConnectionConfig `json:"config"`
+	HasPassword bool             `json:"hasPassword"`
+	SavedAt     time.Time        `json:"savedAt"`
+	Pinned      bool             `json:"pinned"`
+	SortOrder   int              `json:"sortOrder"`
+}
+
+// TableSizeChange compares one table across two snapshots. Status is
+// "added", "removed" or "changed"; deltas are After minus Before.
+type TableSizeChange struct {
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	RowCountBefore   int64  `json:"rowCountBefore"`
+	RowCountAfter    int64  `json:"rowCountAfter"`
+	RowCountDelta    int64  `json:"rowCountDelta"`
+	DataLengthDelta  int64  `json:"dataLengthDelta"`
+	IndexLengthDelta int64  `json:"indexLengthDelta"`
+	// TotalDelta is the change in data plus index bytes
+	TotalDelta int64 `json:"totalDelta"`
+}
+
+// TableSizeComparison lists changed tables, fastest growing first
+type TableSizeComparison struct {
+	From           TableSizeSnapshot `json:"from"`
+	To             TableSizeSnapshot `json:"to"`
+	ElapsedSeconds int64             `json:"elapsedSeconds"`
+	Tables         []TableSizeChange `json:"tables"`
+	TotalDelta     int64             `json:"totalDelta"`
+}
+
+// TableDocumentation is a table's comment with its columns, for a data
+// dictionary view
+type TableDocumentation struct {
+	Database string   `json:"database"`
+	Table    string   `json:"table"`
+	Comment  string   `json:"comment"`
+	Columns  []Column `json:"columns"`
+}
+
+// SchemaOperation is one metadata read of a getSchemaBundle request:
+// listDatabases, listTables of Database or listColumns of Database.Table.
+// Key names its entry in the result and defaults to the method and its
+// arguments, such as "listColumns:shop.orders".
+type SchemaOperation struct {
+	Key      string `json:"key,omitempty"`
+	Method   string `json:"method"`
+	Database string `json:"database,omitempty"`
+	Table    string `json:"table,omitempty"`
+}
+
+// SchemaBundleEntry is the outcome of one operation of a schema bundle,
+// either its Result or its Error
+type SchemaBundleEntry struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
 }
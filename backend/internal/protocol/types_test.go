@@ -212,6 +212,44 @@ func TestQueryResult(t *testing.T) {
 	}
 }
 
+func TestQueryResultObjectRowFormat(t *testing.T) {
+	result := QueryResult{
+		Columns:   []string{"id", "name", "id_2"},
+		Rows:      [][]interface{}{{1, "John", 7}, {2, nil, 8}},
+		RowFormat: RowFormatObject,
+	}
+
+	data, err := json.Marshal(&result)
+	if err != nil {
+		t.Fatalf("Failed to marshal query result: %v", err)
+	}
+
+	var decoded struct {
+		Columns   []string                 `json:"columns"`
+		Rows      []map[string]interface{} `json:"rows"`
+		RowFormat string                   `json:"rowFormat"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal object rows: %v (%s)", err, data)
+	}
+
+	if len(decoded.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(decoded.Rows))
+	}
+	if decoded.Rows[0]["name"] != "John" || decoded.Rows[0]["id_2"] != float64(7) {
+		t.Errorf("Unexpected first row: %v", decoded.Rows[0])
+	}
+	if value, ok := decoded.Rows[1]["name"]; !ok || value != nil {
+		t.Errorf("Expected explicit null for name, got %v (present=%v)", value, ok)
+	}
+	if decoded.RowFormat != RowFormatObject {
+		t.Errorf("Expected rowFormat object, got %q", decoded.RowFormat)
+	}
+	if len(decoded.Columns) != 3 {
+		t.Errorf("Expected columns to be kept, got %v", decoded.Columns)
+	}
+}
+
 func TestTableMetadata(t *testing.T) {
 	table := Table{
 		Name:        "users",
@@ -286,3 +324,38 @@ func TestColumnMetadata(t *testing.T) {
 		t.Errorf("Expected default value '%s', got %v", defaultValue, decoded.Default)
 	}
 }
+
+func TestExportCompleteSerialization(t *testing.T) {
+	complete := ExportComplete{
+		QueryComplete: QueryComplete{
+			RequestID:     "req-1",
+			SQL:           "SELECT * FROM orders",
+			Columns:       []string{"id"},
+			TotalRows:     100,
+			Truncated:     true,
+			MaxRows:       100,
+			ExecutionTime: 1200,
+		},
+		Format: "csv",
+		Chunks: 1,
+	}
+
+	data, err := json.Marshal(complete)
+	if err != nil {
+		t.Fatalf("Failed to marshal export completion: %v", err)
+	}
+
+	// The query summary is flattened into the export's own fields
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal export completion: %v", err)
+	}
+	for _, field := range []string{"requestId", "sql", "columns", "totalRows", "truncated", "maxRows", "executionTime", "format", "totalBytes", "chunks"} {
+		if _, ok := decoded[field]; !ok {
+			t.Errorf("Expected field %s in %s", field, data)
+		}
+	}
+	if decoded["truncated"] != true || decoded["totalRows"] != float64(100) {
+		t.Errorf("Unexpected totals in %s", data)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// cacheStats describes the metadata cache at now. Keys contain connection
+// IDs and database names, so they are only included when asked for; each
+// entry always reports the method that cached it.
+func (s *Server) cacheStats(includeKeys bool, now time.Time) *protocol.CacheStats {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	stats := &protocol.CacheStats{
+		Count:   len(s.cache),
+		Hits:    s.cacheHits.Load(),
+		Misses:  s.cacheMisses.Load(),
+		Entries: make([]protocol.CacheEntryStats, 0, len(s.cache)),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+
+	for key, entry := range s.cache {
+		age := now.Sub(entry.timestamp)
+		ttl := entry.effectiveTTL()
+		stat := protocol.CacheEntryStats{
+			Method:      strings.SplitN(key, ":", 2)[0],
+			AgeMs:       age.Milliseconds(),
+			TTLMs:       ttl.Milliseconds(),
+			RemainingMs: max(ttl-age, 0).Milliseconds(),
+			Expired:     entry.expired(now),
+		}
+		if includeKeys {
+			stat.Key = key
+		}
+		if stat.Expired {
+			// Expired entries stay in the map until replaced or invalidated
+			stats.Expired++
+		}
+		stats.Entries = append(stats.Entries, stat)
+	}
+
+	// Oldest first, so the entries most likely to be stale lead
+	sort.Slice(stats.Entries, func(i, j int) bool {
+		a, b := stats.Entries[i], stats.Entries[j]
+		if a.AgeMs != b.AgeMs {
+			return a.AgeMs > b.AgeMs
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Key < b.Key
+	})
+	return stats
+}
+
+func (s *Server) handleGetCacheStats(params json.RawMessage) (*protocol.CacheStats, error) {
+	var req struct {
+		IncludeKeys bool `json:"includeKeys"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+	return s.cacheStats(req.IncludeKeys, time.Now()), nil
+}
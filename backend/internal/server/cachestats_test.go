@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheStats(t *testing.T) {
+	s := NewServer()
+	s.setCache("listTables:conn1:shop", []string{})
+	s.setCacheWithTTL("listAllTables:conn1", []string{}, 5*time.Minute)
+	s.cache["listDatabases:conn1"] = cacheEntry{data: []string{}, timestamp: time.Now().Add(-time.Minute)}
+
+	s.getFromCache("listTables:conn1:shop")
+	s.getFromCache("listDatabases:conn1") // expired
+	s.getFromCache("listTables:conn2:shop")
+
+	stats := s.cacheStats(false, time.Now())
+	if stats.Count != 3 || stats.Expired != 1 {
+		t.Errorf("Expected 3 entries with 1 expired, got %d with %d expired", stats.Count, stats.Expired)
+	}
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses, got %d and %d", stats.Hits, stats.Misses)
+	}
+	if stats.HitRate < 0.33 || stats.HitRate > 0.34 {
+		t.Errorf("Expected a hit rate of 1/3, got %f", stats.HitRate)
+	}
+
+	oldest := stats.Entries[0]
+	if oldest.Method != "listDatabases" || !oldest.Expired || oldest.RemainingMs != 0 || oldest.TTLMs != 30000 {
+		t.Errorf("Expected the expired listDatabases entry first, got %+v", oldest)
+	}
+	for _, entry := range stats.Entries {
+		if entry.Key != "" {
+			t.Errorf("Expected keys to be left out, got %q", entry.Key)
+		}
+		if entry.Method == "listAllTables" && (entry.TTLMs != 300000 || entry.RemainingMs <= 290000) {
+			t.Errorf("Expected the custom TTL to be reported, got %+v", entry)
+		}
+	}
+
+	stats = s.cacheStats(true, time.Now())
+	if stats.Entries[0].Key != "listDatabases:conn1" {
+		t.Errorf("Expected keys with includeKeys, got %q", stats.Entries[0].Key)
+	}
+}
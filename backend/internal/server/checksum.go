@@ -0,0 +1,79 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"sort"
+	"strconv"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// resultChecksum hashes a result so the same logical result hashes the same
+// on any connection. Columns are hashed in name order, so the select-list
+// order does not matter, but row order does: use ORDER BY for stable results.
+// Every value is written with a type tag and length prefix, so NULL, the
+// empty string and "NULL" all hash differently.
+func resultChecksum(result *protocol.QueryResult, algorithm string) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "crc32":
+		h = crc32.NewIEEE()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+
+	order := make([]int, len(result.Columns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return result.Columns[order[a]] < result.Columns[order[b]]
+	})
+
+	for _, i := range order {
+		writeChecksumValue(h, result.Columns[i])
+	}
+	for _, row := range result.Rows {
+		h.Write([]byte{'\n'})
+		for _, i := range order {
+			writeChecksumValue(h, row[i])
+		}
+	}
+
+	return algorithm + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksumValue writes one canonically encoded value
+func writeChecksumValue(h hash.Hash, value interface{}) {
+	var tag byte
+	var text string
+	switch v := value.(type) {
+	case nil:
+		h.Write([]byte{'N'})
+		return
+	case string:
+		tag, text = 'S', v
+	case []byte:
+		tag, text = 'S', string(v)
+	case int64:
+		tag, text = 'I', strconv.FormatInt(v, 10)
+	case uint64:
+		tag, text = 'I', strconv.FormatUint(v, 10)
+	case float64:
+		tag, text = 'F', strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		tag, text = 'F', strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case bool:
+		tag, text = 'B', strconv.FormatBool(v)
+	default:
+		tag, text = 'V', fmt.Sprint(v)
+	}
+	h.Write([]byte{tag})
+	h.Write([]byte(strconv.Itoa(len(text)) + ":" + text))
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestResultChecksumIgnoresColumnOrder(t *testing.T) {
+	a := &protocol.QueryResult{
+		Columns: []string{"id", "name"},
+		Rows:    [][]interface{}{{int64(1), "ada"}, {int64(2), nil}},
+	}
+	b := &protocol.QueryResult{
+		Columns: []string{"name", "id"},
+		Rows:    [][]interface{}{{"ada", int64(1)}, {nil, int64(2)}},
+	}
+
+	for _, algorithm := range []string{"crc32", "sha256"} {
+		sumA, err := resultChecksum(a, algorithm)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		sumB, err := resultChecksum(b, algorithm)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sumA != sumB {
+			t.Errorf("%s: expected equal checksums, got %s and %s", algorithm, sumA, sumB)
+		}
+		if !strings.HasPrefix(sumA, algorithm+":") {
+			t.Errorf("Expected algorithm prefix, got %s", sumA)
+		}
+	}
+}
+
+func TestResultChecksumDistinguishesValues(t *testing.T) {
+	base := func(rows ...[]interface{}) *protocol.QueryResult {
+		return &protocol.QueryResult{Columns: []string{"a", "b"}, Rows: rows}
+	}
+
+	variants := map[string]*protocol.QueryResult{
+		"null":          base([]interface{}{nil, "x"}),
+		"empty string":  base([]interface{}{"", "x"}),
+		"NULL string":   base([]interface{}{"NULL", "x"}),
+		"shifted text":  base([]interface{}{"x", ""}),
+		"int":           base([]interface{}{int64(1), "x"}),
+		"string 1":      base([]interface{}{"1", "x"}),
+		"row order":     base([]interface{}{"1", "x"}, []interface{}{"2", "y"}),
+		"swapped order": base([]interface{}{"2", "y"}, []interface{}{"1", "x"}),
+	}
+
+	seen := make(map[string]string)
+	for name, result := range variants {
+		sum, err := resultChecksum(result, "sha256")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if other, exists := seen[sum]; exists {
+			t.Errorf("%s and %s hash identically", name, other)
+		}
+		seen[sum] = name
+	}
+}
+
+func TestResultChecksumUnsupportedAlgorithm(t *testing.T) {
+	if _, err := resultChecksum(&protocol.QueryResult{}, "md5"); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+}
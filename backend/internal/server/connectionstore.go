@@ -0,0 +1,409 @@
+package server
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	// storeKeyIterations is the PBKDF2-HMAC-SHA256 work factor for the
+	// connection store key
+	storeKeyIterations = 200000
+	storeSaltSize      = 16
+	// maxStoredConnections bounds the connection store
+	maxStoredConnections = 500
+)
+
+// errStoreNotConfigured is returned by store methods when no store file and
+// secret were set
+var errStoreNotConfigured = errors.New("connection store is not configured (set DATA_WARDEN_CONNECTION_STORE and DATA_WARDEN_CONNECTION_SECRET)")
+
+// connectionStore keeps saved connection configs. Each config, password
+// included, is encrypted with AES-256-GCM under a key derived from a secret
+// the backend is started with, so the file never holds credentials in
+// plaintext. Decrypted configs only live in memory.
+type connectionStore struct {
+	mu      sync.Mutex
+	configs map[string]storedConfig
+	path    string
+	key     []byte
+	salt    []byte
+}
+
+type storedConfig struct {
+	config    protocol.ConnectionConfig
+	savedAt   time.Time
+	pinned    bool
+	sortOrder int
+}
+
+// connectionStoreFile is the persisted form of the store. Only connection
+// IDs and list metadata are readable; the config of each entry is sealed.
+type connectionStoreFile struct {
+	Salt        string                  `json:"salt"`
+	Connections []sealedConnectionEntry `json:"connections"`
+}
+
+type sealedConnectionEntry struct {
+	ID        string    `json:"id"`
+	SavedAt   time.Time `json:"savedAt"`
+	Pinned    bool      `json:"pinned,omitempty"`
+	SortOrder int       `json:"sortOrder,omitempty"`
+	// Sealed is base64 of the GCM nonce followed by the encrypted config
+	// JSON. The ID is authenticated as additional data, so entries cannot be
+	// swapped between IDs.
+	Sealed string `json:"sealed"`
+}
+
+func newConnectionStore() *connectionStore {
+	return &connectionStore{configs: make(map[string]storedConfig)}
+}
+
+// deriveStoreKey derives a 32-byte key from secret with PBKDF2-HMAC-SHA256.
+// One SHA-256 block is exactly the key size, so only block 1 is computed.
+func deriveStoreKey(secret string, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(salt)
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], 1)
+	mac.Write(index[:])
+	u := mac.Sum(nil)
+
+	key := make([]byte, len(u))
+	copy(key, u)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+// sealConfig encrypts a config for the store file
+func sealConfig(key []byte, config protocol.ConnectionConfig) (string, error) {
+	plaintext, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newStoreCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(config.ID))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openConfig decrypts a sealed store entry
+func openConfig(key []byte, id, sealed string) (protocol.ConnectionConfig, error) {
+	var config protocol.ConnectionConfig
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return config, err
+	}
+	gcm, err := newStoreCipher(key)
+	if err != nil {
+		return config, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return config, errors.New("sealed entry is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return config, errors.New("cannot decrypt (wrong secret or corrupted file)")
+	}
+	if err := json.Unmarshal(plaintext, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func newStoreCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// load reads the store file at path, decrypting it with a key derived from
+// secret, and persists to it from then on. A missing file starts an empty
+// store with a new salt.
+func (cs *connectionStore) load(path, secret string) error {
+	if secret == "" {
+		return errors.New("a secret is required to encrypt the connection store")
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var file connectionStoreFile
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		cs.salt = make([]byte, storeSaltSize)
+		if _, err := rand.Read(cs.salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read connection store: %w", err)
+	default:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("failed to parse connection store: %w", err)
+		}
+		if cs.salt, err = base64.StdEncoding.DecodeString(file.Salt); err != nil || len(cs.salt) == 0 {
+			return fmt.Errorf("connection store has an invalid salt")
+		}
+	}
+
+	key := deriveStoreKey(secret, cs.salt, storeKeyIterations)
+	configs := make(map[string]storedConfig, len(file.Connections))
+	for _, entry := range file.Connections {
+		config, err := openConfig(key, entry.ID, entry.Sealed)
+		if err != nil {
+			return fmt.Errorf("failed to load saved connection %s: %w", entry.ID, err)
+		}
+		configs[entry.ID] = storedConfig{config: config, savedAt: entry.SavedAt, pinned: entry.Pinned, sortOrder: entry.SortOrder}
+	}
+
+	cs.path = path
+	cs.key = key
+	cs.configs = configs
+	return nil
+}
+
+// save stores a config under its ID, replacing any saved before but keeping
+// its pin and position, and persists the store. Clients never get saved
+// passwords back, so an empty password keeps the saved one unless
+// clearPassword is set.
+func (cs *connectionStore) save(config protocol.ConnectionConfig, clearPassword bool) (protocol.SavedConnection, error) {
+	config.ID = strings.TrimSpace(config.ID)
+	if config.ID == "" {
+		return protocol.SavedConnection{}, fmt.Errorf("id is required")
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.key == nil {
+		return protocol.SavedConnection{}, errStoreNotConfigured
+	}
+	previous, exists := cs.configs[config.ID]
+	if !exists && len(cs.configs) >= maxStoredConnections {
+		return protocol.SavedConnection{}, fmt.Errorf("too many saved connections (limit %d)", maxStoredConnections)
+	}
+
+	if config.Password == "" && !clearPassword {
+		config.Password = previous.config.Password
+	}
+
+	stored := storedConfig{config: config, savedAt: time.Now().UTC(), pinned: previous.pinned, sortOrder: previous.sortOrder}
+	configs := cs.copyConfigsLocked()
+	configs[config.ID] = stored
+	if err := cs.commitLocked(configs); err != nil {
+		return protocol.SavedConnection{}, err
+	}
+	return stored.redacted(), nil
+}
+
+// get returns a saved config with its password, for opening a connection.
+// It must never be sent to the client.
+func (cs *connectionStore) get(id string) (protocol.ConnectionConfig, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.key == nil {
+		return protocol.ConnectionConfig{}, errStoreNotConfigured
+	}
+	stored, ok := cs.configs[id]
+	if !ok {
+		return protocol.ConnectionConfig{}, fmt.Errorf("saved connection not found: %s", id)
+	}
+	return stored.config, nil
+}
+
+// remove deletes a saved config and persists the store
+func (cs *connectionStore) remove(id string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.key == nil {
+		return errStoreNotConfigured
+	}
+	if _, ok := cs.configs[id]; !ok {
+		return fmt.Errorf("saved connection not found: %s", id)
+	}
+	configs := cs.copyConfigsLocked()
+	delete(configs, id)
+	return cs.commitLocked(configs)
+}
+
+// setPinned pins a saved connection to the top of the list, or unpins it,
+// and persists the store
+func (cs *connectionStore) setPinned(id string, pinned bool) (protocol.SavedConnection, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.key == nil {
+		return protocol.SavedConnection{}, errStoreNotConfigured
+	}
+	stored, ok := cs.configs[id]
+	if !ok {
+		return protocol.SavedConnection{}, fmt.Errorf("saved connection not found: %s", id)
+	}
+	stored.pinned = pinned
+	configs := cs.copyConfigsLocked()
+	configs[id] = stored
+	if err := cs.commitLocked(configs); err != nil {
+		return protocol.SavedConnection{}, err
+	}
+	return stored.redacted(), nil
+}
+
+// reorder gives the listed saved connections sortOrder 1, 2, ... in the
+// order given and persists the store. Connections not listed keep theirs.
+// Unknown IDs are rejected before anything changes.
+func (cs *connectionStore) reorder(ids []string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.key == nil {
+		return errStoreNotConfigured
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := cs.configs[id]; !ok {
+			return fmt.Errorf("saved connection not found: %s", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("saved connection listed twice: %s", id)
+		}
+		seen[id] = true
+	}
+	configs := cs.copyConfigsLocked()
+	for i, id := range ids {
+		stored := configs[id]
+		stored.sortOrder = i + 1
+		configs[id] = stored
+	}
+	return cs.commitLocked(configs)
+}
+
+// list returns the saved connections with passwords redacted: pinned ones
+// first, then by sortOrder, with connections never ordered after those
+// that were, and otherwise by name
+func (cs *connectionStore) list() ([]protocol.SavedConnection, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.key == nil {
+		return nil, errStoreNotConfigured
+	}
+	result := make([]protocol.SavedConnection, 0, len(cs.configs))
+	for _, stored := range cs.configs {
+		result = append(result, stored.redacted())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pinned != result[j].Pinned {
+			return result[i].Pinned
+		}
+		if oi, oj := result[i].SortOrder, result[j].SortOrder; oi != oj {
+			// 0 means no position was set
+			return oj == 0 || (oi != 0 && oi < oj)
+		}
+		a, b := strings.ToLower(result[i].Config.Name), strings.ToLower(result[j].Config.Name)
+		if a != b {
+			return a < b
+		}
+		return result[i].Config.ID < result[j].Config.ID
+	})
+	return result, nil
+}
+
+// copyConfigsLocked returns a copy of the saved configs for a change to be
+// made on before it is committed
+func (cs *connectionStore) copyConfigsLocked() map[string]storedConfig {
+	configs := make(map[string]storedConfig, len(cs.configs)+1)
+	for id, stored := range cs.configs {
+		configs[id] = stored
+	}
+	return configs
+}
+
+// commitLocked persists configs and only then makes them the store's
+// contents, so a failed write leaves memory matching the file
+func (cs *connectionStore) commitLocked(configs map[string]storedConfig) error {
+	if err := cs.persistLocked(configs); err != nil {
+		return err
+	}
+	cs.configs = configs
+	return nil
+}
+
+// persistLocked seals every config and rewrites the store file
+func (cs *connectionStore) persistLocked(configs map[string]storedConfig) error {
+	ids := make([]string, 0, len(configs))
+	for id := range configs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	file := connectionStoreFile{
+		Salt:        base64.StdEncoding.EncodeToString(cs.salt),
+		Connections: make([]sealedConnectionEntry, 0, len(ids)),
+	}
+	for _, id := range ids {
+		stored := configs[id]
+		sealed, err := sealConfig(cs.key, stored.config)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt saved connection %s: %w", id, err)
+		}
+		file.Connections = append(file.Connections, sealedConnectionEntry{
+			ID:        id,
+			SavedAt:   stored.savedAt,
+			Pinned:    stored.pinned,
+			SortOrder: stored.sortOrder,
+			Sealed:    sealed,
+		})
+	}
+
+	if err := writeJSONFile(cs.path, file); err != nil {
+		return fmt.Errorf("failed to write connection store: %w", err)
+	}
+	return nil
+}
+
+// redacted returns the config for the client, without its password
+func (sc storedConfig) redacted() protocol.SavedConnection {
+	config := sc.config
+	hasPassword := config.Password != ""
+	config.Password = ""
+	return protocol.SavedConnection{
+		Config:      config,
+		HasPassword: hasPassword,
+		SavedAt:     sc.savedAt,
+		Pinned:      sc.pinned,
+		SortOrder:   sc.sortOrder,
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestDeriveStoreKey(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 test vectors
+	tests := []struct {
+		iterations int
+		expected   string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(deriveStoreKey("password", []byte("salt"), tt.iterations))
+		if got != tt.expected {
+			t.Errorf("deriveStoreKey(%d iterations) = %s, want %s", tt.iterations, got, tt.expected)
+		}
+	}
+}
+
+func TestConnectionStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.json")
+
+	store := newConnectionStore()
+	if _, err := store.save(protocol.ConnectionConfig{ID: "prod"}, false); err != errStoreNotConfigured {
+		t.Fatalf("Expected errStoreNotConfigured before load, got %v", err)
+	}
+	if err := store.load(path, "s3cret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	saved, err := store.save(protocol.ConnectionConfig{ID: "prod", Name: "Production", Host: "db.internal", Password: "hunter2"}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if saved.Config.Password != "" || !saved.HasPassword {
+		t.Errorf("Expected a redacted password, got %+v", saved)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "db.internal", "Production"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Store file contains %q in plaintext", secret)
+		}
+	}
+
+	reloaded := newConnectionStore()
+	if err := reloaded.load(path, "s3cret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config, err := reloaded.get("prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Password != "hunter2" || config.Host != "db.internal" {
+		t.Errorf("Unexpected config after reload: %+v", config)
+	}
+	list, err := reloaded.list()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list) != 1 || list[0].Config.Password != "" {
+		t.Errorf("Expected one redacted connection, got %+v", list)
+	}
+
+	// Saving an edit without the password keeps it; clearing must be asked for
+	if _, err := reloaded.save(protocol.ConnectionConfig{ID: "prod", Name: "Production", Host: "db2.internal"}, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config, _ := reloaded.get("prod"); config.Password != "hunter2" || config.Host != "db2.internal" {
+		t.Errorf("Expected the edit to keep the saved password, got %+v", config)
+	}
+	saved, err = reloaded.save(protocol.ConnectionConfig{ID: "prod", Name: "Production", Host: "db2.internal"}, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config, _ := reloaded.get("prod"); config.Password != "" || saved.HasPassword {
+		t.Errorf("Expected the password to be cleared, got %+v", config)
+	}
+
+	if err := newConnectionStore().load(path, "wrong"); err == nil {
+		t.Error("Expected loading with the wrong secret to fail")
+	}
+
+	if err := reloaded.remove("prod"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := reloaded.get("prod"); err == nil {
+		t.Error("Expected the removed connection to be gone")
+	}
+}
+
+func TestOpenConfigRejectsSwappedID(t *testing.T) {
+	key := deriveStoreKey("s3cret", []byte("salt"), 1)
+	sealed, err := sealConfig(key, protocol.ConnectionConfig{ID: "a", Password: "x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := openConfig(key, "b", sealed); err == nil {
+		t.Error("Expected an entry moved to another ID to fail authentication")
+	}
+}
+
+func TestConnectionStoreOrdering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.json")
+	store := newConnectionStore()
+	if err := store.load(path, "s3cret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, config := range []protocol.ConnectionConfig{
+		{ID: "a", Name: "Alpha"}, {ID: "b", Name: "Bravo"}, {ID: "c", Name: "Charlie"}, {ID: "d", Name: "Delta"},
+	} {
+		if _, err := store.save(config, false); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if err := store.reorder([]string{"c", "a"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.setPinned("d", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.reorder([]string{"a", "missing"}); err == nil {
+		t.Error("Expected reordering an unknown connection to fail")
+	}
+	// Saving again keeps the pin and position
+	if _, err := store.save(protocol.ConnectionConfig{ID: "d", Name: "Delta (new host)"}, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reloaded := newConnectionStore()
+	if err := reloaded.load(path, "s3cret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	list, err := reloaded.list()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var ids []string
+	for _, saved := range list {
+		ids = append(ids, saved.Config.ID)
+	}
+	// Pinned first, then ordered, then the rest by name
+	if strings.Join(ids, ",") != "d,c,a,b" {
+		t.Errorf("Expected order d,c,a,b, got %v", ids)
+	}
+	if !list[0].Pinned || list[1].SortOrder != 1 || list[3].SortOrder != 0 {
+		t.Errorf("Unexpected metadata: %+v", list)
+	}
+}
+
+func TestConnectionStoreWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	store := newConnectionStore()
+	if err := store.load(filepath.Join(dir, "connections.json"), "s3cret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, id := range []string{"prod", "staging"} {
+		if _, err := store.save(protocol.ConnectionConfig{ID: id, Name: id, Password: "hunter2"}, false); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Every change fails to write and must leave the store as it was
+	store.path = filepath.Join(dir, "missing", "connections.json")
+	if _, err := store.save(protocol.ConnectionConfig{ID: "dev"}, false); err == nil {
+		t.Error("Expected save to fail")
+	}
+	if _, err := store.save(protocol.ConnectionConfig{ID: "prod", Name: "renamed"}, true); err == nil {
+		t.Error("Expected save to fail")
+	}
+	if err := store.remove("staging"); err == nil {
+		t.Error("Expected remove to fail")
+	}
+	if _, err := store.setPinned("staging", true); err == nil {
+		t.Error("Expected setPinned to fail")
+	}
+	if err := store.reorder([]string{"staging", "prod"}); err == nil {
+		t.Error("Expected reorder to fail")
+	}
+
+	list, err := store.list()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list) != 2 || list[0].Config.ID != "prod" || list[1].Config.ID != "staging" {
+		t.Fatalf("Expected prod and staging unchanged, got %+v", list)
+	}
+	for _, saved := range list {
+		if saved.Pinned || saved.SortOrder != 0 || saved.Config.Name != saved.Config.ID {
+			t.Errorf("Expected %s unchanged, got %+v", saved.Config.ID, saved)
+		}
+	}
+	if config, _ := store.get("prod"); config.Password != "hunter2" {
+		t.Errorf("Expected the saved password to be kept, got %q", config.Password)
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	// DefaultCursorIdleTimeout closes cursors the client stopped fetching
+	// from, so abandoned cursors don't hold pooled connections forever
+	DefaultCursorIdleTimeout = 5 * time.Minute
+	// maxCursorsPerConnection keeps cursors from pinning the whole pool
+	maxCursorsPerConnection = 10
+)
+
+// errTooManyCursors is returned by openCursor when a connection already has
+// maxCursorsPerConnection cursors open
+var errTooManyCursors = fmt.Errorf("too many open cursors on this connection (max %d); close unused cursors first", maxCursorsPerConnection)
+
+// openCursor is a cursor registered with the server
+type openCursor struct {
+	connectionID string
+	cursor       *connection.Cursor
+	idle         *time.Timer
+}
+
+func (s *Server) handleOpenCursor(params json.RawMessage) (*protocol.CursorInfo, error) {
+	var req protocol.OpenCursorRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Checked again when the cursor is registered; this only avoids
+	// running the query when the limit is already reached
+	s.cursorsMu.Lock()
+	full := s.cursorCountLocked(req.ConnectionID) >= maxCursorsPerConnection
+	s.cursorsMu.Unlock()
+	if full {
+		return nil, errTooManyCursors
+	}
+
+	cursor, err := conn.OpenCursor(req.SQL, req.NamedArgs)
+	if err != nil {
+		return nil, err
+	}
+	// Read ahead in batches of the negotiated size, which fetchCursor
+	// uses unless the client asks for another count
+	cursor.FetchAhead(req.FetchAhead, s.Capabilities().MaxBatchSize)
+
+	s.cursorsMu.Lock()
+	if s.cursorCountLocked(req.ConnectionID) >= maxCursorsPerConnection {
+		s.cursorsMu.Unlock()
+		cursor.Close()
+		return nil, errTooManyCursors
+	}
+	s.nextCursorID++
+	id := fmt.Sprintf("cursor-%d", s.nextCursorID)
+	s.cursors[id] = &openCursor{
+		connectionID: req.ConnectionID,
+		cursor:       cursor,
+		idle: time.AfterFunc(s.cursorIdleTimeout, func() {
+			if s.closeCursor(id) {
+				log.Printf("Closed idle cursor %s", id)
+			}
+		}),
+	}
+	s.cursorsMu.Unlock()
+
+	columns, original := cursor.Columns()
+	return &protocol.CursorInfo{
+		CursorID:        id,
+		Columns:         columns,
+		OriginalColumns: original,
+	}, nil
+}
+
+func (s *Server) handleFetchCursor(ctx context.Context, requestID string, params json.RawMessage) (*protocol.CursorBatch, error) {
+	var req struct {
+		CursorID string `json:"cursorId"`
+		Count    int    `json:"count"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	count := req.Count
+	if count <= 0 {
+		count = s.Capabilities().MaxBatchSize
+	}
+	if count > maxBatchSizeLimit {
+		count = maxBatchSizeLimit
+	}
+
+	s.cursorsMu.Lock()
+	entry, exists := s.cursors[req.CursorID]
+	s.cursorsMu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("cursor not found: %s", req.CursorID)
+	}
+
+	// Don't let the idle timer close the cursor mid-fetch
+	entry.idle.Stop()
+
+	ctx, done := s.trackQuery(ctx, requestID, "fetchCursor")
+	defer done()
+
+	batch, err := entry.cursor.Fetch(ctx, count)
+	if err != nil || batch.Done {
+		// The cursor has released its connection or is unusable
+		s.closeCursor(req.CursorID)
+		return batch, err
+	}
+
+	entry.idle.Reset(s.cursorIdleTimeout)
+	return batch, nil
+}
+
+func (s *Server) handleCloseCursor(params json.RawMessage) error {
+	var req struct {
+		CursorID string `json:"cursorId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if !s.closeCursor(req.CursorID) {
+		return fmt.Errorf("cursor not found: %s", req.CursorID)
+	}
+	return nil
+}
+
+// closeCursor unregisters and closes a cursor, reporting whether it existed
+func (s *Server) closeCursor(id string) bool {
+	s.cursorsMu.Lock()
+	entry, exists := s.cursors[id]
+	delete(s.cursors, id)
+	s.cursorsMu.Unlock()
+
+	if !exists {
+		return false
+	}
+	entry.idle.Stop()
+	entry.cursor.Close()
+	return true
+}
+
+// closeConnectionCursors closes every cursor open on a connection, or every
+// cursor if connectionID is empty
+func (s *Server) closeConnectionCursors(connectionID string) {
+	s.cursorsMu.Lock()
+	var ids []string
+	for id, entry := range s.cursors {
+		if connectionID == "" || entry.connectionID == connectionID {
+			ids = append(ids, id)
+		}
+	}
+	s.cursorsMu.Unlock()
+
+	for _, id := range ids {
+		s.closeCursor(id)
+	}
+}
+
+// cursorCountLocked returns the number of cursors open on a connection.
+// s.cursorsMu must be held.
+func (s *Server) cursorCountLocked(connectionID string) int {
+	count := 0
+	for _, entry := range s.cursors {
+		if entry.connectionID == connectionID {
+			count++
+		}
+	}
+	return count
+}
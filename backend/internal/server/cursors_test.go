@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCursorMethodsRequireKnownCursor(t *testing.T) {
+	s := NewServer()
+
+	if _, err := s.handleFetchCursor(context.Background(), "req-1", json.RawMessage(`{"cursorId": "cursor-1"}`)); err == nil || !strings.Contains(err.Error(), "cursor not found") {
+		t.Errorf("Expected cursor not found error, got %v", err)
+	}
+	if err := s.handleCloseCursor(json.RawMessage(`{"cursorId": "cursor-1"}`)); err == nil || !strings.Contains(err.Error(), "cursor not found") {
+		t.Errorf("Expected cursor not found error, got %v", err)
+	}
+	if _, err := s.handleOpenCursor(json.RawMessage(`{"connectionId": "conn-1", "sql": "SELECT 1"}`)); err == nil || !strings.Contains(err.Error(), "connection not found") {
+		t.Errorf("Expected connection not found error, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// absoluteDeadlineMs is the smallest deadlineMs read as a Unix time in
+// milliseconds (September 2001); smaller values are relative
+const absoluteDeadlineMs = 1e12
+
+// requestDeadline converts a request's deadlineMs to an absolute time,
+// reporting false when the request has none
+func requestDeadline(deadlineMs int64, received time.Time) (time.Time, bool) {
+	switch {
+	case deadlineMs <= 0:
+		return time.Time{}, false
+	case deadlineMs >= absoluteDeadlineMs:
+		return time.UnixMilli(deadlineMs), true
+	}
+	return received.Add(time.Duration(deadlineMs) * time.Millisecond), true
+}
+
+// timeoutResponse is the error returned for a request past its deadline
+func timeoutResponse(req *protocol.Request) *protocol.Response {
+	return &protocol.Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Error: &protocol.Error{
+			Code:    protocol.RequestTimeout,
+			Message: fmt.Sprintf("%s timed out: deadline exceeded", req.Method),
+			Data:    map[string]string{"method": req.Method},
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	received := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	if _, ok := requestDeadline(0, received); ok {
+		t.Error("Expected no deadline for 0")
+	}
+	if got, _ := requestDeadline(1500, received); !got.Equal(received.Add(1500 * time.Millisecond)) {
+		t.Errorf("Relative deadline = %v", got)
+	}
+	absolute := received.Add(time.Minute)
+	if got, _ := requestDeadline(absolute.UnixMilli(), received); !got.Equal(absolute) {
+		t.Errorf("Absolute deadline = %v, want %v", got, absolute)
+	}
+}
+
+func TestHandleRequestDeadline(t *testing.T) {
+	s := NewServer()
+
+	response := s.HandleRequest(&protocol.Request{JSONRPC: "2.0", ID: "1", Method: "ping", DeadlineMs: 5000})
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %v", response.Error.Message)
+	}
+
+	// An absolute deadline in the past fails without running the method
+	past := time.Now().Add(-time.Second).UnixMilli()
+	response = s.HandleRequest(&protocol.Request{JSONRPC: "2.0", ID: "2", Method: "listDatabases", DeadlineMs: past})
+	if response.Error == nil || response.Error.Code != protocol.RequestTimeout {
+		t.Fatalf("Expected a timeout error, got %+v", response.Error)
+	}
+	if data, _ := response.Error.Data.(map[string]string); data["method"] != "listDatabases" {
+		t.Errorf("Expected the method name in the error data, got %v", response.Error.Data)
+	}
+}
+
+func TestHandleRequestKeepsHandlerErrors(t *testing.T) {
+	s := NewServer()
+
+	// A failure that has nothing to do with the deadline keeps its error
+	response := s.HandleRequest(&protocol.Request{JSONRPC: "2.0", ID: "1", Method: "listDatabases",
+		Params: []byte(`{"connectionId": "missing"}`), DeadlineMs: 5000})
+	if response.Error == nil || response.Error.Code != protocol.InternalError ||
+		!strings.Contains(response.Error.Message, "connection not found") {
+		t.Errorf("Expected the handler's error, got %+v", response.Error)
+	}
+}
+
+func TestDispatchPassesContextToHandlers(t *testing.T) {
+	s := NewServer()
+	s.connections["conn-1"] = &connection.Connection{}
+	// listDatabases joins a call that never finishes, so only the request's
+	// context can end it
+	s.inflight["listDatabases:conn-1"] = &inflightCall{done: make(chan struct{}), cancel: func() {}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := &protocol.Request{JSONRPC: "2.0", ID: "1", Method: "listDatabases", Params: []byte(`{"connectionId": "conn-1"}`)}
+	response, err := s.dispatch(ctx, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the handler to stop at the deadline, got %v", err)
+	}
+	if response.Error == nil {
+		t.Error("Expected an error response")
+	}
+
+	s.inflight["listDatabases:conn-1"] = &inflightCall{done: make(chan struct{}), cancel: func() {}}
+	req.DeadlineMs = 50
+	if response := s.HandleRequest(req); response.Error == nil || response.Error.Code != protocol.RequestTimeout {
+		t.Errorf("Expected a timeout error, got %+v", response.Error)
+	}
+}
+
+func TestTrackQueryUsesRequestDeadline(t *testing.T) {
+	s := NewServer()
+	deadline := time.Now().Add(time.Hour)
+	parent, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	ctx, done := s.trackQuery(parent, "1", "SELECT 1")
+	defer done()
+	if got, ok := ctx.Deadline(); !ok || !got.Equal(deadline) {
+		t.Errorf("Expected the request deadline on the query context, got %v", got)
+	}
+
+	ctx, done = s.trackQuery(context.Background(), "2", "SELECT 1")
+	defer done()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without a client deadline")
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// diffResults compares two query results matched on key columns. Both
+// results must contain the key columns and every column of the left result.
+func diffResults(left, right *protocol.QueryResult, keyColumns []string) (*protocol.ResultDiff, error) {
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("at least one key column is required")
+	}
+
+	// Map the left column order onto the right result
+	rightIndex := make(map[string]int, len(right.Columns))
+	for i, name := range right.Columns {
+		rightIndex[name] = i
+	}
+	columnMap := make([]int, len(left.Columns))
+	leftIndex := make(map[string]int, len(left.Columns))
+	for i, name := range left.Columns {
+		j, ok := rightIndex[name]
+		if !ok {
+			return nil, fmt.Errorf("column %s is missing from the right result", name)
+		}
+		columnMap[i] = j
+		leftIndex[name] = i
+	}
+
+	keyIndexes := make([]int, len(keyColumns))
+	for i, name := range keyColumns {
+		idx, ok := leftIndex[name]
+		if !ok {
+			return nil, fmt.Errorf("key column %s is not in the result", name)
+		}
+		keyIndexes[i] = idx
+	}
+
+	// Index right rows by key, reordered to the left column order
+	rightRows := make(map[string][]interface{}, len(right.Rows))
+	rightOrder := make([]string, 0, len(right.Rows))
+	for _, row := range right.Rows {
+		aligned := make([]interface{}, len(columnMap))
+		for i, j := range columnMap {
+			aligned[i] = row[j]
+		}
+		key := rowKey(aligned, keyIndexes)
+		if _, exists := rightRows[key]; exists {
+			return nil, fmt.Errorf("duplicate key %s in right result", key)
+		}
+		rightRows[key] = aligned
+		rightOrder = append(rightOrder, key)
+	}
+
+	diff := &protocol.ResultDiff{
+		Columns:     left.Columns,
+		KeyColumns:  keyColumns,
+		OnlyInLeft:  make([][]interface{}, 0),
+		OnlyInRight: make([][]interface{}, 0),
+		Changed:     make([]protocol.RowDifference, 0),
+		LeftRows:    int64(len(left.Rows)),
+		RightRows:   int64(len(right.Rows)),
+	}
+
+	seen := make(map[string]bool, len(left.Rows))
+	for _, row := range left.Rows {
+		key := rowKey(row, keyIndexes)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate key %s in left result", key)
+		}
+		seen[key] = true
+
+		other, ok := rightRows[key]
+		if !ok {
+			diff.OnlyInLeft = append(diff.OnlyInLeft, row)
+			continue
+		}
+
+		var changed []string
+		for i, name := range left.Columns {
+			if valueKey(row[i]) != valueKey(other[i]) {
+				changed = append(changed, name)
+			}
+		}
+		if len(changed) == 0 {
+			diff.MatchingRows++
+			continue
+		}
+
+		keyValues := make([]interface{}, len(keyIndexes))
+		for i, idx := range keyIndexes {
+			keyValues[i] = row[idx]
+		}
+		diff.Changed = append(diff.Changed, protocol.RowDifference{
+			Key:            keyValues,
+			Left:           row,
+			Right:          other,
+			ChangedColumns: changed,
+		})
+	}
+
+	for _, key := range rightOrder {
+		if !seen[key] {
+			diff.OnlyInRight = append(diff.OnlyInRight, rightRows[key])
+		}
+	}
+
+	return diff, nil
+}
+
+// rowKey builds a comparable key from the key column values of a row
+func rowKey(row []interface{}, keyIndexes []int) string {
+	values := make([]interface{}, len(keyIndexes))
+	for i, idx := range keyIndexes {
+		values[i] = row[idx]
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// valueKey returns a canonical representation of a value for comparison
+func valueKey(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
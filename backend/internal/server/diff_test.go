@@ -0,0 +1,80 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestDiffResults(t *testing.T) {
+	left := &protocol.QueryResult{
+		Columns: []string{"id", "name", "status"},
+		Rows: [][]interface{}{
+			{int64(1), "alice", "active"},
+			{int64(2), "bob", "active"},
+			{int64(3), "carol", nil},
+		},
+	}
+	// Same data from a replica with a different column order
+	right := &protocol.QueryResult{
+		Columns: []string{"status", "id", "name"},
+		Rows: [][]interface{}{
+			{"active", int64(1), "alice"},
+			{"inactive", int64(2), "bob"},
+			{"active", int64(4), "dave"},
+		},
+	}
+
+	diff, err := diffResults(left, right, []string{"id"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if diff.MatchingRows != 1 {
+		t.Errorf("Expected 1 matching row, got %d", diff.MatchingRows)
+	}
+	if len(diff.OnlyInLeft) != 1 || diff.OnlyInLeft[0][1] != "carol" {
+		t.Errorf("Expected carol only in left, got %v", diff.OnlyInLeft)
+	}
+	if len(diff.OnlyInRight) != 1 || diff.OnlyInRight[0][1] != "dave" {
+		t.Errorf("Expected dave only in right (in left column order), got %v", diff.OnlyInRight)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("Expected 1 changed row, got %d", len(diff.Changed))
+	}
+	changed := diff.Changed[0]
+	if changed.Key[0] != int64(2) {
+		t.Errorf("Expected changed key 2, got %v", changed.Key)
+	}
+	if len(changed.ChangedColumns) != 1 || changed.ChangedColumns[0] != "status" {
+		t.Errorf("Expected only status to change, got %v", changed.ChangedColumns)
+	}
+}
+
+func TestDiffResultsErrors(t *testing.T) {
+	base := &protocol.QueryResult{
+		Columns: []string{"id", "name"},
+		Rows:    [][]interface{}{{int64(1), "a"}, {int64(1), "b"}},
+	}
+	narrow := &protocol.QueryResult{Columns: []string{"id"}}
+
+	testCases := []struct {
+		name  string
+		left  *protocol.QueryResult
+		right *protocol.QueryResult
+		keys  []string
+	}{
+		{"No key columns", base, base, nil},
+		{"Unknown key column", base, base, []string{"missing"}},
+		{"Column missing from right", base, narrow, []string{"id"}},
+		{"Duplicate keys", base, &protocol.QueryResult{Columns: []string{"id", "name"}}, []string{"id"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := diffResults(tc.left, tc.right, tc.keys); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// DefaultHealthSweepInterval is how often open connections are pinged
+const DefaultHealthSweepInterval = 30 * time.Second
+
+// notifyConnectionState emits a connectionStateChanged notification
+func (s *Server) notifyConnectionState(connectionID, state string, err error) {
+	event := protocol.ConnectionStateEvent{
+		ConnectionID: connectionID,
+		State:        state,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.notify("connectionStateChanged", event)
+}
+
+// recordHealth tracks the outcome of a health check and emits a notification
+// when a connection is lost or comes back. Results for a connection that has
+// since been replaced or closed are ignored.
+func (s *Server) recordHealth(connectionID string, conn *connection.Connection, err error) {
+	s.mu.Lock()
+	if s.connections[connectionID] != conn {
+		s.mu.Unlock()
+		return
+	}
+	wasLost := s.lost[connectionID]
+	if err != nil {
+		s.lost[connectionID] = true
+	} else {
+		delete(s.lost, connectionID)
+	}
+	s.mu.Unlock()
+
+	switch {
+	case err != nil && !wasLost:
+		log.Printf("Connection lost: %s: %v", connectionID, err)
+		s.notifyConnectionState(connectionID, protocol.ConnectionStateLost, err)
+	case err == nil && wasLost:
+		log.Printf("Connection restored: %s", connectionID)
+		s.notifyConnectionState(connectionID, protocol.ConnectionStateReconnected, nil)
+	}
+}
+
+// StartHealthSweep pings every open connection on the given interval until
+// Shutdown is called, so lost connections are reported without polling
+func (s *Server) StartHealthSweep(interval time.Duration) {
+	s.mu.Lock()
+	if s.stopSweep != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stopSweep = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.sweepHealth()
+			}
+		}
+	}()
+}
+
+// sweepHealth health-checks a snapshot of the open connections
+func (s *Server) sweepHealth() {
+	s.mu.RLock()
+	conns := make(map[string]*connection.Connection, len(s.connections))
+	for id, conn := range s.connections {
+		conns[id] = conn
+	}
+	s.mu.RUnlock()
+
+	for id, conn := range conns {
+		s.recordHealth(id, conn, conn.HealthCheck(context.Background()))
+	}
+
+	s.mu.RLock()
+	var lost []string
+	if s.autoReconnect {
+		for id := range s.lost {
+			lost = append(lost, id)
+		}
+	}
+	s.mu.RUnlock()
+	if len(lost) > 0 {
+		s.reconnect(lost, connection.NewConnection)
+	}
+}
+
+// reconnectAll re-opens every connection, e.g. after a server restart left
+// all of them stale
+func (s *Server) reconnectAll() map[string]protocol.ReconnectResult {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.connections))
+	for id := range s.connections {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	log.Printf("Reconnecting %d connections", len(ids))
+	return s.reconnect(ids, connection.NewConnection)
+}
+
+// SetAutoReconnect makes the health sweep re-establish connections it finds
+// lost, as after a server restart, instead of only reporting them
+func (s *Server) SetAutoReconnect(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.autoReconnect = enabled
+}
+
+// reconnect re-opens the given connections concurrently from their stored
+// configs with dial. A connection is only replaced once its new pool is
+// established; on failure the old one is kept and marked lost. Connections
+// closed or replaced while dialing are left alone.
+func (s *Server) reconnect(ids []string, dial func(*protocol.ConnectionConfig) (*connection.Connection, error)) map[string]protocol.ReconnectResult {
+	results := make(map[string]protocol.ReconnectResult, len(ids))
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			result := s.reconnectOne(id, dial)
+			resultsMu.Lock()
+			results[id] = result
+			resultsMu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *Server) reconnectOne(id string, dial func(*protocol.ConnectionConfig) (*connection.Connection, error)) protocol.ReconnectResult {
+	old := s.getConnection(id)
+	if old == nil {
+		return protocol.ReconnectResult{Error: fmt.Sprintf("connection not found: %s", id)}
+	}
+
+	start := time.Now()
+	conn, err := dial(old.Config())
+	result := protocol.ReconnectResult{LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		s.recordHealth(id, old, err)
+		return result
+	}
+
+	s.mu.Lock()
+	if s.connections[id] != old {
+		s.mu.Unlock()
+		conn.Close()
+		result.Error = "connection was closed or replaced while reconnecting"
+		return result
+	}
+	s.connections[id] = conn
+	delete(s.lost, id)
+	s.mu.Unlock()
+
+	// Cursors hold pinned connections from the pool being closed
+	s.closeConnectionCursors(id)
+	old.Close()
+	s.invalidateConnectionCache(id)
+
+	log.Printf("Connection re-established: %s", id)
+	s.notifyConnectionState(id, protocol.ConnectionStateReconnected, nil)
+	result.Success = true
+	return result
+}
+
+// Readiness statuses
+const (
+	readinessHealthy  = "healthy"
+	readinessDegraded = "degraded"
+)
+
+// readiness health-checks every open connection in parallel with check and
+// reports each result. The backend is degraded when it has connections but
+// none of them are reachable; with no connections there is nothing to be
+// unreachable, so it is healthy.
+func (s *Server) readiness(check func(*connection.Connection) error) *protocol.Readiness {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.connections))
+	conns := make([]*connection.Connection, 0, len(s.connections))
+	for id, conn := range s.connections {
+		ids = append(ids, id)
+		conns = append(conns, conn)
+	}
+	s.mu.RUnlock()
+
+	health := make([]protocol.ConnectionHealth, len(conns))
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			err := check(conns[i])
+			health[i] = protocol.ConnectionHealth{
+				ConnectionID: ids[i],
+				Reachable:    err == nil,
+				LatencyMs:    time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				health[i].Error = err.Error()
+			}
+			s.recordHealth(ids[i], conns[i], err)
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(health, func(i, j int) bool { return health[i].ConnectionID < health[j].ConnectionID })
+
+	result := &protocol.Readiness{
+		Status:      readinessHealthy,
+		Connections: health,
+		Total:       len(health),
+	}
+	for _, h := range health {
+		if h.Reachable {
+			result.Reachable++
+		}
+	}
+	if result.Total > 0 && result.Reachable == 0 {
+		result.Status = readinessDegraded
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestRecordHealthEmitsStateTransitions(t *testing.T) {
+	s := NewServer()
+	conn := &connection.Connection{}
+	s.connections["conn-1"] = conn
+
+	var states []string
+	s.SetNotifier(func(n *protocol.Notification) {
+		if n.Method != "connectionStateChanged" {
+			t.Errorf("Unexpected notification method: %s", n.Method)
+		}
+		states = append(states, n.Params.(protocol.ConnectionStateEvent).State)
+	})
+
+	lostErr := errors.New("connection refused")
+	s.recordHealth("conn-1", conn, nil)     // healthy, no change
+	s.recordHealth("conn-1", conn, lostErr) // lost
+	s.recordHealth("conn-1", conn, lostErr) // still lost, no repeat
+	s.recordHealth("conn-1", conn, nil)     // restored
+
+	expected := []string{protocol.ConnectionStateLost, protocol.ConnectionStateReconnected}
+	if len(states) != len(expected) {
+		t.Fatalf("Expected states %v, got %v", expected, states)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("State %d: expected %s, got %s", i, expected[i], states[i])
+		}
+	}
+}
+
+func TestRecordHealthIgnoresReplacedConnection(t *testing.T) {
+	s := NewServer()
+	s.connections["conn-1"] = &connection.Connection{}
+
+	notified := false
+	s.SetNotifier(func(n *protocol.Notification) { notified = true })
+
+	stale := &connection.Connection{}
+	s.recordHealth("conn-1", stale, errors.New("broken pipe"))
+	s.recordHealth("conn-2", stale, errors.New("broken pipe"))
+
+	if notified {
+		t.Error("Health results for stale or unknown connections should not notify")
+	}
+}
+
+func TestDisconnectEmitsClosed(t *testing.T) {
+	s := NewServer()
+	s.connections["conn-1"] = &connection.Connection{}
+
+	var events []protocol.ConnectionStateEvent
+	s.SetNotifier(func(n *protocol.Notification) {
+		events = append(events, n.Params.(protocol.ConnectionStateEvent))
+	})
+
+	if err := s.handleDisconnect([]byte(`{"connectionId": "conn-1"}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].ConnectionID != "conn-1" || events[0].State != protocol.ConnectionStateClosed {
+		t.Errorf("Expected a closed event for conn-1, got %+v", events)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	s := NewServer()
+	if r := s.readiness(nil); r.Status != readinessHealthy || r.Total != 0 {
+		t.Errorf("Expected healthy with no connections, got %+v", r)
+	}
+
+	up := &connection.Connection{}
+	down := &connection.Connection{}
+	s.connections["b-up"] = up
+	s.connections["a-down"] = down
+
+	var states []string
+	s.SetNotifier(func(n *protocol.Notification) {
+		states = append(states, n.Params.(protocol.ConnectionStateEvent).State)
+	})
+
+	reachable := map[*connection.Connection]bool{up: true}
+	check := func(c *connection.Connection) error {
+		if reachable[c] {
+			return nil
+		}
+		return errors.New("connection refused")
+	}
+
+	r := s.readiness(check)
+	if r.Status != readinessHealthy || r.Total != 2 || r.Reachable != 1 {
+		t.Errorf("Expected healthy with 1 of 2 reachable, got %+v", r)
+	}
+	if r.Connections[0].ConnectionID != "a-down" || r.Connections[0].Reachable || r.Connections[0].Error == "" {
+		t.Errorf("Expected a-down first and unreachable, got %+v", r.Connections[0])
+	}
+	if len(states) != 1 || states[0] != protocol.ConnectionStateLost {
+		t.Errorf("Expected a lost notification, got %v", states)
+	}
+
+	reachable[up] = false
+	if r := s.readiness(check); r.Status != readinessDegraded || r.Reachable != 0 {
+		t.Errorf("Expected degraded with nothing reachable, got %+v", r)
+	}
+}
+
+func TestReconnectReplacesConnections(t *testing.T) {
+	s := NewServer()
+	healthy := &connection.Connection{}
+	failing := &connection.Connection{}
+	s.connections["conn-1"] = healthy
+	s.connections["conn-2"] = failing
+
+	var states []protocol.ConnectionStateEvent
+	s.SetNotifier(func(n *protocol.Notification) {
+		states = append(states, n.Params.(protocol.ConnectionStateEvent))
+	})
+
+	replacement := &connection.Connection{}
+	dial := func(*protocol.ConnectionConfig) (*connection.Connection, error) {
+		return replacement, nil
+	}
+	results := s.reconnect([]string{"conn-1", "missing"}, dial)
+	if !results["conn-1"].Success {
+		t.Errorf("Expected conn-1 to reconnect, got %+v", results["conn-1"])
+	}
+	if results["missing"].Success || results["missing"].Error == "" {
+		t.Errorf("Expected an error for an unknown connection, got %+v", results["missing"])
+	}
+	if s.connections["conn-1"] != replacement {
+		t.Error("Expected conn-1 to be replaced")
+	}
+	if len(states) != 1 || states[0].State != protocol.ConnectionStateReconnected {
+		t.Errorf("Expected one reconnected notification, got %+v", states)
+	}
+
+	dialErr := errors.New("connection refused")
+	results = s.reconnect([]string{"conn-2"}, func(*protocol.ConnectionConfig) (*connection.Connection, error) {
+		return nil, dialErr
+	})
+	if results["conn-2"].Success || results["conn-2"].Error != dialErr.Error() {
+		t.Errorf("Expected conn-2 to fail, got %+v", results["conn-2"])
+	}
+	if s.connections["conn-2"] != failing || !s.lost["conn-2"] {
+		t.Error("Expected the old conn-2 to be kept and marked lost")
+	}
+}
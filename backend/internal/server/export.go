@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultExportChunkRows = 1000
+	maxExportChunkRows     = 10000
+)
+
+// handleExportQueryStream runs a query and streams its rows as serialized
+// CSV or JSON Lines in exportChunk notifications, so clients can save large
+// results without the backend buffering them or writing files. Cancelling
+// the request kills the query.
+func (s *Server) handleExportQueryStream(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ExportComplete, error) {
+	var req protocol.ExportRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+	// Reject an unknown format before running the query
+	if _, err := protocol.NewExportEncoder(req.Format, nil); err != nil {
+		return nil, err
+	}
+
+	chunkRows := req.ChunkRows
+	if chunkRows <= 0 {
+		chunkRows = defaultExportChunkRows
+	}
+	if chunkRows > maxExportChunkRows {
+		chunkRows = maxExportChunkRows
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	log.Printf("Exporting query as %s (request %s): %s", req.Format, requestID, req.SQL)
+	startTime := time.Now()
+	result, err := s.exportQuery(ctx, conn, requestID, req, chunkRows)
+
+	entry := protocol.HistoryEntry{
+		ConnectionID:  req.ConnectionID,
+		SQL:           req.SQL,
+		ExecutedAt:    startTime,
+		ExecutionTime: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.RowCount = result.TotalRows
+	}
+	s.history.record(entry)
+
+	if err != nil {
+		return nil, err
+	}
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.notify("exportComplete", result)
+	return result, nil
+}
+
+// exportCursor is the part of a connection.Cursor an export reads
+type exportCursor interface {
+	Columns() (columns, original []string)
+	Fetch(ctx context.Context, n int) (*protocol.CursorBatch, error)
+}
+
+// exportQuery reads the query through a cursor one chunk at a time and
+// sends each chunk as it is serialized
+func (s *Server) exportQuery(ctx context.Context, conn *connection.Connection, requestID string, req protocol.ExportRequest, chunkRows int) (*protocol.ExportComplete, error) {
+	// Cancelling the request kills the query, even before its first row
+	cursor, err := conn.OpenCursorContext(ctx, req.SQL, req.NamedArgs)
+	if err != nil {
+		return nil, err
+	}
+	// Closing the cursor kills the query if rows were left unread
+	defer cursor.Close()
+	// Overlap reading the next chunks with encoding and sending this one
+	cursor.FetchAhead(req.FetchAhead, chunkRows)
+
+	return s.exportRows(ctx, cursor, requestID, req, chunkRows)
+}
+
+// exportRows encodes a cursor's rows and sends them in exportChunk
+// notifications of up to chunkRows rows, stopping at req.MaxRows
+func (s *Server) exportRows(ctx context.Context, cursor exportCursor, requestID string, req protocol.ExportRequest, chunkRows int) (*protocol.ExportComplete, error) {
+	columns, _ := cursor.Columns()
+	encoder, err := protocol.NewExportEncoder(req.Format, columns)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encoder.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &protocol.ExportComplete{
+		QueryComplete: protocol.QueryComplete{
+			RequestID: requestID,
+			SQL:       req.SQL,
+			Columns:   columns,
+		},
+		Format: req.Format,
+	}
+	for {
+		// With a cap, ask for one row past it to learn whether more exist
+		fetch := chunkRows
+		remaining := req.MaxRows - result.TotalRows
+		if req.MaxRows > 0 && remaining < int64(fetch) {
+			fetch = int(remaining) + 1
+		}
+		batch, err := cursor.Fetch(ctx, fetch)
+		if err != nil {
+			return nil, err
+		}
+		if req.MaxRows > 0 && int64(len(batch.Rows)) > remaining {
+			batch.Rows = batch.Rows[:remaining]
+			batch.Done = true
+			result.Truncated = true
+			result.MaxRows = req.MaxRows
+		}
+
+		// Empty batches, such as the last one when the rows end exactly at
+		// a chunk boundary, are not sent, except to carry a CSV header of
+		// an empty result
+		if len(batch.Rows) > 0 || len(data) > 0 {
+			encoded, err := encoder.Encode(batch.Rows)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, encoded...)
+
+			result.Chunks++
+			result.TotalRows += int64(len(batch.Rows))
+			result.TotalBytes += int64(len(data))
+			s.notify("exportChunk", protocol.ExportChunk{
+				RequestID: requestID,
+				Sequence:  result.Chunks,
+				Data:      string(data),
+				Rows:      len(batch.Rows),
+			})
+		}
+		if batch.Done {
+			return result, nil
+		}
+		data = nil
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// fakeExportCursor returns the rows 1 to total. Like connection.Cursor, it
+// only reports Done once a fetch finds no further row.
+type fakeExportCursor struct {
+	next, total int
+}
+
+func (c *fakeExportCursor) Columns() ([]string, []string) {
+	return []string{"n"}, nil
+}
+
+func (c *fakeExportCursor) Fetch(_ context.Context, n int) (*protocol.CursorBatch, error) {
+	batch := &protocol.CursorBatch{}
+	for len(batch.Rows) < n {
+		if c.next == c.total {
+			batch.Done = true
+			break
+		}
+		c.next++
+		batch.Rows = append(batch.Rows, []interface{}{int64(c.next)})
+	}
+	return batch, nil
+}
+
+func TestExportRows(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		total     int
+		maxRows   int64
+		rows      int64
+		chunks    []int // Rows per chunk
+		truncated bool
+	}{
+		{"Rows end at a chunk boundary", protocol.ExportFormatJSON, 4, 0, 4, []int{2, 2}, false},
+		{"Uncapped with a partial chunk", protocol.ExportFormatJSON, 5, 0, 5, []int{2, 2, 1}, false},
+		{"One row past the cap", protocol.ExportFormatJSON, 5, 4, 4, []int{2, 2}, true},
+		{"Many rows past the cap", protocol.ExportFormatJSON, 9, 3, 3, []int{2, 1}, true},
+		{"Exactly at the cap", protocol.ExportFormatJSON, 4, 4, 4, []int{2, 2}, false},
+		{"Empty JSON result", protocol.ExportFormatJSON, 0, 0, 0, nil, false},
+		{"Empty CSV result sends the header", protocol.ExportFormatCSV, 0, 0, 0, []int{0}, false},
+		{"CSV at the cap", protocol.ExportFormatCSV, 3, 2, 2, []int{2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			var chunks []protocol.ExportChunk
+			s.SetNotifier(func(n *protocol.Notification) {
+				if n.Method == "exportChunk" {
+					chunks = append(chunks, n.Params.(protocol.ExportChunk))
+				}
+			})
+
+			req := protocol.ExportRequest{Format: tt.format, MaxRows: tt.maxRows}
+			result, err := s.exportRows(context.Background(), &fakeExportCursor{total: tt.total}, "req-1", req, 2)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.TotalRows != tt.rows || result.Truncated != tt.truncated {
+				t.Errorf("Expected %d rows (truncated %v), got %d (truncated %v)", tt.rows, tt.truncated, result.TotalRows, result.Truncated)
+			}
+			if result.Chunks != len(tt.chunks) || len(chunks) != len(tt.chunks) {
+				t.Fatalf("Expected %d chunks, counted %d and sent %d", len(tt.chunks), result.Chunks, len(chunks))
+			}
+
+			var data strings.Builder
+			for i, chunk := range chunks {
+				if chunk.Rows != tt.chunks[i] || chunk.Sequence != i+1 {
+					t.Errorf("Chunk %d: expected %d rows, got %d (sequence %d)", i+1, tt.chunks[i], chunk.Rows, chunk.Sequence)
+				}
+				data.WriteString(chunk.Data)
+			}
+			lines := int64(strings.Count(data.String(), "\n"))
+			if tt.format == protocol.ExportFormatCSV {
+				lines--
+			}
+			if lines != tt.rows || int64(data.Len()) != result.TotalBytes {
+				t.Errorf("Expected %d rows in %d bytes, got %d rows in %d bytes", tt.rows, result.TotalBytes, lines, data.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	maxHistoryEntries   = 1000
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+// queryHistory keeps a bounded in-memory log of executed queries. When an
+// audit log path is set, every entry is also appended to that file as a JSON
+// line so pages older than the in-memory buffer can still be served.
+type queryHistory struct {
+	mu        sync.Mutex
+	entries   []protocol.HistoryEntry // Oldest first
+	max       int
+	auditPath string
+}
+
+func newQueryHistory(max int) *queryHistory {
+	return &queryHistory{
+		entries: make([]protocol.HistoryEntry, 0, 64),
+		max:     max,
+	}
+}
+
+// record appends an entry, evicting the oldest when the buffer is full
+func (h *queryHistory) record(entry protocol.HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Keep timestamps strictly increasing so they can serve as cursors
+	if n := len(h.entries); n > 0 && !entry.ExecutedAt.After(h.entries[n-1].ExecutedAt) {
+		entry.ExecutedAt = h.entries[n-1].ExecutedAt.Add(time.Nanosecond)
+	}
+
+	if len(h.entries) >= h.max {
+		copy(h.entries, h.entries[1:])
+		h.entries = h.entries[:len(h.entries)-1]
+	}
+	h.entries = append(h.entries, entry)
+
+	if h.auditPath != "" {
+		if err := appendAuditEntry(h.auditPath, entry); err != nil {
+			log.Printf("Failed to write audit log: %v", err)
+		}
+	}
+}
+
+// page returns up to limit entries older than the before cursor, newest
+// first. An empty cursor starts from the most recent entry.
+func (h *queryHistory) page(connectionID, before string, limit int) (*protocol.HistoryPage, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	cutoff := int64(0)
+	if before != "" {
+		var err error
+		if cutoff, err = strconv.ParseInt(before, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid cursor: %s", before)
+		}
+	}
+
+	h.mu.Lock()
+	page := &protocol.HistoryPage{Entries: make([]protocol.HistoryEntry, 0, limit)}
+	for i := len(h.entries) - 1; i >= 0 && len(page.Entries) < limit; i-- {
+		entry := h.entries[i]
+		if cutoff > 0 && entry.ExecutedAt.UnixNano() >= cutoff {
+			continue
+		}
+		if connectionID != "" && entry.ConnectionID != connectionID {
+			continue
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	// Once the in-memory buffer is exhausted, continue from the audit log
+	auditPath := h.auditPath
+	fileCutoff := cutoff
+	if len(h.entries) > 0 {
+		oldest := h.entries[0].ExecutedAt.UnixNano()
+		if fileCutoff == 0 || oldest < fileCutoff {
+			fileCutoff = oldest
+		}
+	}
+	h.mu.Unlock()
+
+	if len(page.Entries) < limit && auditPath != "" {
+		older, err := readAuditEntries(auditPath, connectionID, fileCutoff, limit-len(page.Entries))
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, older...)
+	}
+
+	if len(page.Entries) == limit {
+		oldest := page.Entries[len(page.Entries)-1]
+		page.NextCursor = strconv.FormatInt(oldest.ExecutedAt.UnixNano(), 10)
+	}
+
+	return page, nil
+}
+
+// appendAuditEntry appends one entry to the audit log as a JSON line
+func appendAuditEntry(path string, entry protocol.HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// readAuditEntries returns up to limit entries older than cutoff (unix nanos,
+// 0 for no cutoff) from the audit log, newest first
+func readAuditEntries(path, connectionID string, cutoff int64, limit int) ([]protocol.HistoryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	// The file is chronological, so keep a sliding window of the newest matches
+	window := make([]protocol.HistoryEntry, 0, limit)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry protocol.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if cutoff > 0 && entry.ExecutedAt.UnixNano() >= cutoff {
+			continue
+		}
+		if connectionID != "" && entry.ConnectionID != connectionID {
+			continue
+		}
+		if len(window) == limit {
+			copy(window, window[1:])
+			window = window[:limit-1]
+		}
+		window = append(window, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// Reverse to newest first
+	for i, j := 0, len(window)-1; i < j; i, j = i+1, j-1 {
+		window[i], window[j] = window[j], window[i]
+	}
+	return window, nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func recordQueries(h *queryHistory, start time.Time, count int) {
+	for i := 0; i < count; i++ {
+		h.record(protocol.HistoryEntry{
+			ConnectionID: fmt.Sprintf("conn-%d", i%2),
+			SQL:          fmt.Sprintf("SELECT %d", i),
+			ExecutedAt:   start.Add(time.Duration(i) * time.Second),
+		})
+	}
+}
+
+func TestQueryHistoryPagination(t *testing.T) {
+	h := newQueryHistory(100)
+	recordQueries(h, time.Now(), 25)
+
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := h.page("", cursor, 10)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, e := range page.Entries {
+			seen = append(seen, e.SQL)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(seen) != 25 {
+		t.Fatalf("Expected 25 entries across pages, got %d", len(seen))
+	}
+	if seen[0] != "SELECT 24" || seen[24] != "SELECT 0" {
+		t.Errorf("Expected newest-first ordering, got first=%s last=%s", seen[0], seen[24])
+	}
+}
+
+func TestQueryHistoryBoundedBuffer(t *testing.T) {
+	h := newQueryHistory(5)
+	recordQueries(h, time.Now(), 12)
+
+	page, err := h.page("", "", 50)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(page.Entries) != 5 {
+		t.Errorf("Expected buffer to hold 5 entries, got %d", len(page.Entries))
+	}
+	if page.Entries[4].SQL != "SELECT 7" {
+		t.Errorf("Expected oldest retained entry to be SELECT 7, got %s", page.Entries[4].SQL)
+	}
+}
+
+func TestQueryHistoryConnectionFilter(t *testing.T) {
+	h := newQueryHistory(100)
+	recordQueries(h, time.Now(), 10)
+
+	page, err := h.page("conn-1", "", 50)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(page.Entries) != 5 {
+		t.Errorf("Expected 5 entries for conn-1, got %d", len(page.Entries))
+	}
+	for _, e := range page.Entries {
+		if e.ConnectionID != "conn-1" {
+			t.Errorf("Unexpected connection in filtered page: %s", e.ConnectionID)
+		}
+	}
+}
+
+func TestQueryHistoryFallsBackToAuditLog(t *testing.T) {
+	h := newQueryHistory(5)
+	h.auditPath = filepath.Join(t.TempDir(), "audit.jsonl")
+	recordQueries(h, time.Now(), 12)
+
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := h.page("", cursor, 4)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, e := range page.Entries {
+			seen = append(seen, e.SQL)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(seen) != 12 {
+		t.Fatalf("Expected all 12 entries via audit log, got %d: %v", len(seen), seen)
+	}
+	for i, sql := range seen {
+		if expected := fmt.Sprintf("SELECT %d", 11-i); sql != expected {
+			t.Errorf("Entry %d: expected %s, got %s", i, expected, sql)
+		}
+	}
+}
+
+func TestQueryHistoryInvalidCursor(t *testing.T) {
+	h := newQueryHistory(10)
+	if _, err := h.page("", "not-a-cursor", 10); err == nil {
+		t.Error("Expected error for invalid cursor")
+	}
+}
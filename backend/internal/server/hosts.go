@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// defaultMySQLPort is assumed for configs that leave the port unset
+const defaultMySQLPort = 3306
+
+// loopbackHosts all name the local machine
+var loopbackHosts = map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true}
+
+// normalizeHost makes host names comparable: case-insensitive, without
+// IPv6 brackets or a trailing dot, and with every loopback name the same.
+// Names are not resolved, so a host and its IP address do not match.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	host = strings.TrimSuffix(host, ".")
+	if loopbackHosts[host] {
+		return "localhost"
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			return "localhost"
+		}
+		return ip.String()
+	}
+	return host
+}
+
+// hostMatches reports whether host:port names the wanted server; a wanted
+// port of 0 matches any port
+func hostMatches(host string, port int, wantHost string, wantPort int) bool {
+	if port == 0 {
+		port = defaultMySQLPort
+	}
+	return normalizeHost(host) == wantHost && (wantPort == 0 || port == wantPort)
+}
+
+// matchingReplica returns the first replica that is host (and port)
+func matchingReplica(replicas []protocol.HostPort, host string, port int) (protocol.HostPort, bool) {
+	for _, replica := range replicas {
+		if hostMatches(replica.Host, replica.Port, host, port) {
+			return replica, true
+		}
+	}
+	return protocol.HostPort{}, false
+}
+
+// connectionsForHost returns the connections whose primary or one of whose
+// read replicas is host (and port, when not 0), sorted by name
+func connectionsForHost(configs map[string]*protocol.ConnectionConfig, host string, port int) []protocol.HostConnection {
+	want := normalizeHost(host)
+	matches := []protocol.HostConnection{}
+	for id, config := range configs {
+		match := protocol.HostConnection{ConnectionID: id, Name: config.Name}
+		if hostMatches(config.Host, config.Port, want, port) {
+			match.Host, match.Port = config.Host, config.Port
+		} else if replica, ok := matchingReplica(config.ReadReplicas, want, port); ok {
+			match.Host, match.Port, match.Replica = replica.Host, replica.Port, true
+		} else {
+			continue
+		}
+		if match.Port == 0 {
+			match.Port = defaultMySQLPort
+		}
+		matches = append(matches, match)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := strings.ToLower(matches[i].Name), strings.ToLower(matches[j].Name)
+		if a != b {
+			return a < b
+		}
+		return matches[i].ConnectionID < matches[j].ConnectionID
+	})
+	return matches
+}
+
+func (s *Server) handleFindConnectionsByHost(params json.RawMessage) ([]protocol.HostConnection, error) {
+	var req struct {
+		Host string `json:"host"`
+		Port int    `json:"port"` // Optional; 0 matches any port
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if strings.TrimSpace(req.Host) == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+
+	s.mu.RLock()
+	configs := make(map[string]*protocol.ConnectionConfig, len(s.connections))
+	for id, conn := range s.connections {
+		if config := conn.Config(); config != nil {
+			configs[id] = config
+		}
+	}
+	s.mu.RUnlock()
+
+	return connectionsForHost(configs, req.Host, req.Port), nil
+}
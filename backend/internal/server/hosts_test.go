@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestConnectionsForHost(t *testing.T) {
+	configs := map[string]*protocol.ConnectionConfig{
+		"local":   {Name: "Local", Host: "127.0.0.1"},
+		"local2":  {Name: "local dev", Host: "localhost", Port: 3307},
+		"prod":    {Name: "Prod", Host: "DB1.example.com.", Port: 3306},
+		"reports": {Name: "Reports", Host: "db2.example.com", ReadReplicas: []protocol.HostPort{{Host: "db1.example.com", Port: 3306}}},
+		"other":   {Name: "Other", Host: "db3.example.com"},
+	}
+
+	tests := []struct {
+		host     string
+		port     int
+		expected []string
+	}{
+		{"localhost", 0, []string{"local", "local2"}},
+		{"::1", 3306, []string{"local"}},
+		{"[::1]", 3307, []string{"local2"}},
+		{"db1.example.com", 0, []string{"prod", "reports"}},
+		{"db1.example.com", 3307, nil},
+		{"db4.example.com", 0, nil},
+	}
+	for _, tt := range tests {
+		matches := connectionsForHost(configs, tt.host, tt.port)
+		var ids []string
+		for _, m := range matches {
+			ids = append(ids, m.ConnectionID)
+		}
+		if len(ids) != len(tt.expected) {
+			t.Errorf("connectionsForHost(%s, %d) = %v, want %v", tt.host, tt.port, ids, tt.expected)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.expected[i] {
+				t.Errorf("connectionsForHost(%s, %d) = %v, want %v", tt.host, tt.port, ids, tt.expected)
+				break
+			}
+		}
+	}
+
+	matches := connectionsForHost(configs, "db1.example.com", 0)
+	if matches[0].Replica || matches[0].Port != 3306 || !matches[1].Replica {
+		t.Errorf("Expected prod as primary and reports through its replica, got %+v", matches)
+	}
+	if local := connectionsForHost(configs, "127.0.0.1", 3306); len(local) != 1 || local[0].Port != 3306 {
+		t.Errorf("Expected an unset port to be reported as 3306, got %+v", local)
+	}
+}
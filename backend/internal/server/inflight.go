@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"fmt"
+)
+
+// inflightCall tracks a metadata request that is currently executing so
+// identical concurrent requests can wait for it instead of re-querying
+type inflightCall struct {
+	done chan struct{}
+	data interface{}
+	err  error
+	dups int
+
+	// cancel stops the shared work and requests lists the callers still
+	// waiting for it
+	cancel   context.CancelFunc
+	requests []string
+}
+
+// coalesceContext runs fn for the given key unless an identical call is
+// already in flight, in which case it waits for that call and shares its
+// result. The returned bool reports whether the result was shared with
+// another caller. fn runs on a context detached from every caller's, so one
+// caller being cancelled or passing its deadline does not fail the others
+// that joined it; it only returns early with its own context's error. The
+// shared work is cancelled once every caller has given up. requestID
+// registers the caller for inflightRequests.
+func (s *Server) coalesceContext(ctx context.Context, key, requestID string, fn func(ctx context.Context) (interface{}, error)) (interface{}, bool, error) {
+	s.inflightMu.Lock()
+	call, exists := s.inflight[key]
+	if exists {
+		call.dups++
+	} else {
+		var workCtx context.Context
+		call = &inflightCall{done: make(chan struct{})}
+		workCtx, call.cancel = context.WithCancel(context.Background())
+		s.inflight[key] = call
+		go s.runShared(key, call, workCtx, fn)
+	}
+	call.requests = append(call.requests, requestID)
+	s.inflightMu.Unlock()
+
+	select {
+	case <-call.done:
+		s.inflightMu.Lock()
+		shared := call.dups > 0
+		s.inflightMu.Unlock()
+		return call.data, shared, call.err
+	case <-ctx.Done():
+		s.inflightMu.Lock()
+		for i, id := range call.requests {
+			if id == requestID {
+				call.requests = append(call.requests[:i:i], call.requests[i+1:]...)
+				break
+			}
+		}
+		if len(call.requests) == 0 {
+			// Nobody is waiting any more; later callers start afresh
+			call.cancel()
+			if s.inflight[key] == call {
+				delete(s.inflight, key)
+			}
+		}
+		s.inflightMu.Unlock()
+		return nil, false, fmt.Errorf("request cancelled: %w", ctx.Err())
+	}
+}
+
+// runShared runs the work of a coalesceContext call and releases its
+// callers, with an error if fn does not return normally
+func (s *Server) runShared(key string, call *inflightCall, ctx context.Context, fn func(ctx context.Context) (interface{}, error)) {
+	returned := false
+	defer func() {
+		if !returned {
+			call.err = fmt.Errorf("%s failed unexpectedly", key)
+		}
+		s.inflightMu.Lock()
+		if s.inflight[key] == call {
+			delete(s.inflight, key)
+		}
+		s.inflightMu.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+
+	call.data, call.err = fn(ctx)
+	returned = true
+}
+
+// inflightRequests returns the IDs of the requests waiting for the
+// coalesceContext call with the given key
+func (s *Server) inflightRequests(key string) []string {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	call, ok := s.inflight[key]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), call.requests...)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesceSharesInFlightResult(t *testing.T) {
+	s := NewServer()
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "tables", nil
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]interface{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data, _, err := s.coalesceContext(context.Background(), "listTables:conn-1:app", fmt.Sprintf("req-%d", i), fn)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			results[i] = data
+		}(i)
+	}
+
+	// Wait until the first call is in flight and the others have joined it
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.inflightMu.Lock()
+		call := s.inflight["listTables:conn-1:app"]
+		joined := call != nil && call.dups == callers-1
+		s.inflightMu.Unlock()
+		if joined {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for callers to join in-flight request")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected 1 underlying call, got %d", got)
+	}
+	for i, r := range results {
+		if r != "tables" {
+			t.Errorf("Caller %d got %v, expected shared result", i, r)
+		}
+	}
+
+	s.inflightMu.Lock()
+	remaining := len(s.inflight)
+	s.inflightMu.Unlock()
+	if remaining != 0 {
+		t.Errorf("Expected in-flight map to be empty, got %d entries", remaining)
+	}
+}
+
+func TestCoalesceDoesNotShareCompletedCalls(t *testing.T) {
+	s := NewServer()
+
+	var calls int
+	fn := func(context.Context) (interface{}, error) {
+		calls++
+		return nil, errors.New("boom")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, shared, err := s.coalesceContext(context.Background(), "listDatabases:conn-1", "req-1", fn)
+		if err == nil {
+			t.Error("Expected error to be returned")
+		}
+		if shared {
+			t.Error("Sequential calls should not be reported as shared")
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected 2 underlying calls, got %d", calls)
+	}
+}
+
+func TestCoalesceReleasesWaitersWhenCallDoesNotReturn(t *testing.T) {
+	s := NewServer()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		_, _, err := s.coalesceContext(context.Background(), "listColumns:conn-1:app:orders", "req-1", func(context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			// Stops the work without returning, as a failed test helper does
+			runtime.Goexit()
+			return nil, nil
+		})
+		first <- err
+	}()
+	<-started
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, _, err := s.coalesceContext(context.Background(), "listColumns:conn-1:app:orders", "req-2", func(context.Context) (interface{}, error) {
+			return nil, nil
+		})
+		waiter <- err
+	}()
+
+	// Wait for the second caller to join before the work stops
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.inflightMu.Lock()
+		joined := s.inflight["listColumns:conn-1:app:orders"].dups == 1
+		s.inflightMu.Unlock()
+		if joined {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the caller to join")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	select {
+	case err := <-waiter:
+		if err == nil {
+			t.Error("Expected the waiter to get an error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Waiter was not released after the work stopped")
+	}
+	if err := <-first; err == nil {
+		t.Error("Expected the first caller to get an error")
+	}
+
+	s.inflightMu.Lock()
+	remaining := len(s.inflight)
+	s.inflightMu.Unlock()
+	if remaining != 0 {
+		t.Errorf("Expected in-flight map to be empty, got %d entries", remaining)
+	}
+}
+
+func TestCoalesceContextSurvivesLeaderCancel(t *testing.T) {
+	s := NewServer()
+	const key = "listAllTables:conn-1"
+
+	release := make(chan struct{})
+	workCancelled := make(chan bool, 1)
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-release
+		workCancelled <- ctx.Err() != nil
+		return "tables", nil
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, _, err := s.coalesceContext(leaderCtx, key, "req-1", fn)
+		leader <- err
+	}()
+	joiner := make(chan interface{}, 1)
+	waitForRequests(t, s, key, 1)
+	go func() {
+		data, shared, err := s.coalesceContext(context.Background(), key, "req-2", fn)
+		if err != nil || !shared {
+			t.Errorf("Expected a shared result, got shared=%v err=%v", shared, err)
+		}
+		joiner <- data
+	}()
+	waitForRequests(t, s, key, 2)
+
+	// The leader gives up; the joiner keeps the load running
+	cancelLeader()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the leader to be cancelled, got %v", err)
+	}
+	if got := s.inflightRequests(key); len(got) != 1 || got[0] != "req-2" {
+		t.Errorf("Expected only req-2 to be waiting, got %v", got)
+	}
+	close(release)
+
+	if data := <-joiner; data != "tables" {
+		t.Errorf("Expected the joiner to get the result, got %v", data)
+	}
+	if <-workCancelled {
+		t.Error("Shared work should not be cancelled while a caller waits")
+	}
+}
+
+func TestCoalesceContextCancelsWhenEveryCallerLeaves(t *testing.T) {
+	s := NewServer()
+	const key = "getAutocompleteSchema:conn-1:shop"
+
+	workCancelled := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(workCancelled)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := s.coalesceContext(ctx, key, "req-1", fn); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+
+	select {
+	case <-workCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shared work was not cancelled after its only caller left")
+	}
+	if got := s.inflightRequests(key); got != nil {
+		t.Errorf("Expected no in-flight call, got %v", got)
+	}
+}
+
+// waitForRequests waits until n requests wait for the in-flight key
+func waitForRequests(t *testing.T, s *Server, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.inflightRequests(key)) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d requests on %s", n, key)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	// DefaultMaxBatchSize is the rows per streamed batch when the client
+	// does not ask for a size
+	DefaultMaxBatchSize = 1000
+	// maxBatchSizeLimit caps the batch size a client may negotiate
+	maxBatchSizeLimit = 50000
+)
+
+// supportedCompression lists the result encodings the backend can produce,
+// in order of preference
+var supportedCompression = []string{"gzip"}
+
+// supportedResultFormats lists the result formats offered besides JSON
+var supportedResultFormats = []string{protocol.RowFormatArrow}
+
+// defaultCapabilities is the feature set for clients that never call
+// initialize, matching the behavior before negotiation existed
+func defaultCapabilities() protocol.ServerCapabilities {
+	return protocol.ServerCapabilities{
+		MaxBatchSize: DefaultMaxBatchSize,
+	}
+}
+
+// negotiateCapabilities picks the features both the client and the backend
+// support. Streaming is not implemented yet, so it is never granted.
+func negotiateCapabilities(client protocol.ClientCapabilities) protocol.ServerCapabilities {
+	caps := defaultCapabilities()
+
+	for _, encoding := range supportedCompression {
+		for _, requested := range client.Compression {
+			if requested == encoding && caps.Compression == "" {
+				caps.Compression = encoding
+			}
+		}
+	}
+
+	for _, format := range supportedResultFormats {
+		for _, requested := range client.ResultFormats {
+			if requested == format {
+				caps.ResultFormats = append(caps.ResultFormats, format)
+				break
+			}
+		}
+	}
+
+	switch {
+	case client.MaxBatchSize > maxBatchSizeLimit:
+		caps.MaxBatchSize = maxBatchSizeLimit
+	case client.MaxBatchSize > 0:
+		caps.MaxBatchSize = client.MaxBatchSize
+	}
+
+	return caps
+}
+
+// Capabilities returns the features negotiated by initialize, or the
+// defaults if the client has not called it
+func (s *Server) Capabilities() protocol.ServerCapabilities {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.capabilities
+}
+
+func (s *Server) handleInitialize(params json.RawMessage) (*protocol.InitializeResult, error) {
+	var req protocol.InitializeRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Answer newer clients with our revision so they can fall back to it
+	version := req.ProtocolVersion
+	if version <= 0 || version > protocol.ProtocolVersion {
+		version = protocol.ProtocolVersion
+	}
+
+	caps := negotiateCapabilities(req.Capabilities)
+
+	s.mu.Lock()
+	s.capabilities = caps
+	s.mu.Unlock()
+
+	log.Printf("Initialized by %s %s (protocol %d, compression %q, batch size %d, result formats %v)",
+		req.ClientName, req.ClientVersion, version, caps.Compression, caps.MaxBatchSize, caps.ResultFormats)
+
+	return &protocol.InitializeResult{
+		ServerVersion:   Version,
+		ProtocolVersion: version,
+		Capabilities:    caps,
+	}, nil
+}
+
+// resultFormatGranted reports whether initialize granted a result format
+func (s *Server) resultFormatGranted(format string) bool {
+	for _, granted := range s.Capabilities().ResultFormats {
+		if granted == format {
+			return true
+		}
+	}
+	return false
+}
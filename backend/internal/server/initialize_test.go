@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestNegotiateCapabilities(t *testing.T) {
+	testCases := []struct {
+		name     string
+		client   protocol.ClientCapabilities
+		expected protocol.ServerCapabilities
+	}{
+		{
+			name:     "Defaults",
+			client:   protocol.ClientCapabilities{},
+			expected: protocol.ServerCapabilities{MaxBatchSize: DefaultMaxBatchSize},
+		},
+		{
+			name:     "Gzip and batch size",
+			client:   protocol.ClientCapabilities{Compression: []string{"br", "gzip"}, MaxBatchSize: 200},
+			expected: protocol.ServerCapabilities{Compression: "gzip", MaxBatchSize: 200},
+		},
+		{
+			name:     "Unsupported compression",
+			client:   protocol.ClientCapabilities{Compression: []string{"zstd"}},
+			expected: protocol.ServerCapabilities{MaxBatchSize: DefaultMaxBatchSize},
+		},
+		{
+			name:     "Batch size capped and streaming not granted",
+			client:   protocol.ClientCapabilities{Streaming: true, MaxBatchSize: 1 << 30},
+			expected: protocol.ServerCapabilities{MaxBatchSize: maxBatchSizeLimit},
+		},
+		{
+			name:     "Arrow results",
+			client:   protocol.ClientCapabilities{ResultFormats: []string{"parquet", "arrow"}},
+			expected: protocol.ServerCapabilities{MaxBatchSize: DefaultMaxBatchSize, ResultFormats: []string{"arrow"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := negotiateCapabilities(tc.client); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestInitializeStoresCapabilities(t *testing.T) {
+	s := NewServer()
+	if caps := s.Capabilities(); caps.Compression != "" || caps.MaxBatchSize != DefaultMaxBatchSize {
+		t.Errorf("Unexpected default capabilities: %+v", caps)
+	}
+
+	result, err := s.handleInitialize(json.RawMessage(`{
+		"protocolVersion": 99,
+		"clientName": "vscode",
+		"capabilities": {"compression": ["gzip"], "maxBatchSize": 500}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.ProtocolVersion != protocol.ProtocolVersion {
+		t.Errorf("Expected protocol version %d, got %d", protocol.ProtocolVersion, result.ProtocolVersion)
+	}
+	if result.ServerVersion != Version {
+		t.Errorf("Expected server version %s, got %s", Version, result.ServerVersion)
+	}
+	if caps := s.Capabilities(); !reflect.DeepEqual(caps, result.Capabilities) || caps.Compression != "gzip" || caps.MaxBatchSize != 500 {
+		t.Errorf("Negotiated capabilities not stored: %+v", caps)
+	}
+
+	if _, err := s.handleInitialize(json.RawMessage(`{bad json}`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// Version is the backend version reported to the client. Release builds can
+// override it with -ldflags "-X github.com/tazgreenwood/data-warden/internal/server.Version=x.y.z".
+var Version = "0.1.1"
+
+// supportedMethods lists every method dispatch handles. Keep it in
+// sync with the switch; TestSupportedMethodsMatchDispatch checks both ways.
+var supportedMethods = []string{
+	"ping",
+	"readiness",
+	"listMethods",
+	"testConnection",
+	"connect",
+	"disconnect",
+	"healthCheck",
+	"listDatabases",
+	"listTables",
+	"listAllTables",
+	"listColumns",
+	"executeQuery",
+	"cancelQuery",
+	"getPrivileges",
+	"sampleTable",
+	"getQueryHistory",
+	"testCredentials",
+	"listUsers",
+	"listRoles",
+	"diffQueryResults",
+	"profileQuery",
+	"pivotQuery",
+	"createDatabase",
+	"listLocks",
+	"getDatabaseDDL",
+	"dropDatabase",
+	"getPoolStats",
+	"explainProcess",
+	"getAutoIncrement",
+	"explainAnalyze",
+	"listColumnsBulk",
+	"getAutocompleteSchema",
+	"runScript",
+	"getServerTime",
+	"copyTable",
+	"getServerStatus",
+	"initialize",
+	"openCursor",
+	"fetchCursor",
+	"closeCursor",
+	"snapshotTableSizes",
+	"listTableSizeSnapshots",
+	"compareTableSizeSnapshots",
+	"getTableDocumentation",
+	"setTableComment",
+	"setColumnComment",
+	"getSlowQueries",
+	"previewDelete",
+	"getReplicationStatus",
+	"saveQuery",
+	"listSavedQueries",
+	"getSavedQuery",
+	"deleteSavedQuery",
+	"getHierarchy",
+	"listPartitions",
+	"dropPartition",
+	"truncatePartition",
+	"getResultColumnMeta",
+	"getTableDependencyOrder",
+	"exportQueryStream",
+	"probeHost",
+	"getExactRowCounts",
+	"getSqlMode",
+	"checkPrivilege",
+	"topN",
+	"summarizeQuery",
+	"reconnectAll",
+	"getCurrentDatabase",
+	"saveConnection",
+	"loadConnections",
+	"connectSavedConnection",
+	"deleteSavedConnection",
+	"exportSchema",
+	"getCacheStats",
+	"getRowsAround",
+	"getInformationSchema",
+	"explainQuery",
+	"findConnectionsByHost",
+	"assertResultSchema",
+	"scanTable",
+	"getColumnCardinality",
+	"setSavedConnectionPinned",
+	"reorderSavedConnections",
+	"renameColumn",
+	"getSchemaBundle",
+	"getLongTransactions",
+}
+
+// listMethods returns the backend version and its methods in sorted order,
+// so the client can feature-detect against older backends
+func listMethods() *protocol.MethodList {
+	methods := make([]string, len(supportedMethods))
+	copy(methods, supportedMethods)
+	sort.Strings(methods)
+	return &protocol.MethodList{
+		Version: Version,
+		Methods: methods,
+	}
+}
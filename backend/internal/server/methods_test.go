@@ -0,0 +1,91 @@
+package server
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// dispatchedMethods returns the method names in dispatch's switch
+func dispatchedMethods(t *testing.T) map[string]bool {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "server.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse server.go: %v", err)
+	}
+
+	methods := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "dispatch" {
+			continue
+		}
+		for _, stmt := range fn.Body.List {
+			sw, ok := stmt.(*ast.SwitchStmt)
+			if !ok {
+				continue
+			}
+			for _, clause := range sw.Body.List {
+				for _, expr := range clause.(*ast.CaseClause).List {
+					lit, ok := expr.(*ast.BasicLit)
+					if !ok {
+						continue
+					}
+					name, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						t.Fatalf("Unexpected case value %s", lit.Value)
+					}
+					methods[name] = true
+				}
+			}
+		}
+	}
+	return methods
+}
+
+func TestSupportedMethodsMatchDispatch(t *testing.T) {
+	dispatched := dispatchedMethods(t)
+	if len(dispatched) == 0 {
+		t.Fatal("Found no methods in dispatch")
+	}
+
+	listed := make(map[string]bool)
+	for _, method := range supportedMethods {
+		if listed[method] {
+			t.Errorf("Method %s is listed twice", method)
+		}
+		listed[method] = true
+		if !dispatched[method] {
+			t.Errorf("Method %s is listed but not handled", method)
+		}
+	}
+	for method := range dispatched {
+		if !listed[method] {
+			t.Errorf("Method %s is handled but missing from supportedMethods", method)
+		}
+	}
+}
+
+func TestListMethods(t *testing.T) {
+	s := NewServer()
+	response := s.HandleRequest(&protocol.Request{JSONRPC: "2.0", ID: "1", Method: "listMethods"})
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %v", response.Error.Message)
+	}
+
+	list, ok := response.Result.(*protocol.MethodList)
+	if !ok {
+		t.Fatalf("Unexpected result type %T", response.Result)
+	}
+	if list.Version != Version {
+		t.Errorf("Expected version %s, got %s", Version, list.Version)
+	}
+	if !sort.StringsAreSorted(list.Methods) || len(list.Methods) != len(supportedMethods) {
+		t.Errorf("Expected all methods in sorted order, got %v", list.Methods)
+	}
+}
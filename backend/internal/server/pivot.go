@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// Aggregations applied when several rows land in the same pivot cell
+const (
+	pivotFirst = "first"
+	pivotSum   = "sum"
+	pivotCount = "count"
+)
+
+// pivotResult turns a flat result into a crosstab keyed by the rowKey and
+// columnKey column values
+func pivotResult(result *protocol.QueryResult, rowKey, columnKey, valueColumn, aggregate string) (*protocol.PivotResult, error) {
+	if aggregate == "" {
+		aggregate = pivotFirst
+	}
+	if aggregate != pivotFirst && aggregate != pivotSum && aggregate != pivotCount {
+		return nil, fmt.Errorf("unsupported aggregate: %s", aggregate)
+	}
+
+	index := make(map[string]int, len(result.Columns))
+	for i, name := range result.Columns {
+		index[name] = i
+	}
+	lookup := func(name, role string) (int, error) {
+		if name == "" {
+			return 0, fmt.Errorf("%s is required", role)
+		}
+		idx, ok := index[name]
+		if !ok {
+			return 0, fmt.Errorf("%s column %s is not in the result", role, name)
+		}
+		return idx, nil
+	}
+	rowIdx, err := lookup(rowKey, "rowKey")
+	if err != nil {
+		return nil, err
+	}
+	colIdx, err := lookup(columnKey, "columnKey")
+	if err != nil {
+		return nil, err
+	}
+	valueIdx, err := lookup(valueColumn, "valueColumn")
+	if err != nil {
+		return nil, err
+	}
+
+	pivot := &protocol.PivotResult{
+		RowKeys:       make([]string, 0),
+		ColumnKeys:    make([]string, 0),
+		Cells:         make(map[string]map[string]interface{}),
+		Aggregate:     aggregate,
+		ExecutionTime: result.ExecutionTime,
+	}
+	seenColumns := make(map[string]bool)
+
+	for _, row := range result.Rows {
+		r := pivotKey(row[rowIdx])
+		c := pivotKey(row[colIdx])
+		value := row[valueIdx]
+
+		cells, ok := pivot.Cells[r]
+		if !ok {
+			cells = make(map[string]interface{})
+			pivot.Cells[r] = cells
+			pivot.RowKeys = append(pivot.RowKeys, r)
+		}
+		if !seenColumns[c] {
+			seenColumns[c] = true
+			pivot.ColumnKeys = append(pivot.ColumnKeys, c)
+		}
+
+		current, exists := cells[c]
+		switch aggregate {
+		case pivotFirst:
+			if !exists {
+				cells[c] = value
+			}
+		case pivotCount:
+			count, _ := current.(int64)
+			if value != nil {
+				count++
+			}
+			cells[c] = count
+		case pivotSum:
+			if value == nil {
+				if !exists {
+					cells[c] = nil
+				}
+				continue
+			}
+			n, ok := numericValue(value)
+			if !ok {
+				return nil, fmt.Errorf("cannot sum non-numeric value %v in column %s", value, valueColumn)
+			}
+			sum, _ := current.(float64)
+			cells[c] = sum + n
+		}
+	}
+
+	sortPivotKeys(pivot.ColumnKeys)
+
+	// Fill missing cells with null so every row has every column
+	for _, cells := range pivot.Cells {
+		for _, c := range pivot.ColumnKeys {
+			if _, ok := cells[c]; !ok {
+				cells[c] = nil
+			}
+		}
+	}
+
+	return pivot, nil
+}
+
+// pivotKey renders a cell value as a map key
+func pivotKey(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+	return fmt.Sprint(value)
+}
+
+// numericValue converts driver and DECIMAL string values to float64
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// sortPivotKeys sorts numerically when both keys are numbers, otherwise
+// lexically, so "2" sorts before "10"
+func sortPivotKeys(keys []string) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, aErr := strconv.ParseFloat(keys[i], 64)
+		b, bErr := strconv.ParseFloat(keys[j], 64)
+		if aErr == nil && bErr == nil {
+			return a < b
+		}
+		if (aErr == nil) != (bErr == nil) {
+			return aErr == nil
+		}
+		return keys[i] < keys[j]
+	})
+}
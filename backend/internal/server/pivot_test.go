@@ -0,0 +1,86 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func salesResult() *protocol.QueryResult {
+	return &protocol.QueryResult{
+		Columns: []string{"region", "month", "amount"},
+		Rows: [][]interface{}{
+			{"north", int64(10), "5.50"},
+			{"north", int64(2), "1.25"},
+			{"south", int64(2), int64(3)},
+			{"north", int64(2), "2.00"},
+			{"south", int64(10), nil},
+		},
+	}
+}
+
+func TestPivotResultFirst(t *testing.T) {
+	pivot, err := pivotResult(salesResult(), "region", "month", "amount", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(pivot.RowKeys, []string{"north", "south"}) {
+		t.Errorf("Unexpected row keys: %v", pivot.RowKeys)
+	}
+	if !reflect.DeepEqual(pivot.ColumnKeys, []string{"2", "10"}) {
+		t.Errorf("Expected numerically sorted column keys, got %v", pivot.ColumnKeys)
+	}
+	if pivot.Cells["north"]["2"] != "1.25" {
+		t.Errorf("Expected first value to win, got %v", pivot.Cells["north"]["2"])
+	}
+	if v, ok := pivot.Cells["south"]["10"]; !ok || v != nil {
+		t.Errorf("Expected explicit null cell, got %v (present=%v)", v, ok)
+	}
+}
+
+func TestPivotResultSumAndCount(t *testing.T) {
+	sum, err := pivotResult(salesResult(), "region", "month", "amount", "sum")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sum.Cells["north"]["2"] != 3.25 {
+		t.Errorf("Expected sum 3.25, got %v", sum.Cells["north"]["2"])
+	}
+	if sum.Cells["south"]["10"] != nil {
+		t.Errorf("Expected null sum for all-null cell, got %v", sum.Cells["south"]["10"])
+	}
+
+	count, err := pivotResult(salesResult(), "region", "month", "amount", "count")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count.Cells["north"]["2"] != int64(2) {
+		t.Errorf("Expected count 2, got %v", count.Cells["north"]["2"])
+	}
+	if count.Cells["south"]["10"] != int64(0) {
+		t.Errorf("Expected count 0 for null value, got %v", count.Cells["south"]["10"])
+	}
+}
+
+func TestPivotResultErrors(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		rowKey, columnKey, value string
+		aggregate                string
+	}{
+		{"Unknown aggregate", "region", "month", "amount", "avg"},
+		{"Missing row key", "", "month", "amount", ""},
+		{"Unknown column", "region", "week", "amount", ""},
+		{"Non-numeric sum", "month", "amount", "region", "sum"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := pivotResult(salesResult(), tc.rowKey, tc.columnKey, tc.value, tc.aggregate); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	defaultProbeTimeout = 3 * time.Second
+	maxProbeTimeout     = 10 * time.Second
+)
+
+// handleProbeHost checks only that host:port accepts TCP connections, for
+// quick feedback while a connection is being configured. Nothing is stored.
+func (s *Server) handleProbeHost(ctx context.Context, params json.RawMessage) (*protocol.ProbeResult, error) {
+	var req protocol.ProbeRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Host == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+
+	port := req.Port
+	if port == 0 {
+		port = 3306
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port: %d", port)
+	}
+
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	if timeout > maxProbeTimeout {
+		timeout = maxProbeTimeout
+	}
+
+	return connection.ProbeHost(ctx, req.Host, port, timeout), nil
+}
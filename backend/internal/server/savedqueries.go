@@ -0,0 +1,215 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// maxSavedQueries bounds the saved query library
+const maxSavedQueries = 1000
+
+// savedQueries is a library of named queries. When a path is set the
+// library is loaded from and rewritten to that file on every change.
+type savedQueries struct {
+	mu      sync.Mutex
+	queries map[int64]protocol.SavedQuery
+	nextID  int64
+	path    string
+}
+
+// savedQueryFile is the persisted form of the library. NextID is kept so
+// IDs of deleted queries are not handed out again after a restart.
+type savedQueryFile struct {
+	NextID  int64                 `json:"nextId"`
+	Queries []protocol.SavedQuery `json:"queries"`
+}
+
+func newSavedQueries() *savedQueries {
+	return &savedQueries{queries: make(map[int64]protocol.SavedQuery), nextID: 1}
+}
+
+// load reads persisted queries from path and persists to it from then on.
+// A file that cannot be read or parsed leaves the path unset, so it is not
+// overwritten by the next change.
+func (sq *savedQueries) load(path string) error {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			sq.path = path
+			return nil
+		}
+		return fmt.Errorf("failed to read saved queries: %w", err)
+	}
+
+	var file savedQueryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse saved queries: %w", err)
+	}
+	if file.NextID > sq.nextID {
+		sq.nextID = file.NextID
+	}
+	for _, query := range file.Queries {
+		sq.queries[query.ID] = query
+		if query.ID >= sq.nextID {
+			sq.nextID = query.ID + 1
+		}
+	}
+	sq.path = path
+	return nil
+}
+
+// save stores a new query, or replaces the one with query.ID if it is set,
+// and persists the library. If the library cannot be written the change is
+// undone.
+func (sq *savedQueries) save(query protocol.SavedQuery) (protocol.SavedQuery, error) {
+	query.Name = strings.TrimSpace(query.Name)
+	if query.Name == "" {
+		return query, fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(query.SQL) == "" {
+		return query, fmt.Errorf("sql is required")
+	}
+	query.Tags = normalizeTags(query.Tags)
+
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	now := time.Now().UTC()
+	nextID := sq.nextID
+	existing, exists := sq.queries[query.ID]
+	if query.ID != 0 {
+		if !exists {
+			return query, fmt.Errorf("saved query not found: %d", query.ID)
+		}
+		query.CreatedAt = existing.CreatedAt
+	} else {
+		if len(sq.queries) >= maxSavedQueries {
+			return query, fmt.Errorf("too many saved queries (limit %d)", maxSavedQueries)
+		}
+		query.ID = sq.nextID
+		sq.nextID++
+		query.CreatedAt = now
+	}
+	query.UpdatedAt = now
+	sq.queries[query.ID] = query
+	if err := sq.persistLocked(); err != nil {
+		if exists {
+			sq.queries[query.ID] = existing
+		} else {
+			delete(sq.queries, query.ID)
+		}
+		sq.nextID = nextID
+		return query, err
+	}
+	return query, nil
+}
+
+// get returns a saved query by ID
+func (sq *savedQueries) get(id int64) (protocol.SavedQuery, bool) {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	query, ok := sq.queries[id]
+	return query, ok
+}
+
+// remove deletes a saved query and persists the library. If the library
+// cannot be written the query is kept.
+func (sq *savedQueries) remove(id int64) error {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	query, ok := sq.queries[id]
+	if !ok {
+		return fmt.Errorf("saved query not found: %d", id)
+	}
+	delete(sq.queries, id)
+	if err := sq.persistLocked(); err != nil {
+		sq.queries[id] = query
+		return err
+	}
+	return nil
+}
+
+// list returns saved queries sorted by name. With connectionID set, queries
+// associated with other connections are left out; with tag set, only
+// queries carrying that tag are returned.
+func (sq *savedQueries) list(connectionID, tag string) []protocol.SavedQuery {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	result := make([]protocol.SavedQuery, 0, len(sq.queries))
+	for _, query := range sq.queries {
+		if connectionID != "" && query.ConnectionID != "" && query.ConnectionID != connectionID {
+			continue
+		}
+		if tag != "" && !hasTag(query.Tags, tag) {
+			continue
+		}
+		result = append(result, query)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := strings.ToLower(result[i].Name), strings.ToLower(result[j].Name)
+		if a != b {
+			return a < b
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// persistLocked rewrites the library file
+func (sq *savedQueries) persistLocked() error {
+	if sq.path == "" {
+		return nil
+	}
+
+	queries := make([]protocol.SavedQuery, 0, len(sq.queries))
+	for _, query := range sq.queries {
+		queries = append(queries, query)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].ID < queries[j].ID })
+
+	file := savedQueryFile{NextID: sq.nextID, Queries: queries}
+	if err := writeJSONFile(sq.path, file); err != nil {
+		return fmt.Errorf("failed to write saved queries: %w", err)
+	}
+	return nil
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones, comparing
+// case-insensitively
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
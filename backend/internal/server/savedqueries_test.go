@@ -0,0 +1,145 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestSavedQueriesPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saved.json")
+
+	sq := newSavedQueries()
+	if err := sq.load(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	orders, err := sq.save(protocol.SavedQuery{Name: "Open orders", SQL: "SELECT * FROM orders WHERE status = 'open'", ConnectionID: "prod", Tags: []string{"orders", " Orders ", ""}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(orders.Tags) != 1 || orders.Tags[0] != "orders" {
+		t.Errorf("Expected tags to be trimmed and deduplicated, got %v", orders.Tags)
+	}
+	users, _ := sq.save(protocol.SavedQuery{Name: "active users", SQL: "SELECT * FROM users WHERE active"})
+	stale, _ := sq.save(protocol.SavedQuery{Name: "Stale", SQL: "SELECT 1"})
+
+	orders.SQL = "SELECT * FROM orders WHERE status IN ('open', 'held')"
+	updated, err := sq.save(orders)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.ID != orders.ID || !updated.CreatedAt.Equal(orders.CreatedAt) {
+		t.Errorf("Update should keep the ID and creation time, got %+v", updated)
+	}
+	if err := sq.remove(stale.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A new store reads the library back
+	reloaded := newSavedQueries()
+	if err := reloaded.load(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	list := reloaded.list("", "")
+	if len(list) != 2 || list[0].ID != users.ID || list[1].ID != orders.ID {
+		t.Fatalf("Expected active users then Open orders, got %+v", list)
+	}
+	if got, _ := reloaded.get(orders.ID); got.SQL != updated.SQL {
+		t.Errorf("Expected updated SQL, got %q", got.SQL)
+	}
+	if next, _ := reloaded.save(protocol.SavedQuery{Name: "Next", SQL: "SELECT 2"}); next.ID <= stale.ID {
+		t.Errorf("IDs should not be reused, got %d", next.ID)
+	}
+}
+
+func TestSavedQueriesWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "saved.json")
+
+	// A library that cannot be parsed is left alone
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sq := newSavedQueries()
+	if err := sq.load(path); err == nil {
+		t.Fatal("Expected an error loading a corrupt library")
+	}
+	if _, err := sq.save(protocol.SavedQuery{Name: "New", SQL: "SELECT 1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{not json" {
+		t.Errorf("Expected the corrupt library to be kept, got %q", data)
+	}
+
+	// A change that cannot be written is undone
+	sq = newSavedQueries()
+	if err := sq.load(filepath.Join(dir, "other.json")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	kept, err := sq.save(protocol.SavedQuery{Name: "Kept", SQL: "SELECT 1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sq.path = filepath.Join(dir, "missing", "saved.json")
+	if _, err := sq.save(protocol.SavedQuery{Name: "Lost", SQL: "SELECT 2"}); err == nil {
+		t.Error("Expected an error when the library cannot be written")
+	}
+	edited := kept
+	edited.SQL = "SELECT 3"
+	if _, err := sq.save(edited); err == nil {
+		t.Error("Expected an error when the library cannot be written")
+	}
+	if err := sq.remove(kept.ID); err == nil {
+		t.Error("Expected an error when the library cannot be written")
+	}
+	list := sq.list("", "")
+	if len(list) != 1 || list[0].ID != kept.ID || list[0].SQL != kept.SQL {
+		t.Errorf("Expected only the unchanged Kept query, got %+v", list)
+	}
+	if sq.nextID != kept.ID+1 {
+		t.Errorf("Expected the unused ID to be handed out again, got next ID %d", sq.nextID)
+	}
+}
+
+func TestSavedQueriesFilter(t *testing.T) {
+	sq := newSavedQueries()
+	sq.save(protocol.SavedQuery{Name: "a", SQL: "SELECT 1", ConnectionID: "prod", Tags: []string{"Reports"}})
+	sq.save(protocol.SavedQuery{Name: "b", SQL: "SELECT 2", ConnectionID: "dev"})
+	sq.save(protocol.SavedQuery{Name: "c", SQL: "SELECT 3", Tags: []string{"reports"}})
+
+	names := func(queries []protocol.SavedQuery) string {
+		result := ""
+		for _, q := range queries {
+			result += q.Name
+		}
+		return result
+	}
+	if got := names(sq.list("prod", "")); got != "ac" {
+		t.Errorf("Connection filter: expected ac, got %s", got)
+	}
+	if got := names(sq.list("", "reports")); got != "ac" {
+		t.Errorf("Tag filter: expected ac, got %s", got)
+	}
+	if got := names(sq.list("dev", "reports")); got != "c" {
+		t.Errorf("Combined filter: expected c, got %s", got)
+	}
+}
+
+func TestSavedQueriesValidation(t *testing.T) {
+	sq := newSavedQueries()
+	if _, err := sq.save(protocol.SavedQuery{Name: " ", SQL: "SELECT 1"}); err == nil {
+		t.Error("Expected error for an empty name")
+	}
+	if _, err := sq.save(protocol.SavedQuery{Name: "x", SQL: ""}); err == nil {
+		t.Error("Expected error for empty SQL")
+	}
+	if _, err := sq.save(protocol.SavedQuery{ID: 42, Name: "x", SQL: "SELECT 1"}); err == nil {
+		t.Error("Expected error updating a missing query")
+	}
+	if err := sq.remove(42); err == nil {
+		t.Error("Expected error deleting a missing query")
+	}
+}
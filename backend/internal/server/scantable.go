@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// handleScanTable streams a whole table in primary key order as
+// scanTableChunk notifications, one keyset-paginated batch at a time, so
+// memory stays constant however large the table is. Cancelling the request
+// stops the scan between or during batches; the last chunk's LastKey
+// resumes it.
+func (s *Server) handleScanTable(ctx context.Context, requestID string, params json.RawMessage) (*protocol.TableScanComplete, error) {
+	var req protocol.ScanTableRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Database == "" || req.Table == "" {
+		return nil, fmt.Errorf("database and table are required")
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, "scanTable "+req.Database+"."+req.Table)
+	defer done()
+
+	log.Printf("Scanning %s.%s (request %s)", req.Database, req.Table, requestID)
+	startTime := time.Now()
+	result := &protocol.TableScanComplete{
+		QueryComplete: protocol.QueryComplete{RequestID: requestID},
+		Database:      req.Database,
+		Table:         req.Table,
+		LastKey:       req.AfterKey,
+	}
+	keyColumns, err := conn.ScanTable(ctx, req.Database, req.Table, req.AfterKey, req.BatchRows,
+		func(batch *protocol.QueryResult, lastKey []interface{}) error {
+			chunk := protocol.TableScanChunk{
+				RequestID: requestID,
+				Sequence:  result.Chunks + 1,
+				Rows:      batch.Rows,
+				LastKey:   lastKey,
+			}
+			if result.Chunks == 0 {
+				result.Columns = batch.Columns
+				chunk.Columns = batch.Columns
+				chunk.ColumnTypes = batch.ColumnTypes
+			}
+			result.Chunks++
+			result.TotalRows += int64(len(batch.Rows))
+			result.LastKey = lastKey
+			s.notify("scanTableChunk", chunk)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	result.KeyColumns = keyColumns
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.notify("scanTableComplete", result)
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+const (
+	maxSchemaBundleOperations = 500
+	// schemaBundleConcurrency bounds the operations of one bundle running
+	// at once, leaving pooled connections for other requests
+	schemaBundleConcurrency = 4
+)
+
+// schemaOperationKey is the key of an operation's entry in the bundle
+func schemaOperationKey(op protocol.SchemaOperation) string {
+	if op.Key != "" {
+		return op.Key
+	}
+	switch op.Method {
+	case "listTables":
+		return op.Method + ":" + op.Database
+	case "listColumns":
+		return op.Method + ":" + op.Database + "." + op.Table
+	}
+	return op.Method
+}
+
+// runSchemaOperation runs one operation through the handler of the same
+// method, so it shares its cache and coalescing
+func (s *Server) runSchemaOperation(ctx context.Context, requestID, connectionID string, op protocol.SchemaOperation) (interface{}, error) {
+	params, err := json.Marshal(map[string]string{
+		"connectionId": connectionID,
+		"database":     op.Database,
+		"table":        op.Table,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Method {
+	case "listDatabases":
+		return s.handleListDatabases(ctx, requestID, params)
+	case "listTables":
+		if op.Database == "" {
+			return nil, fmt.Errorf("database is required")
+		}
+		return s.handleListTables(ctx, requestID, params)
+	case "listColumns":
+		if op.Database == "" || op.Table == "" {
+			return nil, fmt.Errorf("database and table are required")
+		}
+		return s.handleListColumns(ctx, requestID, params)
+	}
+	return nil, fmt.Errorf("unsupported schema operation: %s", op.Method)
+}
+
+// handleGetSchemaBundle runs several metadata reads concurrently and
+// returns their results keyed by operation, so a client can load what it
+// needs for a first render in one round trip. A failed operation only
+// fails its own entry.
+func (s *Server) handleGetSchemaBundle(ctx context.Context, requestID string, params json.RawMessage) (map[string]protocol.SchemaBundleEntry, error) {
+	var req struct {
+		ConnectionID string                     `json:"connectionId"`
+		Operations   []protocol.SchemaOperation `json:"operations"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if len(req.Operations) > maxSchemaBundleOperations {
+		return nil, fmt.Errorf("too many operations: %d (maximum %d)", len(req.Operations), maxSchemaBundleOperations)
+	}
+
+	keys := make([]string, len(req.Operations))
+	seen := make(map[string]bool, len(req.Operations))
+	for i, op := range req.Operations {
+		keys[i] = schemaOperationKey(op)
+		if seen[keys[i]] {
+			return nil, fmt.Errorf("duplicate operation key: %s", keys[i])
+		}
+		seen[keys[i]] = true
+	}
+
+	if s.getConnection(req.ConnectionID) == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	entries := make([]protocol.SchemaBundleEntry, len(req.Operations))
+	sem := make(chan struct{}, schemaBundleConcurrency)
+	var wg sync.WaitGroup
+	for i, op := range req.Operations {
+		wg.Add(1)
+		go func(i int, op protocol.SchemaOperation) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result, err := s.runSchemaOperation(ctx, requestID, req.ConnectionID, op)
+			if err != nil {
+				entries[i].Error = err.Error()
+				return
+			}
+			entries[i].Result = result
+		}(i, op)
+	}
+	wg.Wait()
+
+	bundle := make(map[string]protocol.SchemaBundleEntry, len(entries))
+	failed := 0
+	for i, entry := range entries {
+		bundle[keys[i]] = entry
+		if entry.Error != "" {
+			failed++
+		}
+	}
+	log.Printf("Loaded schema bundle for %s: %d operations, %d failed", req.ConnectionID, len(entries), failed)
+	return bundle, nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tazgreenwood/data-warden/internal/connection"
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestSchemaOperationKey(t *testing.T) {
+	testCases := []struct {
+		op       protocol.SchemaOperation
+		expected string
+	}{
+		{protocol.SchemaOperation{Method: "listDatabases"}, "listDatabases"},
+		{protocol.SchemaOperation{Method: "listTables", Database: "shop"}, "listTables:shop"},
+		{protocol.SchemaOperation{Method: "listColumns", Database: "shop", Table: "orders"}, "listColumns:shop.orders"},
+		{protocol.SchemaOperation{Key: "orders", Method: "listColumns", Database: "shop", Table: "orders"}, "orders"},
+	}
+
+	for _, tc := range testCases {
+		if got := schemaOperationKey(tc.op); got != tc.expected {
+			t.Errorf("Expected %s, got %s", tc.expected, got)
+		}
+	}
+}
+
+func TestGetSchemaBundlePartialFailure(t *testing.T) {
+	s := NewServer()
+	// Cached results are served without touching the database
+	s.mu.Lock()
+	s.connections["conn-1"] = &connection.Connection{}
+	s.mu.Unlock()
+	s.setCache("listDatabases:conn-1", []protocol.Database{{Name: "shop"}})
+	s.setCache("listTables:conn-1:shop", []protocol.Table{{Name: "orders"}})
+
+	params := json.RawMessage(`{"connectionId":"conn-1","operations":[
+		{"method":"listDatabases"},
+		{"method":"listTables","database":"shop"},
+		{"key":"broken","method":"listColumns","database":"shop"},
+		{"method":"dropDatabase"}
+	]}`)
+	bundle, err := s.handleGetSchemaBundle(context.Background(), "1", params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(bundle) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(bundle))
+	}
+
+	if databases, ok := bundle["listDatabases"].Result.([]protocol.Database); !ok || len(databases) != 1 {
+		t.Errorf("Expected the cached databases, got %+v", bundle["listDatabases"])
+	}
+	if tables, ok := bundle["listTables:shop"].Result.([]protocol.Table); !ok || len(tables) != 1 {
+		t.Errorf("Expected the cached tables, got %+v", bundle["listTables:shop"])
+	}
+	if entry := bundle["broken"]; entry.Result != nil || !strings.Contains(entry.Error, "table are required") {
+		t.Errorf("Expected a validation error, got %+v", entry)
+	}
+	if entry := bundle["dropDatabase"]; !strings.Contains(entry.Error, "unsupported schema operation") {
+		t.Errorf("Expected an unsupported operation error, got %+v", entry)
+	}
+}
+
+func TestGetSchemaBundleRejectsRequest(t *testing.T) {
+	s := NewServer()
+
+	_, err := s.handleGetSchemaBundle(context.Background(), "1", json.RawMessage(`{"connectionId":"missing","operations":[{"method":"listDatabases"}]}`))
+	if err == nil || !strings.Contains(err.Error(), "connection not found") {
+		t.Errorf("Expected connection not found, got %v", err)
+	}
+
+	_, err = s.handleGetSchemaBundle(context.Background(), "1", json.RawMessage(`{"connectionId":"missing","operations":[
+		{"method":"listTables","database":"shop"},
+		{"key":"listTables:shop","method":"listDatabases"}
+	]}`))
+	if err == nil || !strings.Contains(err.Error(), "duplicate operation key") {
+		t.Errorf("Expected a duplicate key error, got %v", err)
+	}
+}
@@ -3,64 +3,234 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tazgreenwood/data-warden/internal/connection"
 	"github.com/tazgreenwood/data-warden/internal/protocol"
 )
 
+const (
+	// defaultCacheTTL applies to cache entries stored without their own TTL
+	defaultCacheTTL = 30 * time.Second
+	// informationSchemaCacheTTL is shorter than the autocomplete schema's
+	// because sizes and update times drift
+	informationSchemaCacheTTL = 2 * time.Minute
+)
+
 type cacheEntry struct {
 	data      interface{}
 	timestamp time.Time
 	ttl       time.Duration
 }
 
+// effectiveTTL returns the entry's TTL, or the default when it has none
+func (e cacheEntry) effectiveTTL() time.Duration {
+	if e.ttl == 0 {
+		return defaultCacheTTL
+	}
+	return e.ttl
+}
+
+// expired reports whether the entry is too old to be served at now
+func (e cacheEntry) expired(now time.Time) bool {
+	return now.Sub(e.timestamp) > e.effectiveTTL()
+}
+
 type queryContext struct {
 	cancel context.CancelFunc
 	sql    string
 }
 
+// systemDatabases are the server's own schemas, skipped when loading user
+// tables and protected from dropDatabase
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
 type Server struct {
 	connections map[string]*connection.Connection
 	mu          sync.RWMutex
 	// Simple cache for metadata queries with 30-second TTL
 	cache   map[string]cacheEntry
 	cacheMu sync.RWMutex
+	// Lookups answered and missed by the cache, for getCacheStats
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
 	// Track running queries for cancellation
 	runningQueries   map[string]queryContext
 	runningQueriesMu sync.RWMutex
+	// Coalesce identical in-flight metadata requests
+	inflight   map[string]*inflightCall
+	inflightMu sync.Mutex
+	// Bounded log of executed queries
+	history *queryHistory
+	// Table size snapshots for growth comparisons
+	sizes *sizeSnapshots
+	// Named queries saved by the user
+	saved *savedQueries
+	// Encrypted connection configs saved by the user
+	connectionStore *connectionStore
+	// Sends JSON-RPC notifications to the client
+	notifier func(*protocol.Notification)
+	// Connections whose last health check failed (guarded by mu)
+	lost map[string]bool
+	// Stops the background health sweep (guarded by mu)
+	stopSweep chan struct{}
+	// Reconnect lost connections from the health sweep (guarded by mu)
+	autoReconnect bool
+	// Features negotiated by initialize (guarded by mu)
+	capabilities protocol.ServerCapabilities
+	// Open result cursors by ID
+	cursors           map[string]*openCursor
+	cursorsMu         sync.Mutex
+	nextCursorID      uint64
+	cursorIdleTimeout time.Duration
 }
 
 func NewServer() *Server {
 	return &Server{
-		connections:    make(map[string]*connection.Connection),
-		cache:          make(map[string]cacheEntry),
-		runningQueries: make(map[string]queryContext),
+		connections:       make(map[string]*connection.Connection),
+		cache:             make(map[string]cacheEntry),
+		runningQueries:    make(map[string]queryContext),
+		inflight:          make(map[string]*inflightCall),
+		history:           newQueryHistory(maxHistoryEntries),
+		sizes:             newSizeSnapshots(maxSizeSnapshots),
+		saved:             newSavedQueries(),
+		connectionStore:   newConnectionStore(),
+		lost:              make(map[string]bool),
+		capabilities:      defaultCapabilities(),
+		cursors:           make(map[string]*openCursor),
+		cursorIdleTimeout: DefaultCursorIdleTimeout,
+	}
+}
+
+// SetNotifier sets the function used to send notifications to the client.
+// It must be safe for concurrent use alongside responses.
+func (s *Server) SetNotifier(notifier func(*protocol.Notification)) {
+	s.notifier = notifier
+}
+
+// notify sends a JSON-RPC notification if a notifier is configured
+func (s *Server) notify(method string, params interface{}) {
+	if s.notifier == nil {
+		return
 	}
+	s.notifier(&protocol.Notification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// SetAuditLog mirrors query history to an append-only JSON lines file so
+// older history pages remain available beyond the in-memory buffer
+func (s *Server) SetAuditLog(path string) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	s.history.auditPath = path
+}
+
+// SetSizeSnapshotFile loads table size snapshots from a JSON file and keeps
+// it updated, so snapshots survive restarts
+func (s *Server) SetSizeSnapshotFile(path string) error {
+	return s.sizes.load(path)
+}
+
+// SetSavedQueryFile loads saved queries from a JSON file and keeps it
+// updated, so the library survives restarts
+func (s *Server) SetSavedQueryFile(path string) error {
+	return s.saved.load(path)
+}
+
+// SetConnectionStore loads saved connections from an encrypted file and
+// keeps it updated. The key is derived from secret, which must be the same
+// on every start.
+func (s *Server) SetConnectionStore(path, secret string) error {
+	return s.connectionStore.load(path, secret)
 }
 
+// HandleRequest runs a request and returns its response. When the request
+// carries a deadline, handlers get a context that expires with it, tracked
+// work (queries, scripts, cursors) is cancelled when it passes, and the
+// response is a RequestTimeout error. Calls that cannot be cancelled finish
+// in the background and their result is dropped.
 func (s *Server) HandleRequest(req *protocol.Request) *protocol.Response {
+	deadline, ok := requestDeadline(req.DeadlineMs, time.Now())
+	if !ok {
+		response, _ := s.dispatch(context.Background(), req)
+		return response
+	}
+	if !time.Now().Before(deadline) {
+		return timeoutResponse(req)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	type outcome struct {
+		response *protocol.Response
+		err      error
+	}
+	result := make(chan outcome, 1)
+	go func() {
+		response, err := s.dispatch(ctx, req)
+		result <- outcome{response, err}
+	}()
+
+	select {
+	case out := <-result:
+		// Only a handler stopped by the deadline reports a timeout; other
+		// failures keep their own error even if they return late
+		if errors.Is(out.err, context.DeadlineExceeded) {
+			return timeoutResponse(req)
+		}
+		return out.response
+	case <-ctx.Done():
+		return timeoutResponse(req)
+	}
+}
+
+// dispatch runs the handler for req.Method with the request's context and
+// returns its response along with the handler's error, if any
+func (s *Server) dispatch(ctx context.Context, req *protocol.Request) (*protocol.Response, error) {
 	log.Printf("Handling request: %s", req.Method)
 
 	response := &protocol.Response{
 		JSONRPC: "2.0",
 		ID:      req.ID,
 	}
+	var handlerErr error
+	fail := func(err error) {
+		handlerErr = err
+		response.Error = &protocol.Error{
+			Code:    protocol.InternalError,
+			Message: err.Error(),
+		}
+	}
 
 	switch req.Method {
 	case "ping":
 		response.Result = map[string]string{"status": "ok"}
 
+	case "readiness":
+		response.Result = s.readiness(func(conn *connection.Connection) error { return conn.HealthCheck(ctx) })
+
+	case "listMethods":
+		response.Result = listMethods()
+
 	case "testConnection":
-		result, err := s.handleTestConnection(req.Params)
+		result, err := s.handleTestConnection(ctx, req.Params)
 		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
+			fail(err)
 		} else {
 			response.Result = result
 		}
@@ -68,10 +238,7 @@ func (s *Server) HandleRequest(req *protocol.Request) *protocol.Response {
 	case "connect":
 		err := s.handleConnect(req.Params)
 		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
+			fail(err)
 		} else {
 			response.Result = map[string]bool{"success": true}
 		}
@@ -79,210 +246,2439 @@ func (s *Server) HandleRequest(req *protocol.Request) *protocol.Response {
 	case "disconnect":
 		err := s.handleDisconnect(req.Params)
 		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
+			fail(err)
 		} else {
 			response.Result = map[string]bool{"success": true}
 		}
 
 	case "healthCheck":
-		err := s.handleHealthCheck(req.Params)
+		err := s.handleHealthCheck(ctx, req.Params)
 		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
+			fail(err)
 		} else {
 			response.Result = map[string]bool{"healthy": true}
 		}
 
-	case "listDatabases":
-		result, err := s.handleListDatabases(req.Params)
-		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
+	case "listDatabases":
+		result, err := s.handleListDatabases(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listTables":
+		result, err := s.handleListTables(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listAllTables":
+		result, err := s.handleListAllTables(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listColumns":
+		result, err := s.handleListColumns(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "executeQuery":
+		result, err := s.handleExecuteQuery(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "cancelQuery":
+		err := s.handleCancelQuery(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getPrivileges":
+		result, err := s.handleGetPrivileges(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "sampleTable":
+		result, err := s.handleSampleTable(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getQueryHistory":
+		result, err := s.handleGetQueryHistory(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "testCredentials":
+		result, err := s.handleTestCredentials(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listUsers":
+		result, err := s.handleListUsers(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listRoles":
+		result, err := s.handleListRoles(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "diffQueryResults":
+		result, err := s.handleDiffQueryResults(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "profileQuery":
+		result, err := s.handleProfileQuery(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "pivotQuery":
+		result, err := s.handlePivotQuery(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "createDatabase":
+		err := s.handleCreateDatabase(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "listLocks":
+		result, err := s.handleListLocks(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getDatabaseDDL":
+		result, err := s.handleGetDatabaseDDL(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "dropDatabase":
+		err := s.handleDropDatabase(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getPoolStats":
+		result, err := s.handleGetPoolStats(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "explainProcess":
+		result, err := s.handleExplainProcess(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getAutoIncrement":
+		result, err := s.handleGetAutoIncrement(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "explainAnalyze":
+		result, err := s.handleExplainAnalyze(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listColumnsBulk":
+		result, err := s.handleListColumnsBulk(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getAutocompleteSchema":
+		result, err := s.handleGetAutocompleteSchema(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "runScript":
+		result, err := s.handleRunScript(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getServerTime":
+		result, err := s.handleGetServerTime(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "copyTable":
+		err := s.handleCopyTable(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getServerStatus":
+		result, err := s.handleGetServerStatus(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "initialize":
+		result, err := s.handleInitialize(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "openCursor":
+		result, err := s.handleOpenCursor(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "fetchCursor":
+		result, err := s.handleFetchCursor(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "closeCursor":
+		err := s.handleCloseCursor(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "snapshotTableSizes":
+		result, err := s.handleSnapshotTableSizes(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listTableSizeSnapshots":
+		result, err := s.handleListTableSizeSnapshots(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "compareTableSizeSnapshots":
+		result, err := s.handleCompareTableSizeSnapshots(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getTableDocumentation":
+		result, err := s.handleGetTableDocumentation(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "setTableComment":
+		err := s.handleSetTableComment(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "setColumnComment":
+		err := s.handleSetColumnComment(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getSlowQueries":
+		result, err := s.handleGetSlowQueries(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "previewDelete":
+		result, err := s.handlePreviewDelete(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getReplicationStatus":
+		result, err := s.handleGetReplicationStatus(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "saveQuery":
+		result, err := s.handleSaveQuery(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listSavedQueries":
+		result, err := s.handleListSavedQueries(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getSavedQuery":
+		result, err := s.handleGetSavedQuery(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "deleteSavedQuery":
+		err := s.handleDeleteSavedQuery(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getHierarchy":
+		result, err := s.handleGetHierarchy(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "listPartitions":
+		result, err := s.handleListPartitions(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "dropPartition":
+		err := s.handleDropPartition(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "truncatePartition":
+		err := s.handleTruncatePartition(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getResultColumnMeta":
+		result, err := s.handleGetResultColumnMeta(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getTableDependencyOrder":
+		result, err := s.handleGetTableDependencyOrder(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "exportQueryStream":
+		result, err := s.handleExportQueryStream(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "probeHost":
+		result, err := s.handleProbeHost(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getExactRowCounts":
+		result, err := s.handleGetExactRowCounts(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getSqlMode":
+		result, err := s.handleGetSQLMode(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "checkPrivilege":
+		result, err := s.handleCheckPrivilege(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "topN":
+		result, err := s.handleTopN(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "summarizeQuery":
+		result, err := s.handleSummarizeQuery(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "reconnectAll":
+		response.Result = s.reconnectAll()
+
+	case "getCurrentDatabase":
+		result, err := s.handleGetCurrentDatabase(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "saveConnection":
+		result, err := s.handleSaveConnection(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "loadConnections":
+		result, err := s.connectionStore.list()
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "connectSavedConnection":
+		err := s.handleConnectSavedConnection(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "deleteSavedConnection":
+		err := s.handleDeleteSavedConnection(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "exportSchema":
+		result, err := s.handleExportSchema(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getCacheStats":
+		result, err := s.handleGetCacheStats(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getRowsAround":
+		result, err := s.handleGetRowsAround(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getInformationSchema":
+		result, err := s.handleGetInformationSchema(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "explainQuery":
+		result, err := s.handleExplainQuery(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "findConnectionsByHost":
+		result, err := s.handleFindConnectionsByHost(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "assertResultSchema":
+		result, err := s.handleAssertResultSchema(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "scanTable":
+		result, err := s.handleScanTable(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getColumnCardinality":
+		result, err := s.handleGetColumnCardinality(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "setSavedConnectionPinned":
+		result, err := s.handleSetSavedConnectionPinned(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "reorderSavedConnections":
+		err := s.handleReorderSavedConnections(req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "renameColumn":
+		err := s.handleRenameColumn(ctx, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = map[string]bool{"success": true}
+		}
+
+	case "getSchemaBundle":
+		result, err := s.handleGetSchemaBundle(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	case "getLongTransactions":
+		result, err := s.handleGetLongTransactions(ctx, req.ID, req.Params)
+		if err != nil {
+			fail(err)
+		} else {
+			response.Result = result
+		}
+
+	default:
+		response.Error = &protocol.Error{
+			Code:    protocol.MethodNotFound,
+			Message: fmt.Sprintf("Method not found: %s", req.Method),
+		}
+	}
+
+	return response, handlerErr
+}
+
+func (s *Server) handleTestConnection(ctx context.Context, params json.RawMessage) (*protocol.ConnectionTestResult, error) {
+	var config protocol.ConnectionConfig
+	if err := json.Unmarshal(params, &config); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn, err := connection.NewConnection(&config)
+	if err != nil {
+		return &protocol.ConnectionTestResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+	defer conn.Close()
+
+	version, err := conn.GetVersion(ctx)
+	if err != nil {
+		return &protocol.ConnectionTestResult{
+			Success: false,
+			Message: fmt.Sprintf("Connected but failed to get version: %v", err),
+		}, nil
+	}
+
+	return &protocol.ConnectionTestResult{
+		Success: true,
+		Message: "Connection successful",
+		Version: version,
+	}, nil
+}
+
+func (s *Server) handleTestCredentials(ctx context.Context, params json.RawMessage) (*protocol.CredentialsTestResult, error) {
+	var config protocol.ConnectionConfig
+	if err := json.Unmarshal(params, &config); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Connect without selecting a database so a missing database doesn't
+	// mask valid credentials
+	database := config.Database
+	config.Database = ""
+
+	conn, err := connection.NewConnection(&config)
+	if err != nil {
+		return &protocol.CredentialsTestResult{
+			Success:  false,
+			Message:  err.Error(),
+			Database: database,
+		}, nil
+	}
+	defer conn.Close()
+
+	version, err := conn.GetVersion(ctx)
+	if err != nil {
+		return &protocol.CredentialsTestResult{
+			Success:  false,
+			Message:  fmt.Sprintf("Connected but failed to get version: %v", err),
+			Database: database,
+		}, nil
+	}
+
+	result := &protocol.CredentialsTestResult{
+		Success:  true,
+		Message:  "Credentials valid",
+		Version:  version,
+		Database: database,
+	}
+	if database == "" {
+		return result, nil
+	}
+
+	exists, err := conn.DatabaseExists(ctx, database)
+	if err != nil {
+		result.Message = fmt.Sprintf("Credentials valid, but could not check database '%s': %v", database, err)
+		return result, nil
+	}
+	result.DatabaseExists = &exists
+	if !exists {
+		result.Message = fmt.Sprintf("Credentials valid, but database '%s' does not exist or is not accessible", database)
+	}
+
+	return result, nil
+}
+
+func (s *Server) handleConnect(params json.RawMessage) error {
+	var config protocol.ConnectionConfig
+	if err := json.Unmarshal(params, &config); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return s.connect(&config)
+}
+
+// connect opens a connection, replacing any open one with the same ID
+func (s *Server) connect(config *protocol.ConnectionConfig) error {
+	conn, err := connection.NewConnection(config)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	// Close existing connection if any
+	existingConn, exists := s.connections[config.ID]
+	if exists {
+		existingConn.Close()
+	}
+
+	s.connections[config.ID] = conn
+	delete(s.lost, config.ID)
+	s.mu.Unlock()
+	log.Printf("Connection established: %s", config.ID)
+
+	state := protocol.ConnectionStateConnected
+	if exists {
+		state = protocol.ConnectionStateReconnected
+	}
+	s.notifyConnectionState(config.ID, state, nil)
+
+	return nil
+}
+
+func (s *Server) handleDisconnect(params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Cursors hold pinned connections from the pool being closed
+	s.closeConnectionCursors(req.ConnectionID)
+
+	s.mu.Lock()
+	conn, exists := s.connections[req.ConnectionID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	conn.Close()
+	delete(s.connections, req.ConnectionID)
+	delete(s.lost, req.ConnectionID)
+	s.mu.Unlock()
+	log.Printf("Connection closed: %s", req.ConnectionID)
+
+	s.notifyConnectionState(req.ConnectionID, protocol.ConnectionStateClosed, nil)
+
+	return nil
+}
+
+func (s *Server) handleHealthCheck(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	err := conn.HealthCheck(ctx)
+	s.recordHealth(req.ConnectionID, conn, err)
+	return err
+}
+
+func (s *Server) handleListDatabases(ctx context.Context, requestID string, params json.RawMessage) ([]protocol.Database, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Check cache first
+	cacheKey := fmt.Sprintf("listDatabases:%s", req.ConnectionID)
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if databases, ok := cached.([]protocol.Database); ok {
+			log.Printf("Cache hit for listDatabases: %s", req.ConnectionID)
+			return databases, nil
+		}
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Share the result with identical requests already in flight
+	result, shared, err := s.coalesceContext(ctx, cacheKey, requestID, func(ctx context.Context) (interface{}, error) {
+		databases, err := conn.ListDatabasesContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Cache the result
+		s.setCache(cacheKey, databases)
+		return databases, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		log.Printf("Coalesced duplicate listDatabases: %s", req.ConnectionID)
+	}
+
+	return result.([]protocol.Database), nil
+}
+
+func (s *Server) handleListTables(ctx context.Context, requestID string, params json.RawMessage) ([]protocol.Table, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Check cache first (names compare case-insensitively on some servers)
+	cacheKey := fmt.Sprintf("listTables:%s:%s", req.ConnectionID, conn.NormalizeIdentifier(req.Database))
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if tables, ok := cached.([]protocol.Table); ok {
+			log.Printf("Cache hit for listTables: %s.%s", req.ConnectionID, req.Database)
+			return tables, nil
+		}
+	}
+
+	// Share the result with identical requests already in flight
+	result, shared, err := s.coalesceContext(ctx, cacheKey, requestID, func(ctx context.Context) (interface{}, error) {
+		tables, err := conn.ListTablesContext(ctx, req.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		// Cache the result
+		s.setCache(cacheKey, tables)
+		return tables, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		log.Printf("Coalesced duplicate listTables: %s.%s", req.ConnectionID, req.Database)
+	}
+
+	return result.([]protocol.Table), nil
+}
+
+// handleListAllTables returns the tables of every user database. With
+// includeStatus, the result also reports which databases were retried or
+// left out; otherwise it is the bare map, and a client only learns that
+// from the progress notifications.
+func (s *Server) handleListAllTables(ctx context.Context, requestID string, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ConnectionID  string `json:"connectionId"`
+		IncludeStatus bool   `json:"includeStatus,omitempty"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Check cache first with longer TTL (5 minutes)
+	cacheKey := fmt.Sprintf("listAllTables:%s", req.ConnectionID)
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if allTables, ok := cached.(*protocol.AllTables); ok {
+			log.Printf("Cache hit for listAllTables: %s", req.ConnectionID)
+			return allTablesResult(allTables, req.IncludeStatus), nil
+		}
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Register for cancellation since large servers can take a while
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("listAllTables %s", req.ConnectionID))
+	defer done()
+
+	// Share the result with identical requests already in flight; the load
+	// carries on while any of them is still waiting
+	result, shared, err := s.coalesceContext(ctx, cacheKey, requestID, func(ctx context.Context) (interface{}, error) {
+		return s.loadAllTables(ctx, conn, cacheKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		log.Printf("Coalesced duplicate listAllTables: %s", req.ConnectionID)
+	}
+
+	return allTablesResult(result.(*protocol.AllTables), req.IncludeStatus), nil
+}
+
+// allTablesResult is the listAllTables response for a load: the whole
+// result with includeStatus, the bare table map without
+func allTablesResult(allTables *protocol.AllTables, includeStatus bool) interface{} {
+	if includeStatus {
+		return allTables
+	}
+	return allTables.Tables
+}
+
+// listTablesAttempts and listTablesRetryDelay bound the retries of one
+// database's tables in listAllTables after transient errors
+const (
+	listTablesAttempts   = 3
+	listTablesRetryDelay = 200 * time.Millisecond
+)
+
+// loadAllTables loads tables from every user database and caches the result,
+// sending a listAllTablesProgress notification after each database to every
+// request waiting for the load. Databases that needed retries or still
+// failed are recorded in the result.
+func (s *Server) loadAllTables(ctx context.Context, conn *connection.Connection, cacheKey string) (*protocol.AllTables, error) {
+	// Get all databases
+	databases, err := conn.ListDatabasesContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	// Filter out system databases
+	userDatabases := make([]string, 0, len(databases))
+	for _, db := range databases {
+		if !systemDatabases[db.Name] {
+			userDatabases = append(userDatabases, db.Name)
+		}
+	}
+
+	// Load tables from all user databases
+	allTables := &protocol.AllTables{Tables: make(map[string][]protocol.Table)}
+	transientFailure := false
+	for i, name := range userDatabases {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("listAllTables cancelled after %d of %d databases", i, len(userDatabases))
+		}
+
+		progress := protocol.TableLoadProgress{
+			Database: name,
+			Loaded:   i + 1,
+			Total:    len(userDatabases),
+		}
+
+		var tables []protocol.Table
+		progress.Retries, err = retryTransient(ctx, listTablesAttempts, listTablesRetryDelay, func() error {
+			var err error
+			tables, err = conn.ListTablesContext(ctx, name)
+			return err
+		})
+		if progress.Retries > 0 {
+			log.Printf("Retried loading tables from %s %d times", name, progress.Retries)
+			if allTables.Retried == nil {
+				allTables.Retried = make(map[string]int)
+			}
+			allTables.Retried[name] = progress.Retries
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("listAllTables cancelled after %d of %d databases", i, len(userDatabases))
+			}
+			// Log error but continue with other databases
+			log.Printf("Failed to load tables from %s: %v", name, err)
+			progress.Error = err.Error()
+			transientFailure = transientFailure || connection.IsTransientError(err)
+			if allTables.Failed == nil {
+				allTables.Failed = make(map[string]string)
+			}
+			allTables.Failed[name] = progress.Error
+		} else {
+			allTables.Tables[name] = tables
+		}
+
+		for _, requestID := range s.inflightRequests(cacheKey) {
+			progress.RequestID = requestID
+			s.notify("listAllTablesProgress", progress)
+		}
+	}
+
+	// Cache with longer TTL for all tables, unless a database is missing
+	// only because of a lasting blip; then try again sooner
+	if transientFailure {
+		s.setCache(cacheKey, allTables)
+	} else {
+		s.setCacheWithTTL(cacheKey, allTables, 5*time.Minute)
+	}
+	return allTables, nil
+}
+
+// retryTransient calls fn until it succeeds, fails with an error that is
+// not transient, or has run attempts times, waiting delay before the first
+// retry and doubling it after each. It returns the number of retries.
+func retryTransient(ctx context.Context, attempts int, delay time.Duration, fn func() error) (int, error) {
+	err := fn()
+	retries := 0
+	for ; retries < attempts-1 && connection.IsTransientError(err); retries++ {
+		select {
+		case <-ctx.Done():
+			return retries, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		err = fn()
+	}
+	return retries, err
+}
+
+func (s *Server) handleListColumns(ctx context.Context, requestID string, params json.RawMessage) ([]protocol.Column, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Share the result with identical requests already in flight
+	key := fmt.Sprintf("listColumns:%s:%s:%s", req.ConnectionID,
+		conn.NormalizeIdentifier(req.Database), conn.NormalizeIdentifier(req.Table))
+	result, shared, err := s.coalesceContext(ctx, key, requestID, func(ctx context.Context) (interface{}, error) {
+		return conn.ListColumnsContext(ctx, req.Database, req.Table)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		log.Printf("Coalesced duplicate listColumns: %s.%s.%s", req.ConnectionID, req.Database, req.Table)
+	}
+
+	return result.([]protocol.Column), nil
+}
+
+func (s *Server) handleExecuteQuery(ctx context.Context, requestID string, params json.RawMessage) (*protocol.QueryResult, error) {
+	var req protocol.QueryRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	switch req.RowFormat {
+	case "", protocol.RowFormatArray, protocol.RowFormatObject:
+	case protocol.RowFormatArrow:
+		if !s.resultFormatGranted(protocol.RowFormatArrow) {
+			return nil, fmt.Errorf("rowFormat arrow must first be negotiated with initialize")
+		}
+	default:
+		return nil, fmt.Errorf("invalid rowFormat: %s", req.RowFormat)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Register this query for potential cancellation
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	log.Printf("Executing query (request %s): %s", requestID, req.SQL)
+	startTime := time.Now()
+	result, err := conn.ExecuteQueryWithOptions(ctx, req.SQL, connection.QueryOptions{
+		Limit:        req.Limit,
+		Offset:       req.Offset,
+		NamedArgs:    req.NamedArgs,
+		ForcePrimary: req.ForcePrimary,
+		RowKeys:      req.RowKeys,
+		RequestID:    requestID,
+		Label:        req.Label,
+		Progress: func(p protocol.QueryProgress) {
+			p.RequestID = requestID
+			s.notify("queryProgress", p)
+		},
+	})
+
+	// Record in query history
+	entry := protocol.HistoryEntry{
+		ConnectionID:  req.ConnectionID,
+		SQL:           req.SQL,
+		ExecutedAt:    startTime,
+		ExecutionTime: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.RowCount = result.TotalRows
+	}
+	s.history.record(entry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// DDL makes cached tables, columns and autocomplete data stale
+	if connection.IsSchemaChange(req.SQL) {
+		s.invalidateConnectionCache(req.ConnectionID)
+	}
+
+	if req.Checksum != "" {
+		if result.Checksum, err = resultChecksum(result, req.Checksum); err != nil {
+			return nil, err
+		}
+		if req.ChecksumOnly {
+			result.Rows = [][]interface{}{}
+		}
+	}
+	switch req.RowFormat {
+	case protocol.RowFormatObject:
+		result.RowFormat = protocol.RowFormatObject
+	case protocol.RowFormatArrow:
+		if result.Arrow, err = protocol.EncodeArrow(result.Columns, result.ColumnTypes, result.Rows); err != nil {
+			return nil, err
+		}
+		result.Rows = [][]interface{}{}
+		result.RowFormat = protocol.RowFormatArrow
+	}
+
+	return result, nil
+}
+
+func (s *Server) handleCancelQuery(params json.RawMessage) error {
+	var req struct {
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	s.runningQueriesMu.Lock()
+	queryCtx, exists := s.runningQueries[req.RequestID]
+	s.runningQueriesMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("query not found or already completed: %s", req.RequestID)
+	}
+
+	log.Printf("Cancelling query (request %s): %s", req.RequestID, queryCtx.sql)
+	queryCtx.cancel()
+	return nil
+}
+
+func (s *Server) handleGetPrivileges(ctx context.Context, params json.RawMessage) (*protocol.PrivilegeInfo, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetPrivileges(ctx)
+}
+
+func (s *Server) handleSampleTable(ctx context.Context, requestID string, params json.RawMessage) (*protocol.SampleResult, error) {
+	var req protocol.SampleRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("sample %s.%s", req.Database, req.Table))
+	defer done()
+
+	return conn.SampleTable(ctx, req.Database, req.Table, req.Limit, req.Random)
+}
+
+func (s *Server) handleGetQueryHistory(params json.RawMessage) (*protocol.HistoryPage, error) {
+	var req protocol.HistoryRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return s.history.page(req.ConnectionID, req.Before, req.Limit)
+}
+
+func (s *Server) handleListUsers(ctx context.Context, params json.RawMessage) (*protocol.AccountList, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.ListUsers(ctx)
+}
+
+func (s *Server) handleListRoles(ctx context.Context, params json.RawMessage) (*protocol.AccountList, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.ListRoles(ctx)
+}
+
+func (s *Server) handleDiffQueryResults(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ResultDiff, error) {
+	var req protocol.DiffRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	leftConn := s.getConnection(req.Left.ConnectionID)
+	if leftConn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.Left.ConnectionID)
+	}
+	rightConn := s.getConnection(req.Right.ConnectionID)
+	if rightConn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.Right.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, req.Left.SQL)
+	defer done()
+
+	// Run both queries concurrently
+	var left, right *protocol.QueryResult
+	var leftErr, rightErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		left, leftErr = leftConn.ExecuteQueryWithContext(ctx, req.Left.SQL, 0, 0)
+	}()
+	go func() {
+		defer wg.Done()
+		right, rightErr = rightConn.ExecuteQueryWithContext(ctx, req.Right.SQL, 0, 0)
+	}()
+	wg.Wait()
+
+	if leftErr != nil {
+		return nil, fmt.Errorf("left query failed: %w", leftErr)
+	}
+	if rightErr != nil {
+		return nil, fmt.Errorf("right query failed: %w", rightErr)
+	}
+
+	return diffResults(left, right, req.KeyColumns)
+}
+
+func (s *Server) handleProfileQuery(ctx context.Context, requestID string, params json.RawMessage) (*protocol.QueryProfile, error) {
+	var req protocol.QuerySpec
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	log.Printf("Profiling query (request %s): %s", requestID, req.SQL)
+	return conn.ProfileQuery(ctx, req.SQL)
+}
+
+func (s *Server) handlePivotQuery(ctx context.Context, requestID string, params json.RawMessage) (*protocol.PivotResult, error) {
+	var req protocol.PivotRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	result, err := conn.ExecuteQueryWithContext(ctx, req.SQL, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return pivotResult(result, req.RowKey, req.ColumnKey, req.ValueColumn, req.Aggregate)
+}
+
+func (s *Server) handleCreateDatabase(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Name         string `json:"name"`
+		Charset      string `json:"charset"`
+		Collation    string `json:"collation"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.CreateDatabase(ctx, req.Name, req.Charset, req.Collation); err != nil {
+		return err
+	}
+
+	// The database list is now stale
+	s.invalidateCacheKey(fmt.Sprintf("listDatabases:%s", req.ConnectionID))
+	s.invalidateCacheKey(fmt.Sprintf("listAllTables:%s", req.ConnectionID))
+	s.invalidateCacheKey(fmt.Sprintf("getDatabaseDDL:%s:%s", req.ConnectionID, conn.NormalizeIdentifier(req.Name)))
+	s.invalidateCache(fmt.Sprintf("getAutocompleteSchema:%s:", req.ConnectionID))
+	log.Printf("Created database %s on %s", req.Name, req.ConnectionID)
+
+	return nil
+}
+
+func (s *Server) handleListLocks(ctx context.Context, params json.RawMessage) (*protocol.LockInfo, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.ListLocks(ctx)
+}
+
+func (s *Server) handleGetDatabaseDDL(ctx context.Context, params json.RawMessage) (*protocol.DatabaseDDL, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Check cache first
+	cacheKey := fmt.Sprintf("getDatabaseDDL:%s:%s", req.ConnectionID, conn.NormalizeIdentifier(req.Database))
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if ddl, ok := cached.(*protocol.DatabaseDDL); ok {
+			log.Printf("Cache hit for getDatabaseDDL: %s.%s", req.ConnectionID, req.Database)
+			return ddl, nil
+		}
+	}
+
+	ddl, err := conn.GetDatabaseDDL(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &protocol.DatabaseDDL{Database: req.Database, DDL: ddl}
+	s.setCache(cacheKey, result)
+	return result, nil
+}
+
+func (s *Server) handleDropDatabase(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Name         string `json:"name"`
+		Confirm      string `json:"confirm"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Require the exact name to be echoed back before destroying anything
+	if req.Name == "" || req.Confirm != req.Name {
+		return fmt.Errorf("confirmation does not match: set confirm to the exact database name %q to drop it", req.Name)
+	}
+	if systemDatabases[strings.ToLower(req.Name)] {
+		return fmt.Errorf("refusing to drop system database: %s", req.Name)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.DropDatabase(ctx, req.Name); err != nil {
+		return err
+	}
+
+	// Tables, columns and DDL for the dropped database may be cached anywhere
+	s.invalidateConnectionCache(req.ConnectionID)
+	log.Printf("Dropped database %s on %s", req.Name, req.ConnectionID)
+
+	return nil
+}
+
+func (s *Server) handleGetPoolStats(params json.RawMessage) (*protocol.PoolStats, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	stats := conn.PoolStats()
+	return &stats, nil
+}
+
+func (s *Server) handleExplainProcess(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ProcessExplain, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		ThreadID     int64  `json:"threadId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("EXPLAIN thread %d", req.ThreadID))
+	defer done()
+
+	return conn.ExplainProcess(ctx, req.ThreadID)
+}
+
+func (s *Server) handleGetAutoIncrement(ctx context.Context, params json.RawMessage) (*protocol.AutoIncrementStatus, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetAutoIncrement(ctx, req.Database, req.Table)
+}
+
+func (s *Server) handleExplainAnalyze(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ExplainAnalyzeResult, error) {
+	var req protocol.QuerySpec
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	log.Printf("Running EXPLAIN ANALYZE (request %s): %s", requestID, req.SQL)
+	return conn.ExplainAnalyze(ctx, req.SQL)
+}
+
+func (s *Server) handleListColumnsBulk(ctx context.Context, requestID string, params json.RawMessage) (map[string]map[string][]protocol.Column, error) {
+	var req struct {
+		ConnectionID string              `json:"connectionId"`
+		Tables       []protocol.TableRef `json:"tables"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, "listColumnsBulk")
+	defer done()
+
+	return conn.ListColumnsBulk(ctx, req.Tables)
+}
+
+func (s *Server) handleGetAutocompleteSchema(ctx context.Context, requestID string, params json.RawMessage) (*protocol.AutocompleteSchema, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Check cache first
+	cacheKey := fmt.Sprintf("getAutocompleteSchema:%s:%s", req.ConnectionID, conn.NormalizeIdentifier(req.Database))
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if schema, ok := cached.(*protocol.AutocompleteSchema); ok {
+			log.Printf("Cache hit for getAutocompleteSchema: %s", req.ConnectionID)
+			return schema, nil
+		}
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, "getAutocompleteSchema")
+	defer done()
+
+	// The shared build runs detached, so cancelling one request does not
+	// fail the others waiting for it
+	result, shared, err := s.coalesceContext(ctx, cacheKey, requestID, func(ctx context.Context) (interface{}, error) {
+		schema, err := conn.GetAutocompleteSchema(ctx, req.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		// The schema is expensive to build and DDL invalidates it, so keep it longer
+		s.setCacheWithTTL(cacheKey, schema, 5*time.Minute)
+		return schema, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		log.Printf("Coalesced duplicate getAutocompleteSchema: %s", req.ConnectionID)
+	}
+
+	return result.(*protocol.AutocompleteSchema), nil
+}
+
+func (s *Server) handleRunScript(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ScriptResult, error) {
+	var req protocol.ScriptRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Register the script so cancelQuery can stop it
+	ctx, done := s.trackQuery(ctx, requestID, req.Script)
+	defer done()
+
+	log.Printf("Running script (request %s)", requestID)
+	startTime := time.Now()
+	result, err := conn.RunScript(ctx, req.Script, connection.ScriptOptions{
+		Transaction:             req.Transaction,
+		DisableForeignKeyChecks: req.DisableForeignKeyChecks,
+	}, func(p protocol.ScriptProgress) {
+		p.RequestID = requestID
+		s.notify("scriptProgress", p)
+	})
+
+	entry := protocol.HistoryEntry{
+		ConnectionID:  req.ConnectionID,
+		SQL:           req.Script,
+		ExecutedAt:    startTime,
+		ExecutionTime: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.RowCount = result.RowsAffected
+	}
+	s.history.record(entry)
+
+	// Scripts commonly contain DDL and even a failed one may have applied some
+	s.invalidateConnectionCache(req.ConnectionID)
+
+	return result, err
+}
+
+func (s *Server) handleGetServerTime(ctx context.Context, params json.RawMessage) (*protocol.ServerTime, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetServerTime(ctx)
+}
+
+func (s *Server) handleCopyTable(ctx context.Context, requestID string, params json.RawMessage) error {
+	var req struct {
+		ConnectionID            string            `json:"connectionId"`
+		Source                  protocol.TableRef `json:"source"`
+		Destination             protocol.TableRef `json:"destination"`
+		WithData                bool              `json:"withData"`
+		DisableForeignKeyChecks bool              `json:"disableForeignKeyChecks"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Copying data can take a while, so let cancelQuery stop it
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("COPY TABLE %s.%s", req.Source.Database, req.Source.Table))
+	defer done()
+
+	err := conn.CopyTable(ctx, req.Source, req.Destination, connection.CopyTableOptions{
+		WithData:                req.WithData,
+		DisableForeignKeyChecks: req.DisableForeignKeyChecks,
+	})
+
+	// The destination table may exist even if the row copy failed
+	s.invalidateConnectionCache(req.ConnectionID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Copied table %s.%s to %s.%s on %s", req.Source.Database, req.Source.Table,
+		req.Destination.Database, req.Destination.Table, req.ConnectionID)
+	return nil
+}
+
+func (s *Server) handleGetServerStatus(params json.RawMessage) (*protocol.ServerStatus, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	status := conn.ServerStatus()
+	return &status, nil
+}
+
+func (s *Server) handleSnapshotTableSizes(ctx context.Context, params json.RawMessage) (*protocol.TableSizeSnapshot, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Database == "" {
+		return nil, fmt.Errorf("database is required")
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Read fresh sizes rather than the cached table list
+	tables, err := conn.ListTablesContext(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := protocol.TableSizeSnapshot{
+		ConnectionID: req.ConnectionID,
+		Database:     req.Database,
+		TakenAt:      time.Now().UTC(),
+		Tables:       make([]protocol.TableSize, 0, len(tables)),
+	}
+	for _, table := range tables {
+		snapshot.Tables = append(snapshot.Tables, protocol.TableSize{
+			Name:        table.Name,
+			RowCount:    table.RowCount,
+			DataLength:  table.DataLength,
+			IndexLength: table.IndexLength,
+		})
+	}
+
+	snapshot, err = s.sizes.add(snapshot)
+	if err != nil {
+		// The snapshot is still kept in memory
+		log.Printf("Failed to persist table size snapshot: %v", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *Server) handleListTableSizeSnapshots(params json.RawMessage) ([]protocol.TableSizeSnapshot, error) {
+	var req struct {
+		Database string `json:"database,omitempty"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return s.sizes.list(req.Database), nil
+}
+
+func (s *Server) handleCompareTableSizeSnapshots(params json.RawMessage) (*protocol.TableSizeComparison, error) {
+	var req struct {
+		FromID int64 `json:"fromId"`
+		ToID   int64 `json:"toId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	from, ok := s.sizes.get(req.FromID)
+	if !ok {
+		return nil, fmt.Errorf("snapshot not found: %d", req.FromID)
+	}
+	to, ok := s.sizes.get(req.ToID)
+	if !ok {
+		return nil, fmt.Errorf("snapshot not found: %d", req.ToID)
+	}
+	if from.Database != to.Database {
+		return nil, fmt.Errorf("snapshots are of different databases: %s and %s", from.Database, to.Database)
+	}
+
+	return compareSizeSnapshots(from, to), nil
+}
+
+func (s *Server) handleGetTableDocumentation(ctx context.Context, params json.RawMessage) (*protocol.TableDocumentation, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetTableDocumentation(ctx, req.Database, req.Table)
+}
+
+// handleSetTableComment sets or clears a table's comment
+func (s *Server) handleSetTableComment(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+		Comment      string `json:"comment"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.SetTableComment(ctx, req.Database, req.Table, req.Comment); err != nil {
+		return err
+	}
+
+	s.invalidateConnectionCache(req.ConnectionID)
+	log.Printf("Set comment on table %s.%s", req.Database, req.Table)
+	return nil
+}
+
+// handleSetColumnComment sets or clears a column's comment, keeping the
+// rest of the column definition unchanged
+func (s *Server) handleSetColumnComment(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+		Column       string `json:"column"`
+		Comment      string `json:"comment"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.SetColumnComment(ctx, req.Database, req.Table, req.Column, req.Comment); err != nil {
+		return err
+	}
+
+	s.invalidateConnectionCache(req.ConnectionID)
+	log.Printf("Set comment on column %s.%s.%s", req.Database, req.Table, req.Column)
+	return nil
+}
+
+func (s *Server) handleRenameColumn(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+		Column       string `json:"column"`
+		NewName      string `json:"newName"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.RenameColumn(ctx, req.Database, req.Table, req.Column, req.NewName); err != nil {
+		return err
+	}
+
+	s.invalidateConnectionCache(req.ConnectionID)
+	log.Printf("Renamed column %s.%s.%s to %s", req.Database, req.Table, req.Column, req.NewName)
+	return nil
+}
+
+func (s *Server) handleGetLongTransactions(ctx context.Context, requestID string, params json.RawMessage) (*protocol.LongTransactionList, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		MinSeconds   int    `json:"minSeconds"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, "getLongTransactions")
+	defer done()
+
+	return conn.GetLongTransactions(ctx, req.MinSeconds)
+}
+
+func (s *Server) handleGetSlowQueries(ctx context.Context, params json.RawMessage) (*protocol.SlowQueryLog, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Limit        int    `json:"limit"`
+		Hours        int    `json:"hours"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetSlowQueries(ctx, req.Limit, req.Hours)
+}
+
+// handlePreviewDelete reports the foreign key effects of deleting rows
+// without deleting them
+func (s *Server) handlePreviewDelete(ctx context.Context, requestID string, params json.RawMessage) (*protocol.DeletePreview, error) {
+	var req struct {
+		ConnectionID string                 `json:"connectionId"`
+		Database     string                 `json:"database"`
+		Table        string                 `json:"table"`
+		Where        map[string]interface{} `json:"where"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	// Counting children of large tables can be slow
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("PREVIEW DELETE %s.%s", req.Database, req.Table))
+	defer done()
+
+	return conn.PreviewDelete(ctx, req.Database, req.Table, req.Where)
+}
+
+func (s *Server) handleGetReplicationStatus(ctx context.Context, params json.RawMessage) (*protocol.ReplicationStatus, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetReplicationStatus(ctx)
+}
+
+// handleSaveQuery adds a query to the saved query library, or updates the
+// saved query with the given ID
+func (s *Server) handleSaveQuery(params json.RawMessage) (*protocol.SavedQuery, error) {
+	var req struct {
+		ID           int64    `json:"id,omitempty"`
+		Name         string   `json:"name"`
+		SQL          string   `json:"sql"`
+		ConnectionID string   `json:"connectionId,omitempty"`
+		Tags         []string `json:"tags,omitempty"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	query, err := s.saved.save(protocol.SavedQuery{
+		ID:           req.ID,
+		Name:         req.Name,
+		SQL:          req.SQL,
+		ConnectionID: req.ConnectionID,
+		Tags:         req.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &query, nil
+}
+
+func (s *Server) handleListSavedQueries(params json.RawMessage) ([]protocol.SavedQuery, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId,omitempty"`
+		Tag          string `json:"tag,omitempty"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return s.saved.list(req.ConnectionID, req.Tag), nil
+}
+
+func (s *Server) handleGetSavedQuery(params json.RawMessage) (*protocol.SavedQuery, error) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	query, ok := s.saved.get(req.ID)
+	if !ok {
+		return nil, fmt.Errorf("saved query not found: %d", req.ID)
+	}
+	return &query, nil
+}
+
+func (s *Server) handleDeleteSavedQuery(params json.RawMessage) error {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return s.saved.remove(req.ID)
+}
+
+func (s *Server) handleGetHierarchy(ctx context.Context, requestID string, params json.RawMessage) (*protocol.Hierarchy, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+		IDColumn     string `json:"idColumn"`
+		ParentColumn string `json:"parentColumn"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("HIERARCHY %s.%s", req.Database, req.Table))
+	defer done()
+
+	return conn.GetHierarchy(ctx, req.Database, req.Table, req.IDColumn, req.ParentColumn)
+}
+
+func (s *Server) handleListPartitions(ctx context.Context, params json.RawMessage) ([]protocol.Partition, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.ListPartitions(ctx, req.Database, req.Table)
+}
+
+func (s *Server) handleDropPartition(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+		Partition    string `json:"partition"`
+		Confirm      string `json:"confirm"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Dropping a partition deletes its rows, so require the name echoed back
+	if req.Partition == "" || req.Confirm != req.Partition {
+		return fmt.Errorf("confirmation does not match: set confirm to the exact partition name %q to drop it", req.Partition)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.DropPartition(ctx, req.Database, req.Table, req.Partition); err != nil {
+		return err
+	}
+
+	s.invalidateConnectionCache(req.ConnectionID)
+	log.Printf("Dropped partition %s of %s.%s on %s", req.Partition, req.Database, req.Table, req.ConnectionID)
+	return nil
+}
+
+func (s *Server) handleTruncatePartition(ctx context.Context, params json.RawMessage) error {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
+		Table        string `json:"table"`
+		Partition    string `json:"partition"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	if err := conn.TruncatePartition(ctx, req.Database, req.Table, req.Partition); err != nil {
+		return err
+	}
+
+	s.invalidateConnectionCache(req.ConnectionID)
+	log.Printf("Truncated partition %s of %s.%s on %s", req.Partition, req.Database, req.Table, req.ConnectionID)
+	return nil
+}
+
+func (s *Server) handleGetResultColumnMeta(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ResultColumnMeta, error) {
+	var req protocol.QuerySpec
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	return conn.GetResultColumnMeta(ctx, req.SQL)
+}
+
+// handleGetTableDependencyOrder returns the foreign key safe insert and
+// delete order of a database's tables
+func (s *Server) handleGetTableDependencyOrder(ctx context.Context, params json.RawMessage) (*protocol.TableDependencyOrder, error) {
+	var req struct {
+		ConnectionID string   `json:"connectionId"`
+		Database     string   `json:"database"`
+		Tables       []string `json:"tables,omitempty"` // All tables when empty
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Database == "" {
+		return nil, fmt.Errorf("database is required")
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetTableDependencyOrder(ctx, req.Database, req.Tables)
+}
+
+// handleGetExactRowCounts counts the rows of many tables with COUNT(*).
+// This scans every table, so it is tracked for cancelQuery.
+func (s *Server) handleGetExactRowCounts(ctx context.Context, requestID string, params json.RawMessage) (*protocol.RowCounts, error) {
+	var req struct {
+		ConnectionID string   `json:"connectionId"`
+		Database     string   `json:"database"`
+		Tables       []string `json:"tables,omitempty"` // All base tables when empty
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Database == "" {
+		return nil, fmt.Errorf("database is required")
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("COUNT rows in %s", req.Database))
+	defer done()
+
+	return conn.GetExactRowCounts(ctx, req.Database, req.Tables)
+}
+
+func (s *Server) handleGetSQLMode(ctx context.Context, params json.RawMessage) (*protocol.SQLMode, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	return conn.GetSQLMode(ctx)
+}
+
+// handleCheckPrivilege reports whether the current user has one privilege
+// on a scope. The user's grants are cached briefly so a UI can check many
+// scopes cheaply.
+func (s *Server) handleCheckPrivilege(ctx context.Context, params json.RawMessage) (*protocol.PrivilegeCheck, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+		Privilege    string `json:"privilege"`
+		Database     string `json:"database,omitempty"`
+		Table        string `json:"table,omitempty"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if strings.TrimSpace(req.Privilege) == "" {
+		return nil, fmt.Errorf("privilege is required")
+	}
+	if req.Table != "" && req.Database == "" {
+		return nil, fmt.Errorf("database is required with table")
+	}
+
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
+
+	cacheKey := fmt.Sprintf("privileges:%s", req.ConnectionID)
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if info, ok := cached.(*protocol.PrivilegeInfo); ok {
+			return conn.CheckPrivilege(info, req.Privilege, req.Database, req.Table), nil
+		}
+	}
+
+	info, err := conn.GetPrivileges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.setCacheWithTTL(cacheKey, info, 10*time.Second)
+	return conn.CheckPrivilege(info, req.Privilege, req.Database, req.Table), nil
+}
 
-	case "listTables":
-		result, err := s.handleListTables(req.Params)
-		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
+func (s *Server) handleTopN(ctx context.Context, requestID string, params json.RawMessage) (*protocol.TopNResult, error) {
+	var req protocol.TopNRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
 
-	case "listAllTables":
-		result, err := s.handleListAllTables(req.Params)
-		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
+	}
 
-	case "listColumns":
-		result, err := s.handleListColumns(req.Params)
-		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("top-N %s.%s by %s", req.Database, req.Table, req.OrderBy))
+	defer done()
 
-	case "executeQuery":
-		result, err := s.handleExecuteQuery(req.ID, req.Params)
-		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
+	return conn.TopN(ctx, req.Database, req.Table, req.OrderBy, req.Direction, req.Nulls, req.N)
+}
 
-	case "cancelQuery":
-		err := s.handleCancelQuery(req.Params)
-		if err != nil {
-			response.Error = &protocol.Error{
-				Code:    protocol.InternalError,
-				Message: err.Error(),
-			}
-		} else {
-			response.Result = map[string]bool{"success": true}
-		}
+func (s *Server) handleSummarizeQuery(ctx context.Context, requestID string, params json.RawMessage) (*protocol.QuerySummary, error) {
+	var req protocol.SummaryRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
 
-	default:
-		response.Error = &protocol.Error{
-			Code:    protocol.MethodNotFound,
-			Message: fmt.Sprintf("Method not found: %s", req.Method),
-		}
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	return response
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	log.Printf("Summarizing query (request %s): %s", requestID, req.SQL)
+	return conn.SummarizeQuery(ctx, req.SQL, req.MaxRows)
 }
 
-func (s *Server) handleTestConnection(params json.RawMessage) (*protocol.ConnectionTestResult, error) {
-	var config protocol.ConnectionConfig
-	if err := json.Unmarshal(params, &config); err != nil {
+func (s *Server) handleGetCurrentDatabase(ctx context.Context, requestID string, params json.RawMessage) (*protocol.CurrentDatabase, error) {
+	var req struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	conn, err := connection.NewConnection(&config)
-	if err != nil {
-		return &protocol.ConnectionTestResult{
-			Success: false,
-			Message: err.Error(),
-		}, nil
+	conn := s.getConnection(req.ConnectionID)
+	if conn == nil {
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
-	defer conn.Close()
 
-	version, err := conn.GetVersion()
-	if err != nil {
-		return &protocol.ConnectionTestResult{
-			Success: false,
-			Message: fmt.Sprintf("Connected but failed to get version: %v", err),
-		}, nil
+	ctx, done := s.trackQuery(ctx, requestID, "getCurrentDatabase")
+	defer done()
+
+	return conn.GetCurrentDatabase(ctx)
+}
+
+// handleSaveConnection encrypts a connection config into the connection
+// store. The response has the password redacted.
+func (s *Server) handleSaveConnection(params json.RawMessage) (*protocol.SavedConnection, error) {
+	var req struct {
+		protocol.ConnectionConfig
+		// Without it, an empty password keeps the saved one
+		ClearPassword bool `json:"clearPassword"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	return &protocol.ConnectionTestResult{
-		Success: true,
-		Message: "Connection successful",
-		Version: version,
-	}, nil
+	saved, err := s.connectionStore.save(req.ConnectionConfig, req.ClearPassword)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
 }
 
-func (s *Server) handleConnect(params json.RawMessage) error {
-	var config protocol.ConnectionConfig
-	if err := json.Unmarshal(params, &config); err != nil {
+// handleConnectSavedConnection opens a connection from the connection store,
+// so the password never crosses the RPC channel
+func (s *Server) handleConnectSavedConnection(params json.RawMessage) error {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
 		return fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	conn, err := connection.NewConnection(&config)
+	config, err := s.connectionStore.get(req.ID)
 	if err != nil {
 		return err
 	}
+	return s.connect(&config)
+}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Close existing connection if any
-	if existingConn, exists := s.connections[config.ID]; exists {
-		existingConn.Close()
+// handleSetSavedConnectionPinned pins or unpins a saved connection
+func (s *Server) handleSetSavedConnectionPinned(params json.RawMessage) (*protocol.SavedConnection, error) {
+	var req struct {
+		ID     string `json:"id"`
+		Pinned bool   `json:"pinned"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	s.connections[config.ID] = conn
-	log.Printf("Connection established: %s", config.ID)
-
-	return nil
+	saved, err := s.connectionStore.setPinned(req.ID, req.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
 }
 
-func (s *Server) handleDisconnect(params json.RawMessage) error {
+// handleReorderSavedConnections sets the position of saved connections in
+// loadConnections to the order of the given IDs
+func (s *Server) handleReorderSavedConnections(params json.RawMessage) error {
 	var req struct {
-		ConnectionID string `json:"connectionId"`
+		IDs []string `json:"ids"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.connectionStore.reorder(req.IDs)
+}
 
-	conn, exists := s.connections[req.ConnectionID]
-	if !exists {
-		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+func (s *Server) handleDeleteSavedConnection(params json.RawMessage) error {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	conn.Close()
-	delete(s.connections, req.ConnectionID)
-	log.Printf("Connection closed: %s", req.ConnectionID)
-
-	return nil
+	return s.connectionStore.remove(req.ID)
 }
 
-func (s *Server) handleHealthCheck(params json.RawMessage) error {
+func (s *Server) handleExportSchema(ctx context.Context, requestID string, params json.RawMessage) (*protocol.SchemaExport, error) {
 	var req struct {
 		ConnectionID string `json:"connectionId"`
+		Database     string `json:"database"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
-		return fmt.Errorf("invalid parameters: %w", err)
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Database == "" {
+		return nil, fmt.Errorf("database is required")
 	}
 
 	conn := s.getConnection(req.ConnectionID)
 	if conn == nil {
-		return fmt.Errorf("connection not found: %s", req.ConnectionID)
+		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	return conn.HealthCheck()
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("export schema %s", req.Database))
+	defer done()
+
+	return conn.ExportSchema(ctx, req.Database)
 }
 
-func (s *Server) handleListDatabases(params json.RawMessage) ([]protocol.Database, error) {
+func (s *Server) handleGetRowsAround(ctx context.Context, requestID string, params json.RawMessage) (*protocol.RowsAround, error) {
 	var req struct {
-		ConnectionID string `json:"connectionId"`
+		ConnectionID string        `json:"connectionId"`
+		Database     string        `json:"database"`
+		Table        string        `json:"table"`
+		Key          []interface{} `json:"key"`    // One value per primary key column
+		Window       int           `json:"window"` // Rows on each side; default 10, max 500
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
-
-	// Check cache first
-	cacheKey := fmt.Sprintf("listDatabases:%s", req.ConnectionID)
-	if cached, ok := s.getFromCache(cacheKey); ok {
-		if databases, ok := cached.([]protocol.Database); ok {
-			log.Printf("Cache hit for listDatabases: %s", req.ConnectionID)
-			return databases, nil
-		}
+	if req.Database == "" || req.Table == "" {
+		return nil, fmt.Errorf("database and table are required")
 	}
 
 	conn := s.getConnection(req.ConnectionID)
@@ -290,17 +2686,13 @@ func (s *Server) handleListDatabases(params json.RawMessage) ([]protocol.Databas
 		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	databases, err := conn.ListDatabases()
-	if err != nil {
-		return nil, err
-	}
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("rows around a key in %s.%s", req.Database, req.Table))
+	defer done()
 
-	// Cache the result
-	s.setCache(cacheKey, databases)
-	return databases, nil
+	return conn.GetRowsAround(ctx, req.Database, req.Table, req.Key, req.Window)
 }
 
-func (s *Server) handleListTables(params json.RawMessage) ([]protocol.Table, error) {
+func (s *Server) handleGetInformationSchema(ctx context.Context, requestID string, params json.RawMessage) (*protocol.InformationSchema, error) {
 	var req struct {
 		ConnectionID string `json:"connectionId"`
 		Database     string `json:"database"`
@@ -308,14 +2700,8 @@ func (s *Server) handleListTables(params json.RawMessage) ([]protocol.Table, err
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
-
-	// Check cache first
-	cacheKey := fmt.Sprintf("listTables:%s:%s", req.ConnectionID, req.Database)
-	if cached, ok := s.getFromCache(cacheKey); ok {
-		if tables, ok := cached.([]protocol.Table); ok {
-			log.Printf("Cache hit for listTables: %s.%s", req.ConnectionID, req.Database)
-			return tables, nil
-		}
+	if req.Database == "" {
+		return nil, fmt.Errorf("database is required")
 	}
 
 	conn := s.getConnection(req.ConnectionID)
@@ -323,79 +2709,67 @@ func (s *Server) handleListTables(params json.RawMessage) ([]protocol.Table, err
 		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	tables, err := conn.ListTables(req.Database)
+	// Check cache first; DDL run through executeQuery invalidates it
+	cacheKey := fmt.Sprintf("getInformationSchema:%s:%s", req.ConnectionID, conn.NormalizeIdentifier(req.Database))
+	if cached, ok := s.getFromCache(cacheKey); ok {
+		if schema, ok := cached.(*protocol.InformationSchema); ok {
+			log.Printf("Cache hit for getInformationSchema: %s.%s", req.ConnectionID, req.Database)
+			return schema, nil
+		}
+	}
+
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("information schema of %s", req.Database))
+	defer done()
+
+	// The shared read runs detached, so cancelling one request does not
+	// fail the others waiting for it
+	result, shared, err := s.coalesceContext(ctx, cacheKey, requestID, func(ctx context.Context) (interface{}, error) {
+		schema, err := conn.GetInformationSchema(ctx, req.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		s.setCacheWithTTL(cacheKey, schema, informationSchemaCacheTTL)
+		return schema, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	if shared {
+		log.Printf("Coalesced duplicate getInformationSchema: %s.%s", req.ConnectionID, req.Database)
+	}
 
-	// Cache the result
-	s.setCache(cacheKey, tables)
-	return tables, nil
+	return result.(*protocol.InformationSchema), nil
 }
 
-func (s *Server) handleListAllTables(params json.RawMessage) (map[string][]protocol.Table, error) {
+func (s *Server) handleExplainQuery(ctx context.Context, requestID string, params json.RawMessage) (*protocol.QueryExplain, error) {
 	var req struct {
 		ConnectionID string `json:"connectionId"`
+		SQL          string `json:"sql"`
+		Tree         bool   `json:"tree,omitempty"` // Also return the plan as a tree
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	// Check cache first with longer TTL (5 minutes)
-	cacheKey := fmt.Sprintf("listAllTables:%s", req.ConnectionID)
-	if cached, ok := s.getFromCache(cacheKey); ok {
-		if allTables, ok := cached.(map[string][]protocol.Table); ok {
-			log.Printf("Cache hit for listAllTables: %s", req.ConnectionID)
-			return allTables, nil
-		}
-	}
-
 	conn := s.getConnection(req.ConnectionID)
 	if conn == nil {
 		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	// Get all databases
-	databases, err := conn.ListDatabases()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list databases: %w", err)
-	}
-
-	// Filter out system databases
-	systemDatabases := map[string]bool{
-		"information_schema": true,
-		"mysql":              true,
-		"performance_schema": true,
-		"sys":                true,
-	}
-
-	// Load tables from all user databases
-	allTables := make(map[string][]protocol.Table)
-	for _, db := range databases {
-		if systemDatabases[db.Name] {
-			continue
-		}
-
-		tables, err := conn.ListTables(db.Name)
-		if err != nil {
-			// Log error but continue with other databases
-			log.Printf("Failed to load tables from %s: %v", db.Name, err)
-			continue
-		}
-
-		allTables[db.Name] = tables
-	}
+	ctx, done := s.trackQuery(ctx, requestID, "EXPLAIN "+req.SQL)
+	defer done()
 
-	// Cache with longer TTL for all tables
-	s.setCacheWithTTL(cacheKey, allTables, 5*time.Minute)
-	return allTables, nil
+	return conn.ExplainQuery(ctx, req.SQL, req.Tree)
 }
 
-func (s *Server) handleListColumns(params json.RawMessage) ([]protocol.Column, error) {
+func (s *Server) handleAssertResultSchema(ctx context.Context, requestID string, params json.RawMessage) (*protocol.SchemaAssertion, error) {
 	var req struct {
-		ConnectionID string `json:"connectionId"`
-		Database     string `json:"database"`
-		Table        string `json:"table"`
+		ConnectionID string                    `json:"connectionId"`
+		SQL          string                    `json:"sql"`
+		Columns      []protocol.ExpectedColumn `json:"columns"`
+		AllowExtra   bool                      `json:"allowExtra,omitempty"`  // Result may have unlisted columns
+		IgnoreOrder  bool                      `json:"ignoreOrder,omitempty"` // Columns may be in any order
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
@@ -406,61 +2780,58 @@ func (s *Server) handleListColumns(params json.RawMessage) ([]protocol.Column, e
 		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	return conn.ListColumns(req.Database, req.Table)
+	ctx, done := s.trackQuery(ctx, requestID, req.SQL)
+	defer done()
+
+	return conn.AssertResultSchema(ctx, req.SQL, req.Columns, req.AllowExtra, req.IgnoreOrder)
 }
 
-func (s *Server) handleExecuteQuery(requestID string, params json.RawMessage) (*protocol.QueryResult, error) {
-	var req protocol.QueryRequest
+func (s *Server) handleGetColumnCardinality(ctx context.Context, requestID string, params json.RawMessage) (*protocol.ColumnCardinality, error) {
+	var req struct {
+		ConnectionID string   `json:"connectionId"`
+		Database     string   `json:"database"`
+		Table        string   `json:"table"`
+		Columns      []string `json:"columns"`
+		Actual       bool     `json:"actual,omitempty"` // Count exactly; reads the whole table
+	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
+	if req.Database == "" || req.Table == "" {
+		return nil, fmt.Errorf("database and table are required")
+	}
 
 	conn := s.getConnection(req.ConnectionID)
 	if conn == nil {
 		return nil, fmt.Errorf("connection not found: %s", req.ConnectionID)
 	}
 
-	// Create a context that can be cancelled
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, done := s.trackQuery(ctx, requestID, fmt.Sprintf("cardinality of %s.%s", req.Database, req.Table))
+	defer done()
+
+	return conn.GetColumnCardinality(ctx, req.Database, req.Table, req.Columns, req.Actual)
+}
+
+// trackQuery creates a cancellable context for a long-running request and
+// registers it so cancelQuery can abort it. The returned function must be
+// called when the request completes. The context derives from parent, the
+// request's context, so it also expires at the client's deadline.
+func (s *Server) trackQuery(parent context.Context, requestID, sql string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
 
-	// Register this query for potential cancellation
 	s.runningQueriesMu.Lock()
 	s.runningQueries[requestID] = queryContext{
 		cancel: cancel,
-		sql:    req.SQL,
+		sql:    sql,
 	}
 	s.runningQueriesMu.Unlock()
 
-	// Ensure cleanup after query completes
-	defer func() {
+	return ctx, func() {
 		s.runningQueriesMu.Lock()
 		delete(s.runningQueries, requestID)
 		s.runningQueriesMu.Unlock()
-	}()
-
-	log.Printf("Executing query (request %s): %s", requestID, req.SQL)
-	return conn.ExecuteQueryWithContext(ctx, req.SQL, req.Limit, req.Offset)
-}
-
-func (s *Server) handleCancelQuery(params json.RawMessage) error {
-	var req struct {
-		RequestID string `json:"requestId"`
-	}
-	if err := json.Unmarshal(params, &req); err != nil {
-		return fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	s.runningQueriesMu.Lock()
-	queryCtx, exists := s.runningQueries[req.RequestID]
-	s.runningQueriesMu.Unlock()
-
-	if !exists {
-		return fmt.Errorf("query not found or already completed: %s", req.RequestID)
+		cancel()
 	}
-
-	log.Printf("Cancelling query (request %s): %s", req.RequestID, queryCtx.sql)
-	queryCtx.cancel()
-	return nil
 }
 
 func (s *Server) getConnection(id string) *connection.Connection {
@@ -470,9 +2841,16 @@ func (s *Server) getConnection(id string) *connection.Connection {
 }
 
 func (s *Server) Shutdown() {
+	s.closeConnectionCursors("")
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.stopSweep != nil {
+		close(s.stopSweep)
+		s.stopSweep = nil
+	}
+
 	log.Println("Shutting down server, closing all connections...")
 	for id, conn := range s.connections {
 		conn.Close()
@@ -486,19 +2864,12 @@ func (s *Server) getFromCache(key string) (interface{}, bool) {
 	defer s.cacheMu.RUnlock()
 
 	entry, exists := s.cache[key]
-	if !exists {
-		return nil, false
-	}
-
-	// Check if cache entry is expired (use entry TTL or default 30 seconds)
-	ttl := entry.ttl
-	if ttl == 0 {
-		ttl = 30 * time.Second
-	}
-	if time.Since(entry.timestamp) > ttl {
+	if !exists || entry.expired(time.Now()) {
+		s.cacheMisses.Add(1)
 		return nil, false
 	}
 
+	s.cacheHits.Add(1)
 	return entry.data, true
 }
 
@@ -530,3 +2901,25 @@ func (s *Server) invalidateCache(prefix string) {
 		}
 	}
 }
+
+// invalidateCacheKey removes the cache entry with exactly this key
+func (s *Server) invalidateCacheKey(key string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	delete(s.cache, key)
+}
+
+// invalidateConnectionCache removes every cache entry scoped to a connection.
+// Keys have the form "method:connectionID[:...]".
+func (s *Server) invalidateConnectionCache(connectionID string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	for key := range s.cache {
+		parts := strings.SplitN(key, ":", 3)
+		if len(parts) >= 2 && parts[1] == connectionID {
+			delete(s.cache, key)
+		}
+	}
+}
@@ -1,8 +1,14 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
 
 	"github.com/tazgreenwood/data-warden/internal/protocol"
 )
@@ -269,3 +275,201 @@ func TestJSONRPCResponseFormat(t *testing.T) {
 		t.Error("Missing error field")
 	}
 }
+
+func TestTestCredentialsReportsConnectFailure(t *testing.T) {
+	s := NewServer()
+
+	if _, err := s.handleTestCredentials(context.Background(), json.RawMessage(`{bad json}`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+
+	result, err := s.handleTestCredentials(context.Background(), json.RawMessage(`{"type": "postgres", "database": "app"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Error("Expected failure for unsupported database type")
+	}
+	if result.Database != "app" {
+		t.Errorf("Expected requested database to be echoed, got %q", result.Database)
+	}
+	if result.DatabaseExists != nil {
+		t.Error("Database existence should not be reported when the connection fails")
+	}
+}
+
+func TestNotifySendsJSONRPCNotification(t *testing.T) {
+	s := NewServer()
+
+	// Without a notifier, notifications are dropped
+	s.notify("listAllTablesProgress", nil)
+
+	var received []*protocol.Notification
+	s.SetNotifier(func(n *protocol.Notification) {
+		received = append(received, n)
+	})
+	s.notify("listAllTablesProgress", protocol.TableLoadProgress{RequestID: "req-1", Loaded: 1, Total: 3})
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(received))
+	}
+	if received[0].JSONRPC != "2.0" || received[0].Method != "listAllTablesProgress" {
+		t.Errorf("Unexpected notification envelope: %+v", received[0])
+	}
+
+	data, err := json.Marshal(received[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal notification: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal notification: %v", err)
+	}
+	if _, hasID := decoded["id"]; hasID {
+		t.Error("Notifications must not carry an id")
+	}
+}
+
+func TestDropDatabaseRequiresConfirmation(t *testing.T) {
+	s := NewServer()
+
+	testCases := []struct {
+		name   string
+		params string
+	}{
+		{"Missing confirm", `{"connectionId": "conn-1", "name": "shop"}`},
+		{"Mismatched confirm", `{"connectionId": "conn-1", "name": "shop", "confirm": "Shop"}`},
+		{"Empty name", `{"connectionId": "conn-1", "name": "", "confirm": ""}`},
+		{"System database", `{"connectionId": "conn-1", "name": "mysql", "confirm": "mysql"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.handleDropDatabase(context.Background(), json.RawMessage(tc.params))
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if strings.Contains(err.Error(), "connection not found") {
+				t.Errorf("Confirmation must be checked before anything else, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestInvalidateConnectionCache(t *testing.T) {
+	s := NewServer()
+	s.setCache("listDatabases:conn-1", []protocol.Database{})
+	s.setCache("listTables:conn-1:shop", []protocol.Table{})
+	s.setCache("listDatabases:conn-10", []protocol.Database{})
+	s.setCache("listTables:conn-2:shop", []protocol.Table{})
+
+	s.invalidateConnectionCache("conn-1")
+
+	for _, key := range []string{"listDatabases:conn-1", "listTables:conn-1:shop"} {
+		if _, ok := s.getFromCache(key); ok {
+			t.Errorf("Expected %s to be invalidated", key)
+		}
+	}
+	for _, key := range []string{"listDatabases:conn-10", "listTables:conn-2:shop"} {
+		if _, ok := s.getFromCache(key); !ok {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+}
+
+func TestInvalidateCacheKey(t *testing.T) {
+	s := NewServer()
+	s.setCache("listDatabases:conn-1", []protocol.Database{})
+	s.setCache("listDatabases:conn-10", []protocol.Database{})
+
+	s.invalidateCacheKey("listDatabases:conn-1")
+
+	if _, ok := s.getFromCache("listDatabases:conn-1"); ok {
+		t.Error("Expected listDatabases:conn-1 to be invalidated")
+	}
+	if _, ok := s.getFromCache("listDatabases:conn-10"); !ok {
+		t.Error("Expected listDatabases:conn-10 to be kept")
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	denied := &mysql.MySQLError{Number: 1044, Message: "Access denied"}
+
+	testCases := []struct {
+		name            string
+		errs            []error
+		expectedCalls   int
+		expectedRetries int
+		expectError     bool
+	}{
+		{"Succeeds first time", []error{nil}, 1, 0, false},
+		{"Succeeds after transient error", []error{deadlock, nil}, 2, 1, false},
+		{"Persistent error is not retried", []error{denied}, 1, 0, true},
+		{"Gives up after all attempts", []error{deadlock, deadlock, deadlock, nil}, 3, 2, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			retries, err := retryTransient(context.Background(), 3, time.Millisecond, func() error {
+				err := tc.errs[calls]
+				calls++
+				return err
+			})
+			if calls != tc.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+			if retries != tc.expectedRetries {
+				t.Errorf("Expected %d retries, got %d", tc.expectedRetries, retries)
+			}
+			if (err != nil) != tc.expectError {
+				t.Errorf("Expected error %v, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestRetryTransientStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	_, err := retryTransient(ctx, 3, time.Hour, func() error {
+		calls++
+		return &mysql.MySQLError{Number: 1205}
+	})
+	if calls != 1 {
+		t.Errorf("Expected 1 call after cancellation, got %d", calls)
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		t.Errorf("Expected the last error to be returned, got %v", err)
+	}
+}
+
+func TestListAllTablesIncludeStatus(t *testing.T) {
+	s := NewServer()
+	s.setCache("listAllTables:conn-1", &protocol.AllTables{
+		Tables:  map[string][]protocol.Table{"shop": {{Name: "orders"}}},
+		Retried: map[string]int{"shop": 1},
+		Failed:  map[string]string{"archive": "Lock wait timeout exceeded"},
+	})
+
+	result, err := s.handleListAllTables(context.Background(), "req-1", json.RawMessage(`{"connectionId": "conn-1"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tables, ok := result.(map[string][]protocol.Table); !ok || len(tables["shop"]) != 1 {
+		t.Errorf("Expected the bare table map by default, got %#v", result)
+	}
+
+	result, err = s.handleListAllTables(context.Background(), "req-2", json.RawMessage(`{"connectionId": "conn-1", "includeStatus": true}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	status, ok := result.(*protocol.AllTables)
+	if !ok || status.Retried["shop"] != 1 || status.Failed["archive"] == "" || len(status.Tables["shop"]) != 1 {
+		t.Errorf("Expected the retried and failed databases, got %#v", result)
+	}
+}
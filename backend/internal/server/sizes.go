@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+// maxSizeSnapshots bounds the table size snapshots kept, oldest dropped first
+const maxSizeSnapshots = 100
+
+// Table size change statuses
+const (
+	sizeAdded   = "added"
+	sizeRemoved = "removed"
+	sizeChanged = "changed"
+)
+
+// sizeSnapshots keeps a bounded list of table size snapshots. When a path is
+// set the list is loaded from and rewritten to that file on every change.
+type sizeSnapshots struct {
+	mu        sync.Mutex
+	snapshots []protocol.TableSizeSnapshot // Oldest first
+	max       int
+	nextID    int64
+	path      string
+}
+
+func newSizeSnapshots(max int) *sizeSnapshots {
+	return &sizeSnapshots{max: max, nextID: 1}
+}
+
+// load reads persisted snapshots from path and persists to it from then
+// on. A file that cannot be read or parsed leaves the path unset, so it is
+// not overwritten by the next snapshot.
+func (ss *sizeSnapshots) load(path string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			ss.path = path
+			return nil
+		}
+		return fmt.Errorf("failed to read size snapshots: %w", err)
+	}
+
+	var snapshots []protocol.TableSizeSnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return fmt.Errorf("failed to parse size snapshots: %w", err)
+	}
+	if len(snapshots) > ss.max {
+		snapshots = snapshots[len(snapshots)-ss.max:]
+	}
+	ss.snapshots = snapshots
+	for _, snapshot := range snapshots {
+		if snapshot.ID >= ss.nextID {
+			ss.nextID = snapshot.ID + 1
+		}
+	}
+	ss.path = path
+	return nil
+}
+
+// add assigns the snapshot an ID, stores it and persists the list
+func (ss *sizeSnapshots) add(snapshot protocol.TableSizeSnapshot) (protocol.TableSizeSnapshot, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	snapshot.ID = ss.nextID
+	ss.nextID++
+
+	if len(ss.snapshots) >= ss.max {
+		copy(ss.snapshots, ss.snapshots[1:])
+		ss.snapshots = ss.snapshots[:len(ss.snapshots)-1]
+	}
+	ss.snapshots = append(ss.snapshots, snapshot)
+
+	if ss.path != "" {
+		if err := writeSnapshots(ss.path, ss.snapshots); err != nil {
+			return snapshot, err
+		}
+	}
+	return snapshot, nil
+}
+
+// get returns a snapshot by ID
+func (ss *sizeSnapshots) get(id int64) (protocol.TableSizeSnapshot, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for _, snapshot := range ss.snapshots {
+		if snapshot.ID == id {
+			return snapshot, true
+		}
+	}
+	return protocol.TableSizeSnapshot{}, false
+}
+
+// list returns snapshot headers without table data, newest first, optionally
+// filtered by database
+func (ss *sizeSnapshots) list(database string) []protocol.TableSizeSnapshot {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result := make([]protocol.TableSizeSnapshot, 0, len(ss.snapshots))
+	for i := len(ss.snapshots) - 1; i >= 0; i-- {
+		snapshot := ss.snapshots[i]
+		if database != "" && snapshot.Database != database {
+			continue
+		}
+		snapshot.Tables = nil
+		result = append(result, snapshot)
+	}
+	return result
+}
+
+// writeSnapshots replaces the snapshot file
+func writeSnapshots(path string, snapshots []protocol.TableSizeSnapshot) error {
+	if err := writeJSONFile(path, snapshots); err != nil {
+		return fmt.Errorf("failed to write size snapshots: %w", err)
+	}
+	return nil
+}
+
+// writeJSONFile replaces a JSON file, writing to a temporary file first so
+// a crash cannot leave it truncated
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// compareSizeSnapshots diffs two snapshots of the same database. Unchanged
+// tables are omitted and the rest are sorted by total growth, largest first.
+func compareSizeSnapshots(from, to protocol.TableSizeSnapshot) *protocol.TableSizeComparison {
+	before := make(map[string]protocol.TableSize, len(from.Tables))
+	for _, table := range from.Tables {
+		before[table.Name] = table
+	}
+
+	changes := make([]protocol.TableSizeChange, 0)
+	seen := make(map[string]bool, len(to.Tables))
+	for _, after := range to.Tables {
+		seen[after.Name] = true
+		old, existed := before[after.Name]
+		change := sizeChange(old, after)
+		switch {
+		case !existed:
+			change.Status = sizeAdded
+		case change.RowCountDelta == 0 && change.DataLengthDelta == 0 && change.IndexLengthDelta == 0:
+			continue
+		default:
+			change.Status = sizeChanged
+		}
+		changes = append(changes, change)
+	}
+	for _, old := range from.Tables {
+		if !seen[old.Name] {
+			change := sizeChange(old, protocol.TableSize{Name: old.Name})
+			change.Status = sizeRemoved
+			changes = append(changes, change)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].TotalDelta != changes[j].TotalDelta {
+			return changes[i].TotalDelta > changes[j].TotalDelta
+		}
+		return changes[i].Name < changes[j].Name
+	})
+
+	comparison := &protocol.TableSizeComparison{
+		From:           from,
+		To:             to,
+		ElapsedSeconds: int64(to.TakenAt.Sub(from.TakenAt) / time.Second),
+		Tables:         changes,
+	}
+	comparison.From.Tables = nil
+	comparison.To.Tables = nil
+	for _, change := range changes {
+		comparison.TotalDelta += change.TotalDelta
+	}
+	return comparison
+}
+
+// sizeChange computes the deltas between two measurements of a table
+func sizeChange(before, after protocol.TableSize) protocol.TableSizeChange {
+	change := protocol.TableSizeChange{
+		Name:             after.Name,
+		RowCountBefore:   before.RowCount,
+		RowCountAfter:    after.RowCount,
+		RowCountDelta:    after.RowCount - before.RowCount,
+		DataLengthDelta:  after.DataLength - before.DataLength,
+		IndexLengthDelta: after.IndexLength - before.IndexLength,
+	}
+	change.TotalDelta = change.DataLengthDelta + change.IndexLengthDelta
+	return change
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tazgreenwood/data-warden/internal/protocol"
+)
+
+func TestCompareSizeSnapshots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	from := protocol.TableSizeSnapshot{
+		ID:       1,
+		Database: "shop",
+		TakenAt:  start,
+		Tables: []protocol.TableSize{
+			{Name: "orders", RowCount: 100, DataLength: 1000, IndexLength: 100},
+			{Name: "users", RowCount: 10, DataLength: 500, IndexLength: 50},
+			{Name: "legacy", RowCount: 5, DataLength: 200, IndexLength: 0},
+		},
+	}
+	to := protocol.TableSizeSnapshot{
+		ID:       2,
+		Database: "shop",
+		TakenAt:  start.Add(time.Hour),
+		Tables: []protocol.TableSize{
+			{Name: "orders", RowCount: 150, DataLength: 4000, IndexLength: 300},
+			{Name: "users", RowCount: 10, DataLength: 500, IndexLength: 50},
+			{Name: "audit", RowCount: 20, DataLength: 800, IndexLength: 0},
+		},
+	}
+
+	comparison := compareSizeSnapshots(from, to)
+
+	if comparison.ElapsedSeconds != 3600 {
+		t.Errorf("Expected 3600 elapsed seconds, got %d", comparison.ElapsedSeconds)
+	}
+	if comparison.From.Tables != nil || comparison.To.Tables != nil {
+		t.Error("Snapshot headers should not repeat table data")
+	}
+
+	expected := []struct {
+		name   string
+		status string
+		total  int64
+	}{
+		{"orders", sizeChanged, 3200},
+		{"audit", sizeAdded, 800},
+		{"legacy", sizeRemoved, -200},
+	}
+	if len(comparison.Tables) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), comparison.Tables)
+	}
+	for i, want := range expected {
+		got := comparison.Tables[i]
+		if got.Name != want.name || got.Status != want.status || got.TotalDelta != want.total {
+			t.Errorf("Change %d: expected %s %s %d, got %s %s %d",
+				i, want.name, want.status, want.total, got.Name, got.Status, got.TotalDelta)
+		}
+	}
+	if comparison.Tables[0].RowCountDelta != 50 {
+		t.Errorf("Expected orders to grow by 50 rows, got %d", comparison.Tables[0].RowCountDelta)
+	}
+	if comparison.TotalDelta != 3800 {
+		t.Errorf("Expected total growth 3800, got %d", comparison.TotalDelta)
+	}
+}
+
+func TestSizeSnapshotsBoundedAndPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sizes.json")
+
+	ss := newSizeSnapshots(2)
+	if err := ss.load(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := ss.add(protocol.TableSizeSnapshot{Database: "shop"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, ok := ss.get(1); ok {
+		t.Error("Expected the oldest snapshot to be evicted")
+	}
+
+	reloaded := newSizeSnapshots(2)
+	if err := reloaded.load(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	list := reloaded.list("shop")
+	if len(list) != 2 || list[0].ID != 3 || list[1].ID != 2 {
+		t.Fatalf("Expected snapshots 3 and 2 after reload, got %+v", list)
+	}
+
+	snapshot, err := reloaded.add(protocol.TableSizeSnapshot{Database: "shop"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.ID != 4 {
+		t.Errorf("Expected IDs to continue after reload, got %d", snapshot.ID)
+	}
+}
+
+func TestSizeSnapshotsCorruptFileKept(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sizes.json")
+	if err := os.WriteFile(path, []byte("[{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ss := newSizeSnapshots(2)
+	if err := ss.load(path); err == nil {
+		t.Fatal("Expected an error loading a corrupt snapshot file")
+	}
+	if _, err := ss.add(protocol.TableSizeSnapshot{Database: "shop"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "[{not json" {
+		t.Errorf("Expected the corrupt snapshot file to be kept, got %q", data)
+	}
+}